@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// =============================================================================
+// Agent Registry
+// =============================================================================
+
+// RegisterAgent registers an agent, or updates its name, description and
+// metadata if it is already registered.
+func (c *Client) RegisterAgent(req *RegisterAgentRequest) (*Agent, error) {
+	respBody, err := c.doRequest(http.MethodPost, "/api/v1/agents", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Agent]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("register agent failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListAgents retrieves the registered agents.
+func (c *Client) ListAgents(limit, offset int) (*AgentList, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+
+	path := "/api/v1/agents"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	respBody, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[AgentList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list agents failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// =============================================================================
+// Agent Memory Operations
+// =============================================================================
+
+// GetAgentMemories retrieves all memories for a specific agent.
+func (c *Client) GetAgentMemories(agentID string, limit, offset int) (*MemoryList, error) {
+	list, err := c.getAgentMemoryList(fmt.Sprintf("/api/v1/agents/%s/memories", url.PathEscape(agentID)), limit, offset, "get agent memories")
+	if err != nil {
+		return nil, err
+	}
+	filterVisibleMemories(list, "", agentID)
+	return list, nil
+}
+
+// SearchAgentMemories performs a semantic search within an agent's
+// memories. req.AgentID is overridden with agentID.
+func (c *Client) SearchAgentMemories(agentID string, req *SearchMemoryRequest) (*SearchResults, error) {
+	scoped := *req
+	scoped.AgentID = agentID
+	return c.SearchMemories(&scoped)
+}
+
+// CreateAgentMemory creates a memory owned by an agent. Like
+// CreateMemory, the request is validated and the client's default
+// metadata is applied before it is sent.
+func (c *Client) CreateAgentMemory(ctx context.Context, agentID string, req *CreateAgentMemoryRequest) (*Memory, error) {
+	if agentID == "" {
+		return nil, fmt.Errorf("agent ID is required")
+	}
+	if req.Content == "" {
+		return nil, fmt.Errorf("content is required")
+	}
+	if err := checkVisibility(req.Visibility); err != nil {
+		return nil, err
+	}
+	if c.defaultMetadata != nil {
+		withDefaults := *req
+		withDefaults.Metadata = mergeMetadata(c.defaultMetadata, req.Metadata)
+		req = &withDefaults
+	}
+	path := fmt.Sprintf("/api/v1/agents/%s/memories", url.PathEscape(agentID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, path, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Memory]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("create agent memory failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ShareAgentMemories shares memories of agentID with another agent. If
+// req.MemoryIDs is empty, all of the agent's memories are shared.
+func (c *Client) ShareAgentMemories(agentID string, req *ShareAgentMemoriesRequest) (*ShareAgentMemoriesResult, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/memories/share", url.PathEscape(agentID))
+
+	respBody, err := c.doRequest(http.MethodPost, path, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[ShareAgentMemoriesResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("share agent memories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GetSharedAgentMemories retrieves memories other agents have shared with
+// agentID.
+func (c *Client) GetSharedAgentMemories(agentID string, limit, offset int) (*MemoryList, error) {
+	return c.getAgentMemoryList(fmt.Sprintf("/api/v1/agents/%s/memories/share", url.PathEscape(agentID)), limit, offset, "get shared agent memories")
+}
+
+// DeleteAgentMemories deletes all memories of an agent.
+func (c *Client) DeleteAgentMemories(ctx context.Context, agentID string) (*ScopedDeleteResult, error) {
+	return c.deleteScoped(ctx, fmt.Sprintf("/api/v1/agents/%s/memories", url.PathEscape(agentID)))
+}
+
+// getAgentMemoryList fetches a paginated memory list from an agent endpoint.
+func (c *Client) getAgentMemoryList(path string, limit, offset int, op string) (*MemoryList, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	respBody, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemoryList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("%s failed: %s", op, resp.Message)
+	}
+
+	return &resp.Data, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateAgentMemory(t *testing.T) {
+	var got CreateAgentMemoryRequest
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/v1/agents/planner/memories" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": Memory{MemoryID: 1, Content: got.Content, AgentID: "planner"}})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "", WithDefaultMetadata(map[string]interface{}{"app": "trips", "source": "default"}))
+	ctx := context.Background()
+	req := &CreateAgentMemoryRequest{Content: "prefers rail", Metadata: map[string]interface{}{"source": "review"}, Visibility: VisibilityAgent}
+	if _, err := c.CreateAgentMemory(ctx, "planner", req); err != nil {
+		t.Fatalf("CreateAgentMemory: %v", err)
+	}
+	if got.Metadata["app"] != "trips" || got.Metadata["source"] != "review" || got.Visibility != VisibilityAgent {
+		t.Errorf("sent metadata %v, visibility %q; want defaults overlaid with the request's", got.Metadata, got.Visibility)
+	}
+	if len(req.Metadata) != 1 {
+		t.Errorf("request metadata modified: %v", req.Metadata)
+	}
+
+	invalid := []struct {
+		agentID string
+		req     *CreateAgentMemoryRequest
+	}{
+		{"", &CreateAgentMemoryRequest{Content: "x"}},
+		{"planner", &CreateAgentMemoryRequest{}},
+		{"planner", &CreateAgentMemoryRequest{Content: "x", Visibility: "team"}},
+	}
+	for _, tt := range invalid {
+		if _, err := c.CreateAgentMemory(ctx, tt.agentID, tt.req); err == nil {
+			t.Errorf("CreateAgentMemory(%q, %+v) succeeded, want a validation error", tt.agentID, tt.req)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1", requests)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := c.CreateAgentMemory(canceled, "planner", &CreateAgentMemoryRequest{Content: "x"}); err == nil {
+		t.Error("CreateAgentMemory with a canceled context succeeded")
+	}
+}
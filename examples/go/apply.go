@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// Declarative Configuration
+// =============================================================================
+
+// Manifest is the desired server configuration, reconciled against the
+// server by Apply, e.g. from a YAML file kept in version control:
+//
+//	retrieval_profiles:
+//	  - name: support
+//	    weights: {vector: 0.6, keyword: 0.3, recency: 0.1}
+//	webhooks:
+//	  - url: https://hooks.example.com/powermem
+//	    events: [memory.created]
+//	    active: true
+//
+// A kind left out of the manifest is not managed; an empty list manages
+// the kind and, when pruning, deletes all its resources.
+type Manifest struct {
+	RetentionPolicies []RetentionPolicy  `json:"retention_policies,omitempty"`
+	TieringRules      []TieringRule      `json:"tiering_rules,omitempty"`
+	Webhooks          []Webhook          `json:"webhooks,omitempty"`
+	RetrievalProfiles []RetrievalProfile `json:"retrieval_profiles,omitempty"`
+	MetadataSchemas   []MetadataSchema   `json:"metadata_schemas,omitempty"`
+	SavedSearches     []SavedSearch      `json:"saved_searches,omitempty"`
+}
+
+// ParseManifest parses a manifest in YAML or JSON. Unknown fields are
+// rejected, so that typos do not silently leave settings out.
+func ParseManifest(data []byte) (*Manifest, error) {
+	tree, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		return &Manifest{}, nil
+	}
+	if _, ok := tree.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("manifest must be a mapping of resource kinds")
+	}
+	data, err = json.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert manifest: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// ApplyOptions controls Apply.
+type ApplyOptions struct {
+	// DryRun computes the changes without making them.
+	DryRun bool
+
+	// Prune deletes resources of the managed kinds that the manifest
+	// does not list. Without it they are reported and left alone.
+	Prune bool
+}
+
+// ApplyAction is what Apply does to a resource.
+type ApplyAction string
+
+const (
+	ApplyCreate    ApplyAction = "create"
+	ApplyUpdate    ApplyAction = "update"
+	ApplyDelete    ApplyAction = "delete"
+	ApplyUnchanged ApplyAction = "unchanged"
+
+	// ApplyUnmanaged is a resource on the server that the manifest does
+	// not list, left alone without ApplyOptions.Prune.
+	ApplyUnmanaged ApplyAction = "unmanaged"
+)
+
+// FieldDiff is a field that differs between the server and the manifest.
+// Path is dotted, e.g. "weights.vector"; Old or New is nil when the field
+// is absent on that side.
+type FieldDiff struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ApplyChange is the change Apply made, or would make in a dry run, to
+// one resource.
+type ApplyChange struct {
+	// Kind is the manifest key of the resource's kind, e.g.
+	// "retrieval_profiles", and Name its name, or URL for webhooks.
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	Action ApplyAction `json:"action"`
+
+	// Diff lists the fields an update changes.
+	Diff []FieldDiff `json:"diff,omitempty"`
+
+	// Err is why the change failed; the other changes are still made.
+	Err error `json:"-"`
+}
+
+// ApplyResult is the outcome of Apply.
+type ApplyResult struct {
+	Changes []ApplyChange `json:"changes"`
+	DryRun  bool          `json:"dry_run,omitempty"`
+}
+
+// Failed returns the changes that failed.
+func (r *ApplyResult) Failed() []ApplyChange {
+	var failed []ApplyChange
+	for _, ch := range r.Changes {
+		if ch.Err != nil {
+			failed = append(failed, ch)
+		}
+	}
+	return failed
+}
+
+// Apply reconciles the server's configuration with m: it creates the
+// resources m lists that the server lacks, updates those that differ and,
+// with opts.Prune, deletes those of the managed kinds that m does not
+// list. Resources are matched by name, webhooks by URL. Run with
+// opts.DryRun to preview the changes and their diffs.
+//
+// An error is returned when the current configuration cannot be read or
+// m is invalid; failures of individual changes are reported in their
+// ApplyChange.Err and do not stop the others.
+func (c *Client) Apply(ctx context.Context, m *Manifest, opts ApplyOptions) (*ApplyResult, error) {
+	kinds := c.applyKinds(m)
+	for _, k := range kinds {
+		if err := k.check(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ApplyResult{Changes: []ApplyChange{}, DryRun: opts.DryRun}
+	for _, k := range kinds {
+		if !k.managed {
+			continue
+		}
+		current, err := k.list(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to list %s: %w", k.name, err)
+		}
+		for _, ch := range k.plan(current, opts.Prune) {
+			if !opts.DryRun {
+				ch.Err = k.apply(ctx, ch)
+			}
+			result.Changes = append(result.Changes, ch.ApplyChange)
+		}
+	}
+	return result, nil
+}
+
+// applyResource is a resource of a kind, by name.
+type applyResource struct {
+	name  string
+	value any
+}
+
+// applyKind reconciles one kind of resource.
+type applyKind struct {
+	// name is the manifest key of the kind.
+	name    string
+	managed bool
+	desired []applyResource
+
+	// ignored are the fields set by the server or never returned by it,
+	// which are not compared.
+	ignored []string
+
+	list func(ctx context.Context) ([]applyResource, error)
+	put  func(ctx context.Context, desired, current *applyResource) error
+	del  func(ctx context.Context, current *applyResource) error
+}
+
+// applyStep is a planned change with the resources it involves.
+type applyStep struct {
+	ApplyChange
+	desired, current *applyResource
+}
+
+// newApplyKind returns the applyKind of resources of type T.
+func newApplyKind[T any](name string, desired []T, nameOf func(*T) string, list func(context.Context) ([]T, error),
+	put func(ctx context.Context, desired, current *T) error, del func(ctx context.Context, current *T) error, ignored ...string) *applyKind {
+	wrap := func(v *T) applyResource { return applyResource{name: nameOf(v), value: v} }
+	k := &applyKind{name: name, managed: desired != nil, ignored: append([]string{"id", "created_at", "updated_at"}, ignored...)}
+	for i := range desired {
+		k.desired = append(k.desired, wrap(&desired[i]))
+	}
+	k.list = func(ctx context.Context) ([]applyResource, error) {
+		items, err := list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resources := make([]applyResource, len(items))
+		for i := range items {
+			resources[i] = wrap(&items[i])
+		}
+		return resources, nil
+	}
+	k.put = func(ctx context.Context, d, cur *applyResource) error {
+		var curValue *T
+		if cur != nil {
+			curValue = cur.value.(*T)
+		}
+		return put(ctx, d.value.(*T), curValue)
+	}
+	k.del = func(ctx context.Context, cur *applyResource) error {
+		return del(ctx, cur.value.(*T))
+	}
+	return k
+}
+
+// applyKinds returns the kinds of resource Apply reconciles, in the order
+// it applies them.
+func (c *Client) applyKinds(m *Manifest) []*applyKind {
+	return []*applyKind{
+		newApplyKind("metadata_schemas", m.MetadataSchemas,
+			func(s *MetadataSchema) string { return s.Name },
+			func(ctx context.Context) ([]MetadataSchema, error) {
+				list, err := c.ListMetadataSchemas(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return list.Schemas, nil
+			},
+			func(ctx context.Context, s, _ *MetadataSchema) error {
+				_, err := c.PutMetadataSchema(ctx, s)
+				return err
+			},
+			func(ctx context.Context, s *MetadataSchema) error { return c.DeleteMetadataSchema(ctx, s.Name) }),
+		newApplyKind("retention_policies", m.RetentionPolicies,
+			func(p *RetentionPolicy) string { return p.Name },
+			func(ctx context.Context) ([]RetentionPolicy, error) {
+				list, err := c.ListRetentionPolicies(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return list.Policies, nil
+			},
+			func(ctx context.Context, p, _ *RetentionPolicy) error {
+				_, err := c.PutRetentionPolicy(ctx, p)
+				return err
+			},
+			func(ctx context.Context, p *RetentionPolicy) error { return c.DeleteRetentionPolicy(ctx, p.Name) }),
+		newApplyKind("tiering_rules", m.TieringRules,
+			func(r *TieringRule) string { return r.Name },
+			func(ctx context.Context) ([]TieringRule, error) {
+				list, err := c.ListTieringRules(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return list.Rules, nil
+			},
+			func(ctx context.Context, r, _ *TieringRule) error {
+				_, err := c.PutTieringRule(ctx, r)
+				return err
+			},
+			func(ctx context.Context, r *TieringRule) error { return c.DeleteTieringRule(ctx, r.Name) }),
+		newApplyKind("retrieval_profiles", m.RetrievalProfiles,
+			func(p *RetrievalProfile) string { return p.Name },
+			func(ctx context.Context) ([]RetrievalProfile, error) {
+				list, err := c.ListRetrievalProfiles(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return list.Profiles, nil
+			},
+			func(ctx context.Context, p, _ *RetrievalProfile) error {
+				_, err := c.PutRetrievalProfile(ctx, p)
+				return err
+			},
+			func(ctx context.Context, p *RetrievalProfile) error { return c.DeleteRetrievalProfile(ctx, p.Name) }),
+		newApplyKind("saved_searches", m.SavedSearches,
+			func(s *SavedSearch) string { return s.Name },
+			func(ctx context.Context) ([]SavedSearch, error) {
+				list, err := c.ListSavedSearches(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return list.Searches, nil
+			},
+			func(ctx context.Context, s, _ *SavedSearch) error {
+				_, err := c.PutSavedSearch(ctx, s)
+				return err
+			},
+			func(ctx context.Context, s *SavedSearch) error { return c.DeleteSavedSearch(ctx, s.Name) }),
+		// The server never returns webhook secrets, so they are only
+		// sent, not compared.
+		newApplyKind("webhooks", m.Webhooks,
+			func(w *Webhook) string { return w.URL },
+			func(ctx context.Context) ([]Webhook, error) {
+				list, err := c.ListWebhooks(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return list.Webhooks, nil
+			},
+			func(ctx context.Context, w, current *Webhook) error {
+				var err error
+				if current == nil {
+					_, err = c.CreateWebhook(ctx, w)
+				} else {
+					_, err = c.UpdateWebhook(ctx, current.ID, w)
+				}
+				return err
+			},
+			func(ctx context.Context, w *Webhook) error { return c.DeleteWebhook(ctx, w.ID) },
+			"secret"),
+	}
+}
+
+// check validates the desired resources of k.
+func (k *applyKind) check() error {
+	seen := make(map[string]bool, len(k.desired))
+	for i, r := range k.desired {
+		if r.name == "" {
+			return fmt.Errorf("%s[%d]: name is required", k.name, i)
+		}
+		if seen[r.name] {
+			return fmt.Errorf("%s: %q is listed twice", k.name, r.name)
+		}
+		seen[r.name] = true
+	}
+	return nil
+}
+
+// plan returns the changes that reconcile current with the desired
+// resources of k, in manifest order followed by deletions.
+func (k *applyKind) plan(current []applyResource, prune bool) []applyStep {
+	byName := make(map[string]*applyResource, len(current))
+	for i := range current {
+		byName[current[i].name] = &current[i]
+	}
+
+	var steps []applyStep
+	for i := range k.desired {
+		d := &k.desired[i]
+		step := applyStep{ApplyChange: ApplyChange{Kind: k.name, Name: d.name}, desired: d}
+		cur, ok := byName[d.name]
+		switch {
+		case !ok:
+			step.Action = ApplyCreate
+		default:
+			step.current = cur
+			step.Diff = diffResources(cur.value, d.value, k.ignored)
+			step.Action = ApplyUnchanged
+			if len(step.Diff) > 0 {
+				step.Action = ApplyUpdate
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	desired := make(map[string]bool, len(k.desired))
+	for _, d := range k.desired {
+		desired[d.name] = true
+	}
+	for i := range current {
+		cur := &current[i]
+		if desired[cur.name] {
+			continue
+		}
+		action := ApplyUnmanaged
+		if prune {
+			action = ApplyDelete
+		}
+		steps = append(steps, applyStep{ApplyChange: ApplyChange{Kind: k.name, Name: cur.name, Action: action}, current: cur})
+	}
+	return steps
+}
+
+// apply makes the change of step.
+func (k *applyKind) apply(ctx context.Context, step applyStep) error {
+	switch step.Action {
+	case ApplyCreate, ApplyUpdate:
+		return k.put(ctx, step.desired, step.current)
+	case ApplyDelete:
+		return k.del(ctx, step.current)
+	}
+	return nil
+}
+
+// diffResources returns the fields that differ between the JSON forms of
+// current and desired, leaving out the ignored top-level fields.
+func diffResources(current, desired any, ignored []string) []FieldDiff {
+	before, after := flattenJSON(current), flattenJSON(desired)
+	for _, leaves := range []map[string]interface{}{before, after} {
+		for path := range leaves {
+			if slices.Contains(ignored, strings.SplitN(path, ".", 2)[0]) {
+				delete(leaves, path)
+			}
+		}
+	}
+
+	var diffs []FieldDiff
+	for path, nv := range after {
+		if ov, ok := before[path]; !ok || !reflect.DeepEqual(ov, nv) {
+			diffs = append(diffs, FieldDiff{Path: path, Old: ov, New: nv})
+		}
+	}
+	for path, ov := range before {
+		if _, ok := after[path]; !ok {
+			diffs = append(diffs, FieldDiff{Path: path, Old: ov})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// flattenJSON returns the leaves of v's JSON form by dotted path. Arrays
+// are leaves.
+func flattenJSON(v any) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil
+	}
+	leaves := make(map[string]interface{})
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		m, ok := v.(map[string]interface{})
+		if !ok || (len(m) == 0 && prefix != "") {
+			leaves[prefix] = v
+			return
+		}
+		for k, child := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			walk(k, child)
+		}
+	}
+	walk("", tree)
+	return leaves
+}
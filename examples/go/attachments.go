@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// AttachmentMetadataField is the metadata field that references a
+// memory's attachment.
+const AttachmentMetadataField = "attachment"
+
+// ErrNoBlobStore is returned by attachment methods when the client has no
+// BlobStore configured.
+var ErrNoBlobStore = errors.New("powermem: no blob store configured")
+
+// Attachment references a file kept in a BlobStore. It is recorded in the
+// metadata of the memory that describes the file, so large artifacts stay
+// out of the memory database.
+type Attachment struct {
+	Key         string `json:"key"`
+	URI         string `json:"uri"`
+	Name        string `json:"name,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// WithBlobStore stores attachments in store.
+func WithBlobStore(store BlobStore) Option {
+	return func(c *Client) {
+		c.blobs = store
+	}
+}
+
+// UploadAttachment uploads the content read from r to the blob store. The
+// key is derived from the content hash, so uploading the same file twice
+// stores it once.
+func (c *Client) UploadAttachment(ctx context.Context, name, contentType string, r io.Reader) (*Attachment, error) {
+	if c.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+
+	// Spool to disk to learn the size and hash before uploading.
+	tmp, err := os.CreateTemp("", "powermem-attachment-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer attachment: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer attachment: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to buffer attachment: %w", err)
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	key := "attachments/" + sum[:2] + "/" + sum
+	if ext := attachmentExt(name); ext != "" {
+		key += ext
+	}
+	if err := c.blobs.Put(ctx, key, tmp, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to upload attachment: %w", err)
+	}
+
+	return &Attachment{
+		Key:         key,
+		URI:         c.blobs.URI(key),
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      sum,
+	}, nil
+}
+
+// CreateMemoryWithAttachment creates a memory that references att. The
+// memory's content should describe the file so that it can be found by
+// search.
+func (c *Client) CreateMemoryWithAttachment(ctx context.Context, req *CreateMemoryRequest, att *Attachment) ([]CreatedMemory, error) {
+	withAttachment := *req
+	withAttachment.Metadata = mergeMetadata(req.Metadata, map[string]interface{}{
+		AttachmentMetadataField: att.metadata(),
+	})
+	return c.createMemory(ctx, &withAttachment)
+}
+
+// AttachmentOf returns the attachment referenced by a memory's metadata.
+func AttachmentOf(metadata map[string]interface{}) (*Attachment, bool) {
+	raw, ok := metadata[AttachmentMetadataField]
+	if !ok {
+		return nil, false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	var att Attachment
+	if err := json.Unmarshal(data, &att); err != nil || att.Key == "" {
+		return nil, false
+	}
+	return &att, true
+}
+
+// OpenAttachment downloads att from the blob store. The caller must close
+// the returned reader.
+func (c *Client) OpenAttachment(ctx context.Context, att *Attachment) (io.ReadCloser, error) {
+	if c.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+	return c.blobs.Open(ctx, att.Key)
+}
+
+// AttachmentURL returns a presigned URL granting read access to att until
+// expires elapses, e.g. for a browser to download it directly.
+func (c *Client) AttachmentURL(ctx context.Context, att *Attachment, expires time.Duration) (string, error) {
+	if c.blobs == nil {
+		return "", ErrNoBlobStore
+	}
+	return c.blobs.SignedURL(ctx, att.Key, expires)
+}
+
+// metadata returns att in the form stored in memory metadata.
+func (att *Attachment) metadata() map[string]interface{} {
+	md := map[string]interface{}{
+		"key":    att.Key,
+		"uri":    att.URI,
+		"size":   att.Size,
+		"sha256": att.SHA256,
+	}
+	if att.Name != "" {
+		md["name"] = att.Name
+	}
+	if att.ContentType != "" {
+		md["content_type"] = att.ContentType
+	}
+	return md
+}
+
+// attachmentExt returns the file extension of name if it is short and
+// safe to use in a blob key.
+func attachmentExt(name string) string {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 || len(name)-i > 10 {
+		return ""
+	}
+	ext := strings.ToLower(name[i:])
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
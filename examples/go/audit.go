@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AuditGenesisHash is the prev_hash of the first record of an audit chain.
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+// AuditRecord is one line of a hash-chained audit log. Each record commits
+// to its predecessor, so removing, reordering or editing a record breaks
+// every hash after it:
+//
+//	hash = hex(sha256(prev_hash + "\n" + seq + "\n" + entry))
+//
+// where entry is the record's entry object exactly as written.
+type AuditRecord struct {
+	Seq      int64           `json:"seq"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+	Entry    json.RawMessage `json:"entry"`
+}
+
+// ComputeHash returns the hash the record should carry.
+func (r *AuditRecord) ComputeHash() string {
+	h := sha256.New()
+	io.WriteString(h, r.PrevHash+"\n"+strconv.FormatInt(r.Seq, 10)+"\n")
+	h.Write(r.Entry)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditCheckpoint identifies a position in an audit chain, e.g. a head
+// hash published to an external system to detect truncation.
+type AuditCheckpoint struct {
+	Seq  int64
+	Hash string
+}
+
+// ParseAuditCheckpoint parses a checkpoint in "SEQ:HASH" form.
+func ParseAuditCheckpoint(s string) (AuditCheckpoint, error) {
+	seq, hash, ok := strings.Cut(s, ":")
+	if !ok {
+		return AuditCheckpoint{}, fmt.Errorf("invalid checkpoint %q: want SEQ:HASH", s)
+	}
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil {
+		return AuditCheckpoint{}, fmt.Errorf("invalid checkpoint %q: %w", s, err)
+	}
+	return AuditCheckpoint{Seq: n, Hash: strings.ToLower(hash)}, nil
+}
+
+func (c AuditCheckpoint) String() string {
+	return strconv.FormatInt(c.Seq, 10) + ":" + c.Hash
+}
+
+// AuditVerifyOptions contains options for verifying an audit chain.
+type AuditVerifyOptions struct {
+	// From is the checkpoint preceding the first record, for verifying a
+	// rotated segment. When nil the log must start at the genesis record.
+	From *AuditCheckpoint
+
+	// ExpectedHead, if set, must match the last record, which detects
+	// records removed from the end of the log.
+	ExpectedHead *AuditCheckpoint
+}
+
+// AuditProblemKind classifies an integrity problem.
+type AuditProblemKind string
+
+const (
+	// AuditMalformed is a line that is not a valid audit record.
+	AuditMalformed AuditProblemKind = "malformed"
+
+	// AuditGap is a jump in sequence numbers, i.e. missing records.
+	AuditGap AuditProblemKind = "gap"
+
+	// AuditBrokenLink is a record whose prev_hash does not match its
+	// predecessor, i.e. records removed, inserted or reordered.
+	AuditBrokenLink AuditProblemKind = "broken_link"
+
+	// AuditAltered is a record whose hash does not match its contents.
+	AuditAltered AuditProblemKind = "altered"
+
+	// AuditTruncated is a log that ends before the expected head.
+	AuditTruncated AuditProblemKind = "truncated"
+)
+
+// AuditProblem describes an integrity problem found in an audit chain.
+type AuditProblem struct {
+	Line   int
+	Seq    int64
+	Kind   AuditProblemKind
+	Detail string
+}
+
+func (p AuditProblem) String() string {
+	return fmt.Sprintf("line %d (seq %d): %s: %s", p.Line, p.Seq, p.Kind, p.Detail)
+}
+
+// AuditReport is the result of verifying an audit chain.
+type AuditReport struct {
+	Records  int
+	Head     AuditCheckpoint
+	Problems []AuditProblem
+}
+
+// OK reports whether the chain verified without problems.
+func (r *AuditReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// VerifyAuditChain walks the audit log read from r and reports gaps and
+// alterations. Each line holds one AuditRecord, optionally preceded by a
+// log prefix such as a timestamp. Verification continues past a problem
+// so that every damaged region is reported; an error is returned only if
+// r cannot be read.
+func VerifyAuditChain(r io.Reader, opts AuditVerifyOptions) (*AuditReport, error) {
+	report := &AuditReport{Head: AuditCheckpoint{Hash: AuditGenesisHash}}
+	if opts.From != nil {
+		report.Head = *opts.From
+	}
+	problem := func(line int, seq int64, kind AuditProblemKind, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, AuditProblem{Line: line, Seq: seq, Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Bytes()
+		if len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+		// Skip a log prefix such as "2025-01-01 12:00:00 - audit - INFO - ".
+		if i := bytes.IndexByte(text, '{'); i > 0 {
+			text = text[i:]
+		}
+
+		var rec AuditRecord
+		if err := json.Unmarshal(text, &rec); err != nil || rec.Hash == "" || len(rec.Entry) == 0 {
+			problem(line, 0, AuditMalformed, "not an audit record")
+			continue
+		}
+		report.Records++
+
+		if want := report.Head.Seq + 1; rec.Seq != want {
+			problem(line, rec.Seq, AuditGap, "expected seq %d", want)
+		}
+		if rec.PrevHash != report.Head.Hash {
+			problem(line, rec.Seq, AuditBrokenLink, "prev_hash %.12s does not match previous record %.12s", rec.PrevHash, report.Head.Hash)
+		}
+		if got := rec.ComputeHash(); got != rec.Hash {
+			problem(line, rec.Seq, AuditAltered, "hash %.12s does not match contents (%.12s)", rec.Hash, got)
+		}
+
+		// Resynchronize on the record as written so later records are
+		// checked against it rather than all reported as broken.
+		report.Head = AuditCheckpoint{Seq: rec.Seq, Hash: rec.Hash}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	if head := opts.ExpectedHead; head != nil && report.Head != *head {
+		if report.Head.Seq < head.Seq {
+			problem(line, report.Head.Seq, AuditTruncated, "log ends at seq %d, expected head %s", report.Head.Seq, head)
+		} else {
+			problem(line, report.Head.Seq, AuditBrokenLink, "head %s does not match expected head %s", report.Head, head)
+		}
+	}
+	return report, nil
+}
+
+// =============================================================================
+// Server Audit Log
+// =============================================================================
+
+// ExportAuditLog streams the server's hash-chained audit log, one
+// AuditRecord per line, starting after afterSeq (0 for the whole log).
+// The caller must close the returned reader.
+func (c *Client) ExportAuditLog(ctx context.Context, afterSeq int64) (io.ReadCloser, error) {
+	path := "/api/v1/audit/export"
+	if afterSeq > 0 {
+		params := url.Values{}
+		params.Set("after_seq", strconv.FormatInt(afterSeq, 10))
+		path += "?" + params.Encode()
+	}
+
+	resp, err := c.doStream(ctx, http.MethodGet, path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
+)
+
+// =============================================================================
+// Metadata Backfill
+// =============================================================================
+
+// backfillRequest is the request body of a backfill.
+type backfillRequest struct {
+	Filters  MetadataFilter   `json:"filters"`
+	Mutation MetadataMutation `json:"mutation"`
+	BackfillOptions
+}
+
+// BackfillMetadata changes the metadata of every memory matching filter
+// in a server-side job, e.g. to add source "legacy" to all memories
+// created before 2024, without exporting and re-importing them:
+//
+//	res, err := client.BackfillMetadata(ctx,
+//		MetaLt("created_at", "2024-01-01T00:00:00Z"),
+//		MetadataMutation{Set: map[string]interface{}{"source": "legacy"}},
+//		BackfillOptions{Reason: "TICKET-42"})
+//
+// Follow the job with WaitForJob and PollOptions.OnProgress. The server
+// records the previous values in a manifest, see GetBackfillManifest, so
+// that RollbackBackfill can undo the change. Run with DryRun first to
+// check what filter matches.
+func (c *Client) BackfillMetadata(ctx context.Context, filter MetadataFilter, mutation MetadataMutation, opts BackfillOptions) (*BackfillResult, error) {
+	if err := c.requireFeature(experimental.AsyncJobs, "BackfillMetadata"); err != nil {
+		return nil, err
+	}
+	if len(filter) == 0 {
+		return nil, errors.New("backfill needs a filter")
+	}
+	if len(mutation.Set) == 0 && len(mutation.Remove) == 0 {
+		return nil, errors.New("backfill needs a mutation")
+	}
+	for _, key := range mutation.Remove {
+		if _, ok := mutation.Set[key]; ok {
+			return nil, fmt.Errorf("backfill both sets and removes metadata key %q", key)
+		}
+	}
+	req := &backfillRequest{Filters: filter, Mutation: mutation, BackfillOptions: opts}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories/metadata/backfill", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[BackfillResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("backfill metadata failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GetBackfillManifest returns a page of the rollback manifest of a
+// backfill job: the metadata it changed on each memory, with the
+// previous values. Entries are added as the job progresses.
+func (c *Client) GetBackfillManifest(ctx context.Context, jobID string, limit, offset int) (*BackfillManifest, error) {
+	if err := c.requireFeature(experimental.AsyncJobs, "GetBackfillManifest"); err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+	path := fmt.Sprintf("/api/v1/jobs/%s/manifest", url.PathEscape(jobID))
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[BackfillManifest]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get backfill manifest failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// RollbackBackfill restores the metadata a backfill job changed from its
+// manifest, in a new job of type JobBackfillRollback. Keys changed again
+// since the backfill are left alone. A running backfill is cancelled
+// first, and the part already applied rolled back.
+func (c *Client) RollbackBackfill(ctx context.Context, jobID string) (*Job, error) {
+	if err := c.requireFeature(experimental.AsyncJobs, "RollbackBackfill"); err != nil {
+		return nil, err
+	}
+	return c.jobAction(ctx, jobID, "rollback")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/oceanbase/powermem/examples/go/internal/wire"
+)
+
+// TokenSource supplies bearer tokens, such as JWTs issued by an API
+// gateway, sent in the Authorization header. A token is cached until
+// shortly before its ExpiresAt, or until the server rejects it with 401,
+// and then requested again.
+type TokenSource interface {
+	Token(ctx context.Context) (Secret, error)
+}
+
+// StaticToken is a TokenSource returning a fixed token.
+type StaticToken string
+
+// Token returns the token, without expiry.
+func (t StaticToken) Token(context.Context) (Secret, error) {
+	return Secret{Value: string(t)}, nil
+}
+
+// WithBearerToken sends token as "Authorization: Bearer <token>" with
+// every request. It is sent in addition to the API key, if any.
+func WithBearerToken(token string) Option {
+	return WithTokenSource(StaticToken(token))
+}
+
+// WithTokenSource sends a bearer token from ts with every request, e.g.
+// to refresh JWTs before they expire. It is sent in addition to the API
+// key, if any.
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = &credentialSource{fetch: ts.Token, what: "bearer token"}
+	}
+}
+
+// setAuth sets the API key and bearer token headers of a request.
+func (c *Client) setAuth(ctx context.Context, header http.Header) error {
+	apiKey, err := c.apiKey(ctx)
+	if err != nil {
+		return err
+	}
+	var token string
+	if c.tokens != nil {
+		if token, err = c.tokens.get(ctx); err != nil {
+			return err
+		}
+	}
+	wire.SetAuth(header, apiKey, token)
+	return nil
+}
+
+// reauthorize returns req with fresh credentials, to send again after
+// the server rejected it with 401, or nil if the credentials did not
+// change. body is the request's body.
+func (c *Client) reauthorize(ctx context.Context, req *http.Request, body []byte) (*http.Request, error) {
+	retry := req.Clone(ctx)
+	if err := c.setAuth(ctx, retry.Header); err != nil {
+		return nil, err
+	}
+	if retry.Header.Get("X-API-Key") == req.Header.Get("X-API-Key") &&
+		retry.Header.Get("Authorization") == req.Header.Get("Authorization") {
+		return nil, nil
+	}
+	if body != nil {
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return retry, nil
+}
+
+// invalidateAuth drops the cached API key and bearer token after the
+// server rejected them, as they may have been rotated or expired early.
+func (c *Client) invalidateAuth() {
+	if c.credentials != nil {
+		c.credentials.invalidate()
+	}
+	if c.tokens != nil {
+		c.tokens.invalidate()
+	}
+}
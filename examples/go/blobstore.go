@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlobStore stores attachment content outside the memory database. Keys
+// are slash-separated paths chosen by the client.
+type BlobStore interface {
+	// Put uploads size bytes read from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Open returns the content stored under key. The caller must close it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// SignedURL returns a URL that grants read access to key until
+	// expires elapses, for handing to browsers or other services.
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+
+	// URI returns the permanent reference to key recorded in memories,
+	// e.g. "s3://bucket/key".
+	URI(key string) string
+}
+
+// =============================================================================
+// Local Disk
+// =============================================================================
+
+// LocalBlobStore stores blobs as files below Dir, for development and
+// single-host deployments. Its signed URLs are plain file:// URLs.
+type LocalBlobStore struct {
+	Dir string
+}
+
+// Put implements BlobStore.
+func (l *LocalBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	// Write to a temporary file first so readers never see partial blobs.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Open implements BlobStore.
+func (l *LocalBlobStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// SignedURL implements BlobStore.
+func (l *LocalBlobStore) SignedURL(_ context.Context, key string, _ time.Duration) (string, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// URI implements BlobStore.
+func (l *LocalBlobStore) URI(key string) string {
+	return "file://" + filepath.ToSlash(filepath.Join(l.Dir, key))
+}
+
+// path maps key to a file below Dir, rejecting keys that escape it.
+func (l *LocalBlobStore) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(key)), nil
+}
+
+// =============================================================================
+// Amazon S3
+// =============================================================================
+
+// S3BlobStore stores blobs in an S3 bucket through presigned URLs, so
+// content never passes through the PowerMem server. Credentials default
+// to the standard AWS_* environment variables.
+type S3BlobStore struct {
+	Bucket string
+
+	// Region defaults to $AWS_REGION.
+	Region string
+
+	// Prefix is prepended to every key.
+	Prefix string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint selects an S3-compatible service such as MinIO, addressed
+	// path-style. Defaults to the bucket's virtual-hosted AWS endpoint.
+	Endpoint string
+
+	HTTPClient *http.Client
+}
+
+// Put implements BlobStore.
+func (s *S3BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	signed, err := s.presign(http.MethodPut, key, 15*time.Minute)
+	if err != nil {
+		return err
+	}
+	return putPresigned(ctx, s.HTTPClient, signed, r, size, contentType)
+}
+
+// Open implements BlobStore.
+func (s *S3BlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	signed, err := s.presign(http.MethodGet, key, 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return getPresigned(ctx, s.HTTPClient, signed)
+}
+
+// SignedURL implements BlobStore.
+func (s *S3BlobStore) SignedURL(_ context.Context, key string, expires time.Duration) (string, error) {
+	return s.presign(http.MethodGet, key, expires)
+}
+
+// URI implements BlobStore.
+func (s *S3BlobStore) URI(key string) string {
+	return "s3://" + s.Bucket + "/" + s.Prefix + key
+}
+
+func (s *S3BlobStore) presign(method, key string, expires time.Duration) (string, error) {
+	region := firstNonEmpty(s.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return "", errors.New("s3: region not configured")
+	}
+	creds := awsCredentials{
+		accessKeyID:     firstNonEmpty(s.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID")),
+		secretAccessKey: firstNonEmpty(s.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		sessionToken:    firstNonEmpty(s.SessionToken, os.Getenv("AWS_SESSION_TOKEN")),
+	}
+	if creds.accessKeyID == "" || creds.secretAccessKey == "" {
+		return "", errors.New("s3: AWS credentials not configured")
+	}
+
+	u := &url.URL{Scheme: "https", Host: s.Bucket + ".s3." + region + ".amazonaws.com", Path: "/" + s.Prefix + key}
+	if s.Endpoint != "" {
+		base, err := url.Parse(s.Endpoint)
+		if err != nil {
+			return "", fmt.Errorf("s3: invalid endpoint: %w", err)
+		}
+		u = &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/" + s.Bucket + "/" + s.Prefix + key}
+	}
+	return presignV4(awsV4, method, u, creds, region, "s3", expires, time.Now()), nil
+}
+
+// =============================================================================
+// Google Cloud Storage
+// =============================================================================
+
+// GCSBlobStore stores blobs in a Cloud Storage bucket through V4 signed
+// URLs, authenticated with an HMAC key of a service account.
+type GCSBlobStore struct {
+	Bucket string
+
+	// Prefix is prepended to every key.
+	Prefix string
+
+	// AccessID and Secret are the service account's HMAC key.
+	AccessID string
+	Secret   string
+
+	HTTPClient *http.Client
+}
+
+// Put implements BlobStore.
+func (g *GCSBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return putPresigned(ctx, g.HTTPClient, g.presign(http.MethodPut, key, 15*time.Minute), r, size, contentType)
+}
+
+// Open implements BlobStore.
+func (g *GCSBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return getPresigned(ctx, g.HTTPClient, g.presign(http.MethodGet, key, 15*time.Minute))
+}
+
+// SignedURL implements BlobStore.
+func (g *GCSBlobStore) SignedURL(_ context.Context, key string, expires time.Duration) (string, error) {
+	return g.presign(http.MethodGet, key, expires), nil
+}
+
+// URI implements BlobStore.
+func (g *GCSBlobStore) URI(key string) string {
+	return "gs://" + g.Bucket + "/" + g.Prefix + key
+}
+
+func (g *GCSBlobStore) presign(method, key string, expires time.Duration) string {
+	u := &url.URL{Scheme: "https", Host: "storage.googleapis.com", Path: "/" + g.Bucket + "/" + g.Prefix + key}
+	creds := awsCredentials{accessKeyID: g.AccessID, secretAccessKey: g.Secret}
+	return presignV4(googV4, method, u, creds, "auto", "storage", expires, time.Now())
+}
+
+// =============================================================================
+// Presigned URLs
+// =============================================================================
+
+// v4Dialect holds the names that differ between AWS Signature Version 4
+// and its Cloud Storage counterpart.
+type v4Dialect struct {
+	algorithm  string
+	header     string
+	keyPrefix  string
+	terminator string
+}
+
+var (
+	awsV4  = v4Dialect{"AWS4-HMAC-SHA256", "X-Amz-", "AWS4", "aws4_request"}
+	googV4 = v4Dialect{"GOOG4-HMAC-SHA256", "X-Goog-", "GOOG4", "goog4_request"}
+)
+
+// presignV4 returns u with a query-string signature allowing method until
+// expires elapses. Only the Host header is signed and the payload is not,
+// so any client can use the URL.
+func presignV4(d v4Dialect, method string, u *url.URL, creds awsCredentials, region, service string, expires time.Duration, now time.Time) string {
+	stamp := now.UTC().Format("20060102T150405Z")
+	date := stamp[:8]
+	scope := date + "/" + region + "/" + service + "/" + d.terminator
+
+	query := map[string]string{
+		d.header + "Algorithm":     d.algorithm,
+		d.header + "Credential":    creds.accessKeyID + "/" + scope,
+		d.header + "Date":          stamp,
+		d.header + "Expires":       strconv.Itoa(int(expires / time.Second)),
+		d.header + "SignedHeaders": "host",
+	}
+	if creds.sessionToken != "" {
+		query[d.header+"Security-Token"] = creds.sessionToken
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = uriEncode(name, true) + "=" + uriEncode(query[name], true)
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+	canonicalURI := uriEncode(u.Path, false)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	stringToSign := d.algorithm + "\n" + stamp + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte(d.keyPrefix+creds.secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, d.terminator)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return u.Scheme + "://" + u.Host + canonicalURI + "?" + canonicalQuery + "&" + d.header + "Signature=" + signature
+}
+
+// uriEncode percent-encodes s as required by V4 signing: every byte except
+// unreserved characters, and '/' too when encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// putPresigned uploads size bytes from r to a presigned URL.
+func putPresigned(ctx context.Context, client *http.Client, signedURL string, r io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, signedURL, r)
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := blobHTTPClient(client).Do(req)
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("upload failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// getPresigned opens a presigned URL for reading.
+func getPresigned(ctx context.Context, client *http.Client, signedURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, signedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+	resp, err := blobHTTPClient(client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, os.ErrNotExist
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("download failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.Body, nil
+}
+
+// blobHTTPClient returns client, or a client without a timeout since blob
+// transfers may be large; contexts bound them instead.
+func blobHTTPClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultCalibrationMinSamples is the number of feedback samples a model
+// needs before ScoreCalibrator.Fit calibrates it.
+const DefaultCalibrationMinSamples = 20
+
+// Calibration maps the raw scores of one embedding model and index to the
+// probability that a result is relevant, using a logistic curve fitted to
+// feedback (Platt scaling):
+//
+//	relevance = 1 / (1 + exp(-(Slope*score + Intercept)))
+type Calibration struct {
+	Model     string  `json:"model"`
+	Slope     float64 `json:"slope"`
+	Intercept float64 `json:"intercept"`
+	Samples   int     `json:"samples"`
+}
+
+// Relevance returns the calibrated relevance of a raw score.
+func (c Calibration) Relevance(score float64) float64 {
+	return 1 / (1 + math.Exp(-(c.Slope*score + c.Intercept)))
+}
+
+// CalibrationSample is a piece of feedback: the raw score of a result and
+// whether the user or agent found it relevant.
+type CalibrationSample struct {
+	Score    float64 `json:"score"`
+	Relevant bool    `json:"relevant"`
+}
+
+// FitCalibration fits a calibration for model to feedback samples. The
+// samples must include both relevant and irrelevant results.
+func FitCalibration(model string, samples []CalibrationSample) (Calibration, error) {
+	var pos, neg float64
+	for _, s := range samples {
+		if s.Relevant {
+			pos++
+		} else {
+			neg++
+		}
+	}
+	if pos == 0 || neg == 0 {
+		return Calibration{}, fmt.Errorf("calibrate %s: need both relevant and irrelevant samples", model)
+	}
+
+	// Platt's smoothed targets avoid overfitting small samples; the
+	// Newton iteration with backtracking follows Lin, Lin and Weng (2007).
+	hi, lo := (pos+1)/(pos+2), 1/(neg+2)
+	target := func(s CalibrationSample) float64 {
+		if s.Relevant {
+			return hi
+		}
+		return lo
+	}
+	// The model is P(relevant) = 1/(1+exp(a*score+b)).
+	loss := func(a, b float64) float64 {
+		var f float64
+		for _, s := range samples {
+			t, z := target(s), a*s.Score+b
+			if z >= 0 {
+				f += t*z + math.Log1p(math.Exp(-z))
+			} else {
+				f += (t-1)*z + math.Log1p(math.Exp(z))
+			}
+		}
+		return f
+	}
+
+	a, b := 0.0, math.Log((neg+1)/(pos+1))
+	f := loss(a, b)
+	for iter := 0; iter < 100; iter++ {
+		h11, h22, h21, g1, g2 := 1e-12, 1e-12, 0.0, 0.0, 0.0
+		for _, s := range samples {
+			z := a*s.Score + b
+			var p, q float64
+			if z >= 0 {
+				p, q = math.Exp(-z)/(1+math.Exp(-z)), 1/(1+math.Exp(-z))
+			} else {
+				p, q = 1/(1+math.Exp(z)), math.Exp(z)/(1+math.Exp(z))
+			}
+			d2 := p * q
+			h11 += s.Score * s.Score * d2
+			h22 += d2
+			h21 += s.Score * d2
+			d1 := target(s) - p
+			g1 += s.Score * d1
+			g2 += d1
+		}
+		if math.Abs(g1) < 1e-5 && math.Abs(g2) < 1e-5 {
+			break
+		}
+
+		det := h11*h22 - h21*h21
+		da, db := -(h22*g1-h21*g2)/det, -(-h21*g1+h11*g2)/det
+		gd := g1*da + g2*db
+		step := 1.0
+		for ; step >= 1e-10; step /= 2 {
+			na, nb := a+step*da, b+step*db
+			if nf := loss(na, nb); nf < f+1e-4*step*gd {
+				a, b, f = na, nb, nf
+				break
+			}
+		}
+		if step < 1e-10 {
+			break
+		}
+	}
+	return Calibration{Model: model, Slope: -a, Intercept: -b, Samples: len(samples)}, nil
+}
+
+// ScoreCalibrator keeps a calibration per embedding model and index, so
+// that relevance thresholds such as SearchMemoryRequest.MinRelevance keep
+// their meaning when the embedding model changes and raw scores shift.
+// Feed it relevance feedback with Observe, then call Fit; persist fitted
+// calibrations with Calibrations and restore them with SetCalibration.
+type ScoreCalibrator struct {
+	// MinSamples is the number of samples a model needs before Fit
+	// calibrates it. Defaults to DefaultCalibrationMinSamples.
+	MinSamples int
+
+	mu           sync.RWMutex
+	samples      map[string][]CalibrationSample
+	calibrations map[string]Calibration
+}
+
+// NewScoreCalibrator creates an empty ScoreCalibrator.
+func NewScoreCalibrator() *ScoreCalibrator {
+	return &ScoreCalibrator{
+		samples:      make(map[string][]CalibrationSample),
+		calibrations: make(map[string]Calibration),
+	}
+}
+
+// WithScoreCalibration sets the calibrated Relevance of search results
+// with cal and applies SearchMemoryRequest.MinRelevance.
+func WithScoreCalibration(cal *ScoreCalibrator) Option {
+	return func(c *Client) {
+		c.calibrator = cal
+	}
+}
+
+// Observe records feedback on a result scored by model, which is the
+// EmbeddingModel of the SearchResults it came from.
+func (s *ScoreCalibrator) Observe(model string, score float64, relevant bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[model] = append(s.samples[model], CalibrationSample{Score: score, Relevant: relevant})
+}
+
+// Fit refits the calibration of every model with enough feedback. Models
+// that cannot be fitted keep their previous calibration and are reported
+// in the returned error.
+func (s *ScoreCalibrator) Fit() error {
+	minSamples := s.MinSamples
+	if minSamples <= 0 {
+		minSamples = DefaultCalibrationMinSamples
+	}
+
+	s.mu.RLock()
+	pending := make(map[string][]CalibrationSample, len(s.samples))
+	for model, samples := range s.samples {
+		if len(samples) >= minSamples {
+			pending[model] = append([]CalibrationSample(nil), samples...)
+		}
+	}
+	s.mu.RUnlock()
+
+	var errs []error
+	fitted := make(map[string]Calibration, len(pending))
+	for model, samples := range pending {
+		cal, err := FitCalibration(model, samples)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fitted[model] = cal
+	}
+
+	s.mu.Lock()
+	for model, cal := range fitted {
+		s.calibrations[model] = cal
+	}
+	s.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// SetCalibration installs a calibration, e.g. one restored from storage.
+func (s *ScoreCalibrator) SetCalibration(cal Calibration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calibrations[cal.Model] = cal
+}
+
+// Calibrations returns the fitted calibrations, ordered by model.
+func (s *ScoreCalibrator) Calibrations() []Calibration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cals := make([]Calibration, 0, len(s.calibrations))
+	for _, cal := range s.calibrations {
+		cals = append(cals, cal)
+	}
+	sort.Slice(cals, func(i, j int) bool { return cals[i].Model < cals[j].Model })
+	return cals
+}
+
+// Relevance returns the calibrated relevance of a score from model, and
+// false if model has no calibration.
+func (s *ScoreCalibrator) Relevance(model string, score float64) (float64, bool) {
+	s.mu.RLock()
+	cal, ok := s.calibrations[model]
+	s.mu.RUnlock()
+	if !ok {
+		return 0, false
+	}
+	return cal.Relevance(score), true
+}
+
+// apply sets the relevance of each result and drops those below
+// minRelevance. Results of an uncalibrated model are kept as they are.
+func (s *ScoreCalibrator) apply(results *SearchResults, minRelevance float64) {
+	s.mu.RLock()
+	cal, ok := s.calibrations[results.EmbeddingModel]
+	s.mu.RUnlock()
+	if !ok {
+		return
+	}
+	kept := results.Results[:0]
+	for _, r := range results.Results {
+		relevance := cal.Relevance(r.Score)
+		if relevance < minRelevance {
+			continue
+		}
+		r.Relevance = &relevance
+		kept = append(kept, r)
+	}
+	results.Results = kept
+}
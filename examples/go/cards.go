@@ -0,0 +1,273 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CardIcon is the kind of icon a front-end shows for a memory card.
+type CardIcon string
+
+const (
+	CardIconUser     CardIcon = "user"     // stated by the user
+	CardIconInferred CardIcon = "inferred" // inferred from a conversation
+	CardIconImported CardIcon = "imported" // imported from another system
+	CardIconAgent    CardIcon = "agent"    // learned by an agent
+	CardIconSummary  CardIcon = "summary"  // summary or insight of other memories
+	CardIconImage    CardIcon = "image"    // image attachment
+	CardIconAudio    CardIcon = "audio"    // audio attachment
+	CardIconDocument CardIcon = "document" // other attachment
+	CardIconMemory   CardIcon = "memory"   // anything else
+)
+
+// CardBadge is a status a front-end shows on a memory card.
+type CardBadge string
+
+const (
+	CardBadgePinned     CardBadge = "pinned"
+	CardBadgePrivate    CardBadge = "private"
+	CardBadgeExpiring   CardBadge = "expiring"
+	CardBadgeSuperseded CardBadge = "superseded"
+	CardBadgeArchived   CardBadge = "archived"
+)
+
+// CardAction is an action a front-end offers on a memory card.
+type CardAction string
+
+const (
+	CardActionEdit       CardAction = "edit"
+	CardActionDelete     CardAction = "delete"
+	CardActionPin        CardAction = "pin"
+	CardActionUnpin      CardAction = "unpin"
+	CardActionAttachment CardAction = "open_attachment"
+)
+
+// MemoryCard is the presentation of a memory for user interfaces, so that
+// front-ends render memories the same way without each deriving titles,
+// icons and actions from the raw fields. It marshals to JSON for web
+// clients.
+type MemoryCard struct {
+	ID MemoryID `json:"id"`
+
+	// Title is the first sentence of the content, shortened to a line,
+	// and Summary the content shortened to a paragraph. Summary is empty
+	// when the title holds the whole content.
+	Title   string `json:"title"`
+	Summary string `json:"summary,omitempty"`
+
+	Icon       CardIcon    `json:"icon"`
+	Categories []string    `json:"categories,omitempty"`
+	Badges     []CardBadge `json:"badges,omitempty"`
+
+	// Score is the relevance of a search result: the calibrated
+	// Relevance when known, else the raw score. Nil for other memories.
+	Score *float64 `json:"score,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	Actions []CardAction `json:"actions"`
+}
+
+// CardOptions configures NewMemoryCard and NewSearchResultCard.
+type CardOptions struct {
+	// TitleLength and SummaryLength bound the title and summary in
+	// characters. Default 80 and 280.
+	TitleLength   int
+	SummaryLength int
+
+	// ReadOnly leaves out the actions that change the memory.
+	ReadOnly bool
+
+	// ExpiringWithin is how close to its expiry a memory is badged as
+	// expiring. Default 7 days.
+	ExpiringWithin time.Duration
+
+	// Now is the time expiry is measured from. Defaults to time.Now().
+	Now time.Time
+}
+
+// withDefaults returns opts with the defaults filled in.
+func (opts CardOptions) withDefaults() CardOptions {
+	if opts.TitleLength <= 0 {
+		opts.TitleLength = 80
+	}
+	if opts.SummaryLength <= 0 {
+		opts.SummaryLength = 280
+	}
+	if opts.ExpiringWithin <= 0 {
+		opts.ExpiringWithin = 7 * 24 * time.Hour
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	return opts
+}
+
+// NewMemoryCard returns the card of a memory.
+func NewMemoryCard(m *Memory, opts CardOptions) MemoryCard {
+	opts = opts.withDefaults()
+	card := newCard(m.MemoryID, m.Content, m.Metadata, m.Source, m.AgentID != "" && m.UserID == "", opts)
+	card.Categories = m.Categories
+	card.CreatedAt, card.UpdatedAt, card.ExpiresAt = m.CreatedAt, m.UpdatedAt, m.ExpiresAt
+
+	if m.Pinned {
+		card.Badges = append(card.Badges, CardBadgePinned)
+	}
+	if m.Visibility == VisibilityPrivate {
+		card.Badges = append(card.Badges, CardBadgePrivate)
+	}
+	if m.ExpiresAt != nil && m.ExpiresAt.Sub(opts.Now) <= opts.ExpiringWithin {
+		card.Badges = append(card.Badges, CardBadgeExpiring)
+	}
+	if m.SupersededBy != nil {
+		card.Badges = append(card.Badges, CardBadgeSuperseded)
+	}
+	if m.Tier == TierCold {
+		card.Badges = append(card.Badges, CardBadgeArchived)
+	}
+
+	card.Actions = cardActions(card.Actions, m.Pinned, m.SupersededBy != nil, opts)
+	return card
+}
+
+// NewSearchResultCard returns the card of a search result.
+func NewSearchResultCard(r *SearchResult, opts CardOptions) MemoryCard {
+	opts = opts.withDefaults()
+	card := newCard(r.MemoryID, r.Content, r.Metadata, r.Source, r.AgentID != "" && r.UserID == "", opts)
+	card.Categories = r.Categories
+
+	score := r.Score
+	if r.Relevance != nil {
+		score = *r.Relevance
+	}
+	card.Score = &score
+
+	if r.Pinned {
+		card.Badges = append(card.Badges, CardBadgePinned)
+	}
+	if r.Visibility == VisibilityPrivate {
+		card.Badges = append(card.Badges, CardBadgePrivate)
+	}
+
+	card.Actions = cardActions(card.Actions, r.Pinned, false, opts)
+	return card
+}
+
+// MemoryCards returns the cards of memories, in order.
+func MemoryCards(memories []Memory, opts CardOptions) []MemoryCard {
+	cards := make([]MemoryCard, len(memories))
+	for i := range memories {
+		cards[i] = NewMemoryCard(&memories[i], opts)
+	}
+	return cards
+}
+
+// SearchResultCards returns the cards of search results, in order.
+func SearchResultCards(results []SearchResult, opts CardOptions) []MemoryCard {
+	cards := make([]MemoryCard, len(results))
+	for i := range results {
+		cards[i] = NewSearchResultCard(&results[i], opts)
+	}
+	return cards
+}
+
+// newCard fills in the fields memories and search results share.
+func newCard(id MemoryID, content string, metadata map[string]interface{}, source MemorySource, agentOnly bool, opts CardOptions) MemoryCard {
+	content = strings.Join(strings.Fields(content), " ")
+	title := shorten(firstSentence(content), opts.TitleLength)
+	card := MemoryCard{ID: id, Title: title, Icon: cardIcon(metadata, source, agentOnly)}
+	if title != content {
+		card.Summary = shorten(content, opts.SummaryLength)
+	}
+	if _, ok := AttachmentOf(metadata); ok {
+		card.Actions = append(card.Actions, CardActionAttachment)
+	}
+	return card
+}
+
+// cardIcon picks the icon of a memory: its attachment's kind, then a
+// summary's, then its source's.
+func cardIcon(metadata map[string]interface{}, source MemorySource, agentOnly bool) CardIcon {
+	if att, ok := AttachmentOf(metadata); ok {
+		switch {
+		case strings.HasPrefix(att.ContentType, "image/"):
+			return CardIconImage
+		case strings.HasPrefix(att.ContentType, "audio/"):
+			return CardIconAudio
+		}
+		return CardIconDocument
+	}
+	if kind, _ := metadata[KindMetadataField].(string); kind == KindSummary || kind == KindInsight {
+		return CardIconSummary
+	}
+	switch {
+	case source == SourceUserStated:
+		return CardIconUser
+	case source == SourceInferred:
+		return CardIconInferred
+	case source == SourceImported:
+		return CardIconImported
+	case agentOnly:
+		return CardIconAgent
+	}
+	return CardIconMemory
+}
+
+// cardActions appends the actions allowed on a memory to actions.
+func cardActions(actions []CardAction, pinned, superseded bool, opts CardOptions) []CardAction {
+	if actions == nil {
+		actions = []CardAction{}
+	}
+	if opts.ReadOnly {
+		return actions
+	}
+	if !superseded {
+		actions = append(actions, CardActionEdit)
+	}
+	if pinned {
+		actions = append(actions, CardActionUnpin)
+	} else {
+		actions = append(actions, CardActionPin)
+	}
+	return append(actions, CardActionDelete)
+}
+
+// firstSentence returns s up to the end of its first sentence.
+func firstSentence(s string) string {
+	for i, r := range s {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		next := i + utf8.RuneLen(r)
+		if next == len(s) || s[next] == ' ' {
+			return s[:next]
+		}
+	}
+	return s
+}
+
+// shorten cuts s to at most n characters at a word boundary, marking the
+// cut with an ellipsis.
+func shorten(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	cut, count := 0, 0
+	for i := range s {
+		if count == n-1 {
+			cut = i
+			break
+		}
+		count++
+	}
+	// Back up to the last word boundary unless the cut is at one.
+	if next, _ := utf8.DecodeRuneInString(s[cut:]); unicode.IsSpace(next) {
+	} else if space := strings.LastIndexFunc(s[:cut], unicode.IsSpace); space > cut/2 {
+		cut = space
+	}
+	return strings.TrimRightFunc(s[:cut], func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }) + "…"
+}
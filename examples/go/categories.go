@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ListCategories lists the categories the server assigned to userID's
+// memories, with the number of memories in each, e.g. to build per-topic
+// views. An empty userID lists the categories across all users. Filter
+// lists and searches by category with ListMemoriesParams.Categories and
+// SearchMemoryRequest.Categories.
+func (c *Client) ListCategories(ctx context.Context, userID string) (*CategoryList, error) {
+	path := "/api/v1/categories"
+	if userID != "" {
+		path += "?" + url.Values{"user_id": {userID}}.Encode()
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[CategoryList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list categories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
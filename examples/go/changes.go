@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/webhooks"
+)
+
+// MemoryEvent is a change to a memory, delivered by SubscribeChanges. The
+// event types are those of webhook deliveries. For deletions and
+// expirations only the memory's IDs are set. Typed converts it to the
+// Event model shared with webhook deliveries.
+type MemoryEvent struct {
+	Type   webhooks.EventType `json:"type"`
+	Memory Memory             `json:"memory"`
+	At     *time.Time         `json:"at,omitempty"`
+
+	// SourceIDs are the memories merged into Memory, for
+	// webhooks.MemoryConsolidated.
+	SourceIDs []MemoryID `json:"source_ids,omitempty"`
+
+	// ResumeToken is the event's position in the change feed. Store it
+	// and pass it as SubscribeParams.ResumeToken to continue after the
+	// event, e.g. across restarts.
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// EventTransport selects how SubscribeChanges receives events.
+type EventTransport string
+
+const (
+	// TransportAuto uses server-sent events, and falls back to long
+	// polling when the SSE stream is unavailable, e.g. behind a proxy
+	// that blocks or buffers it.
+	TransportAuto EventTransport = ""
+
+	// TransportSSE uses server-sent events only.
+	TransportSSE EventTransport = "sse"
+
+	// TransportLongPoll uses long polling only.
+	TransportLongPoll EventTransport = "long_poll"
+)
+
+// DefaultLongPollWait is how long the server holds a long poll open when
+// no events arrive. It stays below the default 30s HTTP client timeout.
+const DefaultLongPollWait = 25 * time.Second
+
+// SubscribeParams contains parameters for SubscribeChanges. Empty fields
+// do not filter.
+type SubscribeParams struct {
+	UserID  string
+	AgentID string
+	RunID   string
+	Types   []webhooks.EventType
+
+	// Transport selects how events are received. Events, resume tokens
+	// and reconnection behave the same on every transport. DialRealtime
+	// ignores it.
+	Transport EventTransport
+
+	// LongPollWait is how long the server holds a long poll open when no
+	// events arrive. Defaults to DefaultLongPollWait.
+	LongPollWait time.Duration
+
+	// ResumeToken starts the feed after the event that carried it.
+	// Empty starts with changes made from now on.
+	ResumeToken string
+
+	// ReconnectDelay is the initial delay before reconnecting after the
+	// stream breaks. It doubles on each failed attempt, up to
+	// MaxReconnectDelay. Defaults to 1s and 30s; a retry interval sent
+	// by the server takes precedence over ReconnectDelay.
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+
+	// OnError, if set, is called from the subscription goroutine with
+	// each error that broke or ended the stream.
+	OnError func(error)
+}
+
+// SubscribeChanges consumes the server's change feed of memory creations,
+// updates and deletions, e.g. to invalidate caches downstream. When the
+// stream breaks it reconnects with backoff and resumes after the last
+// event received, so no changes are missed. The channel is closed when
+// ctx is done or the server rejects the subscription.
+func (c *Client) SubscribeChanges(ctx context.Context, params SubscribeParams) (<-chan MemoryEvent, error) {
+	sub := &changeSubscription{
+		c:        c,
+		params:   params,
+		token:    params.ResumeToken,
+		longPoll: params.Transport == TransportLongPoll,
+	}
+	// Client.Close ends the subscription too.
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	untrack, err := c.trackBackground("change feed", func(closeCtx context.Context) error {
+		cancel()
+		select {
+		case <-stopped:
+			return nil
+		case <-closeCtx.Done():
+			return closeCtx.Err()
+		}
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	fail := func(err error) (<-chan MemoryEvent, error) {
+		untrack()
+		cancel()
+		close(stopped)
+		return nil, err
+	}
+
+	body, err := sub.connect(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	if sub.longPoll {
+		// Poll once without waiting so that a rejected subscription is
+		// reported here, as on the SSE transport.
+		if err := sub.fetch(ctx, 0); err != nil {
+			return fail(err)
+		}
+	}
+
+	events := make(chan MemoryEvent)
+	go func() {
+		defer close(events)
+		defer close(stopped)
+		defer untrack()
+		defer cancel()
+		defer sub.recoverRun()
+		sub.run(ctx, body, events)
+	}()
+	return events, nil
+}
+
+// changeSubscription is the state of a SubscribeChanges stream.
+type changeSubscription struct {
+	c      *Client
+	params SubscribeParams
+	token  string
+	retry  time.Duration
+
+	// longPoll is set once the subscription polls instead of streaming;
+	// pending holds polled events not yet delivered.
+	longPoll bool
+	pending  []MemoryEvent
+}
+
+// recoverRun, deferred by the subscription's goroutine, ends the
+// subscription and reports a panic while reading it, e.g. on malformed
+// server data, instead of crashing the process.
+func (s *changeSubscription) recoverRun() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	pe := newPanicError("change feed", v)
+	s.c.reportPanic(pe)
+	s.reportError(pe)
+}
+
+// reportError calls OnError, if set, recovering a panic in it.
+func (s *changeSubscription) reportError(err error) {
+	if s.params.OnError != nil {
+		s.c.guard("OnError", func() { s.params.OnError(err) })
+	}
+}
+
+// errSSEUnavailable reports an event stream answered with something other
+// than server-sent events, typically by a proxy.
+var errSSEUnavailable = errors.New("server-sent events unavailable")
+
+// connect opens the event stream, resuming after the last event seen. With
+// TransportAuto it switches to long polling when the stream is
+// unavailable; when long polling, the returned body is nil.
+func (s *changeSubscription) connect(ctx context.Context) (io.ReadCloser, error) {
+	if s.longPoll {
+		return nil, nil
+	}
+	body, err := s.connectSSE(ctx)
+	if err != nil && s.params.Transport == TransportAuto && sseUnavailable(err) {
+		s.longPoll = true
+		return nil, nil
+	}
+	return body, err
+}
+
+// sseUnavailable reports whether err means the event stream cannot be
+// used, as opposed to the subscription being rejected.
+func sseUnavailable(err error) bool {
+	if errors.Is(err, errSSEUnavailable) {
+		return true
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotAcceptable,
+		http.StatusNotImplemented, http.StatusBadGateway:
+		return true
+	}
+	return false
+}
+
+// query returns the subscription's filters and resume position.
+func (s *changeSubscription) query() url.Values {
+	query := url.Values{}
+	if s.params.UserID != "" {
+		query.Set("user_id", s.params.UserID)
+	}
+	if s.params.AgentID != "" {
+		query.Set("agent_id", s.params.AgentID)
+	}
+	if s.params.RunID != "" {
+		query.Set("run_id", s.params.RunID)
+	}
+	for _, t := range s.params.Types {
+		query.Add("type", string(t))
+	}
+	if s.token != "" {
+		query.Set("resume_token", s.token)
+	}
+	return query
+}
+
+// connectSSE opens the server-sent event stream.
+func (s *changeSubscription) connectSSE(ctx context.Context) (io.ReadCloser, error) {
+	path := "/api/v1/memories/events"
+	if query := s.query(); len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := s.c.doStream(ctx, http.MethodGet, path, nil, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt != "text/event-stream" {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: unexpected content type %q", errSSEUnavailable, ct)
+		}
+	}
+	return resp.Body, nil
+}
+
+// changePage is the response to a long poll.
+type changePage struct {
+	Events []MemoryEvent `json:"events"`
+
+	// ResumeToken is the feed position after the page, which advances
+	// even when no events matched the filters.
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// fetch long-polls for the events after the last one seen, waiting up to
+// wait for one to arrive, and queues them for delivery.
+func (s *changeSubscription) fetch(ctx context.Context, wait time.Duration) error {
+	query := s.query()
+	query.Set("wait", strconv.FormatFloat(wait.Seconds(), 'f', -1, 64))
+
+	// Give up on a poll the server should have answered long ago, as a
+	// dead connection would otherwise hang until ctx is done.
+	ctx, cancel := context.WithTimeout(ctx, wait+30*time.Second)
+	defer cancel()
+	resp, err := s.c.doStream(ctx, http.MethodGet, "/api/v1/memories/events/poll?"+query.Encode(), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var page APIResponse[changePage]
+	if err := json.Unmarshal(respBody, &page); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !page.Success {
+		return fmt.Errorf("poll change feed failed: %s", page.Message)
+	}
+
+	polled := page.Data.Events
+	if n := len(polled); n > 0 && polled[n-1].ResumeToken == "" {
+		polled[n-1].ResumeToken = page.Data.ResumeToken
+	}
+	s.pending = append(s.pending, polled...)
+	if len(s.pending) == 0 && page.Data.ResumeToken != "" {
+		s.token = page.Data.ResumeToken
+	}
+	return nil
+}
+
+// poll delivers the queued events, then long-polls for more.
+func (s *changeSubscription) poll(ctx context.Context, events chan<- MemoryEvent) error {
+	for len(s.pending) > 0 {
+		event := s.pending[0]
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.pending = s.pending[1:]
+		if event.ResumeToken != "" {
+			s.token = event.ResumeToken
+		}
+	}
+
+	wait := s.params.LongPollWait
+	if wait <= 0 {
+		wait = DefaultLongPollWait
+	}
+	return s.fetch(ctx, wait)
+}
+
+// run delivers events from body, reconnecting until ctx is done or an
+// error is permanent.
+func (s *changeSubscription) run(ctx context.Context, body io.ReadCloser, events chan<- MemoryEvent) {
+	backoff := newReconnectBackoff(s.params)
+	for {
+		var err error
+		switch {
+		case body != nil:
+			err = s.read(ctx, body, events)
+			body.Close()
+			body = nil
+			backoff.reset()
+		case s.longPoll:
+			if err = s.poll(ctx, events); err == nil {
+				backoff.reset()
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.reportError(err)
+		}
+		if permanentStreamError(err) {
+			return
+		}
+		if !backoff.wait(ctx, s.retry) {
+			return
+		}
+
+		body, err = s.connect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.reportError(err)
+			if permanentStreamError(err) {
+				return
+			}
+		}
+	}
+}
+
+// read delivers the events of one connection until it ends.
+func (s *changeSubscription) read(ctx context.Context, body io.Reader, events chan<- MemoryEvent) error {
+	r := newSSEReader(body)
+	defer func() { s.retry = r.retry }()
+	for {
+		ev, err := r.next()
+		if err == io.EOF {
+			return errors.New("change feed closed by server")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read change feed: %w", err)
+		}
+
+		var event MemoryEvent
+		if err := json.Unmarshal([]byte(ev.Data), &event); err != nil {
+			// Skip the event rather than reconnect, which would replay it.
+			s.reportError(fmt.Errorf("failed to parse change event %s: %w", ev.ID, err))
+			if ev.ID != "" {
+				s.token = ev.ID
+			}
+			continue
+		}
+		if event.Type == "" {
+			event.Type = webhooks.EventType(ev.Event)
+		}
+		if ev.ID != "" {
+			event.ResumeToken = ev.ID
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if event.ResumeToken != "" {
+			s.token = event.ResumeToken
+		}
+	}
+}
+
+// permanentStreamError reports whether reconnecting after err is futile,
+// e.g. because the credentials or the resume token were rejected.
+func permanentStreamError(err error) bool {
+	if errors.Is(err, ErrClientClosed) {
+		return true
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+// reconnectBackoff is the jittered exponential delay between reconnection
+// attempts of a stream.
+type reconnectBackoff struct {
+	initial, max, next time.Duration
+}
+
+func newReconnectBackoff(params SubscribeParams) *reconnectBackoff {
+	b := &reconnectBackoff{initial: params.ReconnectDelay, max: params.MaxReconnectDelay}
+	if b.initial <= 0 {
+		b.initial = time.Second
+	}
+	if b.max <= 0 {
+		b.max = 30 * time.Second
+	}
+	b.next = b.initial
+	return b
+}
+
+// reset restarts the backoff after a successful connection.
+func (b *reconnectBackoff) reset() {
+	b.next = b.initial
+}
+
+// wait sleeps before the next attempt, for serverDelay instead if the
+// server requested one, and reports false if ctx is done first.
+func (b *reconnectBackoff) wait(ctx context.Context, serverDelay time.Duration) bool {
+	d := b.next
+	if serverDelay > 0 {
+		d = serverDelay
+	}
+	b.next = min(b.next*2, b.max)
+
+	timer := time.NewTimer(d/2 + jitter(d/2))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,604 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// errProblemsFound makes a command exit with status 1 after it has
+// reported its findings.
+var errProblemsFound = errors.New("problems found")
+
+// command is a subcommand of the example program, e.g. "audit verify".
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+// commands lists the subcommands in the order shown in usage.
+var commands = []command{
+	{"apply", "reconcile the server's configuration with a manifest", runApply},
+	{"audit verify", "verify the hash chain of an audit log", runAuditVerify},
+	{"compat", "check compatibility with server versions in Docker", runCompat},
+	{"memories list", "list memories", runMemoriesList},
+	{"memories search", "search memories", runMemoriesSearch},
+	{"memories move", "re-scope memories in bulk", runMemoriesMove},
+	{"repl", "try memory behavior interactively", runREPL},
+	{"seed", "load a demo scenario of memories", runSeed},
+}
+
+// runCommand runs the subcommand named by args and returns the exit status.
+func runCommand(args []string) int {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) || strings.Join(args[:len(words)], " ") != cmd.name {
+			continue
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		err := cmd.run(ctx, args[len(words):])
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errProblemsFound):
+			return 1
+		default:
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\nCommands:\n", strings.Join(args, " "))
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	return 2
+}
+
+// runApply implements "apply [flags] FILE". It only reports the changes
+// it would make with -dry-run.
+func runApply(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	var opts ApplyOptions
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "show the changes without making them")
+	fs.BoolVar(&opts.Prune, "prune", false, "delete resources of the managed kinds the manifest does not list")
+	out := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: apply [flags] FILE|-\n\nReconciles the server's configuration with the YAML manifest FILE or stdin (-).\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args, out); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	switch name := fs.Arg(0); name {
+	case "":
+		fs.Usage()
+		return errors.New("missing FILE")
+	case "-":
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return err
+	}
+	m, err := ParseManifest(data)
+	if err != nil {
+		return err
+	}
+
+	res, err := clientFromEnv().Apply(ctx, m, opts)
+	if err != nil {
+		return err
+	}
+	if out.structured() {
+		if err := out.print(res); err != nil {
+			return err
+		}
+	} else {
+		printApplyResult(res)
+	}
+	if len(res.Failed()) > 0 {
+		return errProblemsFound
+	}
+	return nil
+}
+
+// printApplyResult prints the changes of res, one per line followed by the
+// diff of updates.
+func printApplyResult(res *ApplyResult) {
+	verbs := map[ApplyAction]string{
+		ApplyCreate:    "created",
+		ApplyUpdate:    "configured",
+		ApplyDelete:    "deleted",
+		ApplyUnchanged: "unchanged",
+		ApplyUnmanaged: "not in manifest (use -prune to delete)",
+	}
+	for _, ch := range res.Changes {
+		status := verbs[ch.Action]
+		switch {
+		case ch.Err != nil:
+			status = fmt.Sprintf("failed to %s: %v", ch.Action, ch.Err)
+		case res.DryRun && ch.Action != ApplyUnchanged && ch.Action != ApplyUnmanaged:
+			status += " (dry run)"
+		}
+		fmt.Printf("%s/%s %s\n", ch.Kind, ch.Name, status)
+		for _, d := range ch.Diff {
+			fmt.Printf("    %s: %s -> %s\n", d.Path, formatDiffValue(d.Old), formatDiffValue(d.New))
+		}
+	}
+}
+
+// formatDiffValue formats a value of a FieldDiff as JSON, or "<unset>".
+func formatDiffValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// runAuditVerify implements "audit verify [flags] [FILE]". Without FILE
+// the audit log is read from the server.
+func runAuditVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	from := fs.String("from", "", "checkpoint `SEQ:HASH` preceding the first record, for rotated segments")
+	head := fs.String("head", "", "expected head `SEQ:HASH`, to detect truncation")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: audit verify [flags] [FILE|-]\n\nVerifies FILE, stdin (-) or the server's audit log.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var opts AuditVerifyOptions
+	if *from != "" {
+		cp, err := ParseAuditCheckpoint(*from)
+		if err != nil {
+			return err
+		}
+		opts.From = &cp
+	}
+	if *head != "" {
+		cp, err := ParseAuditCheckpoint(*head)
+		if err != nil {
+			return err
+		}
+		opts.ExpectedHead = &cp
+	}
+
+	var r io.Reader
+	switch name := fs.Arg(0); name {
+	case "-":
+		r = os.Stdin
+	case "":
+		var afterSeq int64
+		if opts.From != nil {
+			afterSeq = opts.From.Seq
+		}
+		rc, err := clientFromEnv().ExportAuditLog(ctx, afterSeq)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		r = rc
+	default:
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	report, err := VerifyAuditChain(r, opts)
+	if err != nil {
+		return err
+	}
+	for _, p := range report.Problems {
+		fmt.Println(p)
+	}
+	fmt.Printf("%d records, head %s\n", report.Records, report.Head)
+	if !report.OK() {
+		fmt.Printf("FAILED: %d problems\n", len(report.Problems))
+		return errProblemsFound
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// runMemoriesMove implements "memories move [flags]". It only reports what
+// would be moved unless -apply is given.
+func runMemoriesMove(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("memories move", flag.ContinueOnError)
+	fromUser := fs.String("from-user", "", "move memories of user `ID`")
+	fromAgent := fs.String("from-agent", "", "move memories of agent `ID`")
+	fromRun := fs.String("from-run", "", "move memories of run `ID`")
+	filterJSON := fs.String("filter", "", "additional metadata filter as `JSON`")
+	var target MoveTarget
+	fs.StringVar(&target.UserID, "to-user", "", "target user `ID`")
+	fs.StringVar(&target.AgentID, "to-agent", "", "target agent `ID`")
+	fs.StringVar(&target.RunID, "to-run", "", "target run `ID`")
+	fs.Func("to-scope", "target `scope`: user, agent, run or global", func(v string) error {
+		target.Scope = Scope(v)
+		return checkScope(target.Scope)
+	})
+	reason := fs.String("reason", "", "reason recorded in the audit log")
+	apply := fs.Bool("apply", false, "move the memories instead of a dry run")
+	out := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: memories move [flags]\n\nMoves the memories matching the -from flags and -filter to the -to scope.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args, out); err != nil {
+		return err
+	}
+
+	var filters []MetadataFilter
+	for _, f := range []struct{ field, value string }{
+		{"user_id", *fromUser}, {"agent_id", *fromAgent}, {"run_id", *fromRun},
+	} {
+		if f.value != "" {
+			filters = append(filters, MetaEq(f.field, f.value))
+		}
+	}
+	if *filterJSON != "" {
+		var f MetadataFilter
+		if err := json.Unmarshal([]byte(*filterJSON), &f); err != nil {
+			return fmt.Errorf("invalid -filter: %w", err)
+		}
+		filters = append(filters, f)
+	}
+	var filter MetadataFilter
+	switch len(filters) {
+	case 0:
+	case 1:
+		filter = filters[0]
+	default:
+		filter = MetaAnd(filters...)
+	}
+
+	res, err := clientFromEnv().MoveMemories(ctx, filter, target, MoveOptions{DryRun: !*apply, Reason: *reason})
+	if err != nil {
+		return err
+	}
+	if out.structured() {
+		return out.print(res)
+	}
+	if !*apply {
+		for _, m := range res.Preview {
+			fmt.Printf("%s\t%s\t%s\n", m.MemoryID, m.UserID, m.Content)
+		}
+		fmt.Printf("%d memories would be moved; rerun with -apply to move them\n", res.Matched)
+		return nil
+	}
+	if res.JobID != "" {
+		fmt.Printf("moving %d memories in job %s\n", res.Matched, res.JobID)
+		return nil
+	}
+	fmt.Printf("moved %d of %d memories (audit seq %d)\n", res.Moved, res.Matched, res.AuditSeq)
+	return nil
+}
+
+// runMemoriesList implements "memories list [flags]".
+func runMemoriesList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("memories list", flag.ContinueOnError)
+	params := DefaultListParams()
+	fs.StringVar(&params.UserID, "user", "", "list memories of user `ID`")
+	fs.StringVar(&params.AgentID, "agent", "", "list memories of agent `ID`")
+	fs.IntVar(&params.Limit, "limit", params.Limit, "maximum number of memories")
+	fs.IntVar(&params.Offset, "offset", 0, "number of memories to skip")
+	out := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: memories list [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args, out); err != nil {
+		return err
+	}
+
+	list, err := clientFromEnv().ListMemories(params)
+	if err != nil {
+		return err
+	}
+	if out.format == "cards" {
+		return out.print(MemoryCards(list.Memories, CardOptions{}))
+	}
+	if out.structured() {
+		return out.print(list)
+	}
+	for _, m := range list.Memories {
+		fmt.Printf("%s\t%s\t%s\n", m.MemoryID, m.UserID, m.Content)
+	}
+	fmt.Printf("%d of %d memories\n", len(list.Memories), list.Total)
+	return nil
+}
+
+// runMemoriesSearch implements "memories search [flags] QUERY".
+func runMemoriesSearch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("memories search", flag.ContinueOnError)
+	var req SearchMemoryRequest
+	fs.StringVar(&req.UserID, "user", "", "search memories of user `ID`")
+	fs.StringVar(&req.AgentID, "agent", "", "search memories of agent `ID`")
+	fs.IntVar(&req.Limit, "limit", 10, "maximum number of results")
+	out := addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: memories search [flags] QUERY\n\n")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args, out); err != nil {
+		return err
+	}
+	req.Query = strings.Join(fs.Args(), " ")
+	if req.Query == "" {
+		fs.Usage()
+		return errors.New("missing QUERY")
+	}
+
+	results, err := clientFromEnv().searchMemories(ctx, &req)
+	if err != nil {
+		return err
+	}
+	if out.format == "cards" {
+		return out.print(SearchResultCards(results.Results, CardOptions{}))
+	}
+	if out.structured() {
+		return out.print(results)
+	}
+	for _, r := range results.Results {
+		fmt.Printf("%.3f\t%s\t%s\n", r.Score, r.MemoryID, r.Content)
+	}
+	return nil
+}
+
+// runSeed implements "seed [flags]".
+func runSeed(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	name := fs.String("scenario", "", "built-in scenario `NAME` to load")
+	file := fs.String("file", "", "load the scenario from JSON `FILE` instead")
+	var opts SeedOptions
+	fs.StringVar(&opts.Namespace, "namespace", "", "`PREFIX` for the scenario's user and agent IDs")
+	fs.BoolVar(&opts.Infer, "infer", false, "run inference on the seeded memories")
+	reset := fs.Bool("reset", false, "delete memories seeded earlier from the scenario first")
+	list := fs.Bool("list", false, "list the built-in scenarios")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: seed -scenario NAME [flags]\n\nScenarios: %s\n\n", strings.Join(Scenarios(), ", "))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, name := range Scenarios() {
+			s, err := LoadScenario(name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-16s %s\n", s.Name, s.Description)
+		}
+		return nil
+	}
+
+	var s *Scenario
+	var err error
+	switch {
+	case *file != "":
+		var data []byte
+		if data, err = os.ReadFile(*file); err == nil {
+			s, err = ParseScenario(data)
+		}
+	case *name != "":
+		s, err = LoadScenario(*name)
+	default:
+		fs.Usage()
+		return errors.New("missing -scenario")
+	}
+	if err != nil {
+		return err
+	}
+
+	client := clientFromEnv()
+	if *reset {
+		n, err := client.UnseedScenario(ctx, s, opts.Namespace)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("deleted %d previously seeded memories\n", n)
+	}
+	res, err := client.SeedScenario(ctx, s, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("seeded %d memories for users %s", res.Created, strings.Join(res.Users, ", "))
+	if len(res.Agents) > 0 {
+		fmt.Printf(" and agents %s", strings.Join(res.Agents, ", "))
+	}
+	fmt.Println()
+	if res.Failed > 0 {
+		fmt.Printf("%d memories failed\n", res.Failed)
+		return errProblemsFound
+	}
+	return nil
+}
+
+// runCompat implements "compat -versions V1,V2,... [flags]".
+func runCompat(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("compat", flag.ContinueOnError)
+	versions := fs.String("versions", "", "comma-separated server `VERSIONS` (image tags) to check")
+	var launcher DockerLauncher
+	fs.StringVar(&launcher.Image, "image", "oceanbase/powermem-server", "server `IMAGE`")
+	fs.Func("env", "server setting `KEY=VALUE`; may be repeated", func(kv string) error {
+		launcher.Env = append(launcher.Env, kv)
+		return nil
+	})
+	fs.StringVar(&launcher.EnvFile, "env-file", "", "`FILE` of server settings")
+	fs.StringVar(&launcher.Network, "network", "", "Docker `NETWORK` to attach the servers to, e.g. the database's")
+	var opts CompatOptions
+	fs.DurationVar(&opts.StartTimeout, "start-timeout", 2*time.Minute, "how long to wait for each server to become healthy")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: compat -versions V1,V2,... [flags]\n\nStarts each server version in Docker and runs the compatibility checks against it.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	for _, v := range strings.Split(*versions, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			opts.Versions = append(opts.Versions, v)
+		}
+	}
+	if len(opts.Versions) == 0 {
+		fs.Usage()
+		return errors.New("missing -versions")
+	}
+
+	opts.APIKey = os.Getenv("POWERMEM_API_KEY")
+	opts.OnResult = func(version string, res CompatResult) {
+		fmt.Printf("%-12s %-16s %-11s %6s", version, res.Check, res.Status, res.Duration.Round(time.Millisecond))
+		if res.Err != nil {
+			fmt.Printf("  %v", res.Err)
+		}
+		fmt.Println()
+	}
+	reports, err := RunCompatMatrix(ctx, launcher, opts)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, r := range reports {
+		switch {
+		case r.Err != nil:
+			fmt.Printf("%-12s %v\n", r.Version, r.Err)
+			failed = true
+		case !r.Passed():
+			failed = true
+		}
+	}
+	if failed {
+		return errProblemsFound
+	}
+	fmt.Printf("all checks passed against %s\n", strings.Join(opts.Versions, ", "))
+	return nil
+}
+
+// outputFlags are the -output and -query flags of commands whose results
+// scripts consume, kubectl style:
+//
+//	memories list -user u1 -o 'jsonpath={.memories[*].content}'
+//	memories search -query '{.results[?(@.score > 0.8)]}' -o json coffee
+type outputFlags struct {
+	format string
+	query  string
+
+	template *jsonPath
+	selector *jsonPath
+}
+
+func addOutputFlags(fs *flag.FlagSet) *outputFlags {
+	o := &outputFlags{}
+	usage := "output `format`: text, json, cards (memory cards as JSON) or jsonpath=TEMPLATE"
+	fs.StringVar(&o.format, "output", "text", usage)
+	fs.StringVar(&o.format, "o", "text", usage)
+	fs.StringVar(&o.query, "query", "", "JSONPath `expression` selecting part of the result")
+	return o
+}
+
+// parseFlags parses args into fs and validates the output flags, so that
+// a bad template fails before any request is made.
+func parseFlags(fs *flag.FlagSet, args []string, o *outputFlags) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var err error
+	if o.query != "" {
+		if o.selector, err = parseJSONPath(o.query); err != nil {
+			return fmt.Errorf("invalid -query: %w", err)
+		}
+	}
+	switch format, tmpl, _ := strings.Cut(o.format, "="); format {
+	case "text", "json", "cards":
+		if tmpl != "" {
+			return fmt.Errorf("invalid -output %q", o.format)
+		}
+	case "jsonpath":
+		if o.template, err = parseJSONPath(tmpl); err != nil {
+			return fmt.Errorf("invalid -output: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid -output %q: want text, json, cards or jsonpath=TEMPLATE", o.format)
+	}
+	return nil
+}
+
+// structured reports whether the result is to be printed by print rather
+// than as the command's text.
+func (o *outputFlags) structured() bool {
+	return o.format != "text" || o.selector != nil
+}
+
+// print writes result, narrowed by -query, in the -output format. With
+// the text format each selected value is written on its own line.
+func (o *outputFlags) print(result interface{}) error {
+	data, err := toJSONValue(result)
+	if err != nil {
+		return err
+	}
+	if o.selector != nil {
+		data = o.selector.query(data)
+	}
+
+	switch {
+	case o.template != nil:
+		var b strings.Builder
+		if err := o.template.execute(&b, data); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+		_, err := io.WriteString(os.Stdout, b.String())
+		return err
+	case o.format == "json" || o.format == "cards":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	}
+
+	values, ok := data.([]interface{})
+	if !ok {
+		values = []interface{}{data}
+	}
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			fmt.Println(s)
+			continue
+		}
+		enc, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(enc))
+	}
+	return nil
+}
@@ -7,13 +7,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
 )
 
 // Client is a PowerMem API client.
@@ -23,34 +29,192 @@ type Client struct {
 	BaseURL string
 
 	// APIKey is the API key for authentication.
-	// Set via X-API-Key header.
+	// Set via X-API-Key header. If empty, the key is read from the
+	// credential store configured with WithCredentialStore, if any.
 	APIKey string
 
 	// HTTPClient is the underlying HTTP client.
 	// If nil, a default client with 30s timeout is used.
 	HTTPClient *http.Client
+
+	// middleware is the interceptor chain applied to every request,
+	// outermost first.
+	middleware []Middleware
+
+	// redactContent redacts memory content in debug logs.
+	redactContent bool
+
+	// defaultMetadata is merged into the metadata of every create and
+	// update request.
+	defaultMetadata map[string]interface{}
+
+	// defaultScope is the scope of creates that set none.
+	defaultScope Scope
+
+	// credentials supplies the API key when APIKey is empty.
+	credentials *credentialSource
+
+	// tokens supplies the bearer token, if any.
+	tokens *credentialSource
+
+	// ingestorOpts configures the ingestor behind CreateMemoryAsyncNoWait.
+	ingestorOpts *IngestorOptions
+
+	// offlineOpts configures the offline queue behind CreateMemory.
+	offlineOpts *OfflineQueueOptions
+
+	// writePriority is the priority of writes queued by
+	// CreateMemoryAsyncNoWait.
+	writePriority Priority
+
+	// receipts verifies the signed receipts requested for every create.
+	receipts *ReceiptVerifier
+
+	// blobs stores attachment content.
+	blobs BlobStore
+
+	// vision extracts text from images for CreateImageMemory.
+	vision VisionProvider
+
+	// transcriber converts speech to text for CreateAudioMemory.
+	transcriber Transcriber
+
+	// swrOpts configures the cache behind SearchMemoriesSWR.
+	swrOpts *SWROptions
+
+	// calibrator maps raw search scores to calibrated relevance.
+	calibrator *ScoreCalibrator
+
+	// eventParams selects the change feed behind the event handlers.
+	eventParams *SubscribeParams
+
+	// shards routes requests to the shard service of their user.
+	shards ShardResolver
+
+	// onPanic is called with the panics the client recovers.
+	onPanic func(*PanicError)
+
+	// serializeUserWrites serializes writes per user ID.
+	serializeUserWrites bool
+
+	// experimental enables preview APIs, in addition to the environment.
+	experimental experimental.Flags
+
+	// st holds state shared between a client and its children.
+	// Access it through state().
+	st        *clientState
+	stateOnce sync.Once
 }
 
-// NewClient creates a new PowerMem API client.
-func NewClient(baseURL, apiKey string) *Client {
-	return &Client{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// clientState is mutable state shared by a client and all children
+// derived from it with With.
+type clientState struct {
+	// naturalKeyLocks serializes GetOrCreateMemory calls per natural key
+	// when the server lacks native get-or-create support.
+	naturalKeyLocks keyedMutex
+
+	// userWriteLocks serializes writes per user ID when enabled with
+	// WithUserWriteSerialization.
+	userWriteLocks keyedMutex
+
+	// noServerGetOrCreate is set once the server has reported that it does
+	// not support the get-or-create endpoint.
+	noServerGetOrCreate atomic.Bool
+
+	// bundle is the active support bundle, if any.
+	bundle atomic.Pointer[SupportBundle]
+
+	// ingestor uploads writes queued by CreateMemoryAsyncNoWait.
+	ingestor     *Ingestor
+	ingestorErr  error
+	ingestorOnce sync.Once
+
+	// offline queues CreateMemory writes while the server is unreachable.
+	offline     *OfflineQueue
+	offlineErr  error
+	offlineOnce sync.Once
+
+	// searchCache serves SearchMemoriesSWR.
+	searchCache     *searchCache
+	searchCacheErr  error
+	searchCacheOnce sync.Once
+
+	// events dispatches the change feed to OnMemoryCreated and the other
+	// event handlers.
+	events eventDispatcher
+
+	// lifecycle tracks background components stopped by Close.
+	lifecycle lifecycle
+}
+
+// state returns the client's shared state, creating it on first use.
+func (c *Client) state() *clientState {
+	c.stateOnce.Do(func() {
+		if c.st == nil {
+			c.st = &clientState{}
+		}
+	})
+	return c.st
+}
+
+// With returns a child client that shares the parent's HTTP client and
+// state but applies opts on top of the parent's configuration, e.g. to
+// add default metadata for one component:
+//
+//	billing := client.With(WithDefaultMetadata(map[string]interface{}{"component": "billing"}))
+//
+// Changes to the child do not affect the parent.
+func (c *Client) With(opts ...Option) *Client {
+	child := &Client{
+		BaseURL:             c.BaseURL,
+		APIKey:              c.APIKey,
+		HTTPClient:          c.HTTPClient,
+		middleware:          append([]Middleware(nil), c.middleware...),
+		redactContent:       c.redactContent,
+		defaultMetadata:     c.defaultMetadata,
+		defaultScope:        c.defaultScope,
+		credentials:         c.credentials,
+		tokens:              c.tokens,
+		ingestorOpts:        c.ingestorOpts,
+		offlineOpts:         c.offlineOpts,
+		writePriority:       c.writePriority,
+		receipts:            c.receipts,
+		blobs:               c.blobs,
+		vision:              c.vision,
+		transcriber:         c.transcriber,
+		swrOpts:             c.swrOpts,
+		calibrator:          c.calibrator,
+		eventParams:         c.eventParams,
+		shards:              c.shards,
+		onPanic:             c.onPanic,
+		serializeUserWrites: c.serializeUserWrites,
+		experimental:        c.experimental,
+		st:                  c.state(),
+	}
+	for _, opt := range opts {
+		opt(child)
 	}
+	return child
+}
+
+// NewClient creates a new PowerMem API client.
+func NewClient(baseURL, apiKey string, opts ...Option) *Client {
+	return NewClientWithTimeout(baseURL, apiKey, 30*time.Second, opts...)
 }
 
 // NewClientWithTimeout creates a new client with a custom timeout.
-func NewClientWithTimeout(baseURL, apiKey string, timeout time.Duration) *Client {
-	return &Client{
+func NewClientWithTimeout(baseURL, apiKey string, timeout time.Duration, opts ...Option) *Client {
+	c := &Client{
 		BaseURL: baseURL,
 		APIKey:  apiKey,
 		HTTPClient: &http.Client{
 			Timeout: timeout,
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // =============================================================================
@@ -59,49 +223,124 @@ func NewClientWithTimeout(baseURL, apiKey string, timeout time.Duration) *Client
 
 // doRequest performs an HTTP request and returns the response body.
 func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error) {
+	return c.doRequestContext(context.Background(), method, path, body)
+}
+
+// doRequestContext performs an HTTP request bound to ctx and returns the
+// response body.
+func (c *Client) doRequestContext(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	resp, err := c.send(ctx, c.roundTrip(), method, path, body, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if resp.Request != nil {
+			err = timeoutErrorOf(resp.Request.Context(), err)
+		}
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return respBody, nil
+}
+
+// doStream performs an HTTP request bound to ctx and returns the response
+// with its body unread, for streaming endpoints. The caller must close the
+// body. Non-2xx responses are consumed and returned as *Error. accept, if
+// set, is sent as the Accept header. The HTTP client's timeout does not
+// apply, as it would cut long-lived streams; the stream ends with ctx.
+func (c *Client) doStream(ctx context.Context, method, path string, body interface{}, accept string) (*http.Response, error) {
+	return c.send(ctx, c.streamRoundTrip(), method, path, body, accept)
+}
+
+// send performs an HTTP request through rt and returns the response with
+// its body unread. A panic in the middleware, the credential store or the
+// shard resolver is returned as a *PanicError.
+func (c *Client) send(ctx context.Context, rt RoundTripFunc, method, path string, body interface{}, accept string) (_ *http.Response, err error) {
+	defer c.recoverPanic("request", &err)
+
 	var reqBody io.Reader
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		reqBody = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	base, shard, err := c.baseURL(ctx, path, jsonData)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withRequestTrace(ctx, method, path)
+	ctx = context.WithValue(ctx, requestClientKey{}, c)
+	req, err := http.NewRequestWithContext(ctx, method, base+path, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	if c.APIKey != "" {
-		req.Header.Set("X-API-Key", c.APIKey)
+	if shard != "" {
+		req.Header.Set(ShardKeyHeader, shard)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if err := c.setAuth(ctx, req.Header); err != nil {
+		return nil, err
 	}
 
-	// Execute request
-	resp, err := c.HTTPClient.Do(req)
+	// Execute request through the middleware chain
+	resp, err := rt(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", timeoutErrorOf(ctx, err))
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	// A rejected key or token may have been rotated or revoked. The
+	// request is sent again once if fresh credentials differ.
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.invalidateAuth()
+		retry, err := c.reauthorize(ctx, req, jsonData)
+		if err != nil {
+			discardBody(resp)
+			return nil, err
+		}
+		if retry != nil {
+			// Drain the rejected response so that its connection can be
+			// reused for the retry.
+			discardBody(resp)
+			if resp, err = rt(retry); err != nil {
+				return nil, fmt.Errorf("request failed: %w", timeoutErrorOf(ctx, err))
+			}
+			if resp.StatusCode == http.StatusUnauthorized {
+				c.invalidateAuth()
+			}
+		}
 	}
 
 	// Check for HTTP errors
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var apiResp APIResponse[any]
-		if err := json.Unmarshal(respBody, &apiResp); err == nil && apiResp.Error != nil {
-			return nil, fmt.Errorf("API error [%s]: %s", apiResp.Error.Code, apiResp.Error.Message)
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
 		}
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(respBody))
+		return nil, parseError(resp, respBody)
 	}
 
-	return respBody, nil
+	return resp, nil
+}
+
+// discardBody reads up to 64 KiB of resp's body and closes it, so that
+// the connection can be reused when the body is small.
+func discardBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
 }
 
 // =============================================================================
@@ -111,7 +350,12 @@ func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error
 // Health checks the health status of the API server.
 // This endpoint is public and does not require authentication.
 func (c *Client) Health() (*HealthResponse, error) {
-	respBody, err := c.doRequest(http.MethodGet, "/api/v1/system/health", nil)
+	return c.health(context.Background())
+}
+
+// health checks the health of the server, bound to ctx.
+func (c *Client) health(ctx context.Context) (*HealthResponse, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/system/health", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +374,12 @@ func (c *Client) Health() (*HealthResponse, error) {
 
 // Status gets the system status and configuration information.
 func (c *Client) Status() (*SystemStatusResponse, error) {
-	respBody, err := c.doRequest(http.MethodGet, "/api/v1/system/status", nil)
+	return c.status(context.Background())
+}
+
+// status gets the system status, bound to ctx.
+func (c *Client) status(ctx context.Context) (*SystemStatusResponse, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/system/status", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +403,54 @@ func (c *Client) Status() (*SystemStatusResponse, error) {
 // CreateMemory creates a new memory.
 // When infer is true (default), PowerMem may extract multiple memories from the content.
 func (c *Client) CreateMemory(req *CreateMemoryRequest) ([]CreatedMemory, error) {
-	respBody, err := c.doRequest(http.MethodPost, "/api/v1/memories", req)
+	if c.offlineOpts != nil {
+		q, err := c.OfflineQueue()
+		if err != nil {
+			return nil, err
+		}
+		return q.create(context.Background(), c, req)
+	}
+	return c.createMemory(context.Background(), req)
+}
+
+// createMemory creates a new memory, bound to ctx.
+func (c *Client) createMemory(ctx context.Context, req *CreateMemoryRequest) ([]CreatedMemory, error) {
+	req, err := c.prepareCreate(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.postMemory(ctx, req)
+}
+
+// prepareCreate validates req and applies the client's defaults and
+// receipt setting to it.
+func (c *Client) prepareCreate(req *CreateMemoryRequest) (*CreateMemoryRequest, error) {
+	if err := checkVisibility(req.Visibility); err != nil {
+		return nil, err
+	}
+	if err := checkMemoryType(req.MemoryType); err != nil {
+		return nil, err
+	}
+	if err := checkImportance(req.Importance); err != nil {
+		return nil, err
+	}
+	req = c.applyDefaults(req)
+	if err := checkScope(req.Scope); err != nil {
+		return nil, err
+	}
+	if c.receipts != nil && !req.Receipt {
+		withReceipt := *req
+		withReceipt.Receipt = true
+		req = &withReceipt
+	}
+	return req, nil
+}
+
+// postMemory sends a create request prepared by prepareCreate.
+func (c *Client) postMemory(ctx context.Context, req *CreateMemoryRequest) ([]CreatedMemory, error) {
+	unlock := c.lockUserWrites(req.UserID)
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories", req)
+	unlock()
 	if err != nil {
 		return nil, err
 	}
@@ -168,18 +464,112 @@ func (c *Client) CreateMemory(req *CreateMemoryRequest) ([]CreatedMemory, error)
 		return nil, fmt.Errorf("create memory failed: %s", resp.Message)
 	}
 
+	if c.receipts != nil && req.Receipt && !req.Async {
+		for _, m := range resp.Data {
+			if m.Skipped() {
+				continue
+			}
+			if err := c.receipts.VerifyCreated(m); err != nil {
+				return resp.Data, err
+			}
+		}
+	}
+
 	return resp.Data, nil
 }
 
+// BatchCreateMemories creates up to 100 memories sharing one scope in a
+// single request. Items that fail are reported in the result rather than
+// failing the whole call.
+func (c *Client) BatchCreateMemories(ctx context.Context, req *BatchCreateMemoryRequest) (*BatchCreateResult, error) {
+	req, err := c.prepareBatch(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.postBatch(ctx, req)
+}
+
+// prepareBatch validates req and applies the client's defaults to its
+// items.
+func (c *Client) prepareBatch(req *BatchCreateMemoryRequest) (*BatchCreateMemoryRequest, error) {
+	for i, item := range req.Memories {
+		scope := item.Scope
+		if scope == "" {
+			scope = c.defaultScope
+		}
+		if err := checkScope(scope); err != nil {
+			return nil, fmt.Errorf("memory %d: %w", i, err)
+		}
+		if err := checkMemoryType(item.MemoryType); err != nil {
+			return nil, fmt.Errorf("memory %d: %w", i, err)
+		}
+		if err := checkImportance(item.Importance); err != nil {
+			return nil, fmt.Errorf("memory %d: %w", i, err)
+		}
+	}
+	if c.defaultMetadata != nil || c.defaultScope != "" {
+		withDefaults := *req
+		withDefaults.Memories = make([]BatchMemoryItem, len(req.Memories))
+		for i, item := range req.Memories {
+			if c.defaultMetadata != nil {
+				item.Metadata = mergeMetadata(c.defaultMetadata, item.Metadata)
+			}
+			if item.Scope == "" {
+				item.Scope = c.defaultScope
+			}
+			withDefaults.Memories[i] = item
+		}
+		req = &withDefaults
+	}
+	return req, nil
+}
+
+// postBatch sends a batch create request prepared by prepareBatch.
+func (c *Client) postBatch(ctx context.Context, req *BatchCreateMemoryRequest) (*BatchCreateResult, error) {
+	unlock := c.lockUserWrites(req.UserID)
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories/batch", req)
+	unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[BatchCreateResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("batch create memories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
 // GetMemory retrieves a single memory by ID.
 func (c *Client) GetMemory(memoryID MemoryID, userID, agentID string) (*Memory, error) {
+	return c.getMemory(context.Background(), memoryID, userID, agentID)
+}
+
+// getMemory retrieves a single memory by ID, bound to ctx.
+func (c *Client) getMemory(ctx context.Context, memoryID MemoryID, userID, agentID string) (*Memory, error) {
+	return c.GetMemoryWithOptions(ctx, memoryID, GetMemoryOptions{UserID: userID, AgentID: agentID})
+}
+
+// GetMemoryWithOptions retrieves a single memory by ID.
+func (c *Client) GetMemoryWithOptions(ctx context.Context, memoryID MemoryID, opts GetMemoryOptions) (*Memory, error) {
 	// Build query parameters
 	params := url.Values{}
-	if userID != "" {
-		params.Set("user_id", userID)
+	if opts.UserID != "" {
+		params.Set("user_id", opts.UserID)
+	}
+	if opts.AgentID != "" {
+		params.Set("agent_id", opts.AgentID)
 	}
-	if agentID != "" {
-		params.Set("agent_id", agentID)
+	if opts.IncludeEmbeddings {
+		params.Set("include_embeddings", "true")
+	}
+	if opts.IncludeSuperseded {
+		params.Set("include_superseded", "true")
 	}
 
 	path := fmt.Sprintf("/api/v1/memories/%s", memoryID.String())
@@ -187,7 +577,7 @@ func (c *Client) GetMemory(memoryID MemoryID, userID, agentID string) (*Memory,
 		path += "?" + params.Encode()
 	}
 
-	respBody, err := c.doRequest(http.MethodGet, path, nil)
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +594,42 @@ func (c *Client) GetMemory(memoryID MemoryID, userID, agentID string) (*Memory,
 	return &resp.Data, nil
 }
 
+// GetMemoryHistory retrieves the changes to a memory, oldest first, e.g.
+// to show a user exactly how a fact in their profile evolved.
+func (c *Client) GetMemoryHistory(ctx context.Context, memoryID MemoryID, opts HistoryOptions) (*MemoryHistory, error) {
+	params := url.Values{}
+	if opts.UserID != "" {
+		params.Set("user_id", opts.UserID)
+	}
+	if opts.AgentID != "" {
+		params.Set("agent_id", opts.AgentID)
+	}
+	if opts.IncludeSuperseded {
+		params.Set("include_superseded", "true")
+	}
+
+	path := fmt.Sprintf("/api/v1/memories/%s/history", memoryID.String())
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemoryHistory]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get memory history failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
 // ListMemories retrieves a list of memories with optional filtering and pagination.
 func (c *Client) ListMemories(params ListMemoriesParams) (*MemoryList, error) {
 	// Build query parameters
@@ -226,6 +652,15 @@ func (c *Client) ListMemories(params ListMemoriesParams) (*MemoryList, error) {
 	if params.Order != "" {
 		queryParams.Set("order", params.Order)
 	}
+	for _, t := range params.MemoryTypes {
+		queryParams.Add("memory_type", string(t))
+	}
+	if params.Expiry != "" {
+		queryParams.Set("expiry", string(params.Expiry))
+	}
+	for _, category := range params.Categories {
+		queryParams.Add("category", category)
+	}
 
 	path := "/api/v1/memories"
 	if len(queryParams) > 0 {
@@ -246,14 +681,45 @@ func (c *Client) ListMemories(params ListMemoriesParams) (*MemoryList, error) {
 		return nil, fmt.Errorf("list memories failed: %s", resp.Message)
 	}
 
+	filterVisibleMemories(&resp.Data, params.UserID, params.AgentID)
 	return &resp.Data, nil
 }
 
 // UpdateMemory updates an existing memory.
+//
+// Default metadata is merged only when req.Metadata is set, since the server
+// replaces a memory's metadata wholesale on update.
 func (c *Client) UpdateMemory(memoryID MemoryID, req *UpdateMemoryRequest) (*Memory, error) {
+	return c.updateMemory(context.Background(), memoryID, req)
+}
+
+// updateMemory updates an existing memory, bound to ctx.
+func (c *Client) updateMemory(ctx context.Context, memoryID MemoryID, req *UpdateMemoryRequest) (*Memory, error) {
+	if err := checkVisibility(req.Visibility); err != nil {
+		return nil, err
+	}
+	if req.Importance != nil {
+		if err := checkImportance(*req.Importance); err != nil {
+			return nil, err
+		}
+	}
+
 	path := fmt.Sprintf("/api/v1/memories/%s", memoryID.String())
 
-	respBody, err := c.doRequest(http.MethodPut, path, req)
+	if req.TTL > 0 && req.ExpiresAt == nil {
+		withExpiry := *req
+		withExpiry.ExpiresAt = expiresAt(req.TTL)
+		req = &withExpiry
+	}
+	if c.defaultMetadata != nil && req.Metadata != nil {
+		withDefaults := *req
+		withDefaults.Metadata = mergeMetadata(c.defaultMetadata, req.Metadata)
+		req = &withDefaults
+	}
+
+	unlock := c.lockUserWrites(req.UserID)
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, path, req)
+	unlock()
 	if err != nil {
 		return nil, err
 	}
@@ -272,13 +738,39 @@ func (c *Client) UpdateMemory(memoryID MemoryID, req *UpdateMemoryRequest) (*Mem
 
 // DeleteMemory deletes a single memory by ID.
 func (c *Client) DeleteMemory(memoryID MemoryID, userID, agentID string) error {
+	_, err := c.DeleteMemoryWithOptions(context.Background(), memoryID, DeleteMemoryOptions{
+		UserID:  userID,
+		AgentID: agentID,
+	})
+	return err
+}
+
+// DeleteMemoryWithOptions deletes a single memory by ID.
+//
+// When opts.ReturnDeleted is set, the memory is fetched before deletion and
+// returned, e.g. for undo buffers and audit trails; otherwise the returned
+// memory is nil.
+//
+// Deletion is idempotent under retries: if an earlier attempt succeeded
+// but its response was lost, the 404 seen by the retried attempt is treated
+// as success.
+func (c *Client) DeleteMemoryWithOptions(ctx context.Context, memoryID MemoryID, opts DeleteMemoryOptions) (*Memory, error) {
+	var deleted *Memory
+	if opts.ReturnDeleted {
+		mem, err := c.getMemory(ctx, memoryID, opts.UserID, opts.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		deleted = mem
+	}
+
 	// Build query parameters
 	params := url.Values{}
-	if userID != "" {
-		params.Set("user_id", userID)
+	if opts.UserID != "" {
+		params.Set("user_id", opts.UserID)
 	}
-	if agentID != "" {
-		params.Set("agent_id", agentID)
+	if opts.AgentID != "" {
+		params.Set("agent_id", opts.AgentID)
 	}
 
 	path := fmt.Sprintf("/api/v1/memories/%s", memoryID.String())
@@ -286,21 +778,52 @@ func (c *Client) DeleteMemory(memoryID MemoryID, userID, agentID string) error {
 		path += "?" + params.Encode()
 	}
 
-	respBody, err := c.doRequest(http.MethodDelete, path, nil)
+	ctx, attempts := withAttemptCounter(ctx)
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
 	if err != nil {
-		return err
+		if IsNotFound(err) && attempts.Load() > 1 {
+			return deleted, nil
+		}
+		return nil, err
 	}
 
 	var resp APIResponse[DeleteMemoryResponse]
 	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if !resp.Success {
-		return fmt.Errorf("delete memory failed: %s", resp.Message)
+		return nil, fmt.Errorf("delete memory failed: %s", resp.Message)
 	}
 
-	return nil
+	return deleted, nil
+}
+
+// DeleteMemories deletes all memories matching filter on the server.
+//
+// At least one criterion must be set; use the system endpoint to delete
+// everything. With filter.DryRun set nothing is deleted and the result
+// reports how many memories would have been removed.
+func (c *Client) DeleteMemories(ctx context.Context, filter DeleteFilter) (*DeleteMemoriesResult, error) {
+	if filter.isEmpty() {
+		return nil, fmt.Errorf("delete filter must set at least one criterion")
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories/delete-by-filter", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[DeleteMemoriesResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("delete memories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
 }
 
 // =============================================================================
@@ -309,7 +832,15 @@ func (c *Client) DeleteMemory(memoryID MemoryID, userID, agentID string) error {
 
 // SearchMemories performs a semantic search for memories.
 func (c *Client) SearchMemories(req *SearchMemoryRequest) (*SearchResults, error) {
-	respBody, err := c.doRequest(http.MethodPost, "/api/v1/memories/search", req)
+	return c.searchMemories(context.Background(), req)
+}
+
+// searchMemories performs a semantic search for memories, bound to ctx.
+func (c *Client) searchMemories(ctx context.Context, req *SearchMemoryRequest) (*SearchResults, error) {
+	if err := c.requireSearchFeatures(req); err != nil {
+		return nil, err
+	}
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories/search", req)
 	if err != nil {
 		return nil, err
 	}
@@ -323,9 +854,148 @@ func (c *Client) SearchMemories(req *SearchMemoryRequest) (*SearchResults, error
 		return nil, fmt.Errorf("search memories failed: %s", resp.Message)
 	}
 
+	c.processSearchResults(&resp.Data, req)
 	return &resp.Data, nil
 }
 
+// requireSearchFeatures checks that the preview features req uses are
+// enabled.
+func (c *Client) requireSearchFeatures(req *SearchMemoryRequest) error {
+	if req.UseGraph {
+		return c.requireFeature(experimental.Graph, "SearchMemoryRequest.UseGraph")
+	}
+	if req.Mode == SearchModeGraph {
+		return c.requireFeature(experimental.Graph, "SearchModeGraph")
+	}
+	return nil
+}
+
+// processSearchResults applies the client-side filtering and scoring of
+// search results.
+func (c *Client) processSearchResults(results *SearchResults, req *SearchMemoryRequest) {
+	filterVisibleResults(results, req.UserID, req.AgentID)
+	if c.calibrator != nil {
+		c.calibrator.apply(results, req.MinRelevance)
+	}
+	pruneConflicts(results)
+}
+
+// pruneConflicts removes conflict sides that are not among the results,
+// so that memories filtered out above are not exposed through conflicts,
+// and drops conflicts left with a single side.
+func pruneConflicts(results *SearchResults) {
+	if len(results.Conflicts) == 0 {
+		return
+	}
+	kept := make(map[MemoryID]bool, len(results.Results))
+	for _, r := range results.Results {
+		kept[r.MemoryID] = true
+	}
+	conflicts := results.Conflicts[:0]
+	for _, conflict := range results.Conflicts {
+		conflict.Sides = slices.DeleteFunc(conflict.Sides, func(side ConflictSide) bool { return !kept[side.MemoryID] })
+		if len(conflict.Sides) >= 2 {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	results.Conflicts = conflicts
+}
+
+// KeywordSearchMemories searches with BM25 keyword matching only,
+// bypassing embedding generation. Use it for exact-string recall such as
+// IDs and URLs, or while the embedding provider is unavailable.
+func (c *Client) KeywordSearchMemories(ctx context.Context, req *SearchMemoryRequest) (*SearchResults, error) {
+	keyword := *req
+	keyword.Mode = SearchModeKeyword
+	return c.searchMemories(ctx, &keyword)
+}
+
+// GraphSearchMemories searches with SearchModeGraph, blending the
+// memories of entities related to the query's into the ranking.
+// req.GraphDepth must be 1 or 2, or 0 for the server default, since
+// deeper expansions mostly add noise.
+func (c *Client) GraphSearchMemories(ctx context.Context, req *SearchMemoryRequest) (*SearchResults, error) {
+	if err := c.requireFeature(experimental.Graph, "GraphSearchMemories"); err != nil {
+		return nil, err
+	}
+	if req.GraphDepth < 0 || req.GraphDepth > 2 {
+		return nil, fmt.Errorf("invalid graph depth %d: must be 1 or 2", req.GraphDepth)
+	}
+	graph := *req
+	graph.Mode = SearchModeGraph
+	return c.searchMemories(ctx, &graph)
+}
+
+// SearchMemoriesStream searches over a server-sent-events response and
+// delivers results on the returned channel as the server ranks them, so
+// consumers of large result sets can start before the ranking completes.
+// The results channel is closed when the stream ends; the error channel
+// then yields nil or the error that ended it. Cancel ctx to stop early.
+func (c *Client) SearchMemoriesStream(ctx context.Context, req *SearchMemoryRequest) (<-chan SearchResult, <-chan error, error) {
+	if err := c.requireFeature(experimental.Streaming, "SearchMemoriesStream"); err != nil {
+		return nil, nil, err
+	}
+	if err := c.requireSearchFeatures(req); err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.doStream(ctx, http.MethodPost, "/api/v1/memories/search/stream", req, "text/event-stream")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make(chan SearchResult)
+	errc := make(chan error, 1)
+	userID, agentID := req.UserID, req.AgentID
+	go func() {
+		defer resp.Body.Close()
+		defer close(results)
+		var err error
+		defer func() { errc <- err }()
+		defer c.recoverPanic("search stream", &err)
+		err = readSearchStream(ctx, newSSEReader(resp.Body), results, userID, agentID)
+	}()
+	return results, errc, nil
+}
+
+// readSearchStream sends the "result" events of r to results until a
+// "done" event.
+func readSearchStream(ctx context.Context, r *sseReader, results chan<- SearchResult, userID, agentID string) error {
+	for {
+		ev, err := r.next()
+		if err == io.EOF {
+			return fmt.Errorf("failed to read search stream: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read search stream: %w", err)
+		}
+
+		switch ev.Event {
+		case "", "result":
+			var result SearchResult
+			if err := json.Unmarshal([]byte(ev.Data), &result); err != nil {
+				return fmt.Errorf("failed to parse response: %w", err)
+			}
+			if !visibleTo(result.Visibility, result.UserID, result.AgentID, userID, agentID) {
+				continue
+			}
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case "error":
+			var resp APIResponse[any]
+			json.Unmarshal([]byte(ev.Data), &resp)
+			return fmt.Errorf("search memories failed: %s", firstNonEmpty(resp.Message, ev.Data))
+		case "done":
+			return nil
+		}
+	}
+}
+
 // =============================================================================
 // User Memory Operations
 // =============================================================================
@@ -359,5 +1029,31 @@ func (c *Client) GetUserMemories(userID string, limit, offset int) (*MemoryList,
 		return nil, fmt.Errorf("get user memories failed: %s", resp.Message)
 	}
 
+	filterVisibleMemories(&resp.Data, userID, "")
+	return &resp.Data, nil
+}
+
+// DeleteUserMemories deletes all memories of a user, e.g. when offboarding
+// the user or cleaning up after a test run.
+func (c *Client) DeleteUserMemories(ctx context.Context, userID string) (*ScopedDeleteResult, error) {
+	return c.deleteScoped(ctx, fmt.Sprintf("/api/v1/users/%s/memories", url.PathEscape(userID)))
+}
+
+// deleteScoped calls one of the scoped delete-all endpoints.
+func (c *Client) deleteScoped(ctx context.Context, path string) (*ScopedDeleteResult, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[ScopedDeleteResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("delete memories failed: %s", resp.Message)
+	}
+
 	return &resp.Data, nil
 }
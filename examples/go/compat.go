@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/webhooks"
+)
+
+// ServerLauncher starts PowerMem servers of given versions for
+// RunCompatMatrix, e.g. DockerLauncher.
+type ServerLauncher interface {
+	// Start starts a server of version and returns its base URL and a
+	// function stopping it.
+	Start(ctx context.Context, version string) (baseURL string, stop func() error, err error)
+}
+
+// DockerLauncher is a ServerLauncher running each server version as a
+// Docker container of Image tagged with the version, published on a free
+// loopback port and removed when stopped. The server needs a database:
+// pass its settings in Env or EnvFile and, for a database in another
+// container, attach the server to its Network.
+type DockerLauncher struct {
+	// Image defaults to "oceanbase/powermem-server".
+	Image string
+
+	// Env holds KEY=VALUE settings for the server, and EnvFile names a
+	// file of them.
+	Env     []string
+	EnvFile string
+
+	// Network is the Docker network to attach the container to.
+	Network string
+
+	// Docker is the docker binary. Defaults to "docker" on PATH.
+	Docker string
+}
+
+// CompatOptions configures RunCompatMatrix.
+type CompatOptions struct {
+	// Versions are the server versions to test, e.g. the current release
+	// and the two before it.
+	Versions []string
+
+	// APIKey authenticates the checks, if the servers require it.
+	APIKey string
+
+	// StartTimeout bounds the wait for a server to become healthy.
+	// Default 2m.
+	StartTimeout time.Duration
+
+	// CheckTimeout bounds each check. Default 30s.
+	CheckTimeout time.Duration
+
+	// Checks defaults to CompatChecks.
+	Checks []CompatCheck
+
+	// OnResult, if set, is called with each result as it completes, e.g.
+	// to print progress.
+	OnResult func(version string, result CompatResult)
+}
+
+// CompatCheck is one check of the compatibility matrix, run against every
+// server version.
+type CompatCheck struct {
+	Name string
+
+	// Optional marks features older servers may lack. A check failing
+	// with a not-supported response is then reported as
+	// CompatUnsupported rather than a failure.
+	Optional bool
+
+	// Run performs the check with a client of the server under test, in
+	// the namespace of userID.
+	Run func(ctx context.Context, c *Client, userID string) error
+}
+
+// CompatStatus is the outcome of a check.
+type CompatStatus string
+
+const (
+	CompatPass        CompatStatus = "pass"
+	CompatFail        CompatStatus = "fail"
+	CompatUnsupported CompatStatus = "unsupported"
+)
+
+// CompatResult is the outcome of one check against one server version.
+type CompatResult struct {
+	Check    string
+	Status   CompatStatus
+	Err      error
+	Duration time.Duration
+}
+
+// CompatReport is the outcome of the checks against one server version.
+type CompatReport struct {
+	Version string
+
+	// Err is set when the server could not be started; no checks ran.
+	Err error
+
+	Results []CompatResult
+}
+
+// Passed reports whether the server started and no check failed.
+func (r *CompatReport) Passed() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, res := range r.Results {
+		if res.Status == CompatFail {
+			return false
+		}
+	}
+	return true
+}
+
+// RunCompatMatrix starts a server of each version in turn with launcher
+// and runs the checks against it, so that the client's compatibility with
+// older servers is verified rather than assumed. Each version's checks
+// write under a fresh user, deleted afterwards. It returns one report per
+// version, in order, and an error only if ctx is done.
+func RunCompatMatrix(ctx context.Context, launcher ServerLauncher, opts CompatOptions) ([]CompatReport, error) {
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = 2 * time.Minute
+	}
+	if opts.CheckTimeout <= 0 {
+		opts.CheckTimeout = 30 * time.Second
+	}
+	if opts.Checks == nil {
+		opts.Checks = CompatChecks()
+	}
+
+	reports := make([]CompatReport, 0, len(opts.Versions))
+	for _, version := range opts.Versions {
+		report := runCompatVersion(ctx, launcher, version, opts)
+		if err := ctx.Err(); err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// runCompatVersion runs the checks against one server version.
+func runCompatVersion(ctx context.Context, launcher ServerLauncher, version string, opts CompatOptions) CompatReport {
+	report := CompatReport{Version: version}
+	baseURL, stop, err := launcher.Start(ctx, version)
+	if err != nil {
+		report.Err = fmt.Errorf("failed to start server %s: %w", version, err)
+		return report
+	}
+	defer stop()
+
+	c := NewClient(baseURL, opts.APIKey)
+	if err := waitHealthy(ctx, c, opts.StartTimeout); err != nil {
+		report.Err = fmt.Errorf("server %s did not become healthy: %w", version, err)
+		return report
+	}
+
+	userID := "compat-" + randomSuffix()
+	for _, check := range opts.Checks {
+		checkCtx, cancel := context.WithTimeout(ctx, opts.CheckTimeout)
+		start := time.Now()
+		var err error
+		if perr := c.guard("compat check "+check.Name, func() { err = check.Run(checkCtx, c, userID) }); perr != nil {
+			err = perr
+		}
+		cancel()
+
+		res := CompatResult{Check: check.Name, Status: CompatPass, Err: err, Duration: time.Since(start)}
+		switch {
+		case err == nil:
+		case check.Optional && isUnsupported(err):
+			res.Status = CompatUnsupported
+		default:
+			res.Status = CompatFail
+		}
+		report.Results = append(report.Results, res)
+		if opts.OnResult != nil {
+			opts.OnResult(version, res)
+		}
+	}
+
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), opts.CheckTimeout)
+	defer cancel()
+	c.DeleteUserMemories(cleanupCtx, userID)
+	return report
+}
+
+// waitHealthy polls the health endpoint until it answers or timeout
+// elapses.
+func waitHealthy(ctx context.Context, c *Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		_, err := c.health(ctx)
+		if err == nil {
+			return nil
+		}
+		if sleepContext(ctx, time.Second) != nil {
+			return err
+		}
+	}
+}
+
+func randomSuffix() string {
+	var b [6]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// CompatChecks returns the default checks of the compatibility matrix:
+// the core memory operations, which every supported server must pass,
+// and the client's retry and degradation paths, which must hold up
+// whatever the server lacks.
+func CompatChecks() []CompatCheck {
+	return []CompatCheck{
+		{Name: "health", Run: checkHealth},
+		{Name: "create and get", Run: checkCreateGet},
+		{Name: "update", Run: checkUpdate},
+		{Name: "list", Run: checkList},
+		{Name: "search", Run: checkSearch},
+		{Name: "delete", Run: checkDelete},
+		{Name: "retry on 503", Run: checkRetry},
+		{Name: "get or create", Run: checkGetOrCreate},
+		{Name: "change feed", Optional: true, Run: checkChangeFeed},
+	}
+}
+
+func checkHealth(ctx context.Context, c *Client, userID string) error {
+	health, err := c.health(ctx)
+	if err != nil {
+		return err
+	}
+	if health.Status == "" {
+		return errors.New("health response has no status")
+	}
+	return nil
+}
+
+// createCompatMemory stores content verbatim for a check.
+func createCompatMemory(c *Client, userID, content string) (MemoryID, error) {
+	infer := false
+	created, err := c.CreateMemory(&CreateMemoryRequest{Content: content, UserID: userID, Infer: &infer})
+	if err != nil {
+		return 0, err
+	}
+	if len(created) != 1 {
+		return 0, fmt.Errorf("create returned %d memories, want 1", len(created))
+	}
+	return created[0].MemoryID, nil
+}
+
+func checkCreateGet(ctx context.Context, c *Client, userID string) error {
+	const content = "Compatibility check: prefers window seats"
+	id, err := createCompatMemory(c, userID, content)
+	if err != nil {
+		return err
+	}
+	mem, err := c.GetMemoryWithOptions(ctx, id, GetMemoryOptions{UserID: userID})
+	if err != nil {
+		return err
+	}
+	if mem.MemoryID != id || mem.Content != content {
+		return fmt.Errorf("got memory %s %q, want %s %q", mem.MemoryID, mem.Content, id, content)
+	}
+	return nil
+}
+
+func checkUpdate(ctx context.Context, c *Client, userID string) error {
+	id, err := createCompatMemory(c, userID, "Compatibility check: lives in Paris")
+	if err != nil {
+		return err
+	}
+	const content = "Compatibility check: lives in Berlin"
+	if _, err := c.UpdateMemory(id, &UpdateMemoryRequest{Content: content, UserID: userID}); err != nil {
+		return err
+	}
+	mem, err := c.GetMemoryWithOptions(ctx, id, GetMemoryOptions{UserID: userID})
+	if err != nil {
+		return err
+	}
+	if mem.Content != content {
+		return fmt.Errorf("content after update is %q, want %q", mem.Content, content)
+	}
+	return nil
+}
+
+func checkList(ctx context.Context, c *Client, userID string) error {
+	id, err := createCompatMemory(c, userID, "Compatibility check: listed")
+	if err != nil {
+		return err
+	}
+	list, err := c.ListMemories(ListMemoriesParams{UserID: userID, Limit: 100})
+	if err != nil {
+		return err
+	}
+	for _, m := range list.Memories {
+		if m.MemoryID == id {
+			return nil
+		}
+	}
+	return fmt.Errorf("memory %s missing from list", id)
+}
+
+func checkSearch(ctx context.Context, c *Client, userID string) error {
+	if _, err := createCompatMemory(c, userID, "Compatibility check: allergic to peanuts"); err != nil {
+		return err
+	}
+	// Ranking depends on the server's embedding model; only the request
+	// and response shapes are checked.
+	_, err := c.SearchMemories(&SearchMemoryRequest{Query: "food allergies", UserID: userID, Limit: 5})
+	return err
+}
+
+func checkDelete(ctx context.Context, c *Client, userID string) error {
+	id, err := createCompatMemory(c, userID, "Compatibility check: deleted")
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteMemory(id, userID, ""); err != nil {
+		return err
+	}
+	_, err = c.GetMemoryWithOptions(ctx, id, GetMemoryOptions{UserID: userID})
+	if !IsNotFound(err) {
+		return fmt.Errorf("get after delete returned %v, want not found", err)
+	}
+	return nil
+}
+
+// checkRetry fails the first attempt of a request with 503 and expects
+// the retry policy to recover.
+func checkRetry(ctx context.Context, c *Client, userID string) error {
+	failed := false
+	failFirst := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if !failed {
+				failed = true
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader(`{"success":false,"message":"injected"}`)),
+					Request:    req,
+				}, nil
+			}
+			return next(req)
+		}
+	}
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: 50 * time.Millisecond}
+	rc := c.With(WithRetry(policy), WithMiddleware(failFirst))
+	if _, err := rc.ListMemories(ListMemoriesParams{UserID: userID, Limit: 1}); err != nil {
+		return err
+	}
+	if !failed {
+		return errors.New("fault was not injected")
+	}
+	return nil
+}
+
+// checkGetOrCreate expects the same memory from two calls with one
+// natural key, whether or not the server has native get-or-create.
+func checkGetOrCreate(ctx context.Context, c *Client, userID string) error {
+	req := &CreateMemoryRequest{Content: "Compatibility check: home airport is TXL", UserID: userID}
+	first, created, err := c.GetOrCreateMemory(ctx, "compat-home-airport", req)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return errors.New("first call did not create the memory")
+	}
+	second, created, err := c.GetOrCreateMemory(ctx, "compat-home-airport", req)
+	if err != nil {
+		return err
+	}
+	if created || second.MemoryID != first.MemoryID {
+		return fmt.Errorf("second call returned %s (created %t), want existing %s", second.MemoryID, created, first.MemoryID)
+	}
+	return nil
+}
+
+// checkChangeFeed expects a created event for a new memory, over
+// server-sent events or, where those are unavailable, long polling.
+func checkChangeFeed(ctx context.Context, c *Client, userID string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := c.SubscribeChanges(ctx, SubscribeParams{UserID: userID, Types: []webhooks.EventType{webhooks.MemoryCreated}})
+	if err != nil {
+		return err
+	}
+	id, err := createCompatMemory(c, userID, "Compatibility check: change feed")
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return errors.New("change feed closed")
+			}
+			if ev.Memory.MemoryID == id {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("no event for memory %s: %w", id, ctx.Err())
+		}
+	}
+}
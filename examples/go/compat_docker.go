@@ -0,0 +1,65 @@
+//go:build !js && !wasip1 && !tinygo
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Start implements ServerLauncher.
+func (d DockerLauncher) Start(ctx context.Context, version string) (string, func() error, error) {
+	image := firstNonEmpty(d.Image, "oceanbase/powermem-server") + ":" + version
+	args := []string{"run", "--detach", "--rm", "--publish", "127.0.0.1::8000"}
+	if d.Network != "" {
+		args = append(args, "--network", d.Network)
+	}
+	if d.EnvFile != "" {
+		args = append(args, "--env-file", d.EnvFile)
+	}
+	for _, kv := range d.Env {
+		args = append(args, "--env", kv)
+	}
+	args = append(args, image)
+
+	out, err := d.docker(ctx, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	id := strings.TrimSpace(out)
+	stop := func() error {
+		// Remove the container even when ctx is done.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_, err := d.docker(ctx, "rm", "--force", id)
+		return err
+	}
+
+	out, err = d.docker(ctx, "port", id, "8000/tcp")
+	if err != nil {
+		stop()
+		return "", nil, err
+	}
+	// One line per published address, e.g. "127.0.0.1:49153".
+	addr, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	if addr == "" {
+		stop()
+		return "", nil, fmt.Errorf("container %s publishes no port", id)
+	}
+	return "http://" + addr, stop, nil
+}
+
+// docker runs a docker command and returns its output.
+func (d DockerLauncher) docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, firstNonEmpty(d.Docker, "docker"), args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
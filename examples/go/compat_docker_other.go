@@ -0,0 +1,13 @@
+//go:build js || wasip1 || tinygo
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// Start implements ServerLauncher. Docker cannot be run on this platform.
+func (d DockerLauncher) Start(ctx context.Context, version string) (string, func() error, error) {
+	return "", nil, errors.New("docker is not available on this platform")
+}
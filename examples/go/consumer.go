@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/webhooks"
+)
+
+// EventHandlerFunc processes an event. Returning nil acknowledges it; an
+// error has the Consumer retry it.
+type EventHandlerFunc func(ctx context.Context, ev Event) error
+
+// ConsumerOptions configures a Consumer.
+type ConsumerOptions struct {
+	// MaxAttempts bounds the attempts to process an event before it is
+	// dead-lettered. Default 5.
+	MaxAttempts int
+
+	// RetryDelay is the initial delay before retrying a failed event. It
+	// doubles on each attempt, up to MaxRetryDelay. Defaults to 1s and
+	// 30s.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// OnDeadLetter, if set, is called with an event that failed
+	// MaxAttempts times and the last error, e.g. to park it in a queue
+	// for inspection. The consumer then moves on to the next event.
+	OnDeadLetter func(ev Event, err error)
+
+	// Checkpoint, if set, stores the change feed position of the last
+	// event acknowledged or dead-lettered. ConsumeChanges and
+	// ConsumeRealtime resume from it, so events in flight when the
+	// process stopped are delivered again.
+	Checkpoint CheckpointStore
+
+	// OnError, if set, is called with errors that do not stop the
+	// consumer: stream disconnections, unknown event types, events
+	// dead-lettered without OnDeadLetter and checkpoint failures.
+	OnError func(error)
+}
+
+// CheckpointStore persists a consumer's change feed position.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the saved resume token, or "" if none.
+	LoadCheckpoint(ctx context.Context) (string, error)
+	SaveCheckpoint(ctx context.Context, token string) error
+}
+
+// FileCheckpoint is a CheckpointStore keeping the resume token in a file
+// at the given path.
+type FileCheckpoint string
+
+// LoadCheckpoint reads the resume token from the file.
+func (f FileCheckpoint) LoadCheckpoint(ctx context.Context) (string, error) {
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SaveCheckpoint replaces the file with token.
+func (f FileCheckpoint) SaveCheckpoint(ctx context.Context, token string) error {
+	if err := writeFileAtomic(string(f), []byte(token+"\n")); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Consumer processes memory events at least once, whatever their source:
+// the change feed, a realtime connection or webhook deliveries. Failed
+// events are retried with backoff and dead-lettered after MaxAttempts.
+// Events are processed one at a time, in order. An event may be
+// delivered more than once, e.g. after a crash, so handlers should be
+// idempotent; EventMeta.ID identifies redeliveries.
+type Consumer struct {
+	handler EventHandlerFunc
+	opts    ConsumerOptions
+}
+
+// NewConsumer returns a consumer calling handler with each event.
+func NewConsumer(handler EventHandlerFunc, opts ConsumerOptions) *Consumer {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = time.Second
+	}
+	if opts.MaxRetryDelay <= 0 {
+		opts.MaxRetryDelay = 30 * time.Second
+	}
+	return &Consumer{handler: handler, opts: opts}
+}
+
+// Handle processes ev until the handler acknowledges it or it is
+// dead-lettered. It returns an error, leaving ev unacknowledged, only if
+// ctx is done first. A handler that panics fails the attempt with a
+// *PanicError.
+func (cs *Consumer) Handle(ctx context.Context, ev Event) error {
+	delay := cs.opts.RetryDelay
+	for attempt := 1; ; attempt++ {
+		err := cs.call(ctx, ev)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= cs.opts.MaxAttempts {
+			cs.deadLetter(ev, err)
+			return nil
+		}
+		if err := sleepContext(ctx, delay/2+jitter(delay/2)); err != nil {
+			return err
+		}
+		delay = min(delay*2, cs.opts.MaxRetryDelay)
+	}
+}
+
+// ConsumeChanges processes the change feed selected by params, resuming
+// from the checkpoint if one is saved, until ctx is done or the server
+// rejects the subscription. It returns ctx.Err() when ctx is done.
+func (cs *Consumer) ConsumeChanges(ctx context.Context, c *Client, params SubscribeParams) error {
+	token, err := cs.resumeToken(ctx)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		params.ResumeToken = token
+	}
+	if params.OnError == nil {
+		params.OnError = cs.opts.OnError
+	}
+
+	// Stop the subscription when the consumer stops early.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := c.SubscribeChanges(ctx, params)
+	if err != nil {
+		return err
+	}
+	if err := cs.consume(ctx, events); err != nil {
+		return err
+	}
+	return errors.New("change feed ended")
+}
+
+// ConsumeRealtime processes the events pushed on a realtime connection
+// opened with opts, resuming from the checkpoint if one is saved, until
+// ctx is done or the server rejects the connection. It returns ctx.Err()
+// when ctx is done.
+func (cs *Consumer) ConsumeRealtime(ctx context.Context, c *Client, opts RealtimeOptions) error {
+	token, err := cs.resumeToken(ctx)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		opts.ResumeToken = token
+	}
+	if opts.OnError == nil {
+		opts.OnError = cs.opts.OnError
+	}
+	opts.Subscribe = true
+
+	rc, err := c.DialRealtime(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := cs.consume(ctx, rc.Events()); err != nil {
+		return err
+	}
+	return ErrRealtimeDisconnected
+}
+
+// WebhookHandler returns an HTTP handler receiving webhook deliveries
+// signed with secret. It acknowledges a delivery once the event has been
+// processed or dead-lettered, and fails it otherwise so that the server
+// delivers it again. Unknown event types are acknowledged and reported to
+// OnError.
+func (cs *Consumer) WebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if err := webhooks.VerifySignature(r.Header.Get(webhooks.SignatureHeader), body, secret); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		delivery, err := webhooks.ParseEvent(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ev, err := EventFromWebhook(delivery)
+		if errors.Is(err, ErrUnknownEventType) {
+			cs.reportError(err)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := cs.Handle(r.Context(), ev); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// consume processes events until the channel closes or ctx is done,
+// saving the checkpoint after each event.
+func (cs *Consumer) consume(ctx context.Context, events <-chan MemoryEvent) error {
+	for {
+		var raw MemoryEvent
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-events:
+			if !ok {
+				return ctx.Err()
+			}
+			raw = e
+		}
+
+		if ev, err := raw.Typed(); err != nil {
+			cs.reportError(err)
+		} else if err := cs.Handle(ctx, ev); err != nil {
+			return err
+		}
+
+		if cs.opts.Checkpoint != nil && raw.ResumeToken != "" {
+			if err := cs.opts.Checkpoint.SaveCheckpoint(ctx, raw.ResumeToken); err != nil {
+				cs.reportError(err)
+			}
+		}
+	}
+}
+
+// call calls the handler, returning a panic in it as a *PanicError.
+func (cs *Consumer) call(ctx context.Context, ev Event) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = newPanicError("event handler", v)
+		}
+	}()
+	return cs.handler(ctx, ev)
+}
+
+// resumeToken returns the saved checkpoint, if any.
+func (cs *Consumer) resumeToken(ctx context.Context) (string, error) {
+	if cs.opts.Checkpoint == nil {
+		return "", nil
+	}
+	return cs.opts.Checkpoint.LoadCheckpoint(ctx)
+}
+
+// deadLetter hands ev to OnDeadLetter.
+func (cs *Consumer) deadLetter(ev Event, err error) {
+	if cs.opts.OnDeadLetter != nil {
+		defer func() {
+			if v := recover(); v != nil {
+				cs.reportError(newPanicError("dead letter handler", v))
+			}
+		}()
+		cs.opts.OnDeadLetter(ev, err)
+		return
+	}
+	meta := ev.Meta()
+	cs.reportError(fmt.Errorf("dropped %s event %s after %d attempts: %w", meta.Type, meta.ID, cs.opts.MaxAttempts, err))
+}
+
+func (cs *Consumer) reportError(err error) {
+	if cs.opts.OnError != nil {
+		cs.opts.OnError(err)
+	}
+}
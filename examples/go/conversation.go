@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConversationOptions contains options for AddConversation.
+type ConversationOptions struct {
+	UserID   string
+	AgentID  string
+	RunID    string
+	Metadata map[string]interface{}
+	Infer    *bool
+
+	// Async queues extraction in the background; see WaitForJob.
+	Async bool
+}
+
+// AddConversation memorizes a chat exchange in one call. The messages are
+// sent as they are, with their roles, names and timestamps, rather than
+// flattened into one string, so extraction can tell what the user said
+// from what the assistant suggested.
+func (c *Client) AddConversation(ctx context.Context, messages []Message, opts ConversationOptions) ([]CreatedMemory, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("conversation has no messages")
+	}
+	for i, m := range messages {
+		switch m.Role {
+		case RoleUser, RoleAssistant, RoleSystem, RoleTool:
+		default:
+			return nil, fmt.Errorf("message %d: invalid role %q", i, m.Role)
+		}
+		if strings.TrimSpace(m.Content) == "" {
+			return nil, fmt.Errorf("message %d: content is empty", i)
+		}
+	}
+
+	return c.createMemory(ctx, &CreateMemoryRequest{
+		Messages: messages,
+		UserID:   opts.UserID,
+		AgentID:  opts.AgentID,
+		RunID:    opts.RunID,
+		Metadata: opts.Metadata,
+		Infer:    opts.Infer,
+		Async:    opts.Async,
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CredentialService is the service name under which PowerMem API keys are
+// stored in credential stores.
+const CredentialService = "powermem"
+
+// ErrCredentialNotFound is returned by a CredentialStore when no secret is
+// stored for the requested account.
+var ErrCredentialNotFound = errors.New("powermem: credential not found")
+
+// CredentialStore reads and writes secrets, such as the API key, from a
+// secure store like the OS keychain. Secrets managers that issue expiring
+// credentials, such as HashiCorp Vault, implement SecretProvider instead.
+type CredentialStore interface {
+	// Get returns the secret for service and account, or
+	// ErrCredentialNotFound.
+	Get(ctx context.Context, service, account string) (string, error)
+
+	// Set stores or replaces the secret for service and account.
+	Set(ctx context.Context, service, account, secret string) error
+
+	// Delete removes the secret for service and account. Deleting a
+	// missing secret is not an error.
+	Delete(ctx context.Context, service, account string) error
+}
+
+// WithCredentialStore reads the API key from store under CredentialService
+// and account whenever the client's APIKey is empty. The key is cached
+// after the first successful read and re-read after the server rejects it
+// with 401, so a key rotated in the store is picked up without a restart.
+func WithCredentialStore(store CredentialStore, account string) Option {
+	return func(c *Client) {
+		c.credentials = &credentialSource{
+			fetch: func(ctx context.Context) (Secret, error) {
+				key, err := store.Get(ctx, CredentialService, account)
+				return Secret{Value: key}, err
+			},
+			what: "API key from credential store",
+		}
+	}
+}
+
+// SaveAPIKey stores apiKey in store under CredentialService and account,
+// e.g. from a CLI login command.
+func SaveAPIKey(ctx context.Context, store CredentialStore, account, apiKey string) error {
+	return store.Set(ctx, CredentialService, account, apiKey)
+}
+
+// credentialSource caches the API key read from a CredentialStore or
+// SecretProvider, or the bearer token from a TokenSource.
+type credentialSource struct {
+	fetch func(ctx context.Context) (Secret, error)
+
+	// what names the credential in errors.
+	what string
+
+	mu     sync.Mutex
+	cached Secret
+}
+
+// get returns the cached key, reading it again if it is missing or about
+// to expire.
+func (s *credentialSource) get(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached.Value != "" && !s.cached.expiresWithin(secretRefreshMargin) {
+		return s.cached.Value, nil
+	}
+	secret, err := s.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", s.what, err)
+	}
+	s.cached = secret
+	return secret.Value, nil
+}
+
+// invalidate drops the cached key.
+func (s *credentialSource) invalidate() {
+	s.mu.Lock()
+	s.cached = Secret{}
+	s.mu.Unlock()
+}
+
+// apiKey returns the API key to send with a request.
+func (c *Client) apiKey(ctx context.Context) (string, error) {
+	if c.APIKey != "" || c.credentials == nil {
+		return c.APIKey, nil
+	}
+	return c.credentials.get(ctx)
+}
+
+// =============================================================================
+// In-Memory Store
+// =============================================================================
+
+// MemoryCredentialStore is a CredentialStore backed by a map, for tests and
+// for processes that obtain secrets by other means.
+type MemoryCredentialStore struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// Get implements CredentialStore.
+func (m *MemoryCredentialStore) Get(_ context.Context, service, account string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	secret, ok := m.secrets[service+"\x00"+account]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return secret, nil
+}
+
+// Set implements CredentialStore.
+func (m *MemoryCredentialStore) Set(_ context.Context, service, account, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.secrets == nil {
+		m.secrets = make(map[string]string)
+	}
+	m.secrets[service+"\x00"+account] = secret
+	return nil
+}
+
+// Delete implements CredentialStore.
+func (m *MemoryCredentialStore) Delete(_ context.Context, service, account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.secrets, service+"\x00"+account)
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SpeakerMetadataField is the metadata field holding the speaker label of
+// a memory created from a diarized transcript.
+const SpeakerMetadataField = "speaker"
+
+// SpeakerMemoryOptions contains options for CreateSpeakerMemories.
+type SpeakerMemoryOptions struct {
+	// Speakers maps speaker labels, e.g. "Speaker 1" or "Alice", to the
+	// user ID whose memory space receives what that speaker said.
+	Speakers map[string]string
+
+	// DefaultUserID receives segments of speakers missing from Speakers.
+	// When empty those segments are skipped and reported.
+	DefaultUserID string
+
+	AgentID  string
+	RunID    string
+	Metadata map[string]interface{}
+	Infer    *bool
+}
+
+// SpeakerMemoriesResult reports the memories created per user and the
+// speaker labels that could not be attributed.
+type SpeakerMemoriesResult struct {
+	Memories     map[string][]CreatedMemory
+	Unattributed []string
+}
+
+// CreateSpeakerMemories attributes a multi-speaker transcript to the
+// people in it: each speaker's segments become a memory in the memory
+// space of the user mapped to that speaker, so "Alice said" and "Bob said"
+// facts are not blended into one user's memories.
+func (c *Client) CreateSpeakerMemories(ctx context.Context, t *Transcript, opts SpeakerMemoryOptions) (*SpeakerMemoriesResult, error) {
+	type speakerTurns struct {
+		labels   []string
+		lines    []string
+		segments []TranscriptSegment
+	}
+	byUser := make(map[string]*speakerTurns)
+	var users []string
+	unattributed := make(map[string]bool)
+
+	for _, seg := range t.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		userID, ok := opts.Speakers[seg.Speaker]
+		if !ok {
+			userID = opts.DefaultUserID
+		}
+		if userID == "" {
+			unattributed[seg.Speaker] = true
+			continue
+		}
+
+		turns, ok := byUser[userID]
+		if !ok {
+			turns = &speakerTurns{}
+			byUser[userID] = turns
+			users = append(users, userID)
+		}
+		if !slices.Contains(turns.labels, seg.Speaker) {
+			turns.labels = append(turns.labels, seg.Speaker)
+		}
+		turns.lines = append(turns.lines, text)
+		turns.segments = append(turns.segments, seg)
+	}
+
+	result := &SpeakerMemoriesResult{Memories: make(map[string][]CreatedMemory, len(users))}
+	for label := range unattributed {
+		result.Unattributed = append(result.Unattributed, label)
+	}
+	sort.Strings(result.Unattributed)
+
+	for _, userID := range users {
+		turns := byUser[userID]
+		speaker := strings.Join(turns.labels, ", ")
+		created, err := c.createMemory(ctx, &CreateMemoryRequest{
+			Content: strings.Join(turns.lines, "\n"),
+			UserID:  userID,
+			AgentID: opts.AgentID,
+			RunID:   opts.RunID,
+			Metadata: mergeMetadata(opts.Metadata, map[string]interface{}{
+				SpeakerMetadataField:    speaker,
+				TranscriptMetadataField: map[string]interface{}{"segments": turns.segments},
+			}),
+			Infer: opts.Infer,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to create memories for speaker %s: %w", speaker, err)
+		}
+		result.Memories[userID] = created
+	}
+	return result, nil
+}
+
+// transcriptLine matches "[mm:ss] Speaker: text", with the timestamp
+// optional.
+var transcriptLine = regexp.MustCompile(`^(?:\[(\d+):(\d{2})\]\s*)?([^:\[\]]{1,64}):\s*(.*)$`)
+
+// ParseTranscript reads a speaker-labelled text transcript, one turn per
+// line in the form "Alice: text" or "[01:12] Alice: text" as written by
+// Transcript.Content. Lines without a speaker label continue the previous
+// turn.
+func ParseTranscript(r io.Reader) (*Transcript, error) {
+	t := &Transcript{}
+	var text []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		text = append(text, line)
+
+		m := transcriptLine.FindStringSubmatch(line)
+		if m == nil {
+			if n := len(t.Segments); n > 0 {
+				t.Segments[n-1].Text += " " + line
+			} else {
+				t.Segments = append(t.Segments, TranscriptSegment{Text: line})
+			}
+			continue
+		}
+		seg := TranscriptSegment{Speaker: strings.TrimSpace(m[3]), Text: m[4]}
+		if m[1] != "" {
+			mins, _ := strconv.Atoi(m[1])
+			secs, _ := strconv.Atoi(m[2])
+			seg.Start = float64(mins*60 + secs)
+		}
+		t.Segments = append(t.Segments, seg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	t.Text = strings.Join(text, "\n")
+	return t, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/webhooks"
+)
+
+// DefaultDigestTemplate renders a digest as plain text. Templates are
+// executed with the *Digest.
+var DefaultDigestTemplate = template.Must(template.New("digest").Parse(
+	`Here is what your assistant learned about you since {{.Since.Format "January 2"}}:
+{{range .Memories}}
+- {{.Content}}{{end}}
+{{- if .More}}
+
+...and {{.More}} more.{{end}}
+
+You can review, correct or delete these memories in your settings.
+`))
+
+// Digest summarizes the memories recorded for a user in a period, for
+// showing users what an assistant has learned about them.
+type Digest struct {
+	UserID string
+	Since  time.Time
+	Until  time.Time
+
+	// Memories are the most recent memories of the period, and Total the
+	// number recorded in it.
+	Memories []Memory
+	Total    int
+}
+
+// More returns the number of memories of the period not in Memories.
+func (d *Digest) More() int {
+	return max(d.Total-len(d.Memories), 0)
+}
+
+// Render executes tmpl, or DefaultDigestTemplate if nil, with d.
+func (d *Digest) Render(tmpl *template.Template) (string, error) {
+	if tmpl == nil {
+		tmpl = DefaultDigestTemplate
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("failed to render digest: %w", err)
+	}
+	return b.String(), nil
+}
+
+// GenerateDigest collects up to limit of the memories recorded for userID
+// in [since, until), most recent first.
+func (c *Client) GenerateDigest(ctx context.Context, userID string, since, until time.Time, limit int) (*Digest, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	list, err := c.QueryByMetadata(ctx, MetaAnd(
+		MetaEq("user_id", userID),
+		MetaGte("created_at", since.UTC().Format(time.RFC3339)),
+		MetaLt("created_at", until.UTC().Format(time.RFC3339)),
+	), Pagination{Limit: limit, SortBy: "created_at", Order: "desc"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate digest: %w", err)
+	}
+	return &Digest{UserID: userID, Since: since, Until: until, Memories: list.Memories, Total: list.Total}, nil
+}
+
+// DigestDelivery sends a rendered digest to its user.
+type DigestDelivery interface {
+	Deliver(ctx context.Context, d *Digest, text string) error
+}
+
+// DigestDeliveryFunc adapts a function to DigestDelivery, e.g. to post
+// digests to an in-app notification inbox.
+type DigestDeliveryFunc func(ctx context.Context, d *Digest, text string) error
+
+// Deliver implements DigestDelivery.
+func (f DigestDeliveryFunc) Deliver(ctx context.Context, d *Digest, text string) error {
+	return f(ctx, d, text)
+}
+
+// EmailDigestDelivery sends digests by email over SMTP.
+type EmailDigestDelivery struct {
+	// Addr is the SMTP server, e.g. "smtp.example.com:587".
+	Addr string
+	Auth smtp.Auth
+	From string
+
+	// Recipient returns the email address of a user.
+	Recipient func(ctx context.Context, userID string) (string, error)
+
+	// Subject defaults to "What your assistant learned this week".
+	Subject string
+}
+
+// Deliver implements DigestDelivery.
+func (e *EmailDigestDelivery) Deliver(ctx context.Context, d *Digest, text string) error {
+	to, err := e.Recipient(ctx, d.UserID)
+	if err != nil {
+		return fmt.Errorf("email digest: %w", err)
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("email digest: invalid recipient %q", to)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\n", e.From, to, firstNonEmpty(e.Subject, "What your assistant learned this week"))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	if err := smtp.SendMail(e.Addr, e.Auth, e.From, []string{to}, msg.Bytes()); err != nil {
+		return fmt.Errorf("email digest: %w", err)
+	}
+	return nil
+}
+
+// WebhookDigestDelivery posts digests as JSON to a URL, signed like
+// webhook deliveries so receivers can use webhooks.VerifySignature.
+type WebhookDigestDelivery struct {
+	URL        string
+	Secret     string
+	HTTPClient *http.Client
+}
+
+// Deliver implements DigestDelivery.
+func (w *WebhookDigestDelivery) Deliver(ctx context.Context, d *Digest, text string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"user_id":  d.UserID,
+		"since":    d.Since,
+		"until":    d.Until,
+		"total":    d.Total,
+		"text":     text,
+		"memories": d.Memories,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook digest: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook digest: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set(webhooks.SignatureHeader, webhooks.Sign(body, w.Secret, time.Now()))
+	}
+
+	client := w.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook digest: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook digest: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// DigestScheduler sends every user a digest of their new memories once
+// per period.
+type DigestScheduler struct {
+	Client *Client
+
+	// Users returns the users to send digests to.
+	Users func(ctx context.Context) ([]string, error)
+
+	// Period defaults to a week.
+	Period time.Duration
+
+	// MaxMemories caps the memories listed per digest. Defaults to 20.
+	MaxMemories int
+
+	// Template defaults to DefaultDigestTemplate.
+	Template *template.Template
+
+	Deliveries []DigestDelivery
+
+	// SkipEmpty skips users with no new memories in the period.
+	SkipEmpty bool
+
+	// OnError, if set, is called with the error of each user whose
+	// digest failed; the other users are unaffected.
+	OnError func(userID string, err error)
+}
+
+// Run sends digests at the end of every period until ctx is done.
+func (s *DigestScheduler) Run(ctx context.Context) error {
+	period := s.Period
+	if period <= 0 {
+		period = 7 * 24 * time.Hour
+	}
+	since := time.Now()
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case until := <-ticker.C:
+			if err := s.SendAll(ctx, since, until); err != nil && ctx.Err() == nil && s.OnError != nil {
+				s.OnError("", err)
+			}
+			since = until
+		}
+	}
+}
+
+// SendAll sends every user a digest of the memories recorded in
+// [since, until). It returns an error only if the users cannot be listed.
+func (s *DigestScheduler) SendAll(ctx context.Context, since, until time.Time) error {
+	users, err := s.Users(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list digest users: %w", err)
+	}
+	for _, userID := range users {
+		if err := s.Send(ctx, userID, since, until); err != nil && s.OnError != nil {
+			s.OnError(userID, err)
+		}
+	}
+	return nil
+}
+
+// Send generates, renders and delivers the digest of one user.
+func (s *DigestScheduler) Send(ctx context.Context, userID string, since, until time.Time) error {
+	d, err := s.Client.GenerateDigest(ctx, userID, since, until, s.MaxMemories)
+	if err != nil {
+		return err
+	}
+	if s.SkipEmpty && d.Total == 0 {
+		return nil
+	}
+	text, err := d.Render(s.Template)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, delivery := range s.Deliveries {
+		if err := delivery.Deliver(ctx, d, text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
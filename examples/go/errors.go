@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/oceanbase/powermem/examples/go/internal/wire"
+)
+
+// Error is returned for non-2xx responses from the PowerMem API.
+//
+// Errors reported in PowerMem's own envelope populate Code, Message and
+// Details. Errors reported as RFC 7807 problem details additionally
+// populate Type, Title and Instance, with the problem's detail member
+// mapped to Message.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Code is the API error code, e.g. "MEMORY_NOT_FOUND".
+	// For problem details it is the "code" extension member, if present.
+	Code string
+
+	// Message is a human-readable description of the error.
+	Message string
+
+	// Details carries additional error context from the server.
+	Details map[string]interface{}
+
+	// Type is the problem type URI (RFC 7807 only).
+	Type string
+
+	// Title is the short problem summary (RFC 7807 only).
+	Title string
+
+	// Instance identifies the specific occurrence (RFC 7807 only).
+	Instance string
+
+	// Body is the raw response body.
+	Body []byte
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	switch {
+	case e.Code != "":
+		return fmt.Sprintf("API error [%s]: %s", e.Code, e.Message)
+	case e.Type != "" && e.Type != "about:blank":
+		return fmt.Sprintf("HTTP error %d (%s): %s", e.StatusCode, e.Type, e.Message)
+	default:
+		return fmt.Sprintf("HTTP error %d: %s", e.StatusCode, e.Message)
+	}
+}
+
+// parseError builds an *Error from a non-2xx response.
+func parseError(resp *http.Response, body []byte) *Error {
+	e := wire.ParseError(resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	return &Error{
+		StatusCode: e.StatusCode,
+		Code:       e.Code,
+		Message:    e.Message,
+		Details:    e.Details,
+		Type:       e.Type,
+		Title:      e.Title,
+		Instance:   e.Instance,
+		Body:       body,
+	}
+}
+
+// IsNotFound reports whether err is an API error with HTTP status 404.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsOptedOut reports whether err is a write rejected because the user has
+// turned memory off or disabled the memory's topic.
+func IsOptedOut(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && (apiErr.Code == "MEMORY_OPTED_OUT" || apiErr.Code == "TOPIC_DISABLED")
+}
+
+// isUnsupported reports whether err indicates that the server does not
+// implement the requested endpoint or method.
+func isUnsupported(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound:
+		// A missing route carries no PowerMem error code, unlike a
+		// missing resource (e.g. MEMORY_NOT_FOUND).
+		return !strings.HasSuffix(apiErr.Code, "_NOT_FOUND")
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
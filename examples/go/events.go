@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/webhooks"
+)
+
+// ErrUnknownEventType is returned when converting an event of a type this
+// client does not know, e.g. one added by a newer server.
+var ErrUnknownEventType = errors.New("powermem: unknown event type")
+
+// Event is a typed memory event: a MemoryCreatedEvent,
+// MemoryUpdatedEvent, MemoryDeletedEvent, MemoryConsolidatedEvent or
+// MemoryExpiredEvent. Webhook deliveries, realtime pushes and the change
+// feed all convert to it, so one handler serves every integration:
+//
+//	switch ev := ev.(type) {
+//	case MemoryCreatedEvent:
+//		index(ev.Memory)
+//	case MemoryDeletedEvent, MemoryExpiredEvent:
+//		unindex(ev.Meta().MemoryID)
+//	}
+type Event interface {
+	Meta() EventMeta
+}
+
+// EventMeta is what every event carries.
+type EventMeta struct {
+	Type webhooks.EventType
+
+	// ID identifies the event: the webhook delivery ID, or the change
+	// feed position. Redelivered events keep their ID.
+	ID string
+
+	// MemoryID is the memory the event is about.
+	MemoryID MemoryID
+
+	// At is when the change happened, zero if the source did not say.
+	At time.Time
+
+	// ResumeToken is the change feed position after the event, empty for
+	// webhook deliveries.
+	ResumeToken string
+}
+
+// Meta returns m, which makes every type embedding EventMeta an Event.
+func (m EventMeta) Meta() EventMeta {
+	return m
+}
+
+// MemoryCreatedEvent reports a memory created.
+type MemoryCreatedEvent struct {
+	EventMeta
+	Memory Memory
+}
+
+// MemoryUpdatedEvent reports a memory updated; Memory is its new state.
+type MemoryUpdatedEvent struct {
+	EventMeta
+	Memory Memory
+}
+
+// MemoryDeletedEvent reports a memory deleted. Only the IDs of Memory are
+// set.
+type MemoryDeletedEvent struct {
+	EventMeta
+	Memory Memory
+}
+
+// MemoryConsolidatedEvent reports a memory that replaced the memories
+// in SourceIDs, which are deleted without events of their own.
+type MemoryConsolidatedEvent struct {
+	EventMeta
+	Memory    Memory
+	SourceIDs []MemoryID
+}
+
+// MemoryExpiredEvent reports a memory deleted because it expired. Only
+// the IDs of Memory are set.
+type MemoryExpiredEvent struct {
+	EventMeta
+	Memory Memory
+}
+
+// Typed converts ev to the typed event model. It fails with
+// ErrUnknownEventType for types this client does not know.
+func (ev MemoryEvent) Typed() (Event, error) {
+	meta := EventMeta{ID: ev.ResumeToken, ResumeToken: ev.ResumeToken}
+	if ev.At != nil {
+		meta.At = *ev.At
+	}
+	return typedEvent(meta, ev)
+}
+
+// EventFromWebhook converts a webhook delivery to the typed event model.
+// Verify the delivery's signature first.
+func EventFromWebhook(delivery *webhooks.Event) (Event, error) {
+	if !strings.HasPrefix(string(delivery.Type), "memory.") {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEventType, delivery.Type)
+	}
+	var payload struct {
+		Memory
+		SourceIDs []MemoryID `json:"source_ids"`
+	}
+	if err := json.Unmarshal(delivery.Data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse %s payload: %w", delivery.Type, err)
+	}
+
+	meta := EventMeta{ID: delivery.ID, At: delivery.CreatedAt}
+	return typedEvent(meta, MemoryEvent{Type: delivery.Type, Memory: payload.Memory, SourceIDs: payload.SourceIDs})
+}
+
+// typedEvent returns the typed event for ev, with meta completed from ev.
+func typedEvent(meta EventMeta, ev MemoryEvent) (Event, error) {
+	meta.Type = ev.Type
+	meta.MemoryID = ev.Memory.MemoryID
+	switch ev.Type {
+	case webhooks.MemoryCreated:
+		return MemoryCreatedEvent{EventMeta: meta, Memory: ev.Memory}, nil
+	case webhooks.MemoryUpdated:
+		return MemoryUpdatedEvent{EventMeta: meta, Memory: ev.Memory}, nil
+	case webhooks.MemoryDeleted:
+		return MemoryDeletedEvent{EventMeta: meta, Memory: ev.Memory}, nil
+	case webhooks.MemoryConsolidated:
+		return MemoryConsolidatedEvent{EventMeta: meta, Memory: ev.Memory, SourceIDs: ev.SourceIDs}, nil
+	case webhooks.MemoryExpired:
+		return MemoryExpiredEvent{EventMeta: meta, Memory: ev.Memory}, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownEventType, ev.Type)
+}
@@ -0,0 +1,531 @@
+// The examples below are run and checked by go test. Since this
+// directory is package main, pkg.go.dev does not render them, as it only
+// documents importable packages.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
+)
+
+// fakeServer is an in-memory stand-in for the PowerMem API serving the
+// endpoints used by the examples. It stores content verbatim and scores
+// search results by the fraction of query words they contain.
+type fakeServer struct {
+	mu       sync.Mutex
+	memories []Memory
+	nextID   MemoryID
+}
+
+// newExampleClient starts a fake server holding seed and returns a client
+// for it and a function stopping the server.
+func newExampleClient(seed []Memory, opts ...Option) (*Client, func()) {
+	fs := &fakeServer{nextID: 1}
+	for _, m := range seed {
+		fs.add(m)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/system/health", fs.health)
+	mux.HandleFunc("POST /api/v1/memories", fs.create)
+	mux.HandleFunc("POST /api/v1/memories/batch", fs.batch)
+	mux.HandleFunc("GET /api/v1/memories", fs.list)
+	mux.HandleFunc("PUT /api/v1/memories/{id}", fs.update)
+	mux.HandleFunc("DELETE /api/v1/memories/{id}", fs.delete)
+	mux.HandleFunc("POST /api/v1/memories/search", fs.search)
+	mux.HandleFunc("POST /api/v1/memories/search/stream", fs.searchStream)
+	srv := httptest.NewServer(mux)
+	return NewClient(srv.URL, "", opts...), srv.Close
+}
+
+// add stores m under the next ID.
+func (fs *fakeServer) add(m Memory) Memory {
+	m.MemoryID = fs.nextID
+	fs.nextID++
+	fs.memories = append(fs.memories, m)
+	return m
+}
+
+func (fs *fakeServer) reply(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIResponse[interface{}]{Success: status == http.StatusOK, Data: data, Message: http.StatusText(status)})
+}
+
+func (fs *fakeServer) health(w http.ResponseWriter, r *http.Request) {
+	fs.reply(w, http.StatusOK, map[string]string{"status": "healthy", "timestamp": "2026-01-31T06:18:04Z"})
+}
+
+func (fs *fakeServer) create(w http.ResponseWriter, r *http.Request) {
+	var req CreateMemoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fs.reply(w, http.StatusBadRequest, nil)
+		return
+	}
+	fs.mu.Lock()
+	m := fs.add(Memory{Content: req.Content, UserID: req.UserID, AgentID: req.AgentID, Metadata: req.Metadata})
+	fs.mu.Unlock()
+	fs.reply(w, http.StatusOK, []CreatedMemory{{MemoryID: m.MemoryID, Content: m.Content, UserID: m.UserID, AgentID: m.AgentID, Metadata: m.Metadata}})
+}
+
+func (fs *fakeServer) batch(w http.ResponseWriter, r *http.Request) {
+	var req BatchCreateMemoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fs.reply(w, http.StatusBadRequest, nil)
+		return
+	}
+	fs.mu.Lock()
+	result := BatchCreateResult{Total: len(req.Memories)}
+	for i, item := range req.Memories {
+		m := fs.add(Memory{Content: item.Content, UserID: req.UserID, AgentID: req.AgentID, Metadata: item.Metadata, Importance: item.Importance})
+		result.Memories = append(result.Memories, m)
+		result.Items = append(result.Items, BatchItemResult{Index: i, Memories: []Memory{m}})
+		result.CreatedCount++
+	}
+	fs.mu.Unlock()
+	fs.reply(w, http.StatusOK, result)
+}
+
+func (fs *fakeServer) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	fs.mu.Lock()
+	var matched []Memory
+	for _, m := range fs.memories {
+		if user := q.Get("user_id"); user == "" || m.UserID == user {
+			matched = append(matched, m)
+		}
+	}
+	fs.mu.Unlock()
+	if q.Get("order") == "desc" {
+		slices.Reverse(matched)
+	}
+	list := MemoryList{Total: len(matched), Limit: limit, Offset: offset, Memories: []Memory{}}
+	if offset < len(matched) {
+		matched = matched[offset:]
+		if limit > 0 && limit < len(matched) {
+			matched = matched[:limit]
+		}
+		list.Memories = matched
+	}
+	fs.reply(w, http.StatusOK, list)
+}
+
+// find returns the index of the memory with the ID in r's path, or -1.
+func (fs *fakeServer) find(r *http.Request) int {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return slices.IndexFunc(fs.memories, func(m Memory) bool { return m.MemoryID == MemoryID(id) })
+}
+
+func (fs *fakeServer) update(w http.ResponseWriter, r *http.Request) {
+	var req UpdateMemoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fs.reply(w, http.StatusBadRequest, nil)
+		return
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	i := fs.find(r)
+	if i < 0 {
+		fs.reply(w, http.StatusNotFound, nil)
+		return
+	}
+	m := &fs.memories[i]
+	if req.Content != "" {
+		m.Content = req.Content
+	}
+	if req.Metadata != nil {
+		m.Metadata = req.Metadata
+	}
+	if req.Importance != nil {
+		m.Importance = *req.Importance
+	}
+	fs.reply(w, http.StatusOK, m)
+}
+
+func (fs *fakeServer) delete(w http.ResponseWriter, r *http.Request) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	i := fs.find(r)
+	if i < 0 {
+		fs.reply(w, http.StatusNotFound, nil)
+		return
+	}
+	id := fs.memories[i].MemoryID
+	fs.memories = slices.Delete(fs.memories, i, i+1)
+	fs.reply(w, http.StatusOK, DeleteMemoryResponse{MemoryID: id})
+}
+
+// rank returns the memories matching req, best first.
+func (fs *fakeServer) rank(r *http.Request) (*SearchMemoryRequest, []SearchResult, bool) {
+	var req SearchMemoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, nil, false
+	}
+	words := strings.FieldsFunc(strings.ToLower(req.Query), notWordRune)
+	fs.mu.Lock()
+	var results []SearchResult
+	for _, m := range fs.memories {
+		if req.UserID != "" && m.UserID != req.UserID || !fakeMatches(m, req.Filters) {
+			continue
+		}
+		content := strings.FieldsFunc(strings.ToLower(m.Content), notWordRune)
+		n := 0
+		for _, word := range words {
+			if slices.Contains(content, word) {
+				n++
+			}
+		}
+		if n > 0 {
+			results = append(results, SearchResult{MemoryID: m.MemoryID, Content: m.Content, Score: float64(n) / float64(len(words)), Metadata: m.Metadata})
+		}
+	}
+	fs.mu.Unlock()
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	if req.Limit > 0 && req.Limit < len(results) {
+		results = results[:req.Limit]
+	}
+	return &req, results, true
+}
+
+func (fs *fakeServer) search(w http.ResponseWriter, r *http.Request) {
+	req, results, ok := fs.rank(r)
+	if !ok {
+		fs.reply(w, http.StatusBadRequest, nil)
+		return
+	}
+	fs.reply(w, http.StatusOK, SearchResults{Query: req.Query, Results: results, Total: len(results)})
+}
+
+func (fs *fakeServer) searchStream(w http.ResponseWriter, r *http.Request) {
+	_, results, ok := fs.rank(r)
+	if !ok {
+		fs.reply(w, http.StatusBadRequest, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, result := range results {
+		data, _ := json.Marshal(result)
+		fmt.Fprintf(w, "event: result\ndata: %s\n\n", data)
+	}
+	fmt.Fprint(w, "event: done\ndata: {}\n\n")
+}
+
+func notWordRune(r rune) bool {
+	return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+}
+
+// fakeMatches reports whether m matches a metadata filter using equality,
+// "in" and "AND". The "id" field matches the memory ID.
+func fakeMatches(m Memory, filter map[string]interface{}) bool {
+	for field, want := range filter {
+		if field == "AND" {
+			for _, sub := range want.([]interface{}) {
+				if !fakeMatches(m, sub.(map[string]interface{})) {
+					return false
+				}
+			}
+			continue
+		}
+		got := fmt.Sprint(m.Metadata[field])
+		if field == "id" {
+			got = m.MemoryID.String()
+		}
+		op, ok := want.(map[string]interface{})
+		if !ok {
+			if got != fmt.Sprint(want) {
+				return false
+			}
+			continue
+		}
+		in, _ := op["in"].([]interface{})
+		if !slices.ContainsFunc(in, func(v interface{}) bool { return fmt.Sprint(v) == got }) {
+			return false
+		}
+	}
+	return true
+}
+
+// printExampleLatency prints the latency of a call like the example
+// program does, as an upper bound so that the output is stable. The fake
+// server answers well within it; a slower call prints the actual latency
+// and fails the example.
+func printExampleLatency(start time.Time) {
+	const bound = time.Second
+	if d := time.Since(start); d >= bound {
+		fmt.Println("Latency:", d)
+		return
+	}
+	fmt.Println("Latency: <", bound)
+}
+
+// exampleSeed is the memories the examples start from.
+var exampleSeed = []Memory{
+	{Content: "User prefers green tea in the afternoon", UserID: "alice"},
+	{Content: "User drinks black coffee in the morning", UserID: "alice"},
+	{Content: "User likes green smoothies after a run", UserID: "alice"},
+}
+
+func ExampleClient_Health() {
+	client, done := newExampleClient(nil)
+	defer done()
+
+	start := time.Now()
+	health, err := client.Health()
+	if err != nil {
+		fmt.Println("health check failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	fmt.Println("Status:", health.Status)
+	fmt.Println("Timestamp:", health.Timestamp.Format("2006-01-02 15:04:05"))
+	// Output:
+	// Latency: < 1s
+	// Status: healthy
+	// Timestamp: 2026-01-31 06:18:04
+}
+
+func ExampleClient_CreateMemory() {
+	client, done := newExampleClient(nil)
+	defer done()
+
+	// Store the content verbatim; inference may split or rewrite it.
+	infer := false
+	start := time.Now()
+	memories, err := client.CreateMemory(&CreateMemoryRequest{
+		Content:  "User likes Python programming",
+		UserID:   "alice",
+		Metadata: map[string]interface{}{"source": "example"},
+		Infer:    &infer,
+	})
+	if err != nil {
+		fmt.Println("create failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	for _, m := range memories {
+		fmt.Printf("Created %s: %s\n", m.MemoryID, m.Content)
+	}
+	// Output:
+	// Latency: < 1s
+	// Created 1: User likes Python programming
+}
+
+func ExampleClient_ListMemories() {
+	client, done := newExampleClient(exampleSeed)
+	defer done()
+
+	start := time.Now()
+	list, err := client.ListMemories(ListMemoriesParams{UserID: "alice", Limit: 2, Order: "desc"})
+	if err != nil {
+		fmt.Println("list failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	fmt.Printf("Showing %d of %d memories\n", len(list.Memories), list.Total)
+	for _, m := range list.Memories {
+		fmt.Printf("[%s] %s\n", m.MemoryID, m.Content)
+	}
+	// Output:
+	// Latency: < 1s
+	// Showing 2 of 3 memories
+	// [3] User likes green smoothies after a run
+	// [2] User drinks black coffee in the morning
+}
+
+func ExampleClient_SearchMemories() {
+	client, done := newExampleClient(exampleSeed)
+	defer done()
+
+	start := time.Now()
+	results, err := client.SearchMemories(&SearchMemoryRequest{
+		Query:  "green tea",
+		UserID: "alice",
+		Limit:  5,
+	})
+	if err != nil {
+		fmt.Println("search failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	fmt.Printf("Query %q found %d results\n", results.Query, results.Total)
+	for _, r := range results.Results {
+		fmt.Printf("%.2f %s\n", r.Score, r.Content)
+	}
+	// Output:
+	// Latency: < 1s
+	// Query "green tea" found 2 results
+	// 1.00 User prefers green tea in the afternoon
+	// 0.50 User likes green smoothies after a run
+}
+
+func ExampleClient_UpdateMemory() {
+	client, done := newExampleClient(exampleSeed)
+	defer done()
+
+	importance := 0.8
+	start := time.Now()
+	m, err := client.UpdateMemory(1, &UpdateMemoryRequest{
+		Content:    "User prefers jasmine tea in the afternoon",
+		UserID:     "alice",
+		Importance: &importance,
+	})
+	if err != nil {
+		fmt.Println("update failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	fmt.Printf("Updated %s: %s (importance %.1f)\n", m.MemoryID, m.Content, m.Importance)
+	// Output:
+	// Latency: < 1s
+	// Updated 1: User prefers jasmine tea in the afternoon (importance 0.8)
+}
+
+func ExampleClient_DeleteMemory() {
+	client, done := newExampleClient(exampleSeed)
+	defer done()
+
+	start := time.Now()
+	if err := client.DeleteMemory(2, "alice", ""); err != nil {
+		fmt.Println("delete failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	list, err := client.ListMemories(ListMemoriesParams{UserID: "alice"})
+	if err != nil {
+		fmt.Println("list failed:", err)
+		return
+	}
+	fmt.Printf("%d memories left\n", list.Total)
+	// Output:
+	// Latency: < 1s
+	// 2 memories left
+}
+
+func ExampleClient_BatchCreateMemories() {
+	client, done := newExampleClient(nil)
+	defer done()
+
+	// Store the facts verbatim; inference would rewrite them.
+	infer := false
+	start := time.Now()
+	result, err := client.BatchCreateMemories(context.Background(), &BatchCreateMemoryRequest{
+		UserID: "alice",
+		Infer:  &infer,
+		Memories: []BatchMemoryItem{
+			{Content: "User is vegetarian", Importance: 0.9},
+			{Content: "User is training for a marathon in April"},
+			{Content: "User prefers green tea in the afternoon"},
+		},
+	})
+	if err != nil {
+		fmt.Println("batch failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	fmt.Printf("Created %d of %d memories\n", result.CreatedCount, result.Total)
+	for _, item := range result.Items {
+		for _, m := range item.Memories {
+			fmt.Printf("item %d -> memory %s\n", item.Index, m.MemoryID)
+		}
+	}
+	for _, f := range result.Failed {
+		fmt.Printf("item %d failed: %s\n", f.Index, f.Error)
+	}
+	// Output:
+	// Latency: < 1s
+	// Created 3 of 3 memories
+	// item 0 -> memory 1
+	// item 1 -> memory 2
+	// item 2 -> memory 3
+}
+
+func ExampleClient_SearchMemoriesStream() {
+	// Streaming search is a preview feature.
+	client, done := newExampleClient(exampleSeed, WithExperimental(experimental.Streaming))
+	defer done()
+
+	start := time.Now()
+	results, errc, err := client.SearchMemoriesStream(context.Background(), &SearchMemoryRequest{
+		Query:  "coffee in the morning",
+		UserID: "alice",
+	})
+	if err != nil {
+		fmt.Println("search failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	for r := range results {
+		fmt.Printf("%.2f %s\n", r.Score, r.Content)
+	}
+	if err := <-errc; err != nil {
+		fmt.Println("stream failed:", err)
+	}
+	// Output:
+	// Latency: < 1s
+	// 1.00 User drinks black coffee in the morning
+	// 0.50 User prefers green tea in the afternoon
+}
+
+func ExampleClient_HierarchicalSearch() {
+	client, done := newExampleClient([]Memory{
+		{Content: "User is on a vegetarian diet", UserID: "alice"},
+		{Content: "User trains for a marathon as exercise", UserID: "alice"},
+		{
+			Content: "Diet and exercise habits: vegetarian, marathon training",
+			UserID:  "alice",
+			Metadata: map[string]interface{}{
+				KindMetadataField:       KindSummary,
+				RunSummaryMetadataField: RunSummaryLink{RunID: "run-1", MemoryIDs: []MemoryID{1, 2}},
+			},
+		},
+	})
+	defer done()
+
+	// Summaries are searched first and expanded into the memories they
+	// link to when they score at least 0.7.
+	start := time.Now()
+	results, err := client.HierarchicalSearch(context.Background(), &SearchMemoryRequest{
+		Query:  "diet and exercise habits",
+		UserID: "alice",
+	}, HierarchicalSearchOptions{ExpandThreshold: 0.7})
+	if err != nil {
+		fmt.Println("search failed:", err)
+		return
+	}
+	printExampleLatency(start)
+	for _, r := range results.Results {
+		fmt.Printf("%.2f %s\n", r.Summary.Score, r.Summary.Content)
+		for _, d := range r.Details {
+			fmt.Printf("  %.2f %s\n", d.Score, d.Content)
+		}
+	}
+	// Output:
+	// Latency: < 1s
+	// 1.00 Diet and exercise habits: vegetarian, marathon training
+	//   0.25 User is on a vegetarian diet
+	//   0.25 User trains for a marathon as exercise
+}
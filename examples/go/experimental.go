@@ -0,0 +1,32 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
+)
+
+// envExperimental returns the features enabled by the environment, read
+// once. Unknown features are ignored.
+var envExperimental = sync.OnceValue(func() experimental.Flags {
+	fl, _ := experimental.FromEnv()
+	return fl
+})
+
+// WithExperimental enables preview APIs, which otherwise fail with an
+// *experimental.DisabledError. Features enabled by the
+// POWERMEM_EXPERIMENTAL environment variable are enabled as well.
+func WithExperimental(features ...experimental.Feature) Option {
+	return func(c *Client) {
+		c.experimental = c.experimental.Enable(features...)
+	}
+}
+
+// requireFeature returns an *experimental.DisabledError unless f is
+// enabled, by option or environment, for the preview API api.
+func (c *Client) requireFeature(f experimental.Feature, api string) error {
+	if c.experimental.Union(envExperimental()).Enabled(f) {
+		return nil
+	}
+	return &experimental.DisabledError{Feature: f, API: api}
+}
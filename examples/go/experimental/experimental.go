@@ -0,0 +1,143 @@
+// Package experimental names the preview features of the PowerMem client
+// and the flags that enable them. Preview APIs may change or go away in
+// any release; everything else keeps its compatibility guarantees. They
+// fail with a *DisabledError until their feature is enabled, in code:
+//
+//	client := NewClient(baseURL, apiKey, WithExperimental(experimental.Graph))
+//
+// or for a whole deployment, with a comma-separated list of features or
+// "all" in the POWERMEM_EXPERIMENTAL environment variable:
+//
+//	POWERMEM_EXPERIMENTAL=graph,streaming
+package experimental
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// EnvVar is the environment variable listing the enabled features.
+const EnvVar = "POWERMEM_EXPERIMENTAL"
+
+// Feature is a group of preview APIs enabled together.
+type Feature string
+
+const (
+	// Graph covers the knowledge graph APIs: entities, relations,
+	// relation types, traversal, merging and graph-augmented search.
+	Graph Feature = "graph"
+
+	// Streaming covers SearchMemoriesStream.
+	Streaming Feature = "streaming"
+
+	// AsyncJobs covers managing background jobs: listing, cancelling and
+	// retrying them, and metadata backfills. Waiting for the job of an
+	// async write with WaitForJob is stable.
+	AsyncJobs Feature = "async_jobs"
+)
+
+// All lists the known features.
+var All = []Feature{Graph, Streaming, AsyncJobs}
+
+// Known reports whether f is a known feature.
+func (f Feature) Known() bool {
+	return slices.Contains(All, f)
+}
+
+// Flags is a set of enabled features. The zero value enables none.
+type Flags struct {
+	all     bool
+	enabled []Feature
+}
+
+// Enable returns flags with features enabled as well.
+func (fl Flags) Enable(features ...Feature) Flags {
+	enabled := slices.Clone(fl.enabled)
+	for _, f := range features {
+		if !slices.Contains(enabled, f) {
+			enabled = append(enabled, f)
+		}
+	}
+	return Flags{all: fl.all, enabled: enabled}
+}
+
+// Union returns the features enabled in fl or other.
+func (fl Flags) Union(other Flags) Flags {
+	u := fl.Enable(other.enabled...)
+	u.all = fl.all || other.all
+	return u
+}
+
+// Enabled reports whether f is enabled.
+func (fl Flags) Enabled(f Feature) bool {
+	return fl.all || slices.Contains(fl.enabled, f)
+}
+
+// String returns the flags in the format of Parse.
+func (fl Flags) String() string {
+	if fl.all {
+		return "all"
+	}
+	names := make([]string, len(fl.enabled))
+	for i, f := range fl.enabled {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ",")
+}
+
+// Parse parses a comma-separated list of features, or "all". Unknown
+// features are reported in the error but the known ones are still
+// enabled, so that a setting meant for a newer client does not disable
+// everything.
+func Parse(s string) (Flags, error) {
+	var fl Flags
+	var unknown []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch f := Feature(name); {
+		case name == "":
+		case name == "all":
+			fl.all = true
+		case f.Known():
+			fl = fl.Enable(f)
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fl, fmt.Errorf("unknown experimental features %s", strings.Join(unknown, ", "))
+	}
+	return fl, nil
+}
+
+// FromEnv returns the features enabled by the EnvVar environment
+// variable, as parsed by Parse.
+func FromEnv() (Flags, error) {
+	fl, err := Parse(os.Getenv(EnvVar))
+	if err != nil {
+		return fl, fmt.Errorf("%s: %w", EnvVar, err)
+	}
+	return fl, nil
+}
+
+// DisabledError is returned by a preview API whose feature is not
+// enabled.
+type DisabledError struct {
+	Feature Feature
+
+	// API is the disabled API, e.g. "GraphNeighbors".
+	API string
+}
+
+func (e *DisabledError) Error() string {
+	return fmt.Sprintf("powermem: %s is experimental; enable %q with WithExperimental or %s", e.API, e.Feature, EnvVar)
+}
+
+// IsDisabled reports whether err is a *DisabledError.
+func IsDisabled(err error) bool {
+	var de *DisabledError
+	return errors.As(err, &de)
+}
@@ -0,0 +1,15 @@
+package main
+
+import "time"
+
+// expiresAt returns the expiry of a memory written now with ttl.
+func expiresAt(ttl time.Duration) *time.Time {
+	t := time.Now().Add(ttl).UTC()
+	return &t
+}
+
+// Expired reports whether m has expired. The server deletes expired
+// memories in the background, so they may still be returned for a while.
+func (m *Memory) Expired() bool {
+	return m.ExpiresAt != nil && !m.ExpiresAt.After(time.Now())
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ExportFormat is the serialization format of a memory export.
+type ExportFormat string
+
+const (
+	// ExportJSON produces a single JSON array.
+	ExportJSON ExportFormat = "json"
+
+	// ExportNDJSON produces one JSON object per line.
+	ExportNDJSON ExportFormat = "ndjson"
+
+	// ExportCSV produces comma-separated values with a header row.
+	ExportCSV ExportFormat = "csv"
+)
+
+// ExportParams contains parameters for exporting memories.
+type ExportParams struct {
+	UserID  string
+	AgentID string
+	RunID   string
+
+	// Format defaults to ExportJSON.
+	Format ExportFormat
+
+	// IncludeEmbeddings adds each memory's stored vector to the export.
+	IncludeEmbeddings bool
+
+	// IncludeMetadata adds each memory's metadata to the export.
+	IncludeMetadata bool
+
+	// Limit caps the number of exported memories. Zero uses the server
+	// default.
+	Limit int
+}
+
+// ExportMemories streams the memories of a user, agent or run in the
+// chosen format, e.g. for backups and offline analysis. The export is not
+// buffered in memory; the caller must close the returned reader.
+func (c *Client) ExportMemories(ctx context.Context, params ExportParams) (io.ReadCloser, error) {
+	format := params.Format
+	if format == "" {
+		format = ExportJSON
+	}
+	switch format {
+	case ExportJSON, ExportNDJSON, ExportCSV:
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	queryParams := url.Values{}
+	queryParams.Set("format", string(format))
+	if params.UserID != "" {
+		queryParams.Set("user_id", params.UserID)
+	}
+	if params.AgentID != "" {
+		queryParams.Set("agent_id", params.AgentID)
+	}
+	if params.RunID != "" {
+		queryParams.Set("run_id", params.RunID)
+	}
+	if params.IncludeEmbeddings {
+		queryParams.Set("include_embeddings", "true")
+	}
+	if params.IncludeMetadata {
+		queryParams.Set("include_metadata", "true")
+	}
+	if params.Limit > 0 {
+		queryParams.Set("limit", strconv.Itoa(params.Limit))
+	}
+
+	resp, err := c.doStream(ctx, http.MethodGet, "/api/v1/memories/export?"+queryParams.Encode(), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,180 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall/js"
+)
+
+// FetchTransport is an http.RoundTripper that sends requests with the
+// JavaScript fetch API, for clients compiled to WebAssembly and run in
+// browser extensions or edge runtimes such as Cloudflare Workers. Go's
+// default transport also uses fetch in browsers, but not in runtimes that
+// look like Node.js; FetchTransport always does. Response bodies are
+// streamed, so the change feed works too.
+type FetchTransport struct {
+	// Fetch is the fetch function to call. The zero value uses
+	// globalThis.fetch.
+	Fetch js.Value
+}
+
+// WithFetchTransport sends the client's requests with FetchTransport,
+// keeping its timeout.
+func WithFetchTransport() Option {
+	return func(c *Client) {
+		c.HTTPClient = &http.Client{Timeout: c.HTTPClient.Timeout, Transport: FetchTransport{}}
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t FetchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fetch := t.Fetch
+	if fetch.IsUndefined() || fetch.IsNull() {
+		fetch = js.Global().Get("fetch")
+	}
+	if fetch.Type() != js.TypeFunction {
+		return nil, errors.New("fetch: fetch API not available")
+	}
+
+	headers := js.Global().Get("Headers").New()
+	for name, values := range req.Header {
+		for _, v := range values {
+			headers.Call("append", name, v)
+		}
+	}
+	abort := js.Global().Get("AbortController").New()
+	init := js.Global().Get("Object").New()
+	init.Set("method", req.Method)
+	init.Set("headers", headers)
+	init.Set("signal", abort.Get("signal"))
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			buf := js.Global().Get("Uint8Array").New(len(body))
+			js.CopyBytesToJS(buf, body)
+			init.Set("body", buf)
+		}
+	}
+
+	// Abort the fetch, and with it the body stream, when the request's
+	// context is done.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-req.Context().Done():
+			abort.Call("abort")
+		case <-done:
+		}
+	}()
+
+	resp, err := await(fetch.Invoke(req.URL.String(), init))
+	if err != nil {
+		close(done)
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+
+	header := http.Header{}
+	entries := resp.Get("headers").Call("entries")
+	for {
+		next := entries.Call("next")
+		if next.Get("done").Bool() {
+			break
+		}
+		pair := next.Get("value")
+		header.Add(pair.Index(0).String(), pair.Index(1).String())
+	}
+
+	var body io.ReadCloser = http.NoBody
+	if b := resp.Get("body"); !b.IsNull() && !b.IsUndefined() {
+		body = &fetchBody{reader: b.Call("getReader"), done: done}
+	} else {
+		close(done)
+	}
+	status := resp.Get("status").Int()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, resp.Get("statusText").String()),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+// fetchBody reads a fetch response body stream.
+type fetchBody struct {
+	reader js.Value
+	done   chan struct{}
+	buf    []byte
+	err    error
+}
+
+func (b *fetchBody) Read(p []byte) (int, error) {
+	for len(b.buf) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		chunk, err := await(b.reader.Call("read"))
+		switch {
+		case err != nil:
+			b.err = fmt.Errorf("fetch: %w", err)
+		case chunk.Get("done").Bool():
+			b.err = io.EOF
+		default:
+			value := chunk.Get("value")
+			b.buf = make([]byte, value.Get("length").Int())
+			js.CopyBytesToGo(b.buf, value)
+		}
+	}
+	n := copy(p, b.buf)
+	b.buf = b.buf[n:]
+	return n, nil
+}
+
+func (b *fetchBody) Close() error {
+	if b.err == nil {
+		b.err = errors.New("fetch: body closed")
+		b.reader.Call("cancel")
+	}
+	select {
+	case <-b.done:
+	default:
+		close(b.done)
+	}
+	return nil
+}
+
+// await waits for promise to settle and returns its value, or an error
+// holding the rejection reason.
+func await(promise js.Value) (js.Value, error) {
+	type result struct {
+		value js.Value
+		err   error
+	}
+	ch := make(chan result, 1)
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- result{value: args[0]}
+		return nil
+	})
+	defer onResolve.Release()
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- result{err: errors.New(args[0].Call("toString").String())}
+		return nil
+	})
+	defer onReject.Release()
+
+	promise.Call("then", onResolve, onReject)
+	r := <-ch
+	return r.value, r.err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// NaturalKeyMetadataField is the metadata field under which
+// GetOrCreateMemory stores a memory's natural key.
+const NaturalKeyMetadataField = "natural_key"
+
+// getOrCreateRequest is the request body of the server-side get-or-create
+// endpoint.
+type getOrCreateRequest struct {
+	NaturalKey string `json:"natural_key"`
+	*CreateMemoryRequest
+}
+
+// getOrCreateResponse is the response data of the server-side
+// get-or-create endpoint.
+type getOrCreateResponse struct {
+	Memory  Memory `json:"memory"`
+	Created bool   `json:"created"`
+}
+
+// GetOrCreateMemory returns the memory identified by naturalKey, creating
+// it from req if no such memory exists. created reports whether a new
+// memory was stored.
+//
+// The natural key is stored in the memory's metadata under
+// NaturalKeyMetadataField. If req.Infer is nil, inference is disabled so
+// that exactly one memory carries the key.
+//
+// When the server supports it, the lookup and creation happen atomically
+// server-side. Otherwise the client falls back to a search followed by a
+// create, serialized per natural key within this client; concurrent
+// writers in other processes may still race in that mode.
+func (c *Client) GetOrCreateMemory(ctx context.Context, naturalKey string, req *CreateMemoryRequest) (mem *Memory, created bool, err error) {
+	if naturalKey == "" {
+		return nil, false, fmt.Errorf("natural key is required")
+	}
+
+	keyed := *req
+	keyed.Metadata = make(map[string]interface{}, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		keyed.Metadata[k] = v
+	}
+	keyed.Metadata[NaturalKeyMetadataField] = naturalKey
+	if keyed.Infer == nil {
+		infer := false
+		keyed.Infer = &infer
+	}
+	// Both paths send the request as CreateMemory would: validated, with
+	// the client's defaults and receipt setting applied.
+	prepared, err := c.prepareCreate(&keyed)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !c.state().noServerGetOrCreate.Load() {
+		mem, created, err := c.getOrCreateServer(ctx, naturalKey, prepared)
+		if err == nil || !isUnsupported(err) {
+			return mem, created, err
+		}
+		c.state().noServerGetOrCreate.Store(true)
+	}
+
+	unlock := c.state().naturalKeyLocks.Lock(prepared.UserID + "\x00" + prepared.AgentID + "\x00" + naturalKey)
+	defer unlock()
+
+	existing, err := c.findByNaturalKey(ctx, naturalKey, prepared)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+
+	createdMems, err := c.postMemory(ctx, prepared)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(createdMems) == 0 {
+		return nil, false, fmt.Errorf("get or create memory failed: server created no memory")
+	}
+
+	cm := createdMems[0]
+	return &Memory{
+		MemoryID: cm.MemoryID,
+		Content:  cm.Content,
+		UserID:   cm.UserID,
+		AgentID:  cm.AgentID,
+		RunID:    cm.RunID,
+		Metadata: cm.Metadata,
+	}, true, nil
+}
+
+// getOrCreateServer calls the server-side atomic get-or-create endpoint
+// with a request prepared by prepareCreate.
+func (c *Client) getOrCreateServer(ctx context.Context, naturalKey string, req *CreateMemoryRequest) (*Memory, bool, error) {
+	body := getOrCreateRequest{NaturalKey: naturalKey, CreateMemoryRequest: req}
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories/get-or-create", body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var resp APIResponse[getOrCreateResponse]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, false, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, false, fmt.Errorf("get or create memory failed: %s", resp.Message)
+	}
+
+	return &resp.Data.Memory, resp.Data.Created, nil
+}
+
+// findByNaturalKey looks up a memory in req's scope whose metadata carries
+// naturalKey. It returns nil if there is none.
+func (c *Client) findByNaturalKey(ctx context.Context, naturalKey string, req *CreateMemoryRequest) (*Memory, error) {
+	results, err := c.searchMemories(ctx, &SearchMemoryRequest{
+		Query:   req.Content,
+		UserID:  req.UserID,
+		AgentID: req.AgentID,
+		RunID:   req.RunID,
+		Filters: map[string]interface{}{NaturalKeyMetadataField: naturalKey},
+		Limit:   1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results.Results {
+		if r.Metadata[NaturalKeyMetadataField] != naturalKey {
+			continue
+		}
+		return c.getMemory(ctx, r.MemoryID, req.UserID, req.AgentID)
+	}
+	return nil, nil
+}
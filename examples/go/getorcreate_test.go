@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGetOrCreateMemoryAppliesClientDefaults(t *testing.T) {
+	var got getOrCreateRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/memories/get-or-create" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": getOrCreateResponse{
+			Memory:  Memory{MemoryID: 1, Content: got.Content, Metadata: got.Metadata},
+			Created: true,
+		}})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "").With(WithDefaultMetadata(map[string]interface{}{"app": "child"}), WithDefaultScope(ScopeUser))
+	ctx := context.Background()
+	if _, created, err := c.GetOrCreateMemory(ctx, "pref:coffee", &CreateMemoryRequest{Content: "likes coffee", UserID: "u1"}); err != nil || !created {
+		t.Fatalf("GetOrCreateMemory: created %t, err %v", created, err)
+	}
+	if got.NaturalKey != "pref:coffee" || got.Metadata[NaturalKeyMetadataField] != "pref:coffee" {
+		t.Errorf("natural key %q, metadata %v; want pref:coffee in both", got.NaturalKey, got.Metadata)
+	}
+	if got.Metadata["app"] != "child" || got.Scope != ScopeUser {
+		t.Errorf("metadata %v, scope %q; want the client's defaults", got.Metadata, got.Scope)
+	}
+
+	if _, _, err := c.GetOrCreateMemory(ctx, "pref:tea", &CreateMemoryRequest{Content: "x", UserID: "u1", Importance: 2}); err == nil {
+		t.Error("invalid importance was sent")
+	}
+}
+
+// fallbackServer lacks the get-or-create endpoint and stores memories
+// for search by natural key.
+type fallbackServer struct {
+	mu          sync.Mutex
+	stored      []Memory
+	getOrCreate int
+}
+
+func (s *fallbackServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var data interface{}
+	switch {
+	case r.URL.Path == "/api/v1/memories/get-or-create":
+		s.getOrCreate++
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": "Not Found"})
+		return
+	case r.URL.Path == "/api/v1/memories/search":
+		var req SearchMemoryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		results := SearchResults{}
+		for _, m := range s.stored {
+			if m.Metadata[NaturalKeyMetadataField] == req.Filters[NaturalKeyMetadataField] {
+				results.Results = append(results.Results, SearchResult{MemoryID: m.MemoryID, Content: m.Content, Metadata: m.Metadata})
+			}
+		}
+		data = results
+	case r.URL.Path == "/api/v1/memories" && r.Method == http.MethodPost:
+		var req CreateMemoryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		m := Memory{MemoryID: MemoryID(len(s.stored) + 1), Content: req.Content, UserID: req.UserID, Metadata: req.Metadata}
+		s.stored = append(s.stored, m)
+		data = []CreatedMemory{{MemoryID: m.MemoryID, Content: m.Content, UserID: m.UserID, Metadata: m.Metadata}}
+	case strings.HasPrefix(r.URL.Path, "/api/v1/memories/"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/v1/memories/"))
+		if id < 1 || id > len(s.stored) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "code": "MEMORY_NOT_FOUND"})
+			return
+		}
+		data = s.stored[id-1]
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+}
+
+func TestGetOrCreateMemoryFallback(t *testing.T) {
+	fs := &fallbackServer{}
+	srv := httptest.NewServer(fs)
+	defer srv.Close()
+	c := NewClient(srv.URL, "")
+	ctx := context.Background()
+
+	// Concurrent calls for one key create a single memory.
+	const n = 5
+	var wg sync.WaitGroup
+	ids := make([]MemoryID, n)
+	createdCount := 0
+	var mu sync.Mutex
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mem, created, err := c.GetOrCreateMemory(ctx, "pref:coffee", &CreateMemoryRequest{Content: "likes coffee", UserID: "u1"})
+			if err != nil {
+				t.Errorf("GetOrCreateMemory: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			ids[i] = mem.MemoryID
+			if created {
+				createdCount++
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(fs.stored) != 1 || createdCount != 1 {
+		t.Fatalf("stored %d memories, %d calls reported created; want 1 and 1", len(fs.stored), createdCount)
+	}
+	for i, id := range ids {
+		if id != fs.stored[0].MemoryID {
+			t.Errorf("call %d got memory %v, want %v", i, id, fs.stored[0].MemoryID)
+		}
+	}
+
+	// Once the endpoint is known to be missing it is not tried again.
+	if _, created, err := c.GetOrCreateMemory(ctx, "pref:tea", &CreateMemoryRequest{Content: "likes tea", UserID: "u1"}); err != nil || !created {
+		t.Fatalf("GetOrCreateMemory for a new key: created %t, err %v", created, err)
+	}
+	if fs.getOrCreate > n {
+		t.Errorf("get-or-create endpoint called %d times, want at most %d", fs.getOrCreate, n)
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
+)
+
+// =============================================================================
+// Knowledge Graph
+// =============================================================================
+
+// ListEntities lists the entities of userID's knowledge graph, most
+// mentioned first.
+func (c *Client) ListEntities(ctx context.Context, userID string, params EntityListParams) (*EntityList, error) {
+	if err := c.requireFeature(experimental.Graph, "ListEntities"); err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if params.Type != "" {
+		query.Set("type", params.Type)
+	}
+	if params.Query != "" {
+		query.Set("q", params.Query)
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	path := fmt.Sprintf("/api/v1/users/%s/entities", url.PathEscape(userID))
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[EntityList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list entities failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GetUserGraph returns a page of userID's whole knowledge graph, its
+// entities and the relations between them, e.g. to mirror it into a
+// local graph database. Follow NextPage for the rest:
+//
+//	params := UserGraphParams{Limit: 500}
+//	for {
+//		page, err := client.GetUserGraph(ctx, "user123", params)
+//		if err != nil {
+//			return err
+//		}
+//		mirror(page.Entities, page.Relations)
+//		next := page.NextPage(params)
+//		if next == nil {
+//			break
+//		}
+//		params = *next
+//	}
+func (c *Client) GetUserGraph(ctx context.Context, userID string, params UserGraphParams) (*UserGraph, error) {
+	if err := c.requireFeature(experimental.Graph, "GetUserGraph"); err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	query := url.Values{}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Cursor != "" {
+		query.Set("cursor", params.Cursor)
+	}
+	if params.UpdatedSince != nil {
+		query.Set("updated_since", params.UpdatedSince.UTC().Format(time.RFC3339Nano))
+	}
+	path := fmt.Sprintf("/api/v1/users/%s/graph", url.PathEscape(userID))
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[UserGraph]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get user graph failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListRelations lists the relations the server extracted from a memory.
+func (c *Client) ListRelations(ctx context.Context, memoryID MemoryID) (*RelationList, error) {
+	if err := c.requireFeature(experimental.Graph, "ListRelations"); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/api/v1/memories/%s/relations", memoryID.String())
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RelationList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list relations failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// RegisterRelationType registers a custom relation type, so that
+// inference extracts relations of that type from new memories.
+// Registering a type again updates its definition.
+func (c *Client) RegisterRelationType(ctx context.Context, def *RelationTypeDefinition) (*RelationTypeDefinition, error) {
+	if err := c.requireFeature(experimental.Graph, "RegisterRelationType"); err != nil {
+		return nil, err
+	}
+	if def.Type.Builtin() {
+		return nil, fmt.Errorf("relation type %q is built in", def.Type)
+	}
+	if !def.Type.Valid() {
+		return nil, fmt.Errorf("invalid relation type %q", def.Type)
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/relation-types", def)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RelationTypeDefinition]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("register relation type failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListRelationTypes lists the relation types the server extracts, built-in
+// and custom.
+func (c *Client) ListRelationTypes(ctx context.Context) (*RelationTypeList, error) {
+	if err := c.requireFeature(experimental.Graph, "ListRelationTypes"); err != nil {
+		return nil, err
+	}
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/relation-types", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RelationTypeList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list relation types failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// UnregisterRelationType removes a custom relation type. Relations of
+// that type already extracted are kept.
+func (c *Client) UnregisterRelationType(ctx context.Context, t RelationType) error {
+	if err := c.requireFeature(experimental.Graph, "UnregisterRelationType"); err != nil {
+		return err
+	}
+	if t.Builtin() {
+		return fmt.Errorf("relation type %q is built in", t)
+	}
+	if !t.Valid() {
+		return fmt.Errorf("invalid relation type %q", t)
+	}
+	path := fmt.Sprintf("/api/v1/relation-types/%s", url.PathEscape(string(t)))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("unregister relation type failed: %s", resp.Message)
+	}
+
+	return nil
+}
+
+// MergeEntities merges duplicate entities into canonicalID, e.g. "Bob"
+// into "Robert": their names become aliases of the canonical entity and
+// their relations are repointed to it. The duplicates are deleted.
+func (c *Client) MergeEntities(ctx context.Context, canonicalID string, duplicateIDs []string) (*EntityMergeResult, error) {
+	if err := c.requireFeature(experimental.Graph, "MergeEntities"); err != nil {
+		return nil, err
+	}
+	if canonicalID == "" {
+		return nil, fmt.Errorf("canonical entity ID is required")
+	}
+	if len(duplicateIDs) == 0 {
+		return nil, fmt.Errorf("no duplicate entities to merge")
+	}
+	if slices.Contains(duplicateIDs, canonicalID) {
+		return nil, fmt.Errorf("entity %s cannot be merged into itself", canonicalID)
+	}
+	path := fmt.Sprintf("/api/v1/entities/%s/merge", url.PathEscape(canonicalID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, path, &EntityMergeRequest{DuplicateIDs: duplicateIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[EntityMergeResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("merge entities failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// SuggestEntityDuplicates lists groups of userID's entities the server
+// suspects are the same, most confident first, for review before
+// MergeEntities.
+func (c *Client) SuggestEntityDuplicates(ctx context.Context, userID string) (*EntityDuplicatesList, error) {
+	if err := c.requireFeature(experimental.Graph, "SuggestEntityDuplicates"); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/api/v1/users/%s/entities/duplicates", url.PathEscape(userID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[EntityDuplicatesList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("suggest entity duplicates failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GraphNeighbors returns the subgraph of the entities within depth
+// relations of entityID, in either direction. A depth of 0 uses the
+// server default of 1.
+func (c *Client) GraphNeighbors(ctx context.Context, entityID string, depth int) (*Subgraph, error) {
+	if err := c.requireFeature(experimental.Graph, "GraphNeighbors"); err != nil {
+		return nil, err
+	}
+	if entityID == "" {
+		return nil, fmt.Errorf("entity ID is required")
+	}
+	if depth < 0 {
+		return nil, fmt.Errorf("invalid depth %d", depth)
+	}
+	path := fmt.Sprintf("/api/v1/entities/%s/neighbors", url.PathEscape(entityID))
+	if depth > 0 {
+		path += "?depth=" + strconv.Itoa(depth)
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Subgraph]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("graph neighbors failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GraphPath returns a shortest path of relations from one entity to
+// another, answering "what connects X to Y". The path's Found field is
+// false if there is none.
+func (c *Client) GraphPath(ctx context.Context, fromID, toID string) (*EntityPath, error) {
+	if err := c.requireFeature(experimental.Graph, "GraphPath"); err != nil {
+		return nil, err
+	}
+	if fromID == "" || toID == "" {
+		return nil, fmt.Errorf("both entity IDs are required")
+	}
+	path := fmt.Sprintf("/api/v1/entities/%s/path?to=%s", url.PathEscape(fromID), url.QueryEscape(toID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[EntityPath]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("graph path failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
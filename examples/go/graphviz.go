@@ -0,0 +1,153 @@
+package main
+
+// GraphVizNode is an entity as a node for graph front-ends such as vis.js
+// and Cytoscape.js.
+type GraphVizNode struct {
+	// ID is the entity's ID, stable across calls, so that front-ends can
+	// update a rendered graph in place.
+	ID    string `json:"id"`
+	Label string `json:"label"`
+
+	// Group is the entity's type, for styling nodes by type.
+	Group string `json:"group,omitempty"`
+
+	// Weight is the number of memories mentioning the entity, at least 1,
+	// for sizing nodes.
+	Weight float64 `json:"value"`
+
+	// Placeholder is set on nodes standing in for entities known only as
+	// the end of a relation, e.g. on another page of a user's graph.
+	Placeholder bool `json:"placeholder,omitempty"`
+}
+
+// GraphVizEdge is a relation as an edge for graph front-ends.
+type GraphVizEdge struct {
+	ID    string `json:"id"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label"`
+
+	// Weight is the relation's confidence, or 1 when unknown, for edge
+	// widths.
+	Weight float64 `json:"value"`
+}
+
+// GraphViz is a ready-to-render graph in the nodes and edges format of
+// vis.js; Cytoscape returns the elements format of Cytoscape.js. Build it
+// with NewGraphViz or the Viz methods of the graph API responses, and
+// marshal it to JSON for the front-end.
+type GraphViz struct {
+	Nodes []GraphVizNode `json:"nodes"`
+	Edges []GraphVizEdge `json:"edges"`
+
+	nodes map[string]int
+	edges map[string]bool
+}
+
+// NewGraphViz returns the graph of entities and the relations between
+// them.
+func NewGraphViz(entities []Entity, relations []Relation) *GraphViz {
+	g := &GraphViz{Nodes: []GraphVizNode{}, Edges: []GraphVizEdge{}}
+	g.Add(entities, relations)
+	return g
+}
+
+// Add adds entities and relations to g, e.g. the next page of a user's
+// graph. Entities and relations already in g are not added again; an
+// entity replaces the placeholder node of the same ID.
+func (g *GraphViz) Add(entities []Entity, relations []Relation) {
+	if g.nodes == nil {
+		g.nodes = make(map[string]int, len(g.Nodes))
+		for i, n := range g.Nodes {
+			g.nodes[n.ID] = i
+		}
+		g.edges = make(map[string]bool, len(g.Edges))
+		for _, e := range g.Edges {
+			g.edges[e.ID] = true
+		}
+	}
+
+	for _, e := range entities {
+		node := GraphVizNode{ID: e.ID, Label: e.Name, Group: e.Type, Weight: float64(max(e.Mentions, 1))}
+		if i, ok := g.nodes[e.ID]; ok {
+			if g.Nodes[i].Placeholder {
+				g.Nodes[i] = node
+			}
+			continue
+		}
+		g.nodes[e.ID] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	for _, r := range relations {
+		id := r.ID
+		if id == "" {
+			id = r.SourceID + "-" + string(r.Type) + "-" + r.TargetID
+		}
+		if g.edges[id] {
+			continue
+		}
+		g.edges[id] = true
+		g.placeholder(r.SourceID, r.Source)
+		g.placeholder(r.TargetID, r.Target)
+		weight := r.Confidence
+		if weight <= 0 {
+			weight = 1
+		}
+		g.Edges = append(g.Edges, GraphVizEdge{ID: id, From: r.SourceID, To: r.TargetID, Label: string(r.Type), Weight: weight})
+	}
+}
+
+// placeholder adds a placeholder node for the entity id unless g has it.
+func (g *GraphViz) placeholder(id, name string) {
+	if _, ok := g.nodes[id]; ok {
+		return
+	}
+	g.nodes[id] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, GraphVizNode{ID: id, Label: firstNonEmpty(name, id), Weight: 1, Placeholder: true})
+}
+
+// CytoscapeElement is a node or edge in the elements format of
+// Cytoscape.js.
+type CytoscapeElement struct {
+	Group string                 `json:"group"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+// Cytoscape returns g in the elements format of Cytoscape.js, nodes
+// first. Node types are in the "type" data field.
+func (g *GraphViz) Cytoscape() []CytoscapeElement {
+	elements := make([]CytoscapeElement, 0, len(g.Nodes)+len(g.Edges))
+	for _, n := range g.Nodes {
+		data := map[string]interface{}{"id": n.ID, "label": n.Label, "weight": n.Weight}
+		if n.Group != "" {
+			data["type"] = n.Group
+		}
+		if n.Placeholder {
+			data["placeholder"] = true
+		}
+		elements = append(elements, CytoscapeElement{Group: "nodes", Data: data})
+	}
+	for _, e := range g.Edges {
+		elements = append(elements, CytoscapeElement{Group: "edges", Data: map[string]interface{}{
+			"id": e.ID, "source": e.From, "target": e.To, "label": e.Label, "weight": e.Weight,
+		}})
+	}
+	return elements
+}
+
+// Viz returns the subgraph for graph front-ends.
+func (s *Subgraph) Viz() *GraphViz {
+	return NewGraphViz(s.Entities, s.Relations)
+}
+
+// Viz returns the path for graph front-ends.
+func (p *EntityPath) Viz() *GraphViz {
+	return NewGraphViz(p.Entities, p.Relations)
+}
+
+// Viz returns the page of the graph for graph front-ends. Add further
+// pages with GraphViz.Add.
+func (u *UserGraph) Viz() *GraphViz {
+	return NewGraphViz(u.Entities, u.Relations)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/oceanbase/powermem/examples/go/webhooks"
+)
+
+// WithEventSubscription selects the change feed behind OnMemoryCreated and
+// the other event handlers, e.g. to receive only one user's events. Its
+// OnError is replaced by the handlers registered with OnEventError. The
+// feed is shared with the client's children and configured by the client
+// that registers the first handler.
+func WithEventSubscription(params SubscribeParams) Option {
+	return func(c *Client) {
+		c.eventParams = &params
+	}
+}
+
+// OnMemoryCreated registers fn to be called for each memory created. The
+// first handler registered starts a dispatcher goroutine consuming the
+// change feed, stopped by Close. Handlers run one at a time, in event
+// order, and should return quickly. The returned function unregisters fn.
+func (c *Client) OnMemoryCreated(fn func(Memory)) (unregister func()) {
+	return c.onEvent(webhooks.MemoryCreated, func(ev MemoryEvent) { fn(ev.Memory) })
+}
+
+// OnMemoryUpdated registers fn to be called for each memory updated; see
+// OnMemoryCreated.
+func (c *Client) OnMemoryUpdated(fn func(Memory)) (unregister func()) {
+	return c.onEvent(webhooks.MemoryUpdated, func(ev MemoryEvent) { fn(ev.Memory) })
+}
+
+// OnMemoryDeleted registers fn to be called for each memory deleted; only
+// the IDs of the memory are set. See OnMemoryCreated.
+func (c *Client) OnMemoryDeleted(fn func(Memory)) (unregister func()) {
+	return c.onEvent(webhooks.MemoryDeleted, func(ev MemoryEvent) { fn(ev.Memory) })
+}
+
+// OnMemoryEvent registers fn to be called for every event, e.g. to track
+// ResumeToken. See OnMemoryCreated.
+func (c *Client) OnMemoryEvent(fn func(MemoryEvent)) (unregister func()) {
+	return c.onEvent("", fn)
+}
+
+// OnEventError registers fn to be called with errors of the change feed
+// behind the event handlers, such as disconnections. It does not start
+// the dispatcher.
+func (c *Client) OnEventError(fn func(error)) (unregister func()) {
+	d := &c.state().events
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := &errorHandler{fn: fn}
+	d.errHandlers = append(d.errHandlers, h)
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.errHandlers = removeHandler(d.errHandlers, h)
+	}
+}
+
+// eventDispatcher calls the registered handlers with the events of one
+// change feed shared by a client and its children.
+type eventDispatcher struct {
+	mu          sync.Mutex
+	handlers    []*eventHandler
+	errHandlers []*errorHandler
+
+	// c is the client that started the dispatcher; its panic handler
+	// sees panics in the handlers.
+	c *Client
+
+	startOnce sync.Once
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// eventHandler is a handler for one event type, or all if typ is empty.
+type eventHandler struct {
+	typ webhooks.EventType
+	fn  func(MemoryEvent)
+}
+
+type errorHandler struct {
+	fn func(error)
+}
+
+// onEvent registers a handler and starts the dispatcher on first use.
+func (c *Client) onEvent(typ webhooks.EventType, fn func(MemoryEvent)) func() {
+	d := &c.state().events
+	h := &eventHandler{typ: typ, fn: fn}
+	d.mu.Lock()
+	d.handlers = append(d.handlers, h)
+	d.mu.Unlock()
+
+	d.startOnce.Do(func() { c.startDispatcher(d) })
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.handlers = removeHandler(d.handlers, h)
+	}
+}
+
+// startDispatcher starts consuming the change feed.
+func (c *Client) startDispatcher(d *eventDispatcher) {
+	params := SubscribeParams{}
+	if c.eventParams != nil {
+		params = *c.eventParams
+	}
+	params.OnError = d.reportError
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.c, d.cancel, d.done = c, cancel, make(chan struct{})
+	if err := c.addBackground("event dispatcher", d.close); err != nil {
+		cancel()
+		close(d.done)
+		d.reportError(err)
+		return
+	}
+	go d.run(ctx, c, params)
+}
+
+// run subscribes to the change feed, retrying until the subscription is
+// established, and dispatches its events until ctx is done.
+func (d *eventDispatcher) run(ctx context.Context, c *Client, params SubscribeParams) {
+	defer close(d.done)
+	backoff := newReconnectBackoff(params)
+	for {
+		events, err := c.SubscribeChanges(ctx, params)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.reportError(err)
+			if permanentStreamError(err) || !backoff.wait(ctx, 0) {
+				return
+			}
+			continue
+		}
+		for ev := range events {
+			d.dispatch(ev)
+		}
+		// SubscribeChanges reconnects on its own; its channel closes only
+		// when ctx is done or the subscription was rejected.
+		return
+	}
+}
+
+// dispatch calls the handlers registered for ev's type. A handler that
+// panics is reported to the error handlers, and the others still run.
+func (d *eventDispatcher) dispatch(ev MemoryEvent) {
+	d.mu.Lock()
+	var matched []func(MemoryEvent)
+	for _, h := range d.handlers {
+		if h.typ == "" || h.typ == ev.Type {
+			matched = append(matched, h.fn)
+		}
+	}
+	d.mu.Unlock()
+	for _, fn := range matched {
+		if err := d.c.guard("event handler", func() { fn(ev) }); err != nil {
+			d.reportError(err)
+		}
+	}
+}
+
+func (d *eventDispatcher) reportError(err error) {
+	d.mu.Lock()
+	handlers := append([]*errorHandler(nil), d.errHandlers...)
+	d.mu.Unlock()
+	for _, h := range handlers {
+		d.c.guard("event error handler", func() { h.fn(err) })
+	}
+}
+
+// close stops the dispatcher, waiting for the handler running, if any.
+func (d *eventDispatcher) close(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// removeHandler returns handlers without h.
+func removeHandler[T comparable](handlers []T, h T) []T {
+	for i, x := range handlers {
+		if x == h {
+			return append(handlers[:i:i], handlers[i+1:]...)
+		}
+	}
+	return handlers
+}
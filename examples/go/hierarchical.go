@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// KindMetadataField is the metadata field that marks condensed memories,
+// such as run summaries and insights, which hierarchical search
+// retrieves first.
+const KindMetadataField = "kind"
+
+const (
+	// KindSummary marks a memory summarizing other memories, e.g. a run
+	// summary.
+	KindSummary = "summary"
+
+	// KindInsight marks a memory distilled from other memories.
+	KindInsight = "insight"
+)
+
+// HierarchicalSearchOptions controls when HierarchicalSearch drills down.
+type HierarchicalSearchOptions struct {
+	// ExpandThreshold is the score a summary needs for its detailed
+	// memories to be fetched. The calibrated Relevance is used when set.
+	// Defaults to 0.7.
+	ExpandThreshold float64
+
+	// MaxExpanded caps the number of detailed memories fetched across
+	// all summaries. Defaults to 10.
+	MaxExpanded int
+}
+
+// HierarchicalResult is a summary or insight and, if it was expanded, the
+// detailed memories it links to that match the query.
+type HierarchicalResult struct {
+	Summary  SearchResult   `json:"summary"`
+	Expanded bool           `json:"expanded"`
+	Details  []SearchResult `json:"details,omitempty"`
+}
+
+// HierarchicalResults represents the results of a hierarchical search.
+type HierarchicalResults struct {
+	Results []HierarchicalResult `json:"results"`
+	Query   string               `json:"query"`
+}
+
+// HierarchicalSearch searches summary and insight memories first and only
+// expands into the detailed memories they link to when a summary scores
+// at least opts.ExpandThreshold. This keeps prompts small for broad
+// questions while still surfacing specifics when they matter. Summaries
+// link to their details through the RunSummaryMetadataField metadata
+// field and are marked with KindMetadataField.
+func (c *Client) HierarchicalSearch(ctx context.Context, req *SearchMemoryRequest, opts HierarchicalSearchOptions) (*HierarchicalResults, error) {
+	threshold := opts.ExpandThreshold
+	if threshold <= 0 {
+		threshold = 0.7
+	}
+	budget := opts.MaxExpanded
+	if budget <= 0 {
+		budget = 10
+	}
+
+	summaryReq := *req
+	summaryReq.Filters = andFilters(req.Filters, MetaIn(KindMetadataField, KindSummary, KindInsight))
+	summaries, err := c.searchMemories(ctx, &summaryReq)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &HierarchicalResults{Query: req.Query, Results: make([]HierarchicalResult, len(summaries.Results))}
+	owner := make(map[MemoryID]int)
+	var ids []MemoryID
+	for i, s := range summaries.Results {
+		results.Results[i].Summary = s
+		score := s.Score
+		if s.Relevance != nil {
+			score = *s.Relevance
+		}
+		if score < threshold || len(ids) >= budget {
+			continue
+		}
+		results.Results[i].Expanded = true
+		for _, id := range linkedMemoryIDs(s.Metadata) {
+			if _, seen := owner[id]; seen || len(ids) >= budget {
+				continue
+			}
+			owner[id] = i
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return results, nil
+	}
+
+	detailReq := *req
+	detailReq.Limit = len(ids)
+	detailReq.Offset, detailReq.Cursor = 0, ""
+	detailReq.Filters = andFilters(req.Filters, MetadataFilter{"id": map[string]interface{}{"in": ids}})
+	details, err := c.searchMemories(ctx, &detailReq)
+	if err != nil {
+		return results, fmt.Errorf("failed to expand summaries: %w", err)
+	}
+	for _, d := range details.Results {
+		if i, ok := owner[d.MemoryID]; ok {
+			results.Results[i].Details = append(results.Results[i].Details, d)
+		}
+	}
+	return results, nil
+}
+
+// linkedMemoryIDs returns the memories a summary links to.
+func linkedMemoryIDs(metadata map[string]interface{}) []MemoryID {
+	raw, ok := metadata[RunSummaryMetadataField]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var link RunSummaryLink
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil
+	}
+	return link.MemoryIDs
+}
+
+// andFilters restricts a request's filters to those also matching f.
+func andFilters(filters map[string]interface{}, f MetadataFilter) map[string]interface{} {
+	if len(filters) == 0 {
+		return f
+	}
+	return MetaAnd(filters, f)
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ImportOptions contains options for importing memories.
+type ImportOptions struct {
+	// Format is ExportNDJSON (the default), ExportJSON or ExportCSV. CSV
+	// input needs a header row with at least a content column; user_id,
+	// agent_id, run_id, metadata (a JSON object), scope and memory_type
+	// columns are used when present and other columns are ignored.
+	Format ExportFormat
+
+	// UserID, AgentID and RunID, when set, override the scope of every
+	// record, e.g. to import another user's export.
+	UserID  string
+	AgentID string
+	RunID   string
+
+	// BatchSize is the number of records uploaded per request. Defaults to
+	// and is capped at MaxBatchSize.
+	BatchSize int
+
+	// Infer enables server-side inference on the imported records. It
+	// defaults to false so that records are stored verbatim.
+	Infer bool
+
+	// OnProgress, if set, is called after each uploaded batch.
+	OnProgress func(ImportProgress)
+}
+
+// ImportProgress reports the progress of an import.
+type ImportProgress struct {
+	Read     int
+	Imported int
+	Failed   int
+}
+
+// ImportResult reports the outcome of an import. Errors lists every record
+// that was rejected, either while validating or by the server.
+type ImportResult struct {
+	ImportProgress
+	Errors []ImportRecordError
+}
+
+// ImportRecordError describes a record that could not be imported. Record
+// is the 1-based position of the record in the input (the line number for
+// NDJSON, not counting the header for CSV).
+type ImportRecordError struct {
+	Record  int
+	Content string
+	Err     error
+}
+
+func (e ImportRecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Record, e.Err)
+}
+
+func (e ImportRecordError) Unwrap() error {
+	return e.Err
+}
+
+// importRecord is a memory read from an import stream.
+type importRecord struct {
+	Content    string                 `json:"content"`
+	UserID     string                 `json:"user_id"`
+	AgentID    string                 `json:"agent_id"`
+	RunID      string                 `json:"run_id"`
+	Metadata   map[string]interface{} `json:"metadata"`
+	Scope      Scope                  `json:"scope"`
+	MemoryType MemoryType             `json:"memory_type"`
+
+	// position is the record's 1-based position in the input.
+	position int
+}
+
+// recordReader yields import records one at a time. A record-level error
+// is returned with ok set so that reading can continue; other errors end
+// the stream.
+type recordReader func() (rec importRecord, ok bool, err error)
+
+// ImportMemories reads memories from r and uploads them in batches, the
+// inverse of ExportMemories for migrations. Invalid records and records
+// rejected by the server are reported in the result instead of aborting
+// the import; an error is returned only when the input cannot be read or
+// a batch cannot be uploaded, together with the progress made so far.
+func (c *Client) ImportMemories(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	var next recordReader
+	switch opts.Format {
+	case "", ExportNDJSON:
+		next = ndjsonRecords(r)
+	case ExportJSON:
+		next = jsonRecords(r)
+	case ExportCSV:
+		var err error
+		if next, err = csvRecords(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", opts.Format)
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 || batchSize > MaxBatchSize {
+		batchSize = MaxBatchSize
+	}
+
+	result := &ImportResult{}
+	var batch []importRecord
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := c.importBatch(ctx, batch, opts.Infer, result)
+		batch = batch[:0]
+		if err != nil {
+			return err
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(result.ImportProgress)
+		}
+		return nil
+	}
+
+	for {
+		rec, ok, err := next()
+		if err == io.EOF {
+			break
+		}
+		if !ok {
+			return result, errors.Join(err, flush())
+		}
+		result.Read++
+		if err == nil {
+			err = rec.validate()
+		}
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, ImportRecordError{Record: rec.position, Content: rec.Content, Err: err})
+			continue
+		}
+
+		rec.applyScope(opts)
+		// A batch request carries a single scope, so a scope change starts
+		// a new batch.
+		if len(batch) == batchSize || (len(batch) > 0 && !batch[0].sameScope(rec)) {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+		batch = append(batch, rec)
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// importBatch uploads records, which share a scope, and records the outcome
+// in result.
+func (c *Client) importBatch(ctx context.Context, records []importRecord, infer bool, result *ImportResult) error {
+	req := &BatchCreateMemoryRequest{
+		Memories: make([]BatchMemoryItem, len(records)),
+		UserID:   records[0].UserID,
+		AgentID:  records[0].AgentID,
+		RunID:    records[0].RunID,
+		Infer:    &infer,
+	}
+	for i, rec := range records {
+		req.Memories[i] = BatchMemoryItem{
+			Content:    rec.Content,
+			Metadata:   rec.Metadata,
+			Scope:      rec.Scope,
+			MemoryType: rec.MemoryType,
+			Source:     SourceImported,
+		}
+	}
+
+	res, err := c.BatchCreateMemories(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to upload records %d-%d: %w", records[0].position, records[len(records)-1].position, err)
+	}
+
+	result.Imported += len(records) - len(res.Failed)
+	for _, f := range res.Failed {
+		rec := records[0]
+		if f.Index >= 0 && f.Index < len(records) {
+			rec = records[f.Index]
+		}
+		result.Failed++
+		result.Errors = append(result.Errors, ImportRecordError{Record: rec.position, Content: rec.Content, Err: errors.New(f.Error)})
+	}
+	return nil
+}
+
+// validate checks that rec can be uploaded.
+func (rec importRecord) validate() error {
+	if strings.TrimSpace(rec.Content) == "" {
+		return errors.New("content is empty")
+	}
+	if err := checkScope(rec.Scope); err != nil {
+		return err
+	}
+	return checkMemoryType(rec.MemoryType)
+}
+
+// applyScope overrides the scope of rec with the one in opts.
+func (rec *importRecord) applyScope(opts ImportOptions) {
+	if opts.UserID != "" {
+		rec.UserID = opts.UserID
+	}
+	if opts.AgentID != "" {
+		rec.AgentID = opts.AgentID
+	}
+	if opts.RunID != "" {
+		rec.RunID = opts.RunID
+	}
+}
+
+// sameScope reports whether rec and other can share a batch request.
+func (rec importRecord) sameScope(other importRecord) bool {
+	return rec.UserID == other.UserID && rec.AgentID == other.AgentID && rec.RunID == other.RunID
+}
+
+// ndjsonRecords reads one JSON object per line, skipping blank lines.
+func ndjsonRecords(r io.Reader) recordReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	return func() (importRecord, bool, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			rec := importRecord{position: line}
+			if err := json.Unmarshal([]byte(text), &rec); err != nil {
+				return rec, true, fmt.Errorf("invalid JSON: %w", err)
+			}
+			return rec, true, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return importRecord{}, false, fmt.Errorf("failed to read import: %w", err)
+		}
+		return importRecord{}, false, io.EOF
+	}
+}
+
+// jsonRecords reads the elements of a JSON array one at a time.
+func jsonRecords(r io.Reader) recordReader {
+	dec := json.NewDecoder(r)
+	started := false
+	n := 0
+	return func() (importRecord, bool, error) {
+		if !started {
+			started = true
+			tok, err := dec.Token()
+			if err != nil {
+				return importRecord{}, false, fmt.Errorf("failed to read import: %w", err)
+			}
+			if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+				return importRecord{}, false, errors.New("failed to read import: expected a JSON array")
+			}
+		}
+		if !dec.More() {
+			return importRecord{}, false, io.EOF
+		}
+		n++
+		rec := importRecord{position: n}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return importRecord{}, false, fmt.Errorf("failed to read import: %w", err)
+		}
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return rec, true, fmt.Errorf("invalid record: %w", err)
+		}
+		return rec, true, nil
+	}
+}
+
+// csvRecords reads records from CSV with a header row.
+func csvRecords(r io.Reader) (recordReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	if _, ok := columns["content"]; !ok {
+		return nil, errors.New("import header has no content column")
+	}
+
+	n := 0
+	return func() (importRecord, bool, error) {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return importRecord{}, false, io.EOF
+		}
+		n++
+		rec := importRecord{position: n}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return rec, true, err
+			}
+			return rec, false, fmt.Errorf("failed to read import: %w", err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		rec.Content = field("content")
+		rec.UserID = field("user_id")
+		rec.AgentID = field("agent_id")
+		rec.RunID = field("run_id")
+		rec.Scope = Scope(field("scope"))
+		rec.MemoryType = MemoryType(field("memory_type"))
+		if md := field("metadata"); md != "" {
+			if err := json.Unmarshal([]byte(md), &rec.Metadata); err != nil {
+				return rec, true, fmt.Errorf("invalid metadata: %w", err)
+			}
+		}
+		return rec, true, nil
+	}, nil
+}
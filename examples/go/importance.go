@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetImportance sets the importance of a memory, in [0, 1], leaving its
+// content and metadata unchanged.
+func (c *Client) SetImportance(ctx context.Context, memoryID MemoryID, importance float64) (*Memory, error) {
+	return c.updateMemory(ctx, memoryID, &UpdateMemoryRequest{Importance: &importance})
+}
+
+// checkImportance validates the importance of a write request.
+func checkImportance(importance float64) error {
+	if importance < 0 || importance > 1 {
+		return fmt.Errorf("importance must be between 0 and 1, got %g", importance)
+	}
+	return nil
+}
@@ -0,0 +1,882 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/internal/offline"
+)
+
+// ErrQueueFull is returned when the in-memory write queue is full and no
+// spill directory is configured.
+var ErrQueueFull = errors.New("powermem: write queue is full")
+
+// ErrIngestorClosed is returned when submitting to a closed Ingestor.
+var ErrIngestorClosed = errors.New("powermem: ingestor is closed")
+
+// IngestorOptions configures an Ingestor.
+type IngestorOptions struct {
+	// QueueSize bounds the number of writes held in memory per priority.
+	// Default 1000.
+	QueueSize int
+
+	// Workers is the number of concurrent uploads. Default 4.
+	Workers int
+
+	// InteractiveWorkers is the number of additional workers that only
+	// upload PriorityInteractive writes, so live writes proceed even while
+	// all other workers are busy with slow background uploads. Default 1;
+	// set to a negative value to disable.
+	InteractiveWorkers int
+
+	// SpillDir, if set, receives writes that do not fit in the in-memory
+	// queue as NDJSON files, one set per priority. Spilled writes are
+	// uploaded once the queue of their priority has room, including
+	// writes left over by a previous process.
+	SpillDir string
+
+	// MaxAttempts bounds upload attempts per write. Only writes rejected
+	// with 429 or that failed before reaching the server are retried;
+	// after other failures the server may have stored the write. Default
+	// 5.
+	MaxAttempts int
+
+	// BatchSize is the maximum number of writes a worker collects and
+	// uploads through the batch endpoint at once (capped at MaxBatchSize).
+	// Values <= 1 upload every write individually.
+	BatchSize int
+
+	// BatchWait is how long a worker waits for a batch to fill before
+	// uploading what it has. Default 50ms.
+	BatchWait time.Duration
+
+	// Ordering groups and orders collected writes into batches.
+	// Default ScopeSimilarityOrdering.
+	Ordering OrderingStrategy
+
+	// OnDelivery, if set, is called for every write once it has been
+	// uploaded or has permanently failed, in addition to any per-write
+	// callback.
+	OnDelivery func(DeliveryStatus)
+}
+
+// DeliveryStatus reports the outcome of a queued write.
+type DeliveryStatus struct {
+	// Request is the write as submitted, with default metadata applied.
+	Request *CreateMemoryRequest
+
+	// Memories are the memories created by the server on success.
+	Memories []CreatedMemory
+
+	// Err is the final error, or nil on success.
+	Err error
+
+	// Attempts is the number of upload attempts made.
+	Attempts int
+
+	// Spilled reports whether the write passed through the spill directory.
+	Spilled bool
+
+	// Priority is the priority the write was queued with.
+	Priority Priority
+
+	// EnqueuedAt is when the write was submitted.
+	EnqueuedAt time.Time
+}
+
+// Ingestor uploads memory writes in the background so latency-critical
+// paths never block on the PowerMem API. Writes are held in a bounded
+// in-memory queue and, optionally, spilled to disk when it is full.
+type Ingestor struct {
+	client *Client
+	opts   IngestorOptions
+
+	queues  []chan *ingestItem // indexed by Priority.index()
+	quit    chan struct{}
+	baseCtx context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	nextID  atomic.Uint64
+	pending atomic.Int64
+
+	undeliveredMu sync.Mutex
+	undelivered   []*CreateMemoryRequest
+
+	spills []*spillLog // indexed by Priority.index(), nil without SpillDir
+}
+
+// spillPrefixes are the segment name prefixes of the spill logs, indexed
+// by Priority.index(). Normal writes keep the prefix of segments written
+// before spilling was split by priority, which may hold any priority.
+var spillPrefixes = []string{"interactive-spill-", "spill-", "background-spill-"}
+
+// ingestItem is a single queued write.
+type ingestItem struct {
+	ID         uint64               `json:"id"`
+	Request    *CreateMemoryRequest `json:"request"`
+	Priority   Priority             `json:"priority,omitempty"`
+	EnqueuedAt time.Time            `json:"enqueued_at"`
+
+	callback func(DeliveryStatus)
+	spilled  bool
+
+	// sender is the client the write was submitted through, whose
+	// transport and receipt verifier upload it. Writes recovered from the
+	// spill directory have none and go through the ingestor's client.
+	sender *Client
+}
+
+// NewIngestor creates and starts an Ingestor writing through c.
+func NewIngestor(c *Client, opts IngestorOptions) (*Ingestor, error) {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.InteractiveWorkers == 0 {
+		opts.InteractiveWorkers = 1
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.BatchSize > MaxBatchSize {
+		opts.BatchSize = MaxBatchSize
+	}
+	if opts.BatchWait <= 0 {
+		opts.BatchWait = 50 * time.Millisecond
+	}
+	if opts.Ordering == nil {
+		opts.Ordering = ScopeSimilarityOrdering{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ing := &Ingestor{
+		client:  c,
+		opts:    opts,
+		queues:  make([]chan *ingestItem, len(priorities)),
+		quit:    make(chan struct{}),
+		baseCtx: ctx,
+		cancel:  cancel,
+	}
+	for i := range ing.queues {
+		ing.queues[i] = make(chan *ingestItem, opts.QueueSize)
+	}
+
+	if opts.SpillDir != "" {
+		for _, prefix := range spillPrefixes {
+			spill, recovered, err := openSpillLog(opts.SpillDir, prefix)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			ing.spills = append(ing.spills, spill)
+			ing.pending.Add(int64(recovered))
+		}
+		// Each priority drains separately, so spilled interactive writes
+		// are not held up by a full background queue.
+		for _, spill := range ing.spills {
+			ing.wg.Add(1)
+			go ing.drainSpill(spill)
+		}
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		ing.wg.Add(1)
+		go ing.work(PriorityBackground)
+	}
+	for i := 0; i < opts.InteractiveWorkers; i++ {
+		ing.wg.Add(1)
+		go ing.work(PriorityInteractive)
+	}
+	return ing, nil
+}
+
+// Submit enqueues a write with PriorityNormal without waiting for it to be
+// uploaded. callback, if non-nil, is called from a worker goroutine with
+// the outcome. The write is validated, and the client's defaults applied
+// to it, before it is queued.
+func (ing *Ingestor) Submit(req *CreateMemoryRequest, callback func(DeliveryStatus)) error {
+	return ing.SubmitWithPriority(req, PriorityNormal, callback)
+}
+
+// SubmitWithPriority is like Submit but queues the write with priority p.
+func (ing *Ingestor) SubmitWithPriority(req *CreateMemoryRequest, p Priority, callback func(DeliveryStatus)) error {
+	return ing.submit(ing.client, req, p, callback)
+}
+
+// submit queues a write submitted through sender, with sender's defaults
+// and receipt setting applied, so that writes from clients created with
+// With keep their own configuration.
+func (ing *Ingestor) submit(sender *Client, req *CreateMemoryRequest, p Priority, callback func(DeliveryStatus)) error {
+	req, err := sender.prepareCreate(req)
+	if err != nil {
+		return err
+	}
+	item := &ingestItem{
+		ID:         ing.nextID.Add(1),
+		Request:    req,
+		Priority:   p,
+		EnqueuedAt: time.Now(),
+		callback:   callback,
+		sender:     sender,
+	}
+
+	ing.mu.RLock()
+	defer ing.mu.RUnlock()
+	if ing.closed {
+		return ErrIngestorClosed
+	}
+
+	ing.pending.Add(1)
+	select {
+	case ing.queueFor(p) <- item:
+		return nil
+	default:
+	}
+
+	if ing.spills == nil {
+		ing.pending.Add(-1)
+		return ErrQueueFull
+	}
+	if err := ing.spillFor(p).append(item); err != nil {
+		ing.pending.Add(-1)
+		return err
+	}
+	return nil
+}
+
+// Pending returns the number of writes not yet delivered, including
+// spilled ones.
+func (ing *Ingestor) Pending() int {
+	return int(ing.pending.Load())
+}
+
+// UndeliveredError is returned by Close when writes were still pending at
+// the deadline.
+type UndeliveredError struct {
+	// Writes are the undelivered writes that are lost with the process.
+	Writes []*CreateMemoryRequest
+
+	// Spilled is the number of undelivered writes persisted to the spill
+	// directory, to be uploaded by the next process using it.
+	Spilled int
+
+	// Err is the reason waiting stopped, typically ctx.Err().
+	Err error
+}
+
+// Error implements the error interface.
+func (e *UndeliveredError) Error() string {
+	return fmt.Sprintf("powermem: %d writes undelivered, %d spilled to disk: %v", len(e.Writes), e.Spilled, e.Err)
+}
+
+// Unwrap returns the underlying reason.
+func (e *UndeliveredError) Unwrap() error {
+	return e.Err
+}
+
+// Close stops accepting writes and waits until all pending writes are
+// delivered or ctx is done. In the latter case in-flight uploads are
+// cancelled and an *UndeliveredError reports what was not delivered;
+// with a spill directory, such writes are persisted for the next process.
+func (ing *Ingestor) Close(ctx context.Context) error {
+	ing.mu.Lock()
+	if ing.closed {
+		ing.mu.Unlock()
+		return nil
+	}
+	ing.closed = true
+	ing.mu.Unlock()
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	var waitErr error
+	for ing.pending.Load() > 0 && waitErr == nil {
+		select {
+		case <-ctx.Done():
+			waitErr = ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	close(ing.quit)
+	ing.cancel()
+	ing.wg.Wait()
+	for _, q := range ing.queues {
+		for len(q) > 0 {
+			ing.keepUndelivered(<-q)
+		}
+	}
+	for _, spill := range ing.spills {
+		spill.close()
+	}
+
+	if n := int(ing.pending.Load()); n > 0 {
+		return &UndeliveredError{
+			Writes:  ing.undelivered,
+			Spilled: n - len(ing.undelivered),
+			Err:     waitErr,
+		}
+	}
+	return nil
+}
+
+// keepUndelivered persists a write that could not be delivered before
+// Close to the spill directory, or records it as lost if there is none.
+func (ing *Ingestor) keepUndelivered(item *ingestItem) {
+	if ing.spills != nil && ing.spillFor(item.Priority).append(item) == nil {
+		return
+	}
+	ing.undeliveredMu.Lock()
+	ing.undelivered = append(ing.undelivered, item.Request)
+	ing.undeliveredMu.Unlock()
+}
+
+// queueFor returns the in-memory queue for priority p.
+func (ing *Ingestor) queueFor(p Priority) chan *ingestItem {
+	return ing.queues[p.index()]
+}
+
+// spillFor returns the spill log for priority p.
+func (ing *Ingestor) spillFor(p Priority) *spillLog {
+	return ing.spills[p.index()]
+}
+
+// work uploads queued writes with priority of at least minPriority until
+// the ingestor is closed, always taking the highest-priority write first.
+func (ing *Ingestor) work(minPriority Priority) {
+	defer ing.wg.Done()
+	for {
+		item, ok := ing.next(minPriority)
+		if !ok {
+			return
+		}
+		if ing.opts.BatchSize <= 1 {
+			ing.deliver(item)
+			continue
+		}
+		ing.deliverBatch(ing.collect(item))
+	}
+}
+
+// next returns the highest-priority queued write of at least minPriority,
+// blocking until one is available. It returns false once the ingestor is
+// closed.
+func (ing *Ingestor) next(minPriority Priority) (*ingestItem, bool) {
+	last := minPriority.index()
+	for _, q := range ing.queues[:last+1] {
+		select {
+		case item := <-q:
+			return item, true
+		default:
+		}
+	}
+
+	// Nothing queued: wait on all eligible queues.
+	var interactive, normal, background chan *ingestItem
+	interactive = ing.queues[0]
+	if last >= 1 {
+		normal = ing.queues[1]
+	}
+	if last >= 2 {
+		background = ing.queues[2]
+	}
+	select {
+	case <-ing.quit:
+		return nil, false
+	case item := <-interactive:
+		return item, true
+	case item := <-normal:
+		return item, true
+	case item := <-background:
+		return item, true
+	}
+}
+
+// higherWaiting reports whether a write with higher priority than p is
+// queued.
+func (ing *Ingestor) higherWaiting(p Priority) bool {
+	for _, q := range ing.queues[:p.index()] {
+		if len(q) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// collect gathers up to BatchSize writes of first's priority, waiting at
+// most BatchWait for more to arrive. Collection is cut short as soon as a
+// higher-priority write is queued, so the worker can turn to it.
+func (ing *Ingestor) collect(first *ingestItem) []*ingestItem {
+	items := []*ingestItem{first}
+	q := ing.queueFor(first.Priority)
+	timer := time.NewTimer(ing.opts.BatchWait)
+	defer timer.Stop()
+	poll := time.NewTicker(5 * time.Millisecond)
+	defer poll.Stop()
+	for len(items) < ing.opts.BatchSize {
+		if ing.higherWaiting(first.Priority) {
+			return items
+		}
+		select {
+		case item := <-q:
+			items = append(items, item)
+		case <-poll.C:
+		case <-timer.C:
+			return items
+		case <-ing.quit:
+			return items
+		}
+	}
+	return items
+}
+
+// deliverBatch uploads items through the batch endpoint, in batches of
+// writes submitted through the same client.
+func (ing *Ingestor) deliverBatch(items []*ingestItem) {
+	var senders []*Client
+	bySender := make(map[*Client][]*ingestItem)
+	for _, item := range items {
+		sender := ing.senderOf(item)
+		if _, ok := bySender[sender]; !ok {
+			senders = append(senders, sender)
+		}
+		bySender[sender] = append(bySender[sender], item)
+	}
+	for _, sender := range senders {
+		ing.deliverGroups(sender, bySender[sender])
+	}
+}
+
+// deliverGroups groups items submitted through sender with the ordering
+// strategy and uploads each group through the batch endpoint.
+func (ing *Ingestor) deliverGroups(sender *Client, items []*ingestItem) {
+	writes := make([]*CreateMemoryRequest, len(items))
+	for i, item := range items {
+		writes[i] = item.Request
+	}
+
+	for _, group := range splitGroups(writes, ing.opts.Ordering.Group(writes), ing.opts.BatchSize) {
+		if len(group) == 1 {
+			ing.deliver(items[group[0]])
+			continue
+		}
+		batch := make([]*ingestItem, len(group))
+		for i, idx := range group {
+			batch[i] = items[idx]
+		}
+		ing.uploadBatch(sender, batch)
+	}
+}
+
+// senderOf returns the client item is uploaded through.
+func (ing *Ingestor) senderOf(item *ingestItem) *Client {
+	if item.sender != nil {
+		return item.sender
+	}
+	return ing.client
+}
+
+// uploadBatch uploads items sharing one batch key in a single request
+// through sender, with retries, and reports each item's outcome.
+func (ing *Ingestor) uploadBatch(sender *Client, items []*ingestItem) {
+	first := items[0].Request
+	req := &BatchCreateMemoryRequest{
+		UserID:  first.UserID,
+		AgentID: first.AgentID,
+		RunID:   first.RunID,
+		Infer:   first.Infer,
+	}
+	for _, item := range items {
+		req.Memories = append(req.Memories, BatchMemoryItem{
+			Content:    item.Request.Content,
+			Metadata:   item.Request.Metadata,
+			Filters:    item.Request.Filters,
+			Scope:      item.Request.Scope,
+			MemoryType: item.Request.MemoryType,
+			Visibility: item.Request.Visibility,
+			Source:     item.Request.Source,
+			ExpiresAt:  item.Request.ExpiresAt,
+			Importance: item.Request.Importance,
+			Pinned:     item.Request.Pinned,
+		})
+	}
+
+	var (
+		result   *BatchCreateResult
+		err      error
+		attempts int
+	)
+	backoff := 200 * time.Millisecond
+	for {
+		attempts++
+		result, err = sender.postBatch(ing.baseCtx, req)
+		if err == nil || attempts >= ing.opts.MaxAttempts || !isTransient(err) {
+			break
+		}
+		if sleepContext(ing.baseCtx, jitter(backoff)) != nil {
+			break
+		}
+		backoff *= 2
+	}
+
+	statuses := make([]DeliveryStatus, len(items))
+	for i, item := range items {
+		statuses[i] = DeliveryStatus{
+			Request:    item.Request,
+			Attempts:   attempts,
+			Spilled:    item.spilled,
+			Priority:   item.Priority,
+			EnqueuedAt: item.EnqueuedAt,
+			Err:        err,
+		}
+	}
+	if err == nil {
+		for _, f := range result.Failed {
+			if f.Index >= 0 && f.Index < len(statuses) {
+				statuses[f.Index].Err = fmt.Errorf("create memory failed: %s", f.Error)
+			}
+		}
+		for i, memories := range batchItemMemories(result, len(items), first.Infer) {
+			for _, m := range memories {
+				statuses[i].Memories = append(statuses[i].Memories, CreatedMemory{
+					MemoryID: m.MemoryID,
+					Content:  m.Content,
+					UserID:   m.UserID,
+					AgentID:  m.AgentID,
+					RunID:    m.RunID,
+					Metadata: m.Metadata,
+				})
+			}
+		}
+	}
+
+	for i, item := range items {
+		ing.report(item, statuses[i])
+	}
+}
+
+// batchItemMemories returns the memories result created from each of the
+// n items of a batch, by the item index the server reports. Servers that
+// do not report it are matched by position only without inference, when
+// each item that did not fail created exactly one memory; otherwise the
+// memories are left unattributed.
+func batchItemMemories(result *BatchCreateResult, n int, infer *bool) [][]Memory {
+	byItem := make([][]Memory, n)
+	if len(result.Items) > 0 {
+		for _, item := range result.Items {
+			if item.Index >= 0 && item.Index < n {
+				byItem[item.Index] = append(byItem[item.Index], item.Memories...)
+			}
+		}
+		return byItem
+	}
+	if infer == nil || *infer || len(result.Memories) != n-len(result.Failed) {
+		return byItem
+	}
+	failed := make(map[int]bool, len(result.Failed))
+	for _, f := range result.Failed {
+		failed[f.Index] = true
+	}
+	created := result.Memories
+	for i := range byItem {
+		if !failed[i] && len(created) > 0 {
+			byItem[i], created = created[:1], created[1:]
+		}
+	}
+	return byItem
+}
+
+// deliver uploads one write with retries and reports its outcome.
+func (ing *Ingestor) deliver(item *ingestItem) {
+	status := DeliveryStatus{
+		Request:    item.Request,
+		Spilled:    item.spilled,
+		Priority:   item.Priority,
+		EnqueuedAt: item.EnqueuedAt,
+	}
+
+	backoff := 200 * time.Millisecond
+	for {
+		status.Attempts++
+		status.Memories, status.Err = ing.senderOf(item).postMemory(ing.baseCtx, item.Request)
+		if status.Err == nil || status.Attempts >= ing.opts.MaxAttempts || !isTransient(status.Err) {
+			break
+		}
+		if sleepContext(ing.baseCtx, jitter(backoff)) != nil {
+			break
+		}
+		backoff *= 2
+	}
+
+	ing.report(item, status)
+}
+
+// report settles the pending count for item and invokes callbacks.
+func (ing *Ingestor) report(item *ingestItem, status DeliveryStatus) {
+	// Writes cut short by Close are left undelivered.
+	if status.Err != nil && ing.baseCtx.Err() != nil {
+		status.Err = fmt.Errorf("ingestor closed: %w", status.Err)
+		ing.keepUndelivered(item)
+	} else {
+		ing.pending.Add(-1)
+	}
+
+	// A panicking callback must not stop the worker; the panic handler
+	// sees it.
+	if item.callback != nil {
+		ing.senderOf(item).guard("delivery callback", func() { item.callback(status) })
+	}
+	if ing.opts.OnDelivery != nil {
+		ing.client.guard("OnDelivery", func() { ing.opts.OnDelivery(status) })
+	}
+}
+
+// drainSpill moves writes spilled to s back into the in-memory queue as
+// room becomes available.
+func (ing *Ingestor) drainSpill(s *spillLog) {
+	defer ing.wg.Done()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ing.quit:
+			return
+		case <-ticker.C:
+		}
+
+		seg, err := s.next()
+		if err != nil || seg == "" {
+			continue
+		}
+		if !ing.requeueSegment(s, seg) {
+			return
+		}
+	}
+}
+
+// requeueSegment enqueues all writes of a segment of s, blocking while
+// their queue is full, and removes the segment once every write is queued. It
+// returns false if the ingestor was closed first.
+func (ing *Ingestor) requeueSegment(s *spillLog, seg string) bool {
+	items, err := s.read(seg)
+	if err != nil {
+		return true
+	}
+
+	for i, item := range items {
+		select {
+		case ing.queueFor(item.Priority) <- item:
+		case <-ing.quit:
+			// Keep the remainder on disk for the next process.
+			s.rewrite(seg, items[i:])
+			return false
+		}
+	}
+	s.remove(seg)
+	return true
+}
+
+// isTransient reports whether a failed write is worth retrying: the
+// server rejected it with 429 or it provably never reached the server.
+// Other failures, such as 5xx responses and timeouts while waiting for
+// the response, are not retried since the server may have stored the
+// write, and a retry would store it twice.
+func isTransient(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return requestNotSent(err)
+}
+
+// requestNotSent reports whether err means the request provably was not
+// sent: resolving, dialing or handshaking with the server failed or
+// timed out.
+func requestNotSent(err error) bool {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		switch timeoutErr.Phase {
+		case PhaseDNS, PhaseConnect, PhaseTLS:
+			return true
+		}
+		return false
+	}
+	return offline.NotSent(err)
+}
+
+// =============================================================================
+// Disk Spillover
+// =============================================================================
+
+// spillLog stores overflow writes in an offline.Log, keeping the
+// callbacks and senders of writes spilled by this process in memory.
+type spillLog struct {
+	log *offline.Log
+
+	mu        sync.Mutex
+	callbacks map[uint64]spillCallback
+}
+
+// spillCallback is the in-memory state of a spilled write, which is not
+// written to disk.
+type spillCallback struct {
+	callback func(DeliveryStatus)
+	sender   *Client
+}
+
+// openSpillLog opens the segments of dir named with prefix, returning the
+// number of writes recovered from segments left by a previous process.
+func openSpillLog(dir, prefix string) (*spillLog, int, error) {
+	log, recovered, err := offline.Open(dir, prefix, func(line []byte) bool {
+		_, ok := decodeSpillRecord(line)
+		return ok
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open spill directory: %w", err)
+	}
+	return &spillLog{log: log, callbacks: make(map[uint64]spillCallback)}, recovered, nil
+}
+
+// append writes item to the log. Its callback is registered first so
+// that a drainer reading the write back finds it.
+func (s *spillLog) append(item *ingestItem) error {
+	line, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to spill write: %w", err)
+	}
+	if item.callback != nil || item.sender != nil {
+		s.mu.Lock()
+		s.callbacks[item.ID] = spillCallback{callback: item.callback, sender: item.sender}
+		s.mu.Unlock()
+	}
+	if err := s.log.Append(line); err != nil {
+		s.takeCallback(item.ID)
+		return fmt.Errorf("failed to spill write: %w", err)
+	}
+	return nil
+}
+
+// next returns the oldest segment with writes to read, or "" if there is
+// none.
+func (s *spillLog) next() (string, error) {
+	return s.log.Next()
+}
+
+// takeCallback removes and returns the in-memory callback and sender of a
+// spilled write. Writes recovered from a previous process have none.
+func (s *spillLog) takeCallback(id uint64) spillCallback {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cb := s.callbacks[id]
+	delete(s.callbacks, id)
+	return cb
+}
+
+// read returns the writes of a sealed segment with their callbacks.
+// Malformed lines are skipped.
+func (s *spillLog) read(seg string) ([]*ingestItem, error) {
+	lines, err := s.log.Read(seg)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*ingestItem, 0, len(lines))
+	for _, line := range lines {
+		item, _ := decodeSpillRecord(line)
+		cb := s.takeCallback(item.ID)
+		item.callback, item.sender = cb.callback, cb.sender
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// decodeSpillRecord decodes a line of a segment. Lines torn by a crash
+// mid-write and other malformed lines are not records.
+func decodeSpillRecord(line []byte) (*ingestItem, bool) {
+	var item ingestItem
+	if json.Unmarshal(line, &item) != nil || item.Request == nil {
+		return nil, false
+	}
+	item.spilled = true
+	return &item, true
+}
+
+// rewrite replaces seg with the given remaining items.
+func (s *spillLog) rewrite(seg string, items []*ingestItem) {
+	lines := make([][]byte, 0, len(items))
+	for _, item := range items {
+		if line, err := json.Marshal(item); err == nil {
+			lines = append(lines, line)
+		}
+	}
+	s.log.Rewrite(seg, lines)
+}
+
+// remove removes seg once all its writes are handled.
+func (s *spillLog) remove(seg string) {
+	s.log.Remove(seg)
+}
+
+// close seals the active segment so it is recovered by the next process.
+func (s *spillLog) close() {
+	s.log.Close()
+}
+
+// =============================================================================
+// Client Integration
+// =============================================================================
+
+// WithIngestor configures the Ingestor used by CreateMemoryAsyncNoWait.
+// Without it, an ingestor with default options is started on first use.
+func WithIngestor(opts IngestorOptions) Option {
+	return func(c *Client) {
+		c.ingestorOpts = &opts
+	}
+}
+
+// CreateMemoryAsyncNoWait enqueues a memory write and returns immediately,
+// for latency-critical paths where memory writes must never block.
+// onDelivery, if non-nil, is called with the outcome once the write has
+// been uploaded or has permanently failed.
+//
+// Writes are queued with the client's write priority (see
+// WithWritePriority). It returns ErrQueueFull if the queue is full and no
+// spill directory is configured.
+func (c *Client) CreateMemoryAsyncNoWait(req *CreateMemoryRequest, onDelivery func(DeliveryStatus)) error {
+	ing, err := c.ingestor()
+	if err != nil {
+		return err
+	}
+	return ing.submit(c, req, c.writePriority, onDelivery)
+}
+
+// ingestor returns the client's shared Ingestor, starting it on first use.
+func (c *Client) ingestor() (*Ingestor, error) {
+	st := c.state()
+	st.ingestorOnce.Do(func() {
+		opts := IngestorOptions{}
+		if c.ingestorOpts != nil {
+			opts = *c.ingestorOpts
+		}
+		ing, err := NewIngestor(c, opts)
+		if err == nil {
+			if err = c.addBackground("ingestor", ing.Close); err != nil {
+				ing.Close(context.Background())
+				ing = nil
+			}
+		}
+		st.ingestor, st.ingestorErr = ing, err
+	})
+	return st.ingestor, st.ingestorErr
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeServer records the memory writes it receives. status, if set,
+// returns the status code of the nth request, counting from 1.
+type writeServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	n       int
+	writes  []CreateMemoryRequest
+	batches []BatchCreateMemoryRequest
+	status  func(n int) int
+}
+
+func newWriteServer(t testing.TB, status func(n int) int) *writeServer {
+	t.Helper()
+	ws := &writeServer{status: status}
+	ws.Server = httptest.NewServer(http.HandlerFunc(ws.serve))
+	t.Cleanup(ws.Close)
+	return ws
+}
+
+func (ws *writeServer) serve(w http.ResponseWriter, r *http.Request) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.n++
+	if ws.status != nil {
+		if code := ws.status(ws.n); code != http.StatusOK {
+			w.WriteHeader(code)
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": http.StatusText(code)})
+			return
+		}
+	}
+
+	var data interface{}
+	switch r.URL.Path {
+	case "/api/v1/memories":
+		var req CreateMemoryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		ws.writes = append(ws.writes, req)
+		data = []CreatedMemory{{MemoryID: MemoryID(ws.n), Content: req.Content, UserID: req.UserID}}
+	case "/api/v1/memories/batch":
+		var req BatchCreateMemoryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		ws.batches = append(ws.batches, req)
+		result := BatchCreateResult{Total: len(req.Memories), CreatedCount: len(req.Memories)}
+		for i, item := range req.Memories {
+			m := Memory{MemoryID: MemoryID(ws.n*1000 + i), Content: item.Content, UserID: req.UserID}
+			result.Memories = append(result.Memories, m)
+			result.Items = append(result.Items, BatchItemResult{Index: i, Memories: []Memory{m}})
+		}
+		data = result
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+}
+
+// requests returns the number of requests received.
+func (ws *writeServer) requests() int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.n
+}
+
+// deliveries collects delivery statuses.
+type deliveries chan DeliveryStatus
+
+func (d deliveries) callback(s DeliveryStatus) { d <- s }
+
+func (d deliveries) next(t *testing.T) DeliveryStatus {
+	t.Helper()
+	select {
+	case s := <-d:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery within 5s")
+		return DeliveryStatus{}
+	}
+}
+
+// closeClient closes c, failing t on error.
+func closeClient(t *testing.T, c *Client) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestIngestorAppliesSubmittingClientConfig(t *testing.T) {
+	ws := newWriteServer(t, nil)
+	c := NewClient(ws.URL, "")
+	defer closeClient(t, c)
+
+	d := make(deliveries, 1)
+	child := c.With(WithDefaultMetadata(map[string]interface{}{"app": "child"}))
+	if err := child.CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "hello", UserID: "u1"}, d.callback); err != nil {
+		t.Fatalf("CreateMemoryAsyncNoWait: %v", err)
+	}
+	if s := d.next(t); s.Err != nil || len(s.Memories) != 1 {
+		t.Fatalf("delivery: err %v, %d memories", s.Err, len(s.Memories))
+	}
+	if got := ws.writes[0].Metadata["app"]; got != "child" {
+		t.Errorf("metadata app = %v, want child", got)
+	}
+
+	if err := child.CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "x", UserID: "u1", Importance: 2}, nil); err == nil {
+		t.Error("invalid importance was queued")
+	}
+}
+
+func TestIngestorRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       func(n int) int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"rate limited", func(n int) int {
+			if n == 1 {
+				return http.StatusTooManyRequests
+			}
+			return http.StatusOK
+		}, 2, false},
+		// The server may have stored the write before failing.
+		{"server error", func(int) int { return http.StatusInternalServerError }, 1, true},
+		{"gateway timeout", func(int) int { return http.StatusGatewayTimeout }, 1, true},
+		{"bad request", func(int) int { return http.StatusBadRequest }, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := newWriteServer(t, tt.status)
+			c := NewClient(ws.URL, "", WithIngestor(IngestorOptions{MaxAttempts: 3}))
+			defer closeClient(t, c)
+
+			d := make(deliveries, 1)
+			if err := c.CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "hello", UserID: "u1"}, d.callback); err != nil {
+				t.Fatalf("CreateMemoryAsyncNoWait: %v", err)
+			}
+			s := d.next(t)
+			if s.Attempts != tt.wantAttempts || (s.Err != nil) != tt.wantErr {
+				t.Errorf("got %d attempts, err %v; want %d attempts, error %t", s.Attempts, s.Err, tt.wantAttempts, tt.wantErr)
+			}
+			if got := ws.requests(); got != tt.wantAttempts {
+				t.Errorf("server got %d requests, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestIngestorRetriesDialFailures(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c := NewClient("http://"+addr, "", WithIngestor(IngestorOptions{MaxAttempts: 2}))
+	defer closeClient(t, c)
+	d := make(deliveries, 1)
+	if err := c.CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "hello", UserID: "u1"}, d.callback); err != nil {
+		t.Fatalf("CreateMemoryAsyncNoWait: %v", err)
+	}
+	if s := d.next(t); s.Attempts != 2 || s.Err == nil {
+		t.Errorf("got %d attempts, err %v; want 2 attempts and an error", s.Attempts, s.Err)
+	}
+}
+
+func TestRequestNotSent(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, true},
+		{&net.DNSError{Err: "no such host", Name: "powermem.invalid"}, true},
+		{&TimeoutError{Phase: PhaseConnect, Err: context.DeadlineExceeded}, true},
+		{&TimeoutError{Phase: PhaseTLS, Err: context.DeadlineExceeded}, true},
+		{&TimeoutError{Phase: PhaseServerWait, Err: &net.OpError{Op: "dial"}}, false},
+		{&TimeoutError{Phase: PhaseBodyRead, Err: context.DeadlineExceeded}, false},
+		{&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := requestNotSent(tt.err); got != tt.want {
+			t.Errorf("requestNotSent(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+// writeSegment writes a sealed spill segment named with prefix holding the
+// given lines.
+func writeSegment(t *testing.T, dir, prefix string, seq int, lines ...string) {
+	t.Helper()
+	data := ""
+	for _, line := range lines {
+		data += line + "\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%s%012d.ndjson", prefix, seq)), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// spillRecord returns the spill line of a write.
+func spillRecord(t *testing.T, id uint64, content string, p Priority) string {
+	t.Helper()
+	line, err := json.Marshal(&ingestItem{
+		ID:         id,
+		Request:    &CreateMemoryRequest{Content: content, UserID: "u1"},
+		Priority:   p,
+		EnqueuedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(line)
+}
+
+func TestIngestorRecoversSpillWithTornLine(t *testing.T) {
+	ws := newWriteServer(t, nil)
+	dir := t.TempDir()
+	// A crash mid-write leaves a truncated last line.
+	writeSegment(t, dir, "spill-", 1, spillRecord(t, 1, "recovered", PriorityNormal), `{"id":2,"request":{"content":"tor`)
+
+	ing, err := NewIngestor(NewClient(ws.URL, ""), IngestorOptions{SpillDir: dir})
+	if err != nil {
+		t.Fatalf("NewIngestor: %v", err)
+	}
+	if got := ing.Pending(); got != 1 {
+		t.Errorf("Pending() = %d after recovery, want 1", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ing.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(ws.writes) != 1 || ws.writes[0].Content != "recovered" {
+		t.Errorf("server got %+v, want the recovered write", ws.writes)
+	}
+	if segs, _ := filepath.Glob(filepath.Join(dir, "spill-*")); len(segs) != 0 {
+		t.Errorf("segments left after delivery: %v", segs)
+	}
+}
+
+func TestIngestorSpillsWhenQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	ws := newWriteServer(t, nil)
+	handler := ws.Config.Handler
+	ws.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		handler.ServeHTTP(w, r)
+	})
+
+	dir := t.TempDir()
+	c := NewClient(ws.URL, "", WithIngestor(IngestorOptions{QueueSize: 1, Workers: 1, InteractiveWorkers: -1, SpillDir: dir}))
+	defer closeClient(t, c)
+
+	const n = 5
+	d := make(deliveries, n)
+	for i := 0; i < n; i++ {
+		if err := c.CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "write " + strconv.Itoa(i), UserID: "u1"}, d.callback); err != nil {
+			t.Fatalf("CreateMemoryAsyncNoWait #%d: %v", i, err)
+		}
+	}
+	close(release)
+
+	spilled := 0
+	for i := 0; i < n; i++ {
+		s := d.next(t)
+		if s.Err != nil {
+			t.Errorf("delivery of %q failed: %v", s.Request.Content, s.Err)
+		}
+		if s.Spilled {
+			spilled++
+		}
+	}
+	if spilled == 0 {
+		t.Error("no write passed through the spill directory")
+	}
+}
+
+func TestIngestorDrainsSpilledInteractiveWritesFirst(t *testing.T) {
+	release := make(chan struct{})
+	ws := newWriteServer(t, nil)
+	handler := ws.Config.Handler
+	ws.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		handler.ServeHTTP(w, r)
+	})
+
+	dir := t.TempDir()
+	var background []string
+	for i := 1; i <= 5; i++ {
+		background = append(background, spillRecord(t, uint64(i), "background "+strconv.Itoa(i), PriorityBackground))
+	}
+	writeSegment(t, dir, "background-spill-", 1, background...)
+	writeSegment(t, dir, "interactive-spill-", 1, spillRecord(t, 6, "interactive", PriorityInteractive))
+
+	ing, err := NewIngestor(NewClient(ws.URL, ""), IngestorOptions{QueueSize: 1, Workers: 1, InteractiveWorkers: -1, SpillDir: dir})
+	if err != nil {
+		t.Fatalf("NewIngestor: %v", err)
+	}
+	// Release the server once the interactive write is queued, while the
+	// background writes still wait on disk behind a full queue.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ing.queueFor(PriorityInteractive)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("spilled interactive write was not queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ing.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(ws.writes) != 6 {
+		t.Fatalf("server got %d writes, want 6", len(ws.writes))
+	}
+	// At most the background write already in flight goes first.
+	for i, w := range ws.writes[2:] {
+		if w.Content == "interactive" {
+			t.Errorf("interactive write uploaded at position %d, behind background writes", i+2)
+		}
+	}
+}
+
+func TestIngestorBatches(t *testing.T) {
+	tests := []struct {
+		name         string
+		ordering     OrderingStrategy
+		wantRequests int
+	}{
+		{"similarity", ScopeSimilarityOrdering{}, 2},
+		// FIFO only groups consecutive writes of the same scope.
+		{"fifo", FIFOOrdering, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := newWriteServer(t, nil)
+			ing, err := NewIngestor(NewClient(ws.URL, ""), IngestorOptions{
+				Workers:            1,
+				InteractiveWorkers: -1,
+				BatchSize:          10,
+				BatchWait:          200 * time.Millisecond,
+				Ordering:           tt.ordering,
+			})
+			if err != nil {
+				t.Fatalf("NewIngestor: %v", err)
+			}
+			d := make(deliveries, 6)
+			for i := 0; i < 6; i++ {
+				req := &CreateMemoryRequest{Content: "write " + strconv.Itoa(i), UserID: "u" + strconv.Itoa(i%2)}
+				if err := ing.Submit(req, d.callback); err != nil {
+					t.Fatalf("Submit: %v", err)
+				}
+			}
+			for i := 0; i < 6; i++ {
+				if s := d.next(t); s.Err != nil || len(s.Memories) != 1 {
+					t.Errorf("delivery of %q: err %v, %d memories", s.Request.Content, s.Err, len(s.Memories))
+				}
+			}
+			ing.Close(context.Background())
+
+			if got := ws.requests(); got != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", got, tt.wantRequests)
+			}
+			for _, b := range ws.batches {
+				for _, m := range b.Memories {
+					i, _ := strconv.Atoi(m.Content[len("write "):])
+					if want := "u" + strconv.Itoa(i%2); b.UserID != want {
+						t.Errorf("%q batched for %s, want %s", m.Content, b.UserID, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkIngest measures uploading writes of four users interleaved,
+// against a server taking 1ms per request, one by one and in batches.
+func BenchmarkIngest(b *testing.B) {
+	const writes = 400
+	benchmarks := []struct {
+		name string
+		opts IngestorOptions
+	}{
+		{"unbatched", IngestorOptions{}},
+		{"batched/fifo", IngestorOptions{BatchSize: 50, BatchWait: 10 * time.Millisecond, Ordering: FIFOOrdering}},
+		{"batched/similarity", IngestorOptions{BatchSize: 50, BatchWait: 10 * time.Millisecond}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			ws := newWriteServer(b, nil)
+			handler := ws.Config.Handler
+			ws.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(time.Millisecond)
+				handler.ServeHTTP(w, r)
+			})
+			reqs := make([]CreateMemoryRequest, writes)
+			for i := range reqs {
+				reqs[i] = CreateMemoryRequest{Content: "fact " + strconv.Itoa(i), UserID: "u" + strconv.Itoa(i%4)}
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				opts := bm.opts
+				opts.QueueSize = writes
+				ing, err := NewIngestor(NewClient(ws.URL, ""), opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for j := range reqs {
+					req := reqs[j]
+					if err := ing.Submit(&req, nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := ing.Close(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(ws.requests())/float64(b.N), "requests/op")
+		})
+	}
+}
+
+func TestIngestorUploadsHigherPriorityFirst(t *testing.T) {
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	ws := newWriteServer(t, nil)
+	handler := ws.Config.Handler
+	ws.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		handler.ServeHTTP(w, r)
+	})
+
+	ing, err := NewIngestor(NewClient(ws.URL, ""), IngestorOptions{Workers: 1, InteractiveWorkers: -1})
+	if err != nil {
+		t.Fatalf("NewIngestor: %v", err)
+	}
+	submit := func(content string, p Priority) {
+		t.Helper()
+		if err := ing.SubmitWithPriority(&CreateMemoryRequest{Content: content, UserID: "u1"}, p, nil); err != nil {
+			t.Fatalf("SubmitWithPriority: %v", err)
+		}
+	}
+	// Keep the only worker busy while the rest is queued.
+	submit("background 1", PriorityBackground)
+	<-arrived
+	submit("background 2", PriorityBackground)
+	submit("normal", PriorityNormal)
+	submit("interactive", PriorityInteractive)
+	close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ing.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	var got []string
+	for _, w := range ws.writes {
+		got = append(got, w.Content)
+	}
+	want := []string{"background 1", "interactive", "normal", "background 2"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("upload order %q, want %q", got, want)
+	}
+}
+
+func TestIngestorInteractiveWorkers(t *testing.T) {
+	release := make(chan struct{})
+	ws := newWriteServer(t, nil)
+	handler := ws.Config.Handler
+	ws.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("background")) {
+			<-release
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler.ServeHTTP(w, r)
+	})
+
+	ing, err := NewIngestor(NewClient(ws.URL, ""), IngestorOptions{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewIngestor: %v", err)
+	}
+	defer ing.Close(context.Background())
+	defer close(release)
+
+	if err := ing.SubmitWithPriority(&CreateMemoryRequest{Content: "background", UserID: "u1"}, PriorityBackground, nil); err != nil {
+		t.Fatalf("SubmitWithPriority: %v", err)
+	}
+	// The interactive write goes through while the only regular worker
+	// is stuck on the background upload.
+	d := make(deliveries, 1)
+	if err := ing.SubmitWithPriority(&CreateMemoryRequest{Content: "live", UserID: "u1"}, PriorityInteractive, d.callback); err != nil {
+		t.Fatalf("SubmitWithPriority: %v", err)
+	}
+	if s := d.next(t); s.Err != nil || s.Priority != PriorityInteractive {
+		t.Errorf("interactive delivery: err %v, priority %v", s.Err, s.Priority)
+	}
+}
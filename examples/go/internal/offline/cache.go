@@ -0,0 +1,97 @@
+package offline
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is an LRU cache that records when each value was stored. It is
+// safe for concurrent use.
+type Cache[V any] struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// Entry is a cached value.
+type Entry[V any] struct {
+	Key      string    `json:"key"`
+	Value    V         `json:"value"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// NewCache returns a cache holding up to maxEntries values.
+func NewCache[V any](maxEntries int) *Cache[V] {
+	return &Cache[V]{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get returns the value stored under key and when it was stored, marking
+// it recently used.
+func (c *Cache[V]) Get(key string) (V, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, time.Time{}, false
+	}
+	c.lru.MoveToFront(el)
+	e := el.Value.(*Entry[V])
+	return e.Value, e.StoredAt, true
+}
+
+// Put stores v under key, evicting the least recently used value when
+// full.
+func (c *Cache[V]) Put(key string, v V) {
+	c.put(Entry[V]{Key: key, Value: v, StoredAt: time.Now()})
+}
+
+// Entries returns the cached values, least recently used first, e.g. to
+// persist them for Load.
+func (c *Cache[V]) Entries() []Entry[V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]Entry[V], 0, c.lru.Len())
+	for el := c.lru.Back(); el != nil; el = el.Prev() {
+		entries = append(entries, *el.Value.(*Entry[V]))
+	}
+	return entries
+}
+
+// Load stores entries as returned by Entries, keeping their StoredAt.
+func (c *Cache[V]) Load(entries []Entry[V]) {
+	for _, e := range entries {
+		c.put(e)
+	}
+}
+
+// Clear removes all values.
+func (c *Cache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+func (c *Cache[V]) put(e Entry[V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.Key]; ok {
+		*el.Value.(*Entry[V]) = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.Key] = c.lru.PushFront(&e)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*Entry[V]).Key)
+	}
+}
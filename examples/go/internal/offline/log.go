@@ -0,0 +1,168 @@
+// Package offline holds what the full client's offline queue, ingestor
+// spill and search cache share with the mobile package: a durable write
+// log, an LRU cache and the checks for whether the server was reached.
+package offline
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Log is a durable FIFO of records stored as NDJSON segment files named
+// <prefix><seq>.ndjson. Records are appended to an active segment;
+// readers seal it with Next and consume sealed segments in order,
+// removing each once its records are handled. It is safe for concurrent
+// use.
+type Log struct {
+	dir    string
+	prefix string
+	valid  func(line []byte) bool
+
+	mu     sync.Mutex
+	active *os.File
+	seq    int
+}
+
+// Open opens the segments of dir named with prefix, returning the number
+// of records recovered from segments left by a previous process. valid
+// reports whether a line is a record; lines torn by a crash mid-write and
+// other malformed lines are skipped.
+func Open(dir, prefix string, valid func(line []byte) bool) (*Log, int, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, 0, err
+	}
+	l := &Log{dir: dir, prefix: prefix, valid: valid}
+
+	// Seal segments left active by a process that did not shut down cleanly.
+	leftover, _ := filepath.Glob(filepath.Join(dir, prefix+"*.ndjson.active"))
+	for _, name := range leftover {
+		os.Rename(name, strings.TrimSuffix(name, ".active"))
+	}
+
+	segs, err := l.segments()
+	if err != nil {
+		return nil, 0, err
+	}
+	recovered := 0
+	for _, seg := range segs {
+		records, _ := l.Read(seg)
+		recovered += len(records)
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(filepath.Base(seg), prefix), "%d.ndjson", &n)
+		if n > l.seq {
+			l.seq = n
+		}
+	}
+	return l, recovered, nil
+}
+
+// Append writes record, which must not contain a newline, to the active
+// segment.
+func (l *Log) Append(record []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active == nil {
+		l.seq++
+		f, err := os.OpenFile(l.segmentPath(l.seq)+".active", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		l.active = f
+	}
+	_, err := l.active.Write(append(record[:len(record):len(record)], '\n'))
+	return err
+}
+
+// Next seals the active segment if no other is left and returns the
+// oldest sealed segment, or "" if there is none.
+func (l *Log) Next() (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	segs, err := l.segments()
+	if err != nil {
+		return "", err
+	}
+	if len(segs) == 0 && l.active != nil {
+		l.sealLocked()
+		segs, err = l.segments()
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(segs) == 0 {
+		return "", nil
+	}
+	return segs[0], nil
+}
+
+// Read returns the records of a sealed segment.
+func (l *Log) Read(seg string) ([][]byte, error) {
+	f, err := os.Open(seg)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records [][]byte
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if l.valid(sc.Bytes()) {
+			records = append(records, append([]byte(nil), sc.Bytes()...))
+		}
+	}
+	return records, sc.Err()
+}
+
+// Rewrite replaces seg with the records not yet handled.
+func (l *Log) Rewrite(seg string, records [][]byte) error {
+	var data []byte
+	for _, r := range records {
+		data = append(append(data, r...), '\n')
+	}
+	if err := os.WriteFile(seg+".tmp", data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(seg+".tmp", seg)
+}
+
+// Remove removes seg once all its records are handled.
+func (l *Log) Remove(seg string) error {
+	return os.Remove(seg)
+}
+
+// Close seals the active segment so it is recovered by the next process.
+func (l *Log) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active != nil {
+		l.sealLocked()
+	}
+}
+
+// sealLocked closes the active segment and makes it visible to Next.
+func (l *Log) sealLocked() {
+	name := l.active.Name()
+	l.active.Close()
+	l.active = nil
+	os.Rename(name, strings.TrimSuffix(name, ".active"))
+}
+
+// segments returns sealed segment paths in order.
+func (l *Log) segments() ([]string, error) {
+	segs, err := filepath.Glob(filepath.Join(l.dir, l.prefix+"*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(segs)
+	return segs, nil
+}
+
+func (l *Log) segmentPath(seq int) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%s%012d.ndjson", l.prefix, seq))
+}
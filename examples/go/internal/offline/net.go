@@ -0,0 +1,44 @@
+package offline
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// NotSent reports whether err means the request provably was not sent:
+// resolving, dialing or handshaking with the server failed. Only such
+// writes may be queued and sent again, since the server may have stored a
+// write whose request was sent, and sending it again would store it
+// twice.
+func NotSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	return errors.As(err, &dnsErr) || errors.As(err, &certErr) || errors.As(err, &recordErr)
+}
+
+// NoResponse reports whether err, returned instead of a response, means
+// the server could not answer: a network failure or a timeout. Unlike
+// NotSent it includes failures after the request was sent, which is fine
+// for falling back to a local copy on reads.
+func NoResponse(err error) bool {
+	var opErr *net.OpError
+	var netErr net.Error
+	return errors.As(err, &opErr) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// GatewayDown reports whether status is a gateway reporting the server
+// down.
+func GatewayDown(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package offline
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// isRecord accepts complete JSON objects.
+func isRecord(line []byte) bool {
+	return bytes.HasSuffix(line, []byte("}"))
+}
+
+func TestLogRecovery(t *testing.T) {
+	dir := t.TempDir()
+	l, recovered, err := Open(dir, "q-", isRecord)
+	if err != nil || recovered != 0 {
+		t.Fatalf("Open = %d, %v", recovered, err)
+	}
+	for _, r := range []string{`{"n":1}`, `{"n":2}`} {
+		if err := l.Append([]byte(r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A crash leaves the active segment unsealed and its last line torn.
+	active, _ := filepath.Glob(filepath.Join(dir, "q-*.ndjson.active"))
+	if len(active) != 1 {
+		t.Fatalf("active segments %v", active)
+	}
+	f, _ := os.OpenFile(active[0], os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"n":`)
+	f.Close()
+
+	l, recovered, err = Open(dir, "q-", isRecord)
+	if err != nil || recovered != 2 {
+		t.Fatalf("Open = %d, %v; want 2 recovered", recovered, err)
+	}
+	l.Append([]byte(`{"n":3}`))
+	l.Close()
+
+	var got []string
+	for {
+		seg, err := l.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seg == "" {
+			break
+		}
+		records, _ := l.Read(seg)
+		for _, r := range records {
+			got = append(got, string(r))
+		}
+		l.Remove(seg)
+	}
+	if want := []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("records %v, want %v", got, want)
+	}
+}
+
+func TestLogRewrite(t *testing.T) {
+	l, _, _ := Open(t.TempDir(), "q-", isRecord)
+	l.Append([]byte(`{"n":1}`))
+	l.Append([]byte(`{"n":2}`))
+	seg, _ := l.Next()
+	records, _ := l.Read(seg)
+	if err := l.Rewrite(seg, records[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if seg2, _ := l.Next(); seg2 != seg {
+		t.Fatalf("Next = %q after Rewrite, want %q", seg2, seg)
+	}
+	if records, _ = l.Read(seg); len(records) != 1 || string(records[0]) != `{"n":2}` {
+		t.Errorf("records after Rewrite %q", records)
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := NewCache[int](2)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Get("a")
+	c.Put("c", 3)
+	if _, _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry was kept")
+	}
+
+	restored := NewCache[int](2)
+	restored.Load(c.Entries())
+	restored.Put("d", 4)
+	if _, _, ok := restored.Get("a"); ok {
+		t.Error("Load did not keep the recency order")
+	}
+	if v, storedAt, ok := restored.Get("c"); !ok || v != 3 || !storedAt.Equal(c.Entries()[1].StoredAt) {
+		t.Errorf("Get(c) = %d, %v, %t", v, storedAt, ok)
+	}
+}
+
+func TestConnectivity(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	_, dialErr := http.Get("http://" + addr)
+	if !NotSent(dialErr) || !NoResponse(dialErr) {
+		t.Errorf("refused connection: NotSent %t, NoResponse %t", NotSent(dialErr), NoResponse(dialErr))
+	}
+	if err := errors.New("unexpected EOF"); NotSent(err) || NoResponse(err) {
+		t.Errorf("%v classified as a connectivity failure", err)
+	}
+	if !GatewayDown(http.StatusServiceUnavailable) || GatewayDown(http.StatusInternalServerError) {
+		t.Error("GatewayDown")
+	}
+}
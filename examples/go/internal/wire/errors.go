@@ -0,0 +1,104 @@
+package wire
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details,
+// commonly returned by API gateways and proxies in front of PowerMem.
+const ProblemContentType = "application/problem+json"
+
+// ErrorResponse is the content of an error response, reported either in
+// PowerMem's envelope or as RFC 7807 problem details.
+type ErrorResponse struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    map[string]interface{}
+
+	// Type, Title and Instance are set for problem details only.
+	Type     string
+	Title    string
+	Instance string
+}
+
+// ParseError parses the body of a non-2xx response. Problem details map
+// their detail member, or failing that their title, to Message; bodies in
+// neither format become the Message verbatim.
+func ParseError(status int, contentType string, body []byte) *ErrorResponse {
+	e := &ErrorResponse{StatusCode: status}
+	v, err := Parse(body)
+	if err != nil {
+		e.Message = string(body)
+		return e
+	}
+
+	if mediaType(contentType) == ProblemContentType {
+		if s := v.Get("status").Int(); s != 0 {
+			e.StatusCode = s
+		}
+		e.Code = v.Get("code").Str()
+		e.Type = v.Get("type").Str()
+		e.Title = v.Get("title").Str()
+		e.Instance = v.Get("instance").Str()
+		e.Details = v.Get("errors").Map()
+		e.Message = firstNonEmpty(v.Get("detail").Str(), e.Title, http.StatusText(e.StatusCode))
+		return e
+	}
+
+	if apiErr := v.Get("error"); apiErr.Map() != nil {
+		e.Code = apiErr.Get("code").Str()
+		e.Message = apiErr.Get("message").Str()
+		e.Details = apiErr.Get("details").Map()
+		return e
+	}
+	// Errors raised before PowerMem's handlers, e.g. by the web framework,
+	// carry a bare message or detail.
+	e.Message = firstNonEmpty(v.Get("message").Str(), v.Get("detail").Str(), string(body))
+	return e
+}
+
+// Envelope returns the data of a response in PowerMem's envelope, or the
+// error the response reports.
+func Envelope(status int, contentType string, body []byte) (Value, *ErrorResponse) {
+	if status < 200 || status >= 300 {
+		return Value{}, ParseError(status, contentType, body)
+	}
+	v, err := Parse(body)
+	if err != nil {
+		return Value{}, &ErrorResponse{StatusCode: status, Message: "failed to parse response: " + err.Error()}
+	}
+	if !v.Get("success").Bool() {
+		return Value{}, &ErrorResponse{StatusCode: status, Message: v.Get("message").Str()}
+	}
+	return v.Get("data"), nil
+}
+
+// SetAuth sets the X-API-Key and bearer Authorization headers, leaving
+// out those whose credential is empty.
+func SetAuth(header http.Header, apiKey, bearerToken string) {
+	if apiKey != "" {
+		header.Set("X-API-Key", apiKey)
+	}
+	if bearerToken != "" {
+		header.Set("Authorization", "Bearer "+bearerToken)
+	}
+}
+
+// mediaType returns the lower-cased media type of a Content-Type header,
+// without parameters. It avoids package mime, which is large for the
+// targets the minimal client supports.
+func mediaType(contentType string) string {
+	t, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
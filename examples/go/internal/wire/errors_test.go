@@ -0,0 +1,58 @@
+package wire
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        ErrorResponse
+	}{
+		{"envelope", 404, "application/json",
+			`{"success":false,"error":{"code":"MEMORY_NOT_FOUND","message":"no memory 7","details":{"memory_id":7}}}`,
+			ErrorResponse{StatusCode: 404, Code: "MEMORY_NOT_FOUND", Message: "no memory 7", Details: map[string]interface{}{"memory_id": float64(7)}}},
+		{"bare message", 503, "application/json", `{"success":false,"message":"shutting down"}`,
+			ErrorResponse{StatusCode: 503, Message: "shutting down"}},
+		{"framework detail", 405, "application/json", `{"detail":"Method Not Allowed"}`,
+			ErrorResponse{StatusCode: 405, Message: "Method Not Allowed"}},
+		{"problem details", 502, "application/problem+json; charset=utf-8",
+			`{"type":"https://gw.example/upstream","title":"Bad Gateway","status":503,"detail":"upstream down","instance":"/req/1","code":"UPSTREAM"}`,
+			ErrorResponse{StatusCode: 503, Code: "UPSTREAM", Message: "upstream down", Type: "https://gw.example/upstream", Title: "Bad Gateway", Instance: "/req/1"}},
+		{"problem without detail", 429, "application/problem+json", `{"type":"about:blank"}`,
+			ErrorResponse{StatusCode: 429, Message: "Too Many Requests", Type: "about:blank"}},
+		{"plain text", 502, "text/html", "<html>bad gateway</html>",
+			ErrorResponse{StatusCode: 502, Message: "<html>bad gateway</html>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseError(tt.status, tt.contentType, []byte(tt.body)); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseError = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	data, err := Envelope(200, "application/json", []byte(`{"success":true,"data":{"memory_id":"9007199254740993","score":0.5}}`))
+	if err != nil {
+		t.Fatalf("Envelope: %+v", err)
+	}
+	if id := data.Get("memory_id").ID(); id != 9007199254740993 {
+		t.Errorf("memory_id = %d", id)
+	}
+	if score := data.Get("score").Float(); score != 0.5 {
+		t.Errorf("score = %v", score)
+	}
+
+	if _, err := Envelope(200, "application/json", []byte(`{"success":false,"message":"rejected"}`)); err == nil || err.Message != "rejected" {
+		t.Errorf("unsuccessful envelope: %+v", err)
+	}
+	if _, err := Envelope(200, "application/json", []byte(`{"success":`)); err == nil {
+		t.Error("truncated envelope parsed")
+	}
+}
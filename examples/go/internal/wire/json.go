@@ -0,0 +1,225 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Value is a parsed JSON value: nil, bool, json.Number, string,
+// []Value or map[string]Value. It is built with the decoder's tokenizer
+// rather than reflection-based unmarshaling, which TinyGo handles poorly.
+type Value struct {
+	v interface{}
+}
+
+// Parse parses a single JSON document.
+func Parse(data []byte) (Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return parseValue(dec)
+}
+
+func parseValue(dec *json.Decoder) (Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return Value{}, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := map[string]Value{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return Value{}, err
+			}
+			v, err := parseValue(dec)
+			if err != nil {
+				return Value{}, err
+			}
+			obj[key.(string)] = v
+		}
+		_, err := dec.Token()
+		return Value{obj}, err
+	case json.Delim('['):
+		var arr []Value
+		for dec.More() {
+			v, err := parseValue(dec)
+			if err != nil {
+				return Value{}, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return Value{arr}, err
+	case json.Delim('}'), json.Delim(']'):
+		return Value{}, errors.New("unexpected delimiter")
+	}
+	return Value{tok}, nil
+}
+
+// Get returns the field key of an object, or null.
+func (v Value) Get(key string) Value {
+	obj, _ := v.v.(map[string]Value)
+	return obj[key]
+}
+
+// List returns the elements of an array.
+func (v Value) List() []Value {
+	arr, _ := v.v.([]Value)
+	return arr
+}
+
+// Str returns a string, or "" for other types.
+func (v Value) Str() string {
+	s, _ := v.v.(string)
+	return s
+}
+
+// Bool returns a boolean, or false for other types.
+func (v Value) Bool() bool {
+	b, _ := v.v.(bool)
+	return b
+}
+
+// Float returns a number, or 0 for other types.
+func (v Value) Float() float64 {
+	n, _ := v.v.(json.Number)
+	f, _ := n.Float64()
+	return f
+}
+
+// Int returns an integer, or 0 for other types and fractional numbers.
+func (v Value) Int() int {
+	n, _ := v.v.(json.Number)
+	i, _ := strconv.Atoi(string(n))
+	return i
+}
+
+// Map returns an object as encoding/json would decode it into a
+// map[string]interface{}, or nil for other types.
+func (v Value) Map() map[string]interface{} {
+	if _, ok := v.v.(map[string]Value); !ok {
+		return nil
+	}
+	return v.Interface().(map[string]interface{})
+}
+
+// Interface returns v as encoding/json would decode it into an
+// interface{}: numbers become float64.
+func (v Value) Interface() interface{} {
+	switch t := v.v.(type) {
+	case json.Number:
+		f, _ := t.Float64()
+		return f
+	case []Value:
+		arr := make([]interface{}, len(t))
+		for i, e := range t {
+			arr[i] = e.Interface()
+		}
+		return arr
+	case map[string]Value:
+		obj := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			obj[k] = e.Interface()
+		}
+		return obj
+	}
+	return v.v
+}
+
+// ID returns a memory ID, sent as a number or, for IDs beyond 2^53, a
+// string.
+func (v Value) ID() int64 {
+	var s string
+	switch t := v.v.(type) {
+	case json.Number:
+		s = string(t)
+	case string:
+		s = t
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// Object builds a JSON object without reflection. Fields with empty
+// values are left out.
+type Object struct {
+	buf []byte
+}
+
+func (o *Object) key(k string) {
+	if len(o.buf) == 0 {
+		o.buf = append(o.buf, '{')
+	} else {
+		o.buf = append(o.buf, ',')
+	}
+	o.buf = AppendString(o.buf, k)
+	o.buf = append(o.buf, ':')
+}
+
+// Str adds a string field.
+func (o *Object) Str(k, v string) {
+	if v != "" {
+		o.key(k)
+		o.buf = AppendString(o.buf, v)
+	}
+}
+
+// Int adds an integer field.
+func (o *Object) Int(k string, v int) {
+	if v != 0 {
+		o.key(k)
+		o.buf = strconv.AppendInt(o.buf, int64(v), 10)
+	}
+}
+
+// Bool adds a boolean field, also when false.
+func (o *Object) Bool(k string, v bool) {
+	o.key(k)
+	o.buf = strconv.AppendBool(o.buf, v)
+}
+
+// Bytes returns the encoded object.
+func (o *Object) Bytes() []byte {
+	if len(o.buf) == 0 {
+		return []byte("{}")
+	}
+	return append(o.buf, '}')
+}
+
+// AppendString appends s as a JSON string.
+func AppendString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\r':
+			buf = append(buf, '\\', 'r')
+		case c == '\t':
+			buf = append(buf, '\\', 't')
+		case c < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		case c < utf8.RuneSelf:
+			buf = append(buf, c)
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, `�`...)
+			} else {
+				buf = append(buf, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	return append(buf, '"')
+}
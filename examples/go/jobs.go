@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
+)
+
+// =============================================================================
+// Background Jobs
+// =============================================================================
+
+// PollOptions controls how WaitForJob polls a job.
+type PollOptions struct {
+	// Interval is the delay before the first poll. It doubles on each
+	// subsequent poll. Defaults to 200ms.
+	Interval time.Duration
+
+	// MaxInterval caps the delay between polls. Defaults to 5s.
+	MaxInterval time.Duration
+
+	// OnProgress, if set, is called with the job after each poll that
+	// finds it unfinished, e.g. to report its Progress.
+	OnProgress func(*Job)
+}
+
+// GetJob retrieves the current state of a background job.
+func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	path := fmt.Sprintf("/api/v1/jobs/%s", url.PathEscape(jobID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Job]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get job failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListJobs lists background jobs, e.g. the pending and failed ones to
+// monitor a backlog.
+func (c *Client) ListJobs(ctx context.Context, params ListJobsParams) (*JobList, error) {
+	if err := c.requireFeature(experimental.AsyncJobs, "ListJobs"); err != nil {
+		return nil, err
+	}
+	queryParams := url.Values{}
+	for _, status := range params.Status {
+		queryParams.Add("status", string(status))
+	}
+	if params.Type != "" {
+		queryParams.Set("type", string(params.Type))
+	}
+	if params.UserID != "" {
+		queryParams.Set("user_id", params.UserID)
+	}
+	if params.Limit > 0 {
+		queryParams.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset > 0 {
+		queryParams.Set("offset", strconv.Itoa(params.Offset))
+	}
+
+	path := "/api/v1/jobs"
+	if len(queryParams) > 0 {
+		path += "?" + queryParams.Encode()
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[JobList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list jobs failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// CancelJob cancels a pending or running job. Cancelling a finished job
+// fails.
+func (c *Client) CancelJob(ctx context.Context, jobID string) (*Job, error) {
+	if err := c.requireFeature(experimental.AsyncJobs, "CancelJob"); err != nil {
+		return nil, err
+	}
+	return c.jobAction(ctx, jobID, "cancel")
+}
+
+// RetryJob re-queues a failed or cancelled job and returns it in its new
+// pending state.
+func (c *Client) RetryJob(ctx context.Context, jobID string) (*Job, error) {
+	if err := c.requireFeature(experimental.AsyncJobs, "RetryJob"); err != nil {
+		return nil, err
+	}
+	return c.jobAction(ctx, jobID, "retry")
+}
+
+// jobAction posts to a job's action endpoint.
+func (c *Client) jobAction(ctx context.Context, jobID, action string) (*Job, error) {
+	path := fmt.Sprintf("/api/v1/jobs/%s/%s", url.PathEscape(jobID), action)
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Job]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("%s job failed: %s", action, resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// WaitForJob polls a job until it finishes or ctx is done. A job that
+// failed or was cancelled is returned together with an error. When
+// receipts are enabled the created memories are verified as in
+// CreateMemory.
+func (c *Client) WaitForJob(ctx context.Context, jobID string, opts PollOptions) (*Job, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = 5 * time.Second
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		job, err := c.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		switch job.Status {
+		case JobFailed:
+			return job, fmt.Errorf("job %s failed: %s", job.JobID, job.Error)
+		case JobCancelled:
+			return job, fmt.Errorf("job %s was cancelled", job.JobID)
+		case JobSucceeded:
+			if c.receipts != nil {
+				for _, m := range job.Result {
+					if err := c.receipts.VerifyCreated(m); err != nil {
+						return job, err
+					}
+				}
+			}
+			return job, nil
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(job)
+		}
+
+		interval = min(interval*2, maxInterval)
+		timer.Reset(interval)
+	}
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a parsed kubectl-style JSONPath template: literal text with
+// expressions in braces, e.g. "{.memories[*].memory_id}". Supported are
+// fields (.name, ['name']), wildcards (.*, [*]), indices and slices
+// ([0], [-1], [1:3]), recursive descent (..name), filters
+// ([?(@.score > 0.5)], [?(@.user_id == "u1")], [?(@.metadata)]), quoted
+// text ({"\t"}) and iteration:
+//
+//	{range .memories[*]}{.memory_id}{"\t"}{.content}{"\n"}{end}
+type jsonPath struct {
+	parts []jsonPathPart
+}
+
+// jsonPathPart is literal text, or an expression if steps is non-nil.
+// For a range, body is executed with each node the expression selects.
+type jsonPathPart struct {
+	text    string
+	steps   []pathStep
+	isRange bool
+	body    []jsonPathPart
+}
+
+// pathStep selects nodes from the result of the previous step.
+type pathStep struct {
+	kind      stepKind
+	recursive bool
+
+	name       string
+	index      int
+	start, end *int
+	filter     *pathFilter
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepWildcard
+	stepIndex
+	stepSlice
+	stepFilter
+)
+
+// pathFilter keeps the elements for which the path relative to the
+// element compares to value with op, or exists if op is empty.
+type pathFilter struct {
+	path  []pathStep
+	op    string
+	value interface{}
+}
+
+// parseJSONPath parses a template. A template without braces is taken as
+// a single expression, so ".memories[0]" and "{.memories[0]}" are the
+// same.
+func parseJSONPath(tmpl string) (*jsonPath, error) {
+	if !strings.Contains(tmpl, "{") {
+		tmpl = "{" + tmpl + "}"
+	}
+	p := &jsonPath{}
+	// stack holds the part lists being filled: the template's and the
+	// bodies of the enclosing ranges.
+	stack := []*[]jsonPathPart{&p.parts}
+	add := func(part jsonPathPart) {
+		top := stack[len(stack)-1]
+		*top = append(*top, part)
+	}
+	for tmpl != "" {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			add(jsonPathPart{text: unescapeTemplate(tmpl)})
+			break
+		}
+		if open > 0 {
+			add(jsonPathPart{text: unescapeTemplate(tmpl[:open])})
+		}
+		end := closingBrace(tmpl, open)
+		if end < 0 {
+			return nil, fmt.Errorf("jsonpath: unclosed { in %q", tmpl)
+		}
+		expr := strings.TrimSpace(tmpl[open+1 : end])
+		tmpl = tmpl[end+1:]
+
+		switch {
+		case expr == "end":
+			if len(stack) == 1 {
+				return nil, errors.New("jsonpath: {end} without {range}")
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(expr, "range "):
+			steps, err := parsePathSteps(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			add(jsonPathPart{steps: steps, isRange: true})
+			top := *stack[len(stack)-1]
+			stack = append(stack, &top[len(top)-1].body)
+		case strings.HasPrefix(expr, `"`):
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: invalid text %s", expr)
+			}
+			add(jsonPathPart{text: text})
+		default:
+			steps, err := parsePathSteps(expr)
+			if err != nil {
+				return nil, err
+			}
+			add(jsonPathPart{steps: steps})
+		}
+	}
+	if len(stack) > 1 {
+		return nil, errors.New("jsonpath: {range} without {end}")
+	}
+	return p, nil
+}
+
+// closingBrace returns the index of the brace closing the one at open,
+// skipping quoted strings.
+func closingBrace(s string, open int) int {
+	var quote byte
+	for i := open + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// parsePathSteps parses an expression such as "$.memories[*].content".
+// The empty expression and "$" select the root.
+func parsePathSteps(expr string) ([]pathStep, error) {
+	expr = strings.TrimPrefix(expr, "$")
+	steps := []pathStep{}
+	for expr != "" {
+		recursive := false
+		switch {
+		case strings.HasPrefix(expr, ".."):
+			recursive = true
+			expr = expr[2:]
+		case expr[0] == '.':
+			expr = expr[1:]
+		case expr[0] != '[':
+			return nil, fmt.Errorf("jsonpath: unexpected %q", expr)
+		}
+
+		var step pathStep
+		if strings.HasPrefix(expr, "[") {
+			end := closingBracket(expr)
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath: unclosed [ in %q", expr)
+			}
+			var err error
+			if step, err = parseBracket(expr[1:end]); err != nil {
+				return nil, err
+			}
+			expr = expr[end+1:]
+		} else {
+			n := strings.IndexAny(expr, ".[")
+			if n < 0 {
+				n = len(expr)
+			}
+			name := expr[:n]
+			expr = expr[n:]
+			switch name {
+			case "":
+				return nil, errors.New("jsonpath: empty field name")
+			case "*":
+				step = pathStep{kind: stepWildcard}
+			default:
+				step = pathStep{kind: stepField, name: name}
+			}
+		}
+		step.recursive = recursive
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// closingBracket returns the index of the bracket closing the one at the
+// start of s, skipping quoted strings and nested brackets.
+func closingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseBracket parses the inside of a bracket step.
+func parseBracket(s string) (pathStep, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "*":
+		return pathStep{kind: stepWildcard}, nil
+	case strings.HasPrefix(s, "?(") && strings.HasSuffix(s, ")"):
+		f, err := parseFilter(strings.TrimSpace(s[2 : len(s)-1]))
+		if err != nil {
+			return pathStep{}, err
+		}
+		return pathStep{kind: stepFilter, filter: f}, nil
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return pathStep{kind: stepField, name: s[1 : len(s)-1]}, nil
+	case strings.Contains(s, ":"):
+		lo, hi, _ := strings.Cut(s, ":")
+		step := pathStep{kind: stepSlice}
+		for _, b := range []struct {
+			text string
+			dst  **int
+		}{{lo, &step.start}, {hi, &step.end}} {
+			if t := strings.TrimSpace(b.text); t != "" {
+				n, err := strconv.Atoi(t)
+				if err != nil {
+					return pathStep{}, fmt.Errorf("jsonpath: invalid slice [%s]", s)
+				}
+				*b.dst = &n
+			}
+		}
+		return step, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return pathStep{}, fmt.Errorf("jsonpath: invalid subscript [%s]", s)
+	}
+	return pathStep{kind: stepIndex, index: n}, nil
+}
+
+// filterOps are the comparison operators of filters, longest first.
+var filterOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseFilter parses a filter expression such as `@.score > 0.5`.
+func parseFilter(s string) (*pathFilter, error) {
+	if !strings.HasPrefix(s, "@") {
+		return nil, fmt.Errorf("jsonpath: filter %q must start with @", s)
+	}
+	left, op, right := s[1:], "", ""
+	for i := 0; i < len(s) && op == ""; i++ {
+		for _, o := range filterOps {
+			if strings.HasPrefix(s[i:], o) {
+				left, op, right = s[1:i], o, s[i+len(o):]
+				break
+			}
+		}
+	}
+
+	path, err := parsePathSteps(strings.TrimSpace(left))
+	if err != nil {
+		return nil, err
+	}
+	f := &pathFilter{path: path, op: op}
+	if op != "" {
+		right = strings.TrimSpace(right)
+		if len(right) >= 2 && right[0] == '\'' && right[len(right)-1] == '\'' {
+			f.value = right[1 : len(right)-1]
+		} else if err := json.Unmarshal([]byte(right), &f.value); err != nil {
+			return nil, fmt.Errorf("jsonpath: invalid filter value %s", right)
+		}
+	}
+	return f, nil
+}
+
+// toJSONValue converts v to the generic form the evaluator works on, by
+// way of its JSON encoding.
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// query returns the part of data the template's expressions select: the
+// single value of a definite path, i.e. one of only fields and indices,
+// or null if it is absent, else the list of values selected.
+func (p *jsonPath) query(data interface{}) interface{} {
+	out := []interface{}{}
+	definite := true
+	exprs := 0
+	for _, part := range p.parts {
+		if part.steps == nil {
+			continue
+		}
+		exprs++
+		for _, step := range part.steps {
+			definite = definite && !step.recursive && (step.kind == stepField || step.kind == stepIndex)
+		}
+		out = append(out, evalSteps(part.steps, data)...)
+	}
+	if definite && exprs == 1 {
+		if len(out) == 0 {
+			return nil
+		}
+		return out[0]
+	}
+	return out
+}
+
+// execute writes the template with each expression replaced by the nodes
+// it selects, separated by spaces. Strings are written as is, other
+// values as JSON.
+func (p *jsonPath) execute(w io.Writer, data interface{}) error {
+	var b strings.Builder
+	if err := executeParts(&b, p.parts, data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// executeParts writes parts applied to data to b.
+func executeParts(b *strings.Builder, parts []jsonPathPart, data interface{}) error {
+	for _, part := range parts {
+		switch {
+		case part.steps == nil:
+			b.WriteString(part.text)
+			continue
+		case part.isRange:
+			for _, node := range evalSteps(part.steps, data) {
+				if err := executeParts(b, part.body, node); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for i, node := range evalSteps(part.steps, data) {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			if s, ok := node.(string); ok {
+				b.WriteString(s)
+				continue
+			}
+			enc, err := json.Marshal(node)
+			if err != nil {
+				return err
+			}
+			b.Write(enc)
+		}
+	}
+	return nil
+}
+
+// unescapeTemplate interprets the \n and \t escapes kubectl templates
+// use for line breaks.
+func unescapeTemplate(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(s)
+}
+
+// evalSteps applies steps to node.
+func evalSteps(steps []pathStep, node interface{}) []interface{} {
+	nodes := []interface{}{node}
+	for _, step := range steps {
+		var next []interface{}
+		for _, n := range nodes {
+			if step.recursive {
+				for _, d := range descendants(n) {
+					next = append(next, step.apply(d)...)
+				}
+			} else {
+				next = append(next, step.apply(n)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// descendants returns node and all nodes below it, depth first.
+func descendants(node interface{}) []interface{} {
+	out := []interface{}{node}
+	for _, child := range children(node) {
+		out = append(out, descendants(child)...)
+	}
+	return out
+}
+
+// children returns the elements of an array or the values of an object
+// in key order.
+func children(node interface{}) []interface{} {
+	switch n := node.(type) {
+	case []interface{}:
+		return n
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]interface{}, len(keys))
+		for i, k := range keys {
+			out[i] = n[k]
+		}
+		return out
+	}
+	return nil
+}
+
+// apply selects the nodes of step from node.
+func (step pathStep) apply(node interface{}) []interface{} {
+	switch step.kind {
+	case stepField:
+		if m, ok := node.(map[string]interface{}); ok {
+			if v, ok := m[step.name]; ok {
+				return []interface{}{v}
+			}
+		}
+	case stepWildcard:
+		return children(node)
+	case stepIndex:
+		if a, ok := node.([]interface{}); ok {
+			i := step.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				return []interface{}{a[i]}
+			}
+		}
+	case stepSlice:
+		if a, ok := node.([]interface{}); ok {
+			lo, hi := 0, len(a)
+			if step.start != nil {
+				lo = clampIndex(*step.start, len(a))
+			}
+			if step.end != nil {
+				hi = clampIndex(*step.end, len(a))
+			}
+			if lo < hi {
+				return a[lo:hi]
+			}
+		}
+	case stepFilter:
+		var out []interface{}
+		for _, child := range children(node) {
+			if step.filter.match(child) {
+				out = append(out, child)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// clampIndex resolves a slice bound against an array of length n.
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	return max(0, min(i, n))
+}
+
+// match reports whether node passes the filter.
+func (f *pathFilter) match(node interface{}) bool {
+	found := evalSteps(f.path, node)
+	if f.op == "" {
+		return len(found) > 0
+	}
+	for _, v := range found {
+		if compareJSON(v, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareJSON compares a with b using op. Numbers compare numerically and
+// strings lexically; other values support only == and !=.
+func compareJSON(a interface{}, op string, b interface{}) bool {
+	if x, ok := jsonNumber(a); ok {
+		if y, ok := jsonNumber(b); ok {
+			return compareOrdered(x, op, y)
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return compareOrdered(x, op, y)
+		}
+	}
+	// Objects and arrays are not comparable with ==; compare encodings.
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	switch op {
+	case "==":
+		return bytes.Equal(x, y)
+	case "!=":
+		return !bytes.Equal(x, y)
+	}
+	return false
+}
+
+func jsonNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func compareOrdered[T float64 | string](a T, op string, b T) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+//go:build darwin && !tinygo
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// securityNotFound is the exit status of security(1) for a missing item.
+const securityNotFound = 44
+
+// keychain stores secrets in the macOS login keychain via security(1).
+type keychain struct{}
+
+// OSKeychain returns the credential store of the operating system: the
+// login keychain on macOS, the Credential Manager on Windows and the
+// Secret Service (via secret-tool) on Linux.
+func OSKeychain() CredentialStore {
+	return keychain{}
+}
+
+// Get implements CredentialStore.
+func (keychain) Get(ctx context.Context, service, account string) (string, error) {
+	out, err := exec.CommandContext(ctx, "security", "find-generic-password",
+		"-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+			return "", ErrCredentialNotFound
+		}
+		return "", fmt.Errorf("keychain lookup failed: %w", err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// Set implements CredentialStore. The secret is passed on stdin rather
+// than as an argument, where other local users could read it with ps(1).
+func (keychain) Set(ctx context.Context, service, account, secret string) error {
+	if strings.ContainsAny(secret, "\r\n") {
+		return fmt.Errorf("keychain store failed: secret contains a line break")
+	}
+	var stderr bytes.Buffer
+	// With -w last and no value, security prompts for the password and
+	// its confirmation with readpassphrase(3), which reads the
+	// controlling terminal rather than stdin whenever it can open
+	// /dev/tty. Running security in a new session leaves it without one,
+	// so the prompt falls back to stdin instead of waiting for the user
+	// of an interactive shell to type the secret.
+	cmd := exec.CommandContext(ctx, "security", "add-generic-password", "-U",
+		"-s", service, "-a", account, "-w")
+	cmd.Stdin = strings.NewReader(secret + "\n" + secret + "\n")
+	cmd.Stderr = &stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("keychain store failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Delete implements CredentialStore.
+func (keychain) Delete(ctx context.Context, service, account string) error {
+	err := exec.CommandContext(ctx, "security", "delete-generic-password",
+		"-s", service, "-a", account).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("keychain delete failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+//go:build linux && !tinygo
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychain stores secrets in the Secret Service (GNOME Keyring, KWallet)
+// via secret-tool(1) from libsecret.
+type keychain struct{}
+
+// OSKeychain returns the credential store of the operating system: the
+// login keychain on macOS, the Credential Manager on Windows and the
+// Secret Service (via secret-tool) on Linux.
+func OSKeychain() CredentialStore {
+	return keychain{}
+}
+
+// Get implements CredentialStore.
+func (keychain) Get(ctx context.Context, service, account string) (string, error) {
+	out, err := exec.CommandContext(ctx, "secret-tool", "lookup",
+		"service", service, "account", account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(exitErr.Stderr) == 0 {
+			return "", ErrCredentialNotFound
+		}
+		return "", fmt.Errorf("secret service lookup failed: %w", err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// Set implements CredentialStore.
+func (keychain) Set(ctx context.Context, service, account, secret string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "secret-tool", "store",
+		"--label", service+" ("+account+")", "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("secret service store failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Delete implements CredentialStore.
+func (keychain) Delete(ctx context.Context, service, account string) error {
+	err := exec.CommandContext(ctx, "secret-tool", "clear",
+		"service", service, "account", account).Run()
+	if err != nil {
+		return fmt.Errorf("secret service delete failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build tinygo || (!darwin && !linux && !windows)
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// errNoKeychain is returned on platforms without a supported OS keychain.
+var errNoKeychain = errors.New("powermem: no OS keychain available on this platform")
+
+// keychain is a placeholder on platforms without OS keychain support.
+type keychain struct{}
+
+// OSKeychain returns the credential store of the operating system. On
+// this platform none is supported and every operation fails.
+func OSKeychain() CredentialStore {
+	return keychain{}
+}
+
+// Get implements CredentialStore.
+func (keychain) Get(context.Context, string, string) (string, error) {
+	return "", errNoKeychain
+}
+
+// Set implements CredentialStore.
+func (keychain) Set(context.Context, string, string, string) error {
+	return errNoKeychain
+}
+
+// Delete implements CredentialStore.
+func (keychain) Delete(context.Context, string, string) error {
+	return errNoKeychain
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyTracker is a MetricsRecorder that keeps a rolling window of
+// request latencies per endpoint and reports p50/p95/p99, for watching
+// memory-layer SLOs without a full metrics stack:
+//
+//	tracker := NewLatencyTracker(5 * time.Minute)
+//	client := NewClient(baseURL, apiKey, WithMetrics(tracker))
+//	stop := tracker.StartLogging(logger, time.Minute)
+//	defer stop()
+type LatencyTracker struct {
+	window     time.Duration
+	maxSamples int
+
+	mu      sync.Mutex
+	samples map[string]*latencyRing
+}
+
+// LatencySummary holds latency percentiles for one endpoint over the
+// tracker's window.
+type LatencySummary struct {
+	Endpoint string        `json:"endpoint"`
+	Count    int           `json:"count"`
+	Errors   int           `json:"errors"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+}
+
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+	failed   bool
+}
+
+// latencyRing is a ring buffer of samples that grows up to max before
+// wrapping, so that rarely used endpoints stay small.
+type latencyRing struct {
+	buf  []latencySample
+	max  int
+	next int
+	full bool
+}
+
+func (r *latencyRing) add(s latencySample) {
+	if !r.full && len(r.buf) < r.max {
+		r.buf = append(r.buf, s)
+		if len(r.buf) == r.max {
+			r.full = true
+		}
+		return
+	}
+	r.buf[r.next] = s
+	r.next = (r.next + 1) % len(r.buf)
+}
+
+func (r *latencyRing) since(cutoff time.Time) []latencySample {
+	out := make([]latencySample, 0, len(r.buf))
+	for _, s := range r.buf {
+		if s.at.After(cutoff) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// NewLatencyTracker creates a tracker over a rolling window. At most 10000
+// samples are kept per endpoint; under heavier traffic the window is
+// effectively shortened to the most recent samples. Beyond 500 endpoints,
+// requests are tracked together under "{other}".
+func NewLatencyTracker(window time.Duration) *LatencyTracker {
+	return &LatencyTracker{
+		window:     window,
+		maxSamples: 10000,
+		samples:    make(map[string]*latencyRing),
+	}
+}
+
+// ObserveRequest implements MetricsRecorder.
+func (t *LatencyTracker) ObserveRequest(method, endpoint string, status int, duration time.Duration, err error) {
+	key := method + " " + endpoint
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r, ok := t.samples[key]
+	if !ok {
+		if len(t.samples) >= maxMetricEndpoints {
+			key = method + " " + otherEndpoint
+			r = t.samples[key]
+		}
+		if r == nil {
+			r = &latencyRing{max: t.maxSamples}
+			t.samples[key] = r
+		}
+	}
+	r.add(latencySample{at: time.Now(), duration: duration, failed: err != nil || status >= 400})
+}
+
+// Summary returns the latency summary for an endpoint key of the form
+// "METHOD /path/template". ok is false if no samples fall in the window.
+func (t *LatencyTracker) Summary(endpoint string) (summary LatencySummary, ok bool) {
+	cutoff := time.Now().Add(-t.window)
+	t.mu.Lock()
+	r, found := t.samples[endpoint]
+	var samples []latencySample
+	if found {
+		samples = r.since(cutoff)
+	}
+	t.mu.Unlock()
+
+	if len(samples) == 0 {
+		return LatencySummary{}, false
+	}
+	return summarize(endpoint, samples), true
+}
+
+// Summaries returns latency summaries for all endpoints with samples in
+// the window, sorted by endpoint.
+func (t *LatencyTracker) Summaries() []LatencySummary {
+	t.mu.Lock()
+	keys := make([]string, 0, len(t.samples))
+	for k := range t.samples {
+		keys = append(keys, k)
+	}
+	t.mu.Unlock()
+	sort.Strings(keys)
+
+	out := make([]LatencySummary, 0, len(keys))
+	for _, k := range keys {
+		if s, ok := t.Summary(k); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// StartLogging logs all summaries at info level every interval until the
+// returned stop function is called.
+func (t *LatencyTracker) StartLogging(logger *slog.Logger, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, s := range t.Summaries() {
+					logger.InfoContext(ctx, "powermem latency",
+						slog.String("endpoint", s.Endpoint),
+						slog.Int("count", s.Count),
+						slog.Int("errors", s.Errors),
+						slog.Duration("p50", s.P50),
+						slog.Duration("p95", s.P95),
+						slog.Duration("p99", s.P99),
+						slog.Duration("max", s.Max),
+					)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// summarize computes percentiles using the nearest-rank method.
+func summarize(endpoint string, samples []latencySample) LatencySummary {
+	durations := make([]time.Duration, len(samples))
+	errs := 0
+	for i, s := range samples {
+		durations[i] = s.duration
+		if s.failed {
+			errs++
+		}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	rank := func(p float64) time.Duration {
+		idx := int(math.Ceil(p*float64(len(durations)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return durations[idx]
+	}
+	return LatencySummary{
+		Endpoint: endpoint,
+		Count:    len(durations),
+		Errors:   errs,
+		P50:      rank(0.50),
+		P95:      rank(0.95),
+		P99:      rank(0.99),
+		Max:      durations[len(durations)-1],
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrClientClosed is returned when starting background work on a closed
+// client.
+var ErrClientClosed = errors.New("powermem: client is closed")
+
+// backgroundComponent is a goroutine-owning component stopped by Close.
+type backgroundComponent struct {
+	id    uint64
+	name  string
+	close func(ctx context.Context) error
+}
+
+// lifecycle tracks the background components of a client and its children.
+type lifecycle struct {
+	mu         sync.Mutex
+	closed     bool
+	components []backgroundComponent
+	nextID     uint64
+}
+
+// addBackground registers a component to be stopped by Close. It returns
+// ErrClientClosed if the client is already closed.
+func (c *Client) addBackground(name string, closeFn func(ctx context.Context) error) error {
+	_, err := c.trackBackground(name, closeFn)
+	return err
+}
+
+// trackBackground is like addBackground for components that may stop
+// before the client, e.g. a subscription whose context ends. untrack
+// unregisters the component once it has stopped.
+func (c *Client) trackBackground(name string, closeFn func(ctx context.Context) error) (untrack func(), err error) {
+	lc := &c.state().lifecycle
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.closed {
+		return nil, ErrClientClosed
+	}
+	lc.nextID++
+	id := lc.nextID
+	lc.components = append(lc.components, backgroundComponent{id: id, name: name, close: closeFn})
+	return func() {
+		lc.mu.Lock()
+		defer lc.mu.Unlock()
+		lc.components = slices.DeleteFunc(lc.components, func(bc backgroundComponent) bool { return bc.id == id })
+	}, nil
+}
+
+// Close drains pending asynchronous writes and stops all background
+// components of the client and the children sharing its state, waiting at
+// most until ctx is done: queues and workers, change feed subscriptions,
+// event handlers, realtime connections and sync managers. It then closes
+// the HTTP client's idle connections. Undelivered writes are reported
+// through an *UndeliveredError in the returned error (use errors.As).
+//
+// Synchronous calls remain usable after Close; starting new background
+// work fails with ErrClientClosed. Close is idempotent.
+func (c *Client) Close(ctx context.Context) error {
+	lc := &c.state().lifecycle
+	lc.mu.Lock()
+	if lc.closed {
+		lc.mu.Unlock()
+		return nil
+	}
+	lc.closed = true
+	components := lc.components
+	lc.components = nil
+	lc.mu.Unlock()
+
+	// Stop components in reverse order of registration, so that
+	// components built on top of others stop first.
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		if err := components[i].close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", components[i].name, err))
+		}
+	}
+	if c.HTTPClient != nil {
+		c.HTTPClient.CloseIdleConnections()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lifecycleServer answers memory writes and serves a change feed that
+// stays open until the client goes away.
+func lifecycleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/memories/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: 1\nevent: memory.created\ndata: {\"memory_id\": 1}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    []interface{}{map[string]interface{}{"memory_id": 1}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// waitGoroutines waits for the number of goroutines to drop to at most
+// want, failing with the stacks of all goroutines if it does not.
+func waitGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			buf = buf[:runtime.Stack(buf, true)]
+			t.Fatalf("%d goroutines running, want at most %d:\n%s", runtime.NumGoroutine(), want, buf)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloseStopsBackgroundGoroutines(t *testing.T) {
+	srv := lifecycleServer(t)
+	before := runtime.NumGoroutine()
+
+	c := NewClient(srv.URL, "")
+	events, err := c.SubscribeChanges(context.Background(), SubscribeParams{})
+	if err != nil {
+		t.Fatalf("SubscribeChanges: %v", err)
+	}
+	<-events
+
+	delivered := make(chan DeliveryStatus, 1)
+	err = c.With(WithDefaultMetadata(map[string]interface{}{"app": "test"})).
+		CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "hello", UserID: "u1"}, func(s DeliveryStatus) { delivered <- s })
+	if err != nil {
+		t.Fatalf("CreateMemoryAsyncNoWait: %v", err)
+	}
+	if s := <-delivered; s.Err != nil {
+		t.Fatalf("delivery failed: %v", s.Err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for range events {
+	}
+	srv.CloseClientConnections()
+	waitGoroutines(t, before)
+
+	if _, err := c.SubscribeChanges(context.Background(), SubscribeParams{}); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("SubscribeChanges after Close: got %v, want ErrClientClosed", err)
+	}
+}
+
+func TestCloseHonorsContextWithStuckSubscription(t *testing.T) {
+	srv := lifecycleServer(t)
+	before := runtime.NumGoroutine()
+
+	// OnError blocks the subscription's goroutine once the stream breaks.
+	release := make(chan struct{})
+	stuck := make(chan struct{}, 1)
+	c := NewClient(srv.URL, "")
+	events, err := c.SubscribeChanges(context.Background(), SubscribeParams{
+		OnError: func(error) {
+			select {
+			case stuck <- struct{}{}:
+			default:
+			}
+			<-release
+		},
+	})
+	if err != nil {
+		t.Fatalf("SubscribeChanges: %v", err)
+	}
+	<-events
+	srv.CloseClientConnections()
+	<-stuck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = c.Close(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close: got %v, want context.DeadlineExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "change feed") {
+		t.Errorf("Close error %q does not name the change feed", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close took %s past its 100ms deadline", elapsed)
+	}
+
+	close(release)
+	for range events {
+	}
+	srv.CloseClientConnections()
+	waitGoroutines(t, before)
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	srv := lifecycleServer(t)
+	c := NewClient(srv.URL, "")
+	if err := c.CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "hello", UserID: "u1"}, nil); err != nil {
+		t.Fatalf("CreateMemoryAsyncNoWait: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.Close(context.Background()); err != nil {
+			t.Fatalf("Close #%d: %v", i+1, err)
+		}
+	}
+	if err := c.CreateMemoryAsyncNoWait(&CreateMemoryRequest{Content: "late", UserID: "u1"}, nil); !errors.Is(err, ErrIngestorClosed) {
+		t.Errorf("CreateMemoryAsyncNoWait after Close: got %v, want ErrIngestorClosed", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// =============================================================================
+// Memory Links
+// =============================================================================
+
+// CreateMemoryLink records a relationship between two memories, e.g. that
+// a memory was derived from another or supersedes it.
+func (c *Client) CreateMemoryLink(ctx context.Context, link *MemoryLink) (*MemoryLink, error) {
+	if !link.Type.Valid() {
+		return nil, fmt.Errorf("invalid link type %q", link.Type)
+	}
+	if link.FromID == link.ToID {
+		return nil, errors.New("a memory cannot link to itself")
+	}
+	path := fmt.Sprintf("/api/v1/memories/%s/links", link.FromID.String())
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, path, link)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemoryLink]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("create memory link failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// LinkMemories asserts a relationship between two memories that inference
+// did not record, e.g. that fromID supersedes or contradicts toID.
+// metadata, if non-nil, is stored on the link, e.g. who asserted it.
+func (c *Client) LinkMemories(ctx context.Context, fromID, toID MemoryID, linkType LinkType, metadata map[string]interface{}) (*MemoryLink, error) {
+	return c.CreateMemoryLink(ctx, &MemoryLink{FromID: fromID, ToID: toID, Type: linkType, Metadata: metadata})
+}
+
+// UnlinkMemories deletes the links of linkType from fromID to toID, or of
+// any type if linkType is empty. Memories that are not linked are not an
+// error.
+func (c *Client) UnlinkMemories(ctx context.Context, fromID, toID MemoryID, linkType LinkType) error {
+	q := LinkQuery{Direction: LinkOutgoing}
+	if linkType != "" {
+		q.Types = []LinkType{linkType}
+	}
+	links, err := c.ListMemoryLinks(ctx, fromID, q)
+	if err != nil {
+		return err
+	}
+	for _, link := range links.Links {
+		if link.ToID != toID || (linkType != "" && link.Type != linkType) {
+			continue
+		}
+		if err := c.DeleteMemoryLink(ctx, fromID, link.ID); err != nil && !IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListMemoryLinks retrieves the links of a memory.
+func (c *Client) ListMemoryLinks(ctx context.Context, memoryID MemoryID, q LinkQuery) (*MemoryLinkList, error) {
+	path := fmt.Sprintf("/api/v1/memories/%s/links", memoryID.String()) + q.encode(false)
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemoryLinkList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list memory links failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// TraverseMemoryLinks follows links from a memory for up to q.Depth hops
+// and returns the memories and links reached, e.g. all chunks of a
+// document or the chain of memories a fact was derived from.
+func (c *Client) TraverseMemoryLinks(ctx context.Context, memoryID MemoryID, q LinkQuery) (*MemoryGraph, error) {
+	path := fmt.Sprintf("/api/v1/memories/%s/links/traverse", memoryID.String()) + q.encode(true)
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemoryGraph]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("traverse memory links failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteMemoryLink deletes a link of a memory.
+func (c *Client) DeleteMemoryLink(ctx context.Context, memoryID MemoryID, linkID string) error {
+	path := fmt.Sprintf("/api/v1/memories/%s/links/%s", memoryID.String(), url.PathEscape(linkID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete memory link failed: %s", resp.Message)
+	}
+
+	return nil
+}
+
+// encode returns q as a query string, including the depth when
+// traversing.
+func (q LinkQuery) encode(traverse bool) string {
+	params := url.Values{}
+	if q.Direction != "" {
+		params.Set("direction", string(q.Direction))
+	}
+	for _, t := range q.Types {
+		params.Add("type", string(t))
+	}
+	if traverse && q.Depth > 0 {
+		params.Set("depth", strconv.Itoa(q.Depth))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + params.Encode()
+}
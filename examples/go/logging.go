@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// redacted replaces sensitive values in log output.
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are never logged in clear text.
+var sensitiveHeaders = []string{"X-API-Key", "Authorization", "Cookie", "Set-Cookie"}
+
+// WithLogger logs every request and response at debug level to logger.
+// Credentials in headers and secrets in bodies are always redacted; memory
+// content is redacted as well when WithContentRedaction is set.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, loggingMiddleware(logger))
+	}
+}
+
+// WithContentRedaction redacts memory content in request and response
+// bodies logged by WithLogger.
+func WithContentRedaction() Option {
+	return func(c *Client) {
+		c.redactContent = true
+	}
+}
+
+// loggingMiddleware logs request/response pairs at debug level. Response
+// bodies are logged up to maxCapturedBody bytes, and not at all for
+// streams, which are left for the caller to read.
+func loggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			if !logger.Enabled(ctx, slog.LevelDebug) {
+				return next(req)
+			}
+			// The middleware is shared with children created by With,
+			// which may redact differently.
+			var redactContent bool
+			if c := requestClient(req); c != nil {
+				redactContent = c.redactContent
+			}
+
+			var reqBody []byte
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					reqBody, _ = io.ReadAll(body)
+					body.Close()
+				}
+			}
+			logger.DebugContext(ctx, "powermem request",
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.String()),
+				slog.Any("headers", redactHeaders(req.Header)),
+				slog.String("body", redactBody(reqBody, redactContent)),
+			)
+
+			start := time.Now()
+			resp, err := next(req)
+			elapsed := time.Since(start)
+			if err != nil {
+				logger.DebugContext(ctx, "powermem request failed",
+					slog.String("method", req.Method),
+					slog.String("url", req.URL.String()),
+					slog.Duration("duration", elapsed),
+					slog.Any("error", err),
+				)
+				return resp, err
+			}
+
+			respBody, truncated, readErr := peekBody(req, resp)
+			if readErr != nil {
+				logger.DebugContext(ctx, "powermem request failed",
+					slog.String("method", req.Method),
+					slog.String("url", req.URL.String()),
+					slog.Int("status", resp.StatusCode),
+					slog.Duration("duration", elapsed),
+					slog.Any("error", readErr),
+				)
+				return nil, readErr
+			}
+
+			attrs := []any{
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.String()),
+				slog.Int("status", resp.StatusCode),
+				slog.Duration("duration", elapsed),
+				slog.Any("headers", redactHeaders(resp.Header)),
+			}
+			switch {
+			case isStreaming(req, resp):
+				attrs = append(attrs, slog.Bool("stream", true))
+			case truncated:
+				attrs = append(attrs, slog.String("body", redactBody(respBody, redactContent)), slog.Bool("truncated", true))
+			default:
+				attrs = append(attrs, slog.String("body", redactBody(respBody, redactContent)))
+			}
+			logger.DebugContext(ctx, "powermem response", attrs...)
+			return resp, nil
+		}
+	}
+}
+
+// redactHeaders returns a copy of h with credential headers redacted.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// secretFields are JSON fields whose values are always redacted, e.g. a
+// webhook's signing secret or cold storage credentials.
+var secretFields = map[string]bool{
+	"secret":            true,
+	"secret_access_key": true,
+}
+
+// redactBody returns body for logging with secret fields redacted,
+// replacing every "content" field of a JSON body with a placeholder too
+// when redactContent is set.
+func redactBody(body []byte, redactContent bool) string {
+	if len(body) == 0 || (!redactContent && !bytes.Contains(body, []byte(`"secret`))) {
+		return string(body)
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redacted
+	}
+	out, err := json.Marshal(redactFields(v, redactContent))
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+// redactFields walks a decoded JSON value and redacts secret fields and,
+// if redactContent is set, content fields.
+func redactFields(v interface{}, redactContent bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if secretFields[k] || (redactContent && k == "content") {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactFields(val, redactContent)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = redactFields(val, redactContent)
+		}
+	}
+	return v
+}
+
+// redactSecret masks all but the first and last three characters of a
+// secret for display. Short secrets are masked entirely.
+func redactSecret(s string) string {
+	if len(s) <= 8 {
+		return redacted
+	}
+	return s[:3] + "..." + s[len(s)-3:]
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// failingBody returns err after data and records whether it was closed.
+type failingBody struct {
+	r      io.Reader
+	err    error
+	closed bool
+}
+
+func (b *failingBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		err = b.err
+	}
+	return n, err
+}
+
+func (b *failingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	mw := loggingMiddleware(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	req, _ := http.NewRequest(http.MethodGet, "http://powermem.test/api/v1/memories", nil)
+	req.Header.Set("X-API-Key", "secret-key")
+
+	body := `{"success":true,"data":[]}`
+	resp, err := mw(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})(req)
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	if got, _ := io.ReadAll(resp.Body); string(got) != body {
+		t.Errorf("caller read %q, want the whole body %q", got, body)
+	}
+	if strings.Contains(logs.String(), "secret-key") || !strings.Contains(logs.String(), "success") {
+		t.Errorf("logs %q: want the body logged and the API key redacted", logs.String())
+	}
+
+	readErr := errors.New("connection reset")
+	fb := &failingBody{r: strings.NewReader(`{"succ`), err: readErr}
+	resp, err = mw(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: fb}, nil
+	})(req)
+	if resp != nil || !errors.Is(err, readErr) {
+		t.Errorf("got response %v, error %v; want no response and the read error", resp, err)
+	}
+	if !fb.closed {
+		t.Error("body of the failed response was not closed")
+	}
+}
@@ -7,24 +7,56 @@
 // - Search memories
 // - Update memory
 // - Delete memory
+// - Batch ingestion
+// - Streaming search
+// - Retrieval pipeline (explained, hierarchical search)
+//
+// Each operation prints its latency.
 //
 // Usage:
 //
-//	go run .
+//	go run .                          # run all examples
+//	go run . apply [flags] FILE       # reconcile server configuration with a YAML manifest
+//	go run . audit verify [FILE]      # verify an audit log's hash chain
+//	go run . compat -versions V1,V2   # check compatibility with server versions in Docker
+//	go run . memories list [flags]    # list memories
+//	go run . memories search [flags] QUERY
+//	go run . memories move [flags]    # re-scope memories in bulk
+//	go run . repl [flags]             # interactive shell for trying memory behavior
+//	go run . seed -scenario NAME      # load a demo scenario, e.g. travel-planner
+//
+// The memories commands accept -output json or -output jsonpath=TEMPLATE
+// and -query EXPRESSION for scripting.
 //
 // Environment variables:
 //
 //	POWERMEM_BASE_URL - Base URL of the PowerMem API server (default: http://localhost:8000)
 //	POWERMEM_API_KEY  - API key for authentication (optional if auth is disabled)
+//	POWERMEM_KEYCHAIN_ACCOUNT - If set and POWERMEM_API_KEY is not, read the API key from the OS keychain
+//	POWERMEM_BEARER_TOKEN - Bearer token, e.g. a JWT from an API gateway, sent in the Authorization header
+//	POWERMEM_OAUTH2_CLIENT_ID, POWERMEM_OAUTH2_CLIENT_SECRET, POWERMEM_OAUTH2_TOKEN_URL - If set, send
+//	  bearer tokens obtained with the OAuth2 client credentials grant; cannot be combined with
+//	  POWERMEM_BEARER_TOKEN
+//	POWERMEM_DEBUG    - If set, log every request and response to stderr
+//	POWERMEM_EXPERIMENTAL - Preview features to enable, e.g. "graph,streaming" or "all"
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println("PowerMem Go Client Example")
 	fmt.Println(strings.Repeat("=", 60))
@@ -43,8 +75,26 @@ func main() {
 	fmt.Println(strings.Repeat("=", 60))
 }
 
-// initClient creates a PowerMem client from environment variables.
+// initClient creates a PowerMem client from environment variables and
+// prints its configuration.
 func initClient() *Client {
+	client := clientFromEnv()
+
+	fmt.Printf("\nConfiguration:\n")
+	fmt.Printf("  Base URL: %s\n", client.BaseURL)
+	if client.APIKey != "" {
+		fmt.Printf("  API Key:  %s\n", redactSecret(client.APIKey))
+	} else if account := os.Getenv("POWERMEM_KEYCHAIN_ACCOUNT"); account != "" {
+		fmt.Printf("  API Key:  (from OS keychain, account %q)\n", account)
+	} else {
+		fmt.Printf("  API Key:  (not set)\n")
+	}
+
+	return client
+}
+
+// clientFromEnv creates a PowerMem client from environment variables.
+func clientFromEnv() *Client {
 	baseURL := os.Getenv("POWERMEM_BASE_URL")
 	if baseURL == "" {
 		baseURL = "http://localhost:8000"
@@ -52,15 +102,27 @@ func initClient() *Client {
 
 	apiKey := os.Getenv("POWERMEM_API_KEY")
 
-	fmt.Printf("\nConfiguration:\n")
-	fmt.Printf("  Base URL: %s\n", baseURL)
-	if apiKey != "" {
-		fmt.Printf("  API Key:  %s...%s\n", apiKey[:3], apiKey[len(apiKey)-3:])
-	} else {
-		fmt.Printf("  API Key:  (not set)\n")
+	var opts []Option
+	if account := os.Getenv("POWERMEM_KEYCHAIN_ACCOUNT"); apiKey == "" && account != "" {
+		opts = append(opts, WithCredentialStore(OSKeychain(), account))
+	}
+	// Both set a token source, so the one applied last would silently win.
+	token, clientID := os.Getenv("POWERMEM_BEARER_TOKEN"), os.Getenv("POWERMEM_OAUTH2_CLIENT_ID")
+	switch {
+	case token != "" && clientID != "":
+		fmt.Fprintln(os.Stderr, "POWERMEM_BEARER_TOKEN and POWERMEM_OAUTH2_CLIENT_ID are mutually exclusive; set only one")
+		os.Exit(2)
+	case token != "":
+		opts = append(opts, WithBearerToken(token))
+	case clientID != "":
+		opts = append(opts, WithOAuth2(clientID, os.Getenv("POWERMEM_OAUTH2_CLIENT_SECRET"), os.Getenv("POWERMEM_OAUTH2_TOKEN_URL")))
+	}
+	if os.Getenv("POWERMEM_DEBUG") != "" {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		opts = append(opts, WithLogger(logger))
 	}
 
-	return NewClient(baseURL, apiKey)
+	return NewClient(baseURL, apiKey, opts...)
 }
 
 // runExamples executes all example operations.
@@ -113,6 +175,27 @@ func runExamples(client *Client) error {
 		fmt.Println("⚠️  Skipped (no memories created)")
 	}
 
+	// 7. Batch Ingestion
+	batch, err := exampleBatchIngestion(client)
+	if err != nil {
+		fmt.Printf("⚠️  Batch ingestion skipped: %v\n", err)
+	}
+
+	// 8. Streaming Search
+	if err := exampleStreamingSearch(client); err != nil {
+		fmt.Printf("⚠️  Streaming search skipped: %v\n", err)
+	}
+
+	// 9. Retrieval Pipeline
+	if err := exampleRetrievalPipeline(client); err != nil {
+		fmt.Printf("⚠️  Retrieval pipeline skipped: %v\n", err)
+	}
+
+	// Remove the batch so that reruns start from the same state.
+	for _, mem := range batch {
+		client.DeleteMemory(mem.MemoryID, "go-example-user", "go-example-agent")
+	}
+
 	return nil
 }
 
@@ -126,10 +209,12 @@ func exampleHealthCheck(client *Client) error {
 	fmt.Println("1. Health Check")
 	fmt.Println(strings.Repeat("-", 40))
 
+	start := time.Now()
 	health, err := client.Health()
 	if err != nil {
 		return err
 	}
+	printLatency(start)
 
 	fmt.Printf("✓ Status: %s\n", health.Status)
 	fmt.Printf("  Timestamp: %s\n", health.Timestamp.Format("2006-01-02 15:04:05"))
@@ -151,16 +236,18 @@ func exampleCreateMemory(client *Client) ([]CreatedMemory, error) {
 		UserID:  "go-example-user",
 		AgentID: "go-example-agent",
 		Metadata: map[string]interface{}{
-			"source":     "go-client-example",
-			"importance": "high",
+			"source": "go-client-example",
 		},
-		Infer: &infer,
+		Importance: 0.8,
+		Infer:      &infer,
 	}
 
+	start := time.Now()
 	memories, err := client.CreateMemory(req)
 	if err != nil {
 		return nil, err
 	}
+	printLatency(start)
 
 	fmt.Printf("✓ Created %d memory(ies):\n", len(memories))
 	for i, mem := range memories {
@@ -185,10 +272,12 @@ func exampleListMemories(client *Client) error {
 		Order:  "desc",
 	}
 
+	start := time.Now()
 	list, err := client.ListMemories(params)
 	if err != nil {
 		return err
 	}
+	printLatency(start)
 
 	fmt.Printf("✓ Found %d memories (showing %d):\n", list.Total, len(list.Memories))
 	for i, mem := range list.Memories {
@@ -216,10 +305,12 @@ func exampleSearchMemories(client *Client) error {
 		Limit:   5,
 	}
 
+	start := time.Now()
 	results, err := client.SearchMemories(req)
 	if err != nil {
 		return err
 	}
+	printLatency(start)
 
 	fmt.Printf("✓ Query: %q\n", results.Query)
 	fmt.Printf("  Found %d results:\n", results.Total)
@@ -242,21 +333,25 @@ func exampleUpdateMemory(client *Client, memoryID MemoryID) error {
 	fmt.Println("5. Update Memory")
 	fmt.Println(strings.Repeat("-", 40))
 
+	importance := 0.9
+
 	req := &UpdateMemoryRequest{
 		Content: "User loves espresso and visits Starbucks daily",
 		UserID:  "go-example-user",
 		AgentID: "go-example-agent",
 		Metadata: map[string]interface{}{
-			"source":     "go-client-example",
-			"importance": "very-high",
-			"updated":    true,
+			"source":  "go-client-example",
+			"updated": true,
 		},
+		Importance: &importance,
 	}
 
+	start := time.Now()
 	memory, err := client.UpdateMemory(memoryID, req)
 	if err != nil {
 		return err
 	}
+	printLatency(start)
 
 	fmt.Printf("✓ Updated memory ID: %s\n", memory.MemoryID.String())
 	fmt.Printf("  New content: %s\n", memory.Content)
@@ -273,12 +368,129 @@ func exampleDeleteMemory(client *Client, memoryID MemoryID) error {
 	fmt.Println("6. Delete Memory")
 	fmt.Println(strings.Repeat("-", 40))
 
+	start := time.Now()
 	err := client.DeleteMemory(memoryID, "go-example-user", "go-example-agent")
 	if err != nil {
 		return err
 	}
+	printLatency(start)
 
 	fmt.Printf("✓ Deleted memory ID: %s\n", memoryID.String())
 
 	return nil
 }
+
+// exampleBatchIngestion demonstrates creating several memories in one
+// request.
+func exampleBatchIngestion(client *Client) ([]Memory, error) {
+	fmt.Println("\n" + strings.Repeat("-", 40))
+	fmt.Println("7. Batch Ingestion")
+	fmt.Println(strings.Repeat("-", 40))
+
+	// Store the facts verbatim; inference would rewrite them.
+	infer := false
+	req := &BatchCreateMemoryRequest{
+		UserID:  "go-example-user",
+		AgentID: "go-example-agent",
+		Infer:   &infer,
+		Memories: []BatchMemoryItem{
+			{Content: "User is vegetarian", Importance: 0.9},
+			{Content: "User is training for a marathon in April"},
+			{Content: "User prefers green tea in the afternoon"},
+		},
+	}
+
+	start := time.Now()
+	result, err := client.BatchCreateMemories(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	printLatency(start)
+
+	fmt.Printf("✓ Created %d of %d memories\n", result.CreatedCount, result.Total)
+	for _, f := range result.Failed {
+		fmt.Printf("  ✗ [%d] %s: %s\n", f.Index, f.Content, f.Error)
+	}
+
+	return result.Memories, nil
+}
+
+// exampleStreamingSearch demonstrates receiving search results as they
+// are ranked.
+func exampleStreamingSearch(client *Client) error {
+	fmt.Println("\n" + strings.Repeat("-", 40))
+	fmt.Println("8. Streaming Search")
+	fmt.Println(strings.Repeat("-", 40))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req := &SearchMemoryRequest{
+		Query:   "What does the user drink?",
+		UserID:  "go-example-user",
+		AgentID: "go-example-agent",
+		Limit:   5,
+	}
+
+	start := time.Now()
+	results, errc, err := client.With(WithExperimental(experimental.Streaming)).SearchMemoriesStream(ctx, req)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for result := range results {
+		n++
+		if n == 1 {
+			fmt.Printf("  First result after %s\n", time.Since(start).Round(time.Millisecond))
+		}
+		fmt.Printf("  [%d] Score: %.4f  %s\n", n, result.Score, result.Content)
+	}
+	if err := <-errc; err != nil {
+		return err
+	}
+	printLatency(start)
+
+	fmt.Printf("✓ Streamed %d results\n", n)
+	return nil
+}
+
+// exampleRetrievalPipeline demonstrates explained, hierarchical
+// retrieval: summaries first, expanded into their details when they
+// score well.
+func exampleRetrievalPipeline(client *Client) error {
+	fmt.Println("\n" + strings.Repeat("-", 40))
+	fmt.Println("9. Retrieval Pipeline")
+	fmt.Println(strings.Repeat("-", 40))
+
+	req := &SearchMemoryRequest{
+		Query:   "What are the user's health habits?",
+		UserID:  "go-example-user",
+		AgentID: "go-example-agent",
+		Limit:   5,
+		Explain: true,
+	}
+
+	start := time.Now()
+	results, err := client.HierarchicalSearch(context.Background(), req, HierarchicalSearchOptions{})
+	if err != nil {
+		return err
+	}
+	printLatency(start)
+
+	fmt.Printf("✓ Query: %q\n", results.Query)
+	for i, r := range results.Results {
+		fmt.Printf("  [%d] Score: %.4f  %s\n", i+1, r.Summary.Score, r.Summary.Content)
+		if b := scoreBreakdown(r.Summary); b != "" {
+			fmt.Printf("      %s\n", b)
+		}
+		for _, d := range r.Details {
+			fmt.Printf("      ↳ Score: %.4f  %s\n", d.Score, d.Content)
+		}
+	}
+
+	return nil
+}
+
+// printLatency prints the time elapsed since start.
+func printLatency(start time.Time) {
+	fmt.Printf("  Latency: %s\n", time.Since(start).Round(time.Millisecond))
+}
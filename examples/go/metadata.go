@@ -0,0 +1,48 @@
+package main
+
+// WithDefaultMetadata merges md into the metadata of every create and
+// update request, e.g. to record app version, environment or region on
+// every write. Keys set explicitly on a request take precedence.
+//
+// Applied to a child client (see Client.With), md is merged over the
+// parent's defaults.
+func WithDefaultMetadata(md map[string]interface{}) Option {
+	return func(c *Client) {
+		c.defaultMetadata = mergeMetadata(c.defaultMetadata, md)
+	}
+}
+
+// applyDefaults returns req with the client's default metadata merged in,
+// its default scope set if req has none and its TTL resolved to ExpiresAt,
+// so that queued writes keep the expiry they were made with. req itself
+// is not modified.
+func (c *Client) applyDefaults(req *CreateMemoryRequest) *CreateMemoryRequest {
+	resolveTTL := req.TTL > 0 && req.ExpiresAt == nil
+	if c.defaultMetadata == nil && (c.defaultScope == "" || req.Scope != "") && !resolveTTL {
+		return req
+	}
+	withDefaults := *req
+	if resolveTTL {
+		withDefaults.ExpiresAt = expiresAt(req.TTL)
+	}
+	if c.defaultMetadata != nil {
+		withDefaults.Metadata = mergeMetadata(c.defaultMetadata, req.Metadata)
+	}
+	if withDefaults.Scope == "" {
+		withDefaults.Scope = c.defaultScope
+	}
+	return &withDefaults
+}
+
+// mergeMetadata returns a new map holding base overlaid with override.
+// Neither argument is modified.
+func mergeMetadata(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
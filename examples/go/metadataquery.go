@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// MetadataFilter is a predicate over memory fields and metadata, in the
+// server's filter syntax. Keys that are not memory columns (user_id,
+// agent_id, run_id, created_at, ...) refer to metadata fields. Build
+// filters with the Meta* helpers, or write them literally:
+//
+//	MetadataFilter{"category": "travel", "priority": map[string]interface{}{"gte": 3}}
+type MetadataFilter map[string]interface{}
+
+// MetaEq matches memories whose field equals value.
+func MetaEq(field string, value interface{}) MetadataFilter {
+	return MetadataFilter{field: value}
+}
+
+// MetaNe matches memories whose field differs from value.
+func MetaNe(field string, value interface{}) MetadataFilter {
+	return metaOp(field, "ne", value)
+}
+
+// MetaGt matches memories whose field is greater than value.
+func MetaGt(field string, value interface{}) MetadataFilter {
+	return metaOp(field, "gt", value)
+}
+
+// MetaGte matches memories whose field is greater than or equal to value.
+func MetaGte(field string, value interface{}) MetadataFilter {
+	return metaOp(field, "gte", value)
+}
+
+// MetaLt matches memories whose field is less than value.
+func MetaLt(field string, value interface{}) MetadataFilter {
+	return metaOp(field, "lt", value)
+}
+
+// MetaLte matches memories whose field is less than or equal to value.
+func MetaLte(field string, value interface{}) MetadataFilter {
+	return metaOp(field, "lte", value)
+}
+
+// MetaIn matches memories whose field is one of values.
+func MetaIn(field string, values ...interface{}) MetadataFilter {
+	return metaOp(field, "in", values)
+}
+
+// MetaNotIn matches memories whose field is none of values.
+func MetaNotIn(field string, values ...interface{}) MetadataFilter {
+	return metaOp(field, "nin", values)
+}
+
+// MetaLike matches memories whose field matches a SQL LIKE pattern.
+func MetaLike(field, pattern string) MetadataFilter {
+	return metaOp(field, "like", pattern)
+}
+
+// MetaAnd matches memories matching all filters.
+func MetaAnd(filters ...MetadataFilter) MetadataFilter {
+	return MetadataFilter{"AND": filters}
+}
+
+// MetaOr matches memories matching any of filters.
+func MetaOr(filters ...MetadataFilter) MetadataFilter {
+	return MetadataFilter{"OR": filters}
+}
+
+func metaOp(field, op string, value interface{}) MetadataFilter {
+	return MetadataFilter{field: map[string]interface{}{op: value}}
+}
+
+// metadataQueryRequest is the request body of a metadata query.
+type metadataQueryRequest struct {
+	Filters MetadataFilter `json:"filters"`
+	Pagination
+}
+
+// QueryByMetadata lists the memories matching filter, without a
+// natural-language query and without calling the embedder.
+// The caller identity for visibility is the user_id and agent_id the
+// filter matches exactly, if any.
+func (c *Client) QueryByMetadata(ctx context.Context, filter MetadataFilter, page Pagination) (*MemoryList, error) {
+	req := &metadataQueryRequest{Filters: filter, Pagination: page}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories/query", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemoryList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("query by metadata failed: %s", resp.Message)
+	}
+
+	userID, _ := filter["user_id"].(string)
+	agentID, _ := filter["agent_id"].(string)
+	filterVisibleMemories(&resp.Data, userID, agentID)
+	return &resp.Data, nil
+}
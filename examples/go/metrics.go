@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsRecorder receives one observation per HTTP request issued by the
+// client. Implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	// ObserveRequest records a completed request. endpoint is the
+	// normalized path template (e.g. "/api/v1/memories/{id}"), status is 0
+	// when no response was received, and err is the transport error, if any.
+	ObserveRequest(method, endpoint string, status int, duration time.Duration, err error)
+}
+
+// WithMetrics records request counts, latencies and errors per endpoint.
+func WithMetrics(rec MetricsRecorder) Option {
+	return WithMiddleware(metricsMiddleware(rec))
+}
+
+// metricsMiddleware reports every round trip to rec.
+func metricsMiddleware(rec MetricsRecorder) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			rec.ObserveRequest(req.Method, normalizeEndpoint(req.URL.EscapedPath()), status, time.Since(start), err)
+			return resp, err
+		}
+	}
+}
+
+// idSegments maps the collections in request paths to the placeholder of
+// the ID segment following them.
+var idSegments = map[string]string{
+	"users":              "{user_id}",
+	"agents":             "{agent_id}",
+	"runs":               "{run_id}",
+	"jobs":               "{job_id}",
+	"entities":           "{entity_id}",
+	"webhooks":           "{webhook_id}",
+	"search-pins":        "{pin_id}",
+	"suppressions":       "{suppression_id}",
+	"relation-types":     "{name}",
+	"retrieval-profiles": "{name}",
+	"retention-policies": "{name}",
+	"metadata-schemas":   "{name}",
+	"saved-searches":     "{name}",
+	"tiering-rules":      "{name}",
+}
+
+// routeSegments are the fixed segments that may follow a collection in
+// idSegments, which are not IDs.
+var routeSegments = map[string]bool{
+	"duplicates": true,
+}
+
+// normalizeEndpoint replaces IDs in a request path with placeholders so
+// metrics are aggregated per endpoint rather than per resource.
+func normalizeEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		if _, err := strconv.ParseInt(seg, 10, 64); err == nil {
+			segments[i] = "{id}"
+			continue
+		}
+		if i > 0 && !routeSegments[seg] {
+			if placeholder, ok := idSegments[segments[i-1]]; ok {
+				segments[i] = placeholder
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// maxMetricEndpoints bounds the endpoints a recorder keeps series for;
+// requests to further endpoints, e.g. paths normalizeEndpoint does not
+// know, are recorded under otherEndpoint.
+const maxMetricEndpoints = 500
+
+// otherEndpoint is the endpoint of requests beyond maxMetricEndpoints.
+const otherEndpoint = "{other}"
+
+// =============================================================================
+// Prometheus Recorder
+// =============================================================================
+
+// DefaultLatencyBuckets are the histogram buckets, in seconds, used by
+// PrometheusRecorder when none are supplied.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// PrometheusRecorder is a MetricsRecorder that exposes its data in the
+// Prometheus text exposition format. Mount it on a metrics server:
+//
+//	rec := NewPrometheusRecorder(nil)
+//	client := NewClient(baseURL, apiKey, WithMetrics(rec))
+//	http.Handle("/metrics", rec)
+//
+// It exports:
+//
+//	powermem_client_requests_total{method,endpoint,code}
+//	powermem_client_request_errors_total{method,endpoint}
+//	powermem_client_request_duration_seconds{method,endpoint} (histogram)
+//
+// IDs in paths are replaced by placeholders, e.g.
+// "/api/v1/jobs/{job_id}", and beyond 500 endpoints requests are counted
+// under endpoint="{other}", so that the number of series stays bounded.
+type PrometheusRecorder struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	errors    map[endpointKey]uint64
+	latencies map[endpointKey]*histogram
+}
+
+type endpointKey struct {
+	method   string
+	endpoint string
+}
+
+type requestKey struct {
+	endpointKey
+	code string
+}
+
+type histogram struct {
+	counts []uint64 // cumulative counts are computed on export
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusRecorder creates a recorder with the given latency buckets
+// in seconds. If buckets is nil, DefaultLatencyBuckets is used.
+func NewPrometheusRecorder(buckets []float64) *PrometheusRecorder {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &PrometheusRecorder{
+		buckets:   b,
+		requests:  make(map[requestKey]uint64),
+		errors:    make(map[endpointKey]uint64),
+		latencies: make(map[endpointKey]*histogram),
+	}
+}
+
+// ObserveRequest implements MetricsRecorder.
+func (p *PrometheusRecorder) ObserveRequest(method, endpoint string, status int, duration time.Duration, err error) {
+	ek := endpointKey{method: method, endpoint: endpoint}
+	code := strconv.Itoa(status)
+	if status == 0 {
+		code = "error"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.latencies[ek]
+	if !ok {
+		if len(p.latencies) >= maxMetricEndpoints {
+			ek.endpoint = otherEndpoint
+			h = p.latencies[ek]
+		}
+		if h == nil {
+			h = &histogram{counts: make([]uint64, len(p.buckets))}
+			p.latencies[ek] = h
+		}
+	}
+
+	p.requests[requestKey{endpointKey: ek, code: code}]++
+	if err != nil || status >= 400 {
+		p.errors[ek]++
+	}
+
+	seconds := duration.Seconds()
+	for i, le := range p.buckets {
+		if seconds <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP writes all metrics in the Prometheus text exposition format.
+func (p *PrometheusRecorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP powermem_client_requests_total Total PowerMem API requests by endpoint and status code.")
+	fmt.Fprintln(w, "# TYPE powermem_client_requests_total counter")
+	reqKeys := make([]requestKey, 0, len(p.requests))
+	for k := range p.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		if reqKeys[i].endpointKey != reqKeys[j].endpointKey {
+			return lessEndpoint(reqKeys[i].endpointKey, reqKeys[j].endpointKey)
+		}
+		return reqKeys[i].code < reqKeys[j].code
+	})
+	for _, k := range reqKeys {
+		fmt.Fprintf(w, "powermem_client_requests_total{method=%q,endpoint=%q,code=%q} %d\n",
+			k.method, k.endpoint, k.code, p.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP powermem_client_request_errors_total Failed PowerMem API requests (transport errors and HTTP status >= 400).")
+	fmt.Fprintln(w, "# TYPE powermem_client_request_errors_total counter")
+	for _, k := range sortedEndpoints(p.errors) {
+		fmt.Fprintf(w, "powermem_client_request_errors_total{method=%q,endpoint=%q} %d\n",
+			k.method, k.endpoint, p.errors[k])
+	}
+
+	fmt.Fprintln(w, "# HELP powermem_client_request_duration_seconds PowerMem API request latency.")
+	fmt.Fprintln(w, "# TYPE powermem_client_request_duration_seconds histogram")
+	for _, k := range sortedEndpoints(p.latencies) {
+		h := p.latencies[k]
+		var cumulative uint64
+		for i, le := range p.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "powermem_client_request_duration_seconds_bucket{method=%q,endpoint=%q,le=%q} %d\n",
+				k.method, k.endpoint, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "powermem_client_request_duration_seconds_bucket{method=%q,endpoint=%q,le=\"+Inf\"} %d\n",
+			k.method, k.endpoint, h.count)
+		fmt.Fprintf(w, "powermem_client_request_duration_seconds_sum{method=%q,endpoint=%q} %g\n",
+			k.method, k.endpoint, h.sum)
+		fmt.Fprintf(w, "powermem_client_request_duration_seconds_count{method=%q,endpoint=%q} %d\n",
+			k.method, k.endpoint, h.count)
+	}
+}
+
+func lessEndpoint(a, b endpointKey) bool {
+	if a.endpoint != b.endpoint {
+		return a.endpoint < b.endpoint
+	}
+	return a.method < b.method
+}
+
+func sortedEndpoints[V any](m map[endpointKey]V) []endpointKey {
+	keys := make([]endpointKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessEndpoint(keys[i], keys[j]) })
+	return keys
+}
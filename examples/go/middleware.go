@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// RoundTripFunc performs a single HTTP exchange with the PowerMem API.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc to observe or modify requests and
+// responses, e.g. for logging, auth refresh, header mutation or metrics.
+//
+// A middleware must call next exactly once unless it short-circuits the
+// request with its own response or error.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Option configures a Client at construction time.
+type Option func(*Client)
+
+// WithMiddleware appends middleware to the client's interceptor chain.
+// Middleware registered first runs outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// Use appends middleware to the interceptor chain of an existing client.
+// It is not safe to call Use concurrently with in-flight requests.
+func (c *Client) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+// roundTrip composes the middleware chain around the underlying HTTP client.
+func (c *Client) roundTrip() RoundTripFunc {
+	return c.chain(c.httpClient().Do)
+}
+
+// streamRoundTrip is roundTrip for streaming responses. The HTTP client's
+// Timeout covers reading the whole body, so it is lifted; streams are
+// bounded by their context instead.
+func (c *Client) streamRoundTrip() RoundTripFunc {
+	client := *c.httpClient()
+	client.Timeout = 0
+	rt := c.chain(client.Do)
+	return func(req *http.Request) (*http.Response, error) {
+		return rt(req.WithContext(context.WithValue(req.Context(), streamingKey{}, true)))
+	}
+}
+
+// streamingKey marks the context of requests sent by streamRoundTrip.
+type streamingKey struct{}
+
+// isStreaming reports whether resp is a stream, e.g. server-sent events or
+// an export, whose body must be left to the caller to read.
+func isStreaming(req *http.Request, resp *http.Response) bool {
+	if req.Context().Value(streamingKey{}) != nil {
+		return true
+	}
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mt {
+	case "text/event-stream", "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return true
+	}
+	return false
+}
+
+// maxCapturedBody bounds the response body middleware captures for logs
+// and support bundles.
+const maxCapturedBody = 64 << 10
+
+// peekBody returns up to maxCapturedBody bytes of resp's body, and whether
+// there was more, leaving the whole body for the caller to read. Nothing
+// is read from streaming responses. If reading fails, the body is closed
+// and the response must be discarded.
+func peekBody(req *http.Request, resp *http.Response) (prefix []byte, truncated bool, err error) {
+	if isStreaming(req, resp) {
+		return nil, false, nil
+	}
+	prefix, err = io.ReadAll(io.LimitReader(resp.Body, maxCapturedBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, false, err
+	}
+	body := resp.Body
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+	if len(prefix) > maxCapturedBody {
+		prefix, truncated = prefix[:maxCapturedBody], true
+	}
+	return prefix, truncated, nil
+}
+
+// requestClientKey binds the client sending a request to its context.
+type requestClientKey struct{}
+
+// requestClient returns the client sending req, so that middleware shared
+// with children created by With uses the sending child's configuration.
+func requestClient(req *http.Request) *Client {
+	c, _ := req.Context().Value(requestClientKey{}).(*Client)
+	return c
+}
+
+// chain composes the middleware chain around do.
+func (c *Client) chain(do RoundTripFunc) RoundTripFunc {
+	next := c.captureMiddleware(do)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+	return next
+}
+
+// httpClient returns the configured HTTP client, falling back to a
+// default client with a 30s timeout.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// =============================================================================
+// Built-in Middleware
+// =============================================================================
+
+// HeaderMiddleware sets the given headers on every outgoing request.
+func HeaderMiddleware(headers map[string]string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package minimal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/oceanbase/powermem/examples/go/internal/wire"
+)
+
+// Memory is a stored memory. Score is set on search results.
+type Memory struct {
+	ID      int64
+	Content string
+	UserID  string
+	AgentID string
+	Score   float64
+}
+
+// Write is a memory to create.
+type Write struct {
+	UserID  string
+	AgentID string
+	RunID   string
+	Content string
+
+	// Infer extracts facts from Content on the server instead of storing
+	// it verbatim.
+	Infer bool
+}
+
+// Query is a search.
+type Query struct {
+	UserID  string
+	AgentID string
+	Text    string
+
+	// Limit bounds the number of results; 0 uses the server default.
+	Limit int
+}
+
+// Health checks that the server is up.
+func (c *Client) Health(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodGet, "/api/v1/system/health", nil)
+	return err
+}
+
+// Add creates a memory and returns the memories stored, more than one if
+// inference extracted several facts.
+func (c *Client) Add(ctx context.Context, w Write) ([]Memory, error) {
+	if w.Content == "" {
+		return nil, errors.New("powermem: content is empty")
+	}
+	var body wire.Object
+	body.Str("content", w.Content)
+	body.Str("user_id", w.UserID)
+	body.Str("agent_id", w.AgentID)
+	body.Str("run_id", w.RunID)
+	body.Bool("infer", w.Infer)
+
+	data, err := c.do(ctx, http.MethodPost, "/api/v1/memories", body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return memories(data.List()), nil
+}
+
+// Search returns the memories matching q, best first.
+func (c *Client) Search(ctx context.Context, q Query) ([]Memory, error) {
+	var body wire.Object
+	body.Str("query", q.Text)
+	body.Str("user_id", q.UserID)
+	body.Str("agent_id", q.AgentID)
+	body.Int("limit", q.Limit)
+
+	data, err := c.do(ctx, http.MethodPost, "/api/v1/memories/search", body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return memories(data.Get("results").List()), nil
+}
+
+// Get returns a memory by ID.
+func (c *Client) Get(ctx context.Context, id int64) (*Memory, error) {
+	data, err := c.do(ctx, http.MethodGet, "/api/v1/memories/"+strconv.FormatInt(id, 10), nil)
+	if err != nil {
+		return nil, err
+	}
+	m := memory(data)
+	return &m, nil
+}
+
+// List returns up to limit of a user's memories, newest first, skipping
+// offset.
+func (c *Client) List(ctx context.Context, userID string, limit, offset int) ([]Memory, error) {
+	params := url.Values{}
+	if userID != "" {
+		params.Set("user_id", userID)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+	path := "/api/v1/memories"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	data, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return memories(data.Get("memories").List()), nil
+}
+
+// Delete deletes a memory.
+func (c *Client) Delete(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodDelete, "/api/v1/memories/"+strconv.FormatInt(id, 10), nil)
+	return err
+}
+
+func memories(values []wire.Value) []Memory {
+	ms := make([]Memory, 0, len(values))
+	for _, v := range values {
+		ms = append(ms, memory(v))
+	}
+	return ms
+}
+
+func memory(v wire.Value) Memory {
+	return Memory{
+		ID:      v.Get("memory_id").ID(),
+		Content: v.Get("content").Str(),
+		UserID:  v.Get("user_id").Str(),
+		AgentID: v.Get("agent_id").Str(),
+		Score:   v.Get("score").Float(),
+	}
+}
@@ -0,0 +1,102 @@
+// Package minimal is a reduced PowerMem client for TinyGo, embedded devices
+// and edge functions. It covers the core memory operations only: health,
+// create, get, list, search and delete.
+//
+// Unlike the full client it has no reflection-based JSON encoding, no
+// generics and no background goroutines, and it leaves out the heavy
+// subsystems entirely: realtime connections, the change feed, offline
+// queues, caches, attachments, receipts and the command line tools. Only
+// net/http and a streaming JSON tokenizer are linked in.
+//
+//	c := minimal.New("http://powermem.local:8000", apiKey)
+//	stored, err := c.Add(ctx, minimal.Write{UserID: "thermostat-1", Content: "User prefers 20°C at night"})
+//	results, err := c.Search(ctx, minimal.Query{UserID: "thermostat-1", Text: "temperature", Limit: 3})
+package minimal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/internal/wire"
+)
+
+// Client is a minimal PowerMem client.
+type Client struct {
+	// BaseURL is the base URL of the PowerMem API server.
+	BaseURL string
+
+	// APIKey, if set, is sent in the X-API-Key header.
+	APIKey string
+
+	// BearerToken, if set, is sent in the Authorization header, e.g. a
+	// JWT issued by an API gateway.
+	BearerToken string
+
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+}
+
+// New returns a client with a 30 second timeout.
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Error is an error response from the server. Other errors mean the
+// server could not be reached.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	msg := "powermem: " + strconv.Itoa(e.StatusCode)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// do sends a request with the JSON body, if any, and returns the data of
+// the API response envelope.
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (wire.Value, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, r)
+	if err != nil {
+		return wire.Value{}, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	wire.SetAuth(req.Header, c.APIKey, c.BearerToken)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return wire.Value{}, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return wire.Value{}, err
+	}
+
+	data, apiErr := wire.Envelope(resp.StatusCode, resp.Header.Get("Content-Type"), respBody)
+	if apiErr != nil {
+		return wire.Value{}, &Error{StatusCode: apiErr.StatusCode, Code: apiErr.Code, Message: apiErr.Message}
+	}
+	return data, nil
+}
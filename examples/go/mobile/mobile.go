@@ -0,0 +1,324 @@
+// Package mobile is the PowerMem API for iOS and Android apps, bound with
+// gomobile:
+//
+//	gomobile bind -target=android -o powermem.aar ./mobile
+//	gomobile bind -target=ios -o PowerMem.xcframework ./mobile
+//
+// It wraps the minimal client with an on-device search cache and an
+// offline write queue, both kept in a directory of the app's, so an
+// assistant keeps working without connectivity. The queue and the cache
+// are the ones behind the full client's offline queue and
+// stale-while-revalidate search. The API only uses types
+// gomobile can bind: strings, numbers, bools, errors and pointers to the
+// structs declared here. Calls block, so make them off the UI thread.
+package mobile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/internal/offline"
+	"github.com/oceanbase/powermem/examples/go/minimal"
+)
+
+// maxCachedSearches bounds the searches kept for offline use; the least
+// recently used is evicted first.
+const maxCachedSearches = 200
+
+// Client is a PowerMem client for mobile apps. It is safe for concurrent
+// use.
+type Client struct {
+	c     *minimal.Client
+	dir   string
+	queue *offline.Log
+	cache *offline.Cache[[]minimal.Memory]
+
+	// syncMu serializes uploads of queued writes, which keeps them in
+	// order.
+	syncMu sync.Mutex
+
+	mu      sync.Mutex
+	timeout time.Duration
+	pending int
+}
+
+// queuedWrite is a write made while the server was unreachable.
+type queuedWrite struct {
+	UserID  string `json:"user_id"`
+	Content string `json:"content"`
+	Infer   bool   `json:"infer"`
+}
+
+// NewClient returns a client for the server at baseURL. dataDir is a
+// directory private to the app, e.g. Context.getFilesDir() on Android or
+// the Application Support directory on iOS; the cache and the queued
+// writes of a previous run are loaded from it.
+func NewClient(baseURL, apiKey, dataDir string) (*Client, error) {
+	queue, pending, err := offline.Open(dataDir, "queue-", func(line []byte) bool {
+		var w queuedWrite
+		return json.Unmarshal(line, &w) == nil && w.Content != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		c:       minimal.New(baseURL, apiKey),
+		dir:     dataDir,
+		queue:   queue,
+		cache:   offline.NewCache[[]minimal.Memory](maxCachedSearches),
+		timeout: 15 * time.Second,
+		pending: pending,
+	}
+	var cached []offline.Entry[[]minimal.Memory]
+	if err := c.load("cache.json", &cached); err != nil {
+		return nil, err
+	}
+	c.cache.Load(cached)
+	return c, nil
+}
+
+// SetTimeout sets the timeout of each request in seconds. Default 15.
+func (c *Client) SetTimeout(seconds int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = time.Duration(seconds) * time.Second
+}
+
+// Memory is a stored memory. Score is set on search results.
+type Memory struct {
+	ID      int64
+	Content string
+	UserID  string
+	Score   float64
+}
+
+// MemoryList is a list of memories; gomobile cannot bind slices of
+// structs.
+type MemoryList struct {
+	// Cached is set when the server was unreachable and the list comes
+	// from the on-device cache.
+	Cached bool
+
+	memories []minimal.Memory
+}
+
+// Len returns the number of memories.
+func (l *MemoryList) Len() int {
+	return len(l.memories)
+}
+
+// Get returns the i-th memory.
+func (l *MemoryList) Get(i int) *Memory {
+	m := l.memories[i]
+	return &Memory{ID: m.ID, Content: m.Content, UserID: m.UserID, Score: m.Score}
+}
+
+// AddResult is the outcome of Add.
+type AddResult struct {
+	// Queued is set when the server was unreachable and the write was
+	// queued for Sync.
+	Queued bool
+
+	memories []minimal.Memory
+}
+
+// Memories returns the memories stored, empty if the write was queued.
+func (r *AddResult) Memories() *MemoryList {
+	return &MemoryList{memories: r.memories}
+}
+
+// Health returns an error if the server cannot be reached.
+func (c *Client) Health() error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.c.Health(ctx)
+}
+
+// Add creates a memory for userID. When the server is unreachable the
+// write is queued on the device and uploaded by Sync, and the result is
+// marked Queued. Infer extracts facts from content on the server.
+func (c *Client) Add(userID, content string, infer bool) (*AddResult, error) {
+	if err := c.sync(); err != nil && !retryable(err) {
+		return nil, err
+	}
+
+	if c.Pending() == 0 {
+		ctx, cancel := c.context()
+		defer cancel()
+		stored, err := c.c.Add(ctx, minimal.Write{UserID: userID, Content: content, Infer: infer})
+		if err == nil {
+			return &AddResult{memories: stored}, nil
+		}
+		// A write whose request was sent may have been stored; queueing
+		// it could store it twice.
+		if !offline.NotSent(err) {
+			return nil, err
+		}
+	}
+
+	// Queue behind earlier writes, keeping them in order.
+	line, err := json.Marshal(queuedWrite{UserID: userID, Content: content, Infer: infer})
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.queue.Append(line); err != nil {
+		return nil, err
+	}
+	c.pending++
+	return &AddResult{Queued: true}, nil
+}
+
+// Search returns up to limit of userID's memories matching query, best
+// first. When the server is unreachable it returns the results of the
+// same search cached on the device, if any, marked Cached.
+func (c *Client) Search(userID, query string, limit int) (*MemoryList, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+	results, err := c.c.Search(ctx, minimal.Query{UserID: userID, Text: query, Limit: limit})
+	key := userID + "\x00" + query
+
+	if err != nil {
+		cached, _, ok := c.cache.Get(key)
+		if !ok || !unreachable(err) {
+			return nil, err
+		}
+		if limit > 0 && len(cached) > limit {
+			cached = cached[:limit]
+		}
+		return &MemoryList{Cached: true, memories: cached}, nil
+	}
+
+	c.cache.Put(key, results)
+	// The cache is best effort; a failed save only loses offline results.
+	_ = c.saveCache()
+	return &MemoryList{memories: results}, nil
+}
+
+// Delete deletes a memory. It needs the server.
+func (c *Client) Delete(id int64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.c.Delete(ctx, id)
+}
+
+// Pending returns the number of queued writes.
+func (c *Client) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending
+}
+
+// Sync uploads the queued writes in order, e.g. when the app regains
+// connectivity, and returns the number still pending. Writes the server
+// rejects, or that fail once sent since the server may have stored them,
+// are dropped.
+func (c *Client) Sync() (int, error) {
+	err := c.sync()
+	return c.Pending(), err
+}
+
+// ClearCache removes the cached search results, e.g. on sign-out.
+func (c *Client) ClearCache() error {
+	c.cache.Clear()
+	return c.saveCache()
+}
+
+// sync uploads queued writes until the queue is empty or a write has to
+// stay queued.
+func (c *Client) sync() error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+	for {
+		seg, err := c.queue.Next()
+		if err != nil || seg == "" {
+			return err
+		}
+		records, err := c.queue.Read(seg)
+		if err != nil {
+			return err
+		}
+
+		for i, record := range records {
+			var w queuedWrite
+			json.Unmarshal(record, &w)
+			ctx, cancel := c.context()
+			_, err := c.c.Add(ctx, minimal.Write{UserID: w.UserID, Content: w.Content, Infer: w.Infer})
+			cancel()
+			if err != nil && retryable(err) {
+				c.queue.Rewrite(seg, records[i:])
+				return err
+			}
+			c.mu.Lock()
+			c.pending--
+			c.mu.Unlock()
+		}
+		if err := c.queue.Remove(seg); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	c.mu.Lock()
+	timeout := c.timeout
+	c.mu.Unlock()
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// load reads the JSON file name of the data directory into v, leaving v
+// alone if the file does not exist.
+func (c *Client) load(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveCache replaces the cache file of the data directory with the
+// cached searches.
+func (c *Client) saveCache() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.Marshal(c.cache.Entries())
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, "cache.json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// retryable reports whether a queued write that failed with err stays
+// queued: its request was not sent, or the server asked to slow down.
+func retryable(err error) bool {
+	var apiErr *minimal.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return offline.NotSent(err)
+}
+
+// unreachable reports whether err means the server could not answer, as
+// opposed to an error response, so that cached results may stand in.
+func unreachable(err error) bool {
+	var apiErr *minimal.Error
+	if errors.As(err, &apiErr) {
+		return offline.GatewayDown(apiErr.StatusCode)
+	}
+	return offline.NoResponse(err)
+}
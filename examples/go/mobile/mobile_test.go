@@ -0,0 +1,133 @@
+package mobile
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// memoryServer stores writes and answers searches with them.
+type memoryServer struct {
+	mu     sync.Mutex
+	writes []string
+	status int
+}
+
+func (s *memoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	var data interface{}
+	switch r.URL.Path {
+	case "/api/v1/memories":
+		s.writes = append(s.writes, body["content"].(string))
+		data = []map[string]interface{}{{"memory_id": len(s.writes), "content": body["content"]}}
+	case "/api/v1/memories/search":
+		var results []map[string]interface{}
+		for i, c := range s.writes {
+			results = append(results, map[string]interface{}{"memory_id": i + 1, "content": c, "score": 1})
+		}
+		data = map[string]interface{}{"results": results}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+}
+
+// freeAddr returns an address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// serve starts s on addr.
+func serve(t *testing.T, addr string, s http.Handler) *httptest.Server {
+	t.Helper()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	srv := httptest.NewUnstartedServer(s)
+	srv.Listener.Close()
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOfflineWritesAndSearches(t *testing.T) {
+	addr := freeAddr(t)
+	dir := t.TempDir()
+	ms := &memoryServer{}
+	srv := serve(t, addr, ms)
+
+	c, err := NewClient("http://"+addr, "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Add("u1", "likes tea", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Search("u1", "tea", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Close()
+	res, err := c.Add("u1", "likes coffee", false)
+	if err != nil || !res.Queued {
+		t.Fatalf("Add with the server down: %+v, %v; want queued", res, err)
+	}
+	list, err := c.Search("u1", "tea", 5)
+	if err != nil || !list.Cached || list.Len() != 1 || list.Get(0).Content != "likes tea" {
+		t.Fatalf("Search with the server down: %+v, %v; want the cached result", list, err)
+	}
+
+	// A new process finds the queued write and the cached search.
+	c, err = NewClient("http://"+addr, "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Pending() != 1 {
+		t.Errorf("Pending() = %d after restart, want 1", c.Pending())
+	}
+	if list, err := c.Search("u1", "tea", 5); err != nil || !list.Cached {
+		t.Errorf("Search after restart: %+v, %v; want the cached result", list, err)
+	}
+
+	serve(t, addr, ms)
+	if pending, err := c.Sync(); err != nil || pending != 0 {
+		t.Fatalf("Sync = %d, %v", pending, err)
+	}
+	if len(ms.writes) != 2 || ms.writes[1] != "likes coffee" {
+		t.Errorf("server got %q", ms.writes)
+	}
+}
+
+func TestErrorsAfterSendingAreNotQueued(t *testing.T) {
+	ms := &memoryServer{status: http.StatusGatewayTimeout}
+	srv := httptest.NewServer(ms)
+	defer srv.Close()
+	c, err := NewClient(srv.URL, "", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server may have stored the write; queueing it could store it
+	// twice.
+	if res, err := c.Add("u1", "likes tea", false); err == nil || res != nil {
+		t.Errorf("Add = %+v, %v; want the server's error", res, err)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("Pending() = %d, want 0", c.Pending())
+	}
+}
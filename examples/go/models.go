@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"strconv"
 	"time"
+
+	"github.com/oceanbase/powermem/examples/go/webhooks"
 )
 
 // MemoryID is a custom type for handling 64-bit memory IDs.
@@ -68,8 +70,9 @@ type APIResponse[T any] struct {
 
 // APIError represents an error response from the API.
 type APIError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
 }
 
 // =============================================================================
@@ -78,14 +81,51 @@ type APIError struct {
 
 // Memory represents a memory record in PowerMem.
 type Memory struct {
-	MemoryID  MemoryID               `json:"memory_id"`
-	Content   string                 `json:"content"`
-	UserID    string                 `json:"user_id,omitempty"`
-	AgentID   string                 `json:"agent_id,omitempty"`
-	RunID     string                 `json:"run_id,omitempty"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
-	CreatedAt *time.Time             `json:"created_at,omitempty"`
-	UpdatedAt *time.Time             `json:"updated_at,omitempty"`
+	MemoryID   MemoryID               `json:"memory_id"`
+	Content    string                 `json:"content"`
+	UserID     string                 `json:"user_id,omitempty"`
+	AgentID    string                 `json:"agent_id,omitempty"`
+	RunID      string                 `json:"run_id,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Visibility Visibility             `json:"visibility,omitempty"`
+	Source     MemorySource           `json:"source,omitempty"`
+	MemoryType MemoryType             `json:"memory_type,omitempty"`
+
+	// Importance is how much the memory matters, in [0, 1]; see
+	// SearchMemoryRequest.ImportanceWeight.
+	Importance float64 `json:"importance,omitempty"`
+
+	// Pinned memories are never updated or deleted by inference and are
+	// returned by every matching search; see PinMemory.
+	Pinned bool `json:"pinned,omitempty"`
+
+	// Categories are the topics the server assigned the memory, e.g.
+	// "food"; see ListCategories.
+	Categories []string `json:"categories,omitempty"`
+
+	// ExpiresAt is when the server deletes the memory, nil for never.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Tier is where the memory's content and vector are stored. Reading a
+	// cold memory by ID rehydrates it transparently.
+	Tier StorageTier `json:"tier,omitempty"`
+
+	// Embedding is the stored vector, returned only when requested with
+	// IncludeEmbeddings.
+	Embedding []float32 `json:"embedding,omitempty"`
+
+	// SupersededIDs are the older memories this memory replaced, e.g.
+	// "lives in Berlin" replacing "lives in Paris". SupersededBy is set
+	// on a memory that a newer one replaced.
+	SupersededIDs []MemoryID `json:"superseded_ids,omitempty"`
+	SupersededBy  *MemoryID  `json:"superseded_by,omitempty"`
+
+	// Superseded holds the replaced memories themselves, returned only
+	// when requested with IncludeSuperseded.
+	Superseded []Memory `json:"superseded,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 }
 
 // MemoryList represents a paginated list of memories.
@@ -96,21 +136,135 @@ type MemoryList struct {
 	Offset   int      `json:"offset"`
 }
 
+// Visibility controls which callers can see a memory in list and search
+// results. The empty value is treated as VisibilityShared.
+type Visibility string
+
+const (
+	// VisibilityPrivate memories are visible only to the agent that
+	// created them, within the owning user's store.
+	VisibilityPrivate Visibility = "private"
+
+	// VisibilityAgent memories are visible to the owning agent across all
+	// users, e.g. knowledge the agent learned.
+	VisibilityAgent Visibility = "agent"
+
+	// VisibilityShared memories are visible to every agent acting for the
+	// owning user.
+	VisibilityShared Visibility = "shared"
+
+	// VisibilityPublic memories are visible to every caller.
+	VisibilityPublic Visibility = "public"
+)
+
+// Scope is the level a memory is shared at. The empty value uses the
+// client's default scope (see WithDefaultScope), else the server's.
+type Scope string
+
+const (
+	// ScopeUser memories belong to one user.
+	ScopeUser Scope = "user"
+
+	// ScopeAgent memories belong to one agent.
+	ScopeAgent Scope = "agent"
+
+	// ScopeRun memories belong to one run and are dropped with it.
+	ScopeRun Scope = "run"
+
+	// ScopeGlobal memories are shared across users and agents.
+	ScopeGlobal Scope = "global"
+)
+
+// MemoryType classifies what a memory holds. The empty value lets the
+// server classify the memory.
+type MemoryType string
+
+const (
+	// MemoryEpisodic memories record events, e.g. "met Alice on Tuesday".
+	MemoryEpisodic MemoryType = "episodic"
+
+	// MemorySemantic memories record facts and preferences, e.g. "is
+	// vegetarian".
+	MemorySemantic MemoryType = "semantic"
+
+	// MemoryProcedural memories record how to do something, e.g. "deploy
+	// with make release".
+	MemoryProcedural MemoryType = "procedural"
+
+	// MemoryWorking memories hold short-lived task context.
+	MemoryWorking MemoryType = "working"
+)
+
 // =============================================================================
 // Create Memory
 // =============================================================================
 
 // CreateMemoryRequest represents the request body for creating a memory.
 type CreateMemoryRequest struct {
-	Content    string                 `json:"content"`
+	Content    string                 `json:"content,omitempty"`
 	UserID     string                 `json:"user_id,omitempty"`
 	AgentID    string                 `json:"agent_id,omitempty"`
 	RunID      string                 `json:"run_id,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 	Filters    map[string]interface{} `json:"filters,omitempty"`
-	Scope      string                 `json:"scope,omitempty"`
-	MemoryType string                 `json:"memory_type,omitempty"`
+	Scope      Scope                  `json:"scope,omitempty"`
+	MemoryType MemoryType             `json:"memory_type,omitempty"`
 	Infer      *bool                  `json:"infer,omitempty"`
+	Visibility Visibility             `json:"visibility,omitempty"`
+
+	// Importance is how much the memory matters, in [0, 1]. Zero lets
+	// the server score it.
+	Importance float64 `json:"importance,omitempty"`
+
+	// Pinned protects the memory from inference and makes it surface in
+	// every matching search; see PinMemory.
+	Pinned bool `json:"pinned,omitempty"`
+
+	// ExpiresAt makes the memory expire at that time, after which the
+	// server deletes it, e.g. for short-lived working memories. TTL sets
+	// ExpiresAt relative to when the request is made instead.
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	TTL       time.Duration `json:"-"`
+
+	// Messages is a chat exchange to memorize instead of Content, see
+	// AddConversation.
+	Messages []Message `json:"messages,omitempty"`
+
+	// Source records where the content came from. Empty lets the server
+	// decide: SourceInferred when Infer extracts facts, else
+	// SourceUserStated.
+	Source MemorySource `json:"source,omitempty"`
+
+	// Receipt asks the server to return a signed receipt for each
+	// created memory.
+	Receipt bool `json:"receipt,omitempty"`
+
+	// Async queues the memory for background processing, which avoids
+	// waiting on slow extraction when Infer is set. The response holds a
+	// single entry carrying the JobID; see WaitForJob.
+	Async bool `json:"async,omitempty"`
+}
+
+// MessageRole is the author role of a conversation message.
+type MessageRole string
+
+const (
+	RoleUser      MessageRole = "user"
+	RoleAssistant MessageRole = "assistant"
+	RoleSystem    MessageRole = "system"
+	RoleTool      MessageRole = "tool"
+)
+
+// Message is one message of a conversation, in the shape agent frameworks
+// emit chat history.
+type Message struct {
+	Role    MessageRole `json:"role"`
+	Content string      `json:"content"`
+
+	// Name distinguishes participants sharing a role, e.g. in a group
+	// chat or a tool's name.
+	Name      string     `json:"name,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
 }
 
 // CreatedMemory represents a simplified memory returned after creation.
@@ -121,6 +275,112 @@ type CreatedMemory struct {
 	AgentID  string                 `json:"agent_id,omitempty"`
 	RunID    string                 `json:"run_id,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Receipt is set when the request asked for one.
+	Receipt *Receipt `json:"receipt,omitempty"`
+
+	// JobID is set instead of MemoryID when the request was Async.
+	JobID string `json:"job_id,omitempty"`
+
+	// SupersededIDs are the older memories this memory replaced, and
+	// PreviousContent the content it replaced when inference updated an
+	// existing memory in place.
+	SupersededIDs   []MemoryID `json:"superseded_ids,omitempty"`
+	PreviousContent string     `json:"previous_memory,omitempty"`
+
+	// Skip is set instead of MemoryID when the memory was intentionally
+	// not stored because of the user's memory settings.
+	Skip *WriteSkip `json:"skip,omitempty"`
+
+	// Entities and Relations are what inference added to the knowledge
+	// graph from this memory, set when the request had Infer enabled.
+	Entities  []Entity   `json:"entities,omitempty"`
+	Relations []Relation `json:"relations,omitempty"`
+}
+
+// Skipped reports whether the memory was intentionally not stored.
+func (m CreatedMemory) Skipped() bool {
+	return m.Skip != nil
+}
+
+// SkipReason is why a write was not stored.
+type SkipReason string
+
+const (
+	// SkipOptedOut means the user has turned memory off.
+	SkipOptedOut SkipReason = "opted_out"
+
+	// SkipTopicDisabled means the content was classified into a topic
+	// the user has disabled.
+	SkipTopicDisabled SkipReason = "topic_disabled"
+)
+
+// WriteSkip describes a memory suppressed at ingest.
+type WriteSkip struct {
+	Reason SkipReason `json:"reason"`
+
+	// Topic and Confidence are the classification that matched, for
+	// SkipTopicDisabled.
+	Topic      string  `json:"topic,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// MaxBatchSize is the maximum number of memories per batch request.
+const MaxBatchSize = 100
+
+// BatchCreateMemoryRequest represents the request body for creating
+// multiple memories in one scope.
+type BatchCreateMemoryRequest struct {
+	Memories []BatchMemoryItem `json:"memories"`
+	UserID   string            `json:"user_id,omitempty"`
+	AgentID  string            `json:"agent_id,omitempty"`
+	RunID    string            `json:"run_id,omitempty"`
+	Infer    *bool             `json:"infer,omitempty"`
+}
+
+// BatchMemoryItem is a single memory in a batch create request.
+type BatchMemoryItem struct {
+	Content    string                 `json:"content"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Filters    map[string]interface{} `json:"filters,omitempty"`
+	Scope      Scope                  `json:"scope,omitempty"`
+	MemoryType MemoryType             `json:"memory_type,omitempty"`
+	Visibility Visibility             `json:"visibility,omitempty"`
+	Source     MemorySource           `json:"source,omitempty"`
+	ExpiresAt  *time.Time             `json:"expires_at,omitempty"`
+	Importance float64                `json:"importance,omitempty"`
+	Pinned     bool                   `json:"pinned,omitempty"`
+
+	// CreatedAt backdates the memory, e.g. to seed fixtures with a
+	// realistic history. Nil uses the time of the request.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// BatchCreateResult represents the response data for a batch create.
+// Memories holds the created memories in request order, skipping failed
+// items; with inference an item may create none or several, so use Items
+// to tell which item created which memories.
+type BatchCreateResult struct {
+	Memories     []Memory          `json:"memories"`
+	Items        []BatchItemResult `json:"items,omitempty"`
+	Total        int               `json:"total"`
+	CreatedCount int               `json:"created_count"`
+	FailedCount  int               `json:"failed_count"`
+	Failed       []BatchFailure    `json:"failed,omitempty"`
+}
+
+// BatchItemResult holds the memories created from the item at Index of a
+// batch request.
+type BatchItemResult struct {
+	Index    int      `json:"index"`
+	Memories []Memory `json:"memories"`
+}
+
+// BatchFailure describes an item of a batch that could not be created.
+type BatchFailure struct {
+	Index   int    `json:"index"`
+	Content string `json:"content"`
+	Error   string `json:"error"`
 }
 
 // =============================================================================
@@ -129,10 +389,62 @@ type CreatedMemory struct {
 
 // UpdateMemoryRequest represents the request body for updating a memory.
 type UpdateMemoryRequest struct {
-	Content  string                 `json:"content,omitempty"`
-	UserID   string                 `json:"user_id,omitempty"`
-	AgentID  string                 `json:"agent_id,omitempty"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Content    string                 `json:"content,omitempty"`
+	UserID     string                 `json:"user_id,omitempty"`
+	AgentID    string                 `json:"agent_id,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Visibility Visibility             `json:"visibility,omitempty"`
+
+	// Importance, if set, replaces the memory's importance; see
+	// SetImportance.
+	Importance *float64 `json:"importance,omitempty"`
+
+	// Pinned, if set, pins or unpins the memory; see PinMemory.
+	Pinned *bool `json:"pinned,omitempty"`
+
+	// ExpiresAt and TTL change when the memory expires, as on create.
+	// Leaving both unset keeps the current expiry.
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	TTL       time.Duration `json:"-"`
+}
+
+// PatchField names a field of a MemoryPatch to change.
+type PatchField string
+
+const (
+	PatchContent    PatchField = "content"
+	PatchMetadata   PatchField = "metadata"
+	PatchVisibility PatchField = "visibility"
+	PatchImportance PatchField = "importance"
+	PatchExpiresAt  PatchField = "expires_at"
+	PatchMemoryType PatchField = "memory_type"
+	PatchPinned     PatchField = "pinned"
+)
+
+// PatchMetadataKey names a single metadata key, so that a patch changes or
+// clears it and leaves the other keys alone.
+func PatchMetadataKey(key string) PatchField {
+	return PatchMetadata + "." + PatchField(key)
+}
+
+// MemoryPatch is a partial update for PatchMemory. Only the fields named
+// in Mask change; a masked field left at its zero value is cleared, e.g.
+// a masked metadata key missing from Metadata is removed.
+type MemoryPatch struct {
+	Mask []PatchField
+
+	Content    string
+	Metadata   map[string]interface{}
+	Visibility Visibility
+	Importance float64
+	ExpiresAt  *time.Time
+	MemoryType MemoryType
+	Pinned     bool
+
+	// UserID and AgentID identify the owner for access checks, as on
+	// update.
+	UserID  string
+	AgentID string
 }
 
 // =============================================================================
@@ -147,14 +459,157 @@ type SearchMemoryRequest struct {
 	RunID   string                 `json:"run_id,omitempty"`
 	Filters map[string]interface{} `json:"filters,omitempty"`
 	Limit   int                    `json:"limit,omitempty"`
+
+	// Rerank re-scores the candidates with a cross-encoder, trading
+	// latency for precision. RerankModel selects the reranker; empty uses
+	// the server default.
+	Rerank      bool   `json:"rerank,omitempty"`
+	RerankModel string `json:"rerank_model,omitempty"`
+
+	// Offset skips that many results, for numbered result pages. Cursor
+	// continues from the NextCursor of a previous page instead, which
+	// stays stable while memories are added; set one or the other.
+	Offset int    `json:"offset,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+
+	// IncludeEmbeddings returns each result's stored vector, e.g. for
+	// client-side clustering or dedup analysis.
+	IncludeEmbeddings bool `json:"include_embeddings,omitempty"`
+
+	// Profile selects a named RetrievalProfile; fields set on the request
+	// override the profile's.
+	Profile string `json:"profile,omitempty"`
+
+	// Mode selects the retrieval method. Empty uses the server default.
+	Mode SearchMode `json:"mode,omitempty"`
+
+	// PreferSummaries returns the summary of a closed run in place of
+	// the run's raw memories once the run ended more than SummaryAfterDays
+	// ago (server default 7), keeping long-past sessions compact.
+	PreferSummaries  bool `json:"prefer_summaries,omitempty"`
+	SummaryAfterDays int  `json:"summary_after_days,omitempty"`
+
+	// MinRelevance drops results whose calibrated relevance is below it.
+	// Unlike a raw score threshold it survives embedding model changes.
+	// It is applied by the client and needs WithScoreCalibration.
+	MinRelevance float64 `json:"-"`
+
+	// TrustWeight overrides the weight of source trust in the ranking
+	// (see SetTrustScores). Zero for none.
+	TrustWeight *float64 `json:"trust_weight,omitempty"`
+
+	// ImportanceWeight overrides the weight of memory importance in the
+	// ranking, so that important memories outrank slightly closer
+	// matches. Zero for none. MinImportance drops results less important
+	// than it.
+	ImportanceWeight *float64 `json:"importance_weight,omitempty"`
+	MinImportance    float64  `json:"min_importance,omitempty"`
+
+	// DetectConflicts reports results that contradict each other in
+	// SearchResults.Conflicts, with both sides and their timestamps,
+	// instead of leaving the ranking to pick one silently. Agents can
+	// then ask a clarifying question.
+	DetectConflicts bool `json:"detect_conflicts,omitempty"`
+
+	// IncludeCold also searches memories in cold storage, which is
+	// slower; matching cold memories are rehydrated.
+	IncludeCold bool `json:"include_cold,omitempty"`
+
+	// MemoryTypes restricts results to memories of these types. Empty
+	// searches all types.
+	MemoryTypes []MemoryType `json:"memory_types,omitempty"`
+
+	// Categories restricts results to memories in any of these
+	// categories; see ListCategories.
+	Categories []string `json:"categories,omitempty"`
+
+	// Explain returns each result's ScoreBreakdown, e.g. to tune
+	// weights and profiles.
+	Explain bool `json:"explain,omitempty"`
+
+	// UseGraph also retrieves memories about entities related to the
+	// query's through the knowledge graph, e.g. memories about Alice's
+	// employer for "where does Alice work". GraphDepth bounds the
+	// relations followed, by UseGraph and SearchModeGraph; 0 uses the
+	// server default. Results reached through the graph carry their
+	// GraphPath.
+	UseGraph   bool `json:"use_graph,omitempty"`
+	GraphDepth int  `json:"graph_depth,omitempty"`
+
+	// GraphWeight overrides the weight of graph proximity in the ranking
+	// of a SearchModeGraph search. Zero ranks memories reached through
+	// the graph by their own score only.
+	GraphWeight *float64 `json:"graph_weight,omitempty"`
 }
 
+// SearchMode selects how a search retrieves candidates.
+type SearchMode string
+
+const (
+	// SearchModeHybrid blends vector and keyword retrieval.
+	SearchModeHybrid SearchMode = "hybrid"
+
+	// SearchModeVector uses embedding similarity only.
+	SearchModeVector SearchMode = "vector"
+
+	// SearchModeKeyword uses BM25 keyword matching only and never calls
+	// the embedding provider.
+	SearchModeKeyword SearchMode = "keyword"
+
+	// SearchModeGraph runs a hybrid search, then expands it with the
+	// memories of the graph neighbors, within GraphDepth relations, of
+	// the entities the query and its top results mention, and blends
+	// them into one ranking. It improves recall for relational questions
+	// such as "who does my manager report to".
+	SearchModeGraph SearchMode = "graph"
+)
+
 // SearchResult represents a single search result.
 type SearchResult struct {
 	MemoryID MemoryID               `json:"memory_id"`
 	Content  string                 `json:"content"`
 	Score    float64                `json:"score"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// RerankScore is the reranker's score when the search asked for
+	// reranking; results are then ordered by it. Score stays the
+	// retrieval score.
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+
+	// Embedding is set when the search asked for IncludeEmbeddings.
+	Embedding []float32 `json:"embedding,omitempty"`
+
+	// Pinned is set when a SearchPin placed or boosted the result, or
+	// the memory itself is pinned (see PinMemory).
+	Pinned bool `json:"pinned,omitempty"`
+
+	// ScoreBreakdown is the contribution of each ranking signal to Score,
+	// e.g. "vector", "keyword" or "recency", set when the search asked
+	// to Explain.
+	ScoreBreakdown map[string]float64 `json:"score_breakdown,omitempty"`
+
+	// Relevance is the calibrated probability in [0, 1] that the result
+	// is relevant, set when the client has a calibration for the model
+	// that scored it.
+	Relevance *float64 `json:"relevance,omitempty"`
+
+	// Source is where the memory came from, and Trust the trust score of
+	// that source when the ranking weighed it.
+	Source MemorySource `json:"source,omitempty"`
+	Trust  *float64     `json:"trust,omitempty"`
+
+	MemoryType MemoryType `json:"memory_type,omitempty"`
+	Importance float64    `json:"importance,omitempty"`
+	Categories []string   `json:"categories,omitempty"`
+
+	UserID     string     `json:"user_id,omitempty"`
+	AgentID    string     `json:"agent_id,omitempty"`
+	Visibility Visibility `json:"visibility,omitempty"`
+
+	// GraphPath is the chain of entities and relations from the query's
+	// entities to the memory, set on results a UseGraph search reached
+	// through the knowledge graph.
+	GraphPath *EntityPath `json:"graph_path,omitempty"`
 }
 
 // SearchResults represents the search response data.
@@ -162,6 +617,81 @@ type SearchResults struct {
 	Results []SearchResult `json:"results"`
 	Total   int            `json:"total"`
 	Query   string         `json:"query"`
+
+	// EmbeddingModel identifies the model and index that produced the
+	// scores, which is the key of score calibrations.
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+
+	// HasMore reports whether another page follows. NextCursor, when
+	// set, is the Cursor for that page.
+	HasMore    bool   `json:"has_more,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// Conflicts are the contradictions among the results, set when the
+	// request asked to DetectConflicts.
+	Conflicts []MemoryConflict `json:"conflicts,omitempty"`
+}
+
+// MemoryConflict is a set of retrieved memories that contradict each
+// other, e.g. "prefers window seats" and "prefers aisle seats". Memories
+// replaced by a newer one (see Memory.SupersededBy) do not conflict.
+type MemoryConflict struct {
+	// Summary describes the contradiction.
+	Summary string `json:"summary,omitempty"`
+
+	// Sides are the conflicting memories, oldest first.
+	Sides []ConflictSide `json:"sides"`
+}
+
+// ConflictSide is one memory of a MemoryConflict.
+type ConflictSide struct {
+	MemoryID  MemoryID     `json:"memory_id"`
+	Content   string       `json:"content"`
+	Source    MemorySource `json:"source,omitempty"`
+	CreatedAt *time.Time   `json:"created_at,omitempty"`
+	UpdatedAt *time.Time   `json:"updated_at,omitempty"`
+}
+
+// Latest returns the most recently updated side of the conflict.
+func (c MemoryConflict) Latest() ConflictSide {
+	var latest ConflictSide
+	var latestAt time.Time
+	for i, side := range c.Sides {
+		at := side.CreatedAt
+		if side.UpdatedAt != nil {
+			at = side.UpdatedAt
+		}
+		if i == 0 || (at != nil && at.After(latestAt)) {
+			latest = side
+			if at != nil {
+				latestAt = *at
+			}
+		}
+	}
+	return latest
+}
+
+// NextPage returns the request for the page after r, or nil if r is the
+// last page. req is the request that returned r.
+func (r *SearchResults) NextPage(req *SearchMemoryRequest) *SearchMemoryRequest {
+	if !r.HasMore {
+		return nil
+	}
+	next := *req
+	if r.NextCursor != "" {
+		next.Cursor = r.NextCursor
+		next.Offset = 0
+	} else {
+		limit := req.Limit
+		if limit <= 0 {
+			limit = len(r.Results)
+		}
+		if limit == 0 {
+			return nil
+		}
+		next.Offset = req.Offset + limit
+	}
+	return &next
 }
 
 // =============================================================================
@@ -173,44 +703,1348 @@ type DeleteMemoryResponse struct {
 	MemoryID MemoryID `json:"memory_id"`
 }
 
+// GetMemoryOptions contains options for retrieving a single memory.
+type GetMemoryOptions struct {
+	UserID  string
+	AgentID string
+
+	// IncludeEmbeddings returns the memory's stored vector.
+	IncludeEmbeddings bool
+
+	// IncludeSuperseded returns the memories this memory replaced.
+	IncludeSuperseded bool
+}
+
+// MemoryHistoryEvent is the kind of change a history entry records.
+type MemoryHistoryEvent string
+
+const (
+	HistoryAdd    MemoryHistoryEvent = "ADD"
+	HistoryUpdate MemoryHistoryEvent = "UPDATE"
+	HistoryDelete MemoryHistoryEvent = "DELETE"
+)
+
+// MemoryHistoryEntry is one change to a memory.
+type MemoryHistoryEntry struct {
+	MemoryID        MemoryID           `json:"memory_id"`
+	Event           MemoryHistoryEvent `json:"event"`
+	Content         string             `json:"content"`
+	PreviousContent string             `json:"previous_memory,omitempty"`
+
+	// SupersededIDs are the memories replaced by this change.
+	SupersededIDs []MemoryID `json:"superseded_ids,omitempty"`
+
+	At *time.Time `json:"at,omitempty"`
+}
+
+// MemoryHistory represents the changes to a memory, oldest first.
+type MemoryHistory struct {
+	MemoryID MemoryID             `json:"memory_id"`
+	Entries  []MemoryHistoryEntry `json:"entries"`
+}
+
+// HistoryOptions contains options for retrieving a memory's history.
+type HistoryOptions struct {
+	UserID  string
+	AgentID string
+
+	// IncludeSuperseded merges in the history of the memories this
+	// memory replaced, so the full evolution of a fact is shown.
+	IncludeSuperseded bool
+}
+
+// DeleteMemoryOptions contains options for deleting a single memory.
+type DeleteMemoryOptions struct {
+	UserID  string
+	AgentID string
+
+	// ReturnDeleted fetches the memory before deleting it so it can be
+	// returned to the caller.
+	ReturnDeleted bool
+}
+
+// DeleteFilter selects memories for DeleteMemories. All set criteria must
+// match.
+type DeleteFilter struct {
+	UserID  string `json:"user_id,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+	RunID   string `json:"run_id,omitempty"`
+
+	// Metadata matches memories whose metadata contains all given pairs.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Before matches memories created before this time.
+	Before *time.Time `json:"before,omitempty"`
+
+	// DryRun reports how many memories match without deleting them.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// isEmpty reports whether no criterion is set.
+func (f DeleteFilter) isEmpty() bool {
+	return f.UserID == "" && f.AgentID == "" && f.RunID == "" && len(f.Metadata) == 0 && f.Before == nil
+}
+
+// DeleteMemoriesResult represents the response data for a filtered delete.
+type DeleteMemoriesResult struct {
+	// Count is the number of memories deleted, or that would be deleted
+	// in a dry run.
+	Count     int        `json:"count"`
+	DryRun    bool       `json:"dry_run"`
+	MemoryIDs []MemoryID `json:"memory_ids,omitempty"`
+}
+
+// ScopedDeleteResult represents the response data for deleting all
+// memories of a user, agent or run.
+type ScopedDeleteResult struct {
+	UserID       string `json:"user_id,omitempty"`
+	AgentID      string `json:"agent_id,omitempty"`
+	RunID        string `json:"run_id,omitempty"`
+	DeletedCount int    `json:"deleted_count"`
+	FailedCount  int    `json:"failed_count"`
+	Total        int    `json:"total"`
+}
+
 // =============================================================================
-// System Endpoints
+// Bulk Re-Scoping
 // =============================================================================
 
-// HealthResponse represents the health check response data.
-type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
+// MoveTarget is the scope memories are moved to. Empty fields keep the
+// memories' current values.
+type MoveTarget struct {
+	UserID  string `json:"user_id,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+	RunID   string `json:"run_id,omitempty"`
+	Scope   Scope  `json:"scope,omitempty"`
 }
 
-// SystemStatusResponse represents the system status response data.
-type SystemStatusResponse struct {
-	Status      string    `json:"status"`
-	Version     string    `json:"version"`
-	StorageType string    `json:"storage_type"`
-	LLMProvider string    `json:"llm_provider"`
-	Timestamp   time.Time `json:"timestamp"`
+// MoveOptions contains options for MoveMemories.
+type MoveOptions struct {
+	// DryRun reports what would be moved without moving anything.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Reason is recorded in the audit log, e.g. a ticket reference.
+	Reason string `json:"reason,omitempty"`
+}
+
+// MoveResult is the outcome of MoveMemories.
+type MoveResult struct {
+	// Matched is the number of memories matching the filter, and Moved
+	// the number moved, which is 0 for a dry run.
+	Matched int  `json:"matched"`
+	Moved   int  `json:"moved"`
+	DryRun  bool `json:"dry_run,omitempty"`
+
+	// Preview holds a sample of the matching memories for a dry run.
+	Preview []Memory `json:"preview,omitempty"`
+
+	// JobID is set when the server moves the memories in the background,
+	// as it does for large moves; see WaitForJob.
+	JobID string `json:"job_id,omitempty"`
+
+	// AuditSeq is the sequence number of the move's audit record.
+	AuditSeq int64 `json:"audit_seq,omitempty"`
 }
 
 // =============================================================================
-// List Parameters
+// Metadata Backfill
 // =============================================================================
 
-// ListMemoriesParams contains parameters for listing memories.
-type ListMemoriesParams struct {
-	UserID  string
-	AgentID string
-	Limit   int
-	Offset  int
-	SortBy  string // created_at, updated_at, id
-	Order   string // asc, desc
+// MetadataMutation is the change BackfillMetadata applies to the metadata
+// of each matching memory.
+type MetadataMutation struct {
+	// Set adds these keys. Keys a memory already has keep their value
+	// unless Overwrite is set.
+	Set       map[string]interface{} `json:"set,omitempty"`
+	Overwrite bool                   `json:"overwrite,omitempty"`
+
+	// Remove deletes these keys.
+	Remove []string `json:"remove,omitempty"`
 }
 
-// DefaultListParams returns default list parameters.
-func DefaultListParams() ListMemoriesParams {
-	return ListMemoriesParams{
-		Limit:  100,
-		Offset: 0,
-		Order:  "desc",
-	}
+// BackfillOptions contains options for BackfillMetadata.
+type BackfillOptions struct {
+	// DryRun reports what would change without changing anything.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Reason is recorded in the audit log, e.g. a ticket reference.
+	Reason string `json:"reason,omitempty"`
+}
+
+// BackfillResult is the outcome of BackfillMetadata.
+type BackfillResult struct {
+	// Matched is the number of memories matching the filter.
+	Matched int  `json:"matched"`
+	DryRun  bool `json:"dry_run,omitempty"`
+
+	// Preview holds a sample of the matching memories, as they would be
+	// after the mutation, for a dry run.
+	Preview []Memory `json:"preview,omitempty"`
+
+	// JobID is the background job applying the mutation; see
+	// WaitForJob. It is empty for a dry run.
+	JobID string `json:"job_id,omitempty"`
+}
+
+// BackfillManifest records the metadata a backfill job changed, so that
+// RollbackBackfill can restore it.
+type BackfillManifest struct {
+	JobID   string                  `json:"job_id"`
+	Entries []BackfillManifestEntry `json:"entries"`
+	Total   int                     `json:"total"`
+}
+
+// BackfillManifestEntry is the change a backfill made to one memory.
+type BackfillManifestEntry struct {
+	MemoryID MemoryID `json:"memory_id"`
+
+	// Before holds the previous values of the keys the backfill changed,
+	// and Added the keys it added, which had no previous value.
+	Before map[string]interface{} `json:"before,omitempty"`
+	Added  []string               `json:"added,omitempty"`
+}
+
+// =============================================================================
+// Storage Tiering
+// =============================================================================
+
+// StorageTier is where a memory's content and vector are stored.
+type StorageTier string
+
+const (
+	// TierHot memories are in the primary store and index. The empty
+	// value is treated as TierHot.
+	TierHot StorageTier = "hot"
+
+	// TierCold memories are archived to object storage and left out of
+	// searches unless IncludeCold is set.
+	TierCold StorageTier = "cold"
+)
+
+// ColdStorageConfig configures the S3-compatible bucket receiving
+// archived memories.
+type ColdStorageConfig struct {
+	// Endpoint is the S3 API endpoint, e.g. "https://s3.us-east-1.amazonaws.com"
+	// or a MinIO URL.
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+	Region   string `json:"region,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+
+	// AccessKeyID and SecretAccessKey are write-only: the server never
+	// returns the secret.
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+}
+
+// TieringRule moves memories matching Filter to cold storage once they
+// have not been accessed for AfterDays days.
+type TieringRule struct {
+	Name      string         `json:"name"`
+	Filter    MetadataFilter `json:"filter,omitempty"`
+	AfterDays int            `json:"after_days"`
+
+	// Disabled keeps the rule without applying it.
+	Disabled bool `json:"disabled,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// TieringRuleList represents a list of tiering rules.
+type TieringRuleList struct {
+	Rules []TieringRule `json:"rules"`
+	Total int           `json:"total"`
+}
+
+// =============================================================================
+// Retention Policies
+// =============================================================================
+
+// RetentionPolicy deletes, or archives to cold storage, the memories
+// matching Filter once they are older than MaxAgeDays, or beyond the
+// MaxMemories newest of their user. The server applies policies
+// periodically.
+type RetentionPolicy struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Filter      MetadataFilter `json:"filter,omitempty"`
+
+	// MaxAgeDays and MaxMemories bound the memories kept; a policy sets
+	// at least one. Zero does not bound.
+	MaxAgeDays  int `json:"max_age_days,omitempty"`
+	MaxMemories int `json:"max_memories,omitempty"`
+
+	// Archive moves expired memories to cold storage instead of deleting
+	// them.
+	Archive bool `json:"archive,omitempty"`
+
+	// Disabled keeps the policy without applying it.
+	Disabled bool `json:"disabled,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// RetentionPolicyList represents a list of retention policies.
+type RetentionPolicyList struct {
+	Policies []RetentionPolicy `json:"policies"`
+	Total    int               `json:"total"`
+}
+
+// =============================================================================
+// Memory Links
+// =============================================================================
+
+// LinkType is the kind of relationship a MemoryLink records, read as
+// "From <type> To".
+type LinkType string
+
+const (
+	// LinkDerivedFrom links a memory to a memory it was inferred from.
+	LinkDerivedFrom LinkType = "derived_from"
+
+	// LinkChunkOf links a chunk to the memory it was split from.
+	LinkChunkOf LinkType = "chunk_of"
+
+	// LinkSupersedes links a memory to an older memory it replaces.
+	LinkSupersedes LinkType = "supersedes"
+
+	// LinkContradicts links memories that cannot both be true.
+	LinkContradicts LinkType = "contradicts"
+)
+
+// Valid reports whether t is a known link type.
+func (t LinkType) Valid() bool {
+	switch t {
+	case LinkDerivedFrom, LinkChunkOf, LinkSupersedes, LinkContradicts:
+		return true
+	}
+	return false
+}
+
+// LinkDirection selects which links of a memory to follow.
+type LinkDirection string
+
+const (
+	// LinkOutgoing follows links from the memory, e.g. to its sources.
+	LinkOutgoing LinkDirection = "out"
+
+	// LinkIncoming follows links to the memory, e.g. to its chunks.
+	LinkIncoming LinkDirection = "in"
+
+	// LinkBoth follows links in either direction.
+	LinkBoth LinkDirection = "both"
+)
+
+// MemoryLink is a directed relationship between two memories.
+type MemoryLink struct {
+	ID        string                 `json:"id,omitempty"`
+	FromID    MemoryID               `json:"from_id"`
+	ToID      MemoryID               `json:"to_id"`
+	Type      LinkType               `json:"type"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt *time.Time             `json:"created_at,omitempty"`
+}
+
+// MemoryLinkList represents the links of a memory.
+type MemoryLinkList struct {
+	Links []MemoryLink `json:"links"`
+	Total int          `json:"total"`
+}
+
+// LinkQuery selects the links to list or traverse. Empty fields do not
+// filter; Direction defaults to LinkOutgoing.
+type LinkQuery struct {
+	Direction LinkDirection
+	Types     []LinkType
+
+	// Depth is the number of hops TraverseMemoryLinks follows. Defaults
+	// to 1.
+	Depth int
+}
+
+// MemoryGraph is the part of the memory graph reached by a traversal.
+type MemoryGraph struct {
+	Memories []Memory     `json:"memories"`
+	Links    []MemoryLink `json:"links"`
+}
+
+// =============================================================================
+// Knowledge Graph
+// =============================================================================
+
+// Entity is a node of the knowledge graph the server builds from a user's
+// memories during inference: a person, place, organization or thing.
+type Entity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	// Type classifies the entity, e.g. "person" or "location".
+	Type   string `json:"type,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+
+	// Aliases are other names the entity was mentioned by.
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Mentions is the number of memories mentioning the entity.
+	Mentions int `json:"mentions,omitempty"`
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	CreatedAt  *time.Time             `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time             `json:"updated_at,omitempty"`
+}
+
+// EntityList represents the response data of ListEntities.
+type EntityList struct {
+	Entities []Entity `json:"entities"`
+	Total    int      `json:"total"`
+}
+
+// EntityListParams filters and pages ListEntities. Empty fields do not
+// filter.
+type EntityListParams struct {
+	// Type restricts the list to entities of this type.
+	Type string
+
+	// Query restricts the list to entities whose name or an alias
+	// contains it.
+	Query string
+
+	Limit  int
+	Offset int
+}
+
+// Relation is a directed edge of the knowledge graph, read as
+// "Source <Type> Target", e.g. "Alice works_at Acme".
+type Relation struct {
+	ID string `json:"id"`
+
+	// SourceID and TargetID are the entities the relation connects, and
+	// Source and Target their names.
+	SourceID string       `json:"source_id"`
+	Source   string       `json:"source,omitempty"`
+	Type     RelationType `json:"type"`
+	TargetID string       `json:"target_id"`
+	Target   string       `json:"target,omitempty"`
+
+	// MemoryID is the memory the relation was extracted from.
+	MemoryID MemoryID `json:"memory_id,omitempty"`
+
+	// Confidence is the extraction's confidence in [0, 1].
+	Confidence float64    `json:"confidence,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+// RelationType is the kind of a Relation. Besides the built-in types,
+// a relation may have a custom type registered with
+// RegisterRelationType, named in lower snake case like "mentor_of".
+type RelationType string
+
+const (
+	// RelationKnows relates people who know each other.
+	RelationKnows RelationType = "knows"
+
+	// RelationPrefers relates a person to something they prefer.
+	RelationPrefers RelationType = "prefers"
+
+	// RelationWorksAt relates a person to their employer.
+	RelationWorksAt RelationType = "works_at"
+
+	// RelationSupersedes relates an entity to an older one it replaces,
+	// e.g. a new address to the previous one.
+	RelationSupersedes RelationType = "supersedes"
+)
+
+// maxRelationTypeLength is the longest relation type name the server
+// accepts.
+const maxRelationTypeLength = 64
+
+// Builtin reports whether t is a built-in relation type.
+func (t RelationType) Builtin() bool {
+	switch t {
+	case RelationKnows, RelationPrefers, RelationWorksAt, RelationSupersedes:
+		return true
+	}
+	return false
+}
+
+// Valid reports whether t is a built-in relation type or a well-formed
+// custom one: a lowercase letter followed by lowercase letters, digits
+// and underscores, at most 64 bytes long. Whether a custom type is
+// registered is only known to the server.
+func (t RelationType) Valid() bool {
+	if t.Builtin() {
+		return true
+	}
+	if t == "" || len(t) > maxRelationTypeLength || t[0] < 'a' || t[0] > 'z' {
+		return false
+	}
+	for i := 1; i < len(t); i++ {
+		b := t[i]
+		if (b < 'a' || b > 'z') && (b < '0' || b > '9') && b != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// RelationTypeDefinition describes a custom relation type, registered
+// with RegisterRelationType so that inference extracts it.
+type RelationTypeDefinition struct {
+	Type RelationType `json:"type"`
+
+	// Description tells the extraction model when the relation applies,
+	// e.g. "Source mentors Target professionally".
+	Description string `json:"description,omitempty"`
+
+	// SourceTypes and TargetTypes restrict the entity types the relation
+	// connects, e.g. "person". Empty allows any.
+	SourceTypes []string `json:"source_types,omitempty"`
+	TargetTypes []string `json:"target_types,omitempty"`
+
+	// Symmetric marks relations that hold in both directions, like
+	// "knows".
+	Symmetric bool `json:"symmetric,omitempty"`
+
+	// Builtin is set by the server on the built-in types.
+	Builtin   bool       `json:"builtin,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// RelationTypeList represents the response data of ListRelationTypes.
+type RelationTypeList struct {
+	Types []RelationTypeDefinition `json:"types"`
+	Total int                      `json:"total"`
+}
+
+// RelationList represents the response data of ListRelations.
+type RelationList struct {
+	Relations []Relation `json:"relations"`
+	Total     int        `json:"total"`
+}
+
+// EntityMergeRequest is the body of MergeEntities.
+type EntityMergeRequest struct {
+	DuplicateIDs []string `json:"duplicate_ids"`
+}
+
+// EntityMergeResult represents the response data of MergeEntities.
+type EntityMergeResult struct {
+	// Entity is the canonical entity after the merge, with the names of
+	// the duplicates among its aliases.
+	Entity Entity `json:"entity"`
+
+	// MergedIDs are the duplicates merged and deleted.
+	MergedIDs []string `json:"merged_ids"`
+
+	// RelationsMoved is the number of relations repointed from the
+	// duplicates to the canonical entity.
+	RelationsMoved int `json:"relations_moved"`
+}
+
+// EntityDuplicates is a group of entities the server suspects are the
+// same, e.g. "Bob" and "Robert Smith", returned by
+// SuggestEntityDuplicates.
+type EntityDuplicates struct {
+	// Canonical is the entity suggested to keep, usually the most
+	// mentioned, and Duplicates the entities to merge into it.
+	Canonical  Entity   `json:"canonical"`
+	Duplicates []Entity `json:"duplicates"`
+
+	// Confidence is the server's confidence in [0, 1] that the entities
+	// are the same, and Reason why it thinks so.
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// DuplicateIDs returns the IDs of the duplicates, for MergeEntities.
+func (d *EntityDuplicates) DuplicateIDs() []string {
+	ids := make([]string, len(d.Duplicates))
+	for i, e := range d.Duplicates {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// EntityDuplicatesList represents the response data of
+// SuggestEntityDuplicates.
+type EntityDuplicatesList struct {
+	Groups []EntityDuplicates `json:"groups"`
+	Total  int                `json:"total"`
+}
+
+// Subgraph is the part of a knowledge graph around an entity, returned
+// by GraphNeighbors.
+type Subgraph struct {
+	// Root is the entity the subgraph was expanded from.
+	Root Entity `json:"root"`
+
+	// Entities are the entities within the requested depth of Root,
+	// Root included, and Relations the relations between them.
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+
+	// Truncated is set when the server capped the subgraph's size, so
+	// some entities within the depth are missing.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Entity returns the entity of the subgraph with the given ID.
+func (g *Subgraph) Entity(id string) (Entity, bool) {
+	for _, e := range g.Entities {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entity{}, false
+}
+
+// EntityPath is a shortest path between two entities, returned by
+// GraphPath. Relations[i] connects Entities[i] and Entities[i+1], in
+// either direction.
+type EntityPath struct {
+	// Found is false when the entities are not connected; the other
+	// fields are then empty.
+	Found     bool       `json:"found"`
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+}
+
+// Len returns the number of relations on the path.
+func (p *EntityPath) Len() int {
+	return len(p.Relations)
+}
+
+// String formats the path as "Alice -[works_at]- Acme -[located_in]-
+// Berlin", for logs and explanations.
+func (p *EntityPath) String() string {
+	var s string
+	for i, e := range p.Entities {
+		if i > 0 && i <= len(p.Relations) {
+			s += " -[" + string(p.Relations[i-1].Type) + "]- "
+		}
+		s += e.Name
+	}
+	return s
+}
+
+// UserGraphParams pages GetUserGraph.
+type UserGraphParams struct {
+	// Limit caps the entities of a page. Zero uses the server default.
+	Limit int
+
+	// Cursor continues from the NextCursor of a previous page.
+	Cursor string
+
+	// UpdatedSince restricts the graph to entities and relations created
+	// or updated after it, for incremental mirroring. Nil returns all.
+	UpdatedSince *time.Time
+}
+
+// UserGraph is a page of a user's knowledge graph, returned by
+// GetUserGraph. Relations are paged with their source entity, so every
+// relation appears on exactly one page; its target may be on another.
+type UserGraph struct {
+	UserID    string     `json:"user_id"`
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+
+	// TotalEntities and TotalRelations count the whole graph, across
+	// pages.
+	TotalEntities  int `json:"total_entities"`
+	TotalRelations int `json:"total_relations"`
+
+	// HasMore reports whether another page follows, from NextCursor.
+	HasMore    bool   `json:"has_more,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NextPage returns the params for the page after g, or nil if g is the
+// last page. params are the params that returned g.
+func (g *UserGraph) NextPage(params UserGraphParams) *UserGraphParams {
+	if !g.HasMore || g.NextCursor == "" {
+		return nil
+	}
+	params.Cursor = g.NextCursor
+	return &params
+}
+
+// =============================================================================
+// Memory Provenance
+// =============================================================================
+
+// MemorySource records where a memory's content came from.
+type MemorySource string
+
+const (
+	// SourceUserStated is content the user stated directly.
+	SourceUserStated MemorySource = "user_stated"
+
+	// SourceInferred is content a model inferred, e.g. facts extracted
+	// from a conversation.
+	SourceInferred MemorySource = "inferred"
+
+	// SourceImported is content imported from another system.
+	SourceImported MemorySource = "imported"
+)
+
+// TrustScores map each source to a trust score in [0, 1], used as a
+// ranking signal. Sources missing from the map use the server default.
+type TrustScores map[MemorySource]float64
+
+// DefaultTrustScores returns the server's default trust scores, which
+// rank stated facts above inferences and inferences above imports.
+func DefaultTrustScores() TrustScores {
+	return TrustScores{
+		SourceUserStated: 1.0,
+		SourceInferred:   0.6,
+		SourceImported:   0.4,
+	}
+}
+
+// =============================================================================
+// End-User Memory Settings
+// =============================================================================
+
+// MemorySettings are a user's own choices about what is remembered. The
+// server enforces them on every write: memories suppressed by them are
+// reported as skipped (see CreatedMemory.Skip), or, on servers that reject
+// such writes, fail with an error for which IsOptedOut is true.
+type MemorySettings struct {
+	UserID string `json:"user_id"`
+
+	// OptedOut turns memory off for the user; existing memories are kept
+	// until deleted.
+	OptedOut bool `json:"opted_out"`
+
+	// DisabledTopics are topics that are never remembered: built-in
+	// ones such as TopicHealth, or topics defined in CustomTopics.
+	DisabledTopics []string `json:"disabled_topics,omitempty"`
+
+	// CustomTopics are the user's own topics, e.g. a former employer.
+	CustomTopics []TopicDefinition `json:"custom_topics,omitempty"`
+
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Built-in topics that can be disabled in MemorySettings.
+const (
+	TopicHealth        = "health"
+	TopicFinances      = "finances"
+	TopicRelationships = "relationships"
+	TopicPolitics      = "politics"
+	TopicReligion      = "religion"
+	TopicLocation      = "location"
+)
+
+// TopicDefinition defines a custom topic. At ingest the server's
+// classifier matches content against Description, and content containing
+// any of Keywords matches regardless of the classifier.
+type TopicDefinition struct {
+	Topic       string   `json:"topic"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+}
+
+// MemoryCategory is a human-friendly group of a user's memories, e.g.
+// "Food & Drink", for display in a settings UI.
+type MemoryCategory struct {
+	// Topic is the category's identifier, as used in DisabledTopics.
+	Topic    string   `json:"topic"`
+	Label    string   `json:"label"`
+	Memories []Memory `json:"memories"`
+	Total    int      `json:"total"`
+
+	// Disabled reports whether the user has disabled the topic.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// CategorizedMemories represents a user's memories grouped by category.
+type CategorizedMemories struct {
+	Categories []MemoryCategory `json:"categories"`
+	Total      int              `json:"total"`
+}
+
+// Category is a topic the server assigns extracted facts to, with the
+// number of memories in it.
+type Category struct {
+	// Name identifies the category in filters and DisabledTopics.
+	Name  string `json:"name"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+// CategoryList represents the response data of ListCategories.
+type CategoryList struct {
+	Categories []Category `json:"categories"`
+	Total      int        `json:"total"`
+}
+
+// =============================================================================
+// Sessions
+// =============================================================================
+
+// SessionSnapshot is what OpenSession pre-loads for a user: everything an
+// interactive agent reads on every turn besides the turn's own search.
+type SessionSnapshot struct {
+	UserID string `json:"user_id"`
+
+	// ProfileSummary is a short natural-language summary of the user
+	// built from their memories, for the system prompt.
+	ProfileSummary string `json:"profile_summary,omitempty"`
+
+	// Pinned are the user's pinned memories and those their search pins
+	// point to.
+	Pinned []Memory `json:"pinned,omitempty"`
+
+	// Hot are the user's most recently used memories.
+	Hot []Memory `json:"hot,omitempty"`
+
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// =============================================================================
+// User Profiles
+// =============================================================================
+
+// UserProfile is the server's synthesis of what it knows about a user,
+// built from their memories, e.g. for personalizing emails.
+type UserProfile struct {
+	UserID string `json:"user_id"`
+
+	// Summary is a short natural-language summary of the user.
+	Summary string `json:"summary"`
+
+	// Attributes are the facts extracted about the user, e.g. "city" or
+	// "preferred_language".
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+
+	// Categories are the categories of the user's memories, most used
+	// first.
+	Categories []string `json:"categories,omitempty"`
+
+	MemoryCount int        `json:"memory_count"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// =============================================================================
+// Agents
+// =============================================================================
+
+// Agent represents a registered agent.
+type Agent struct {
+	AgentID     string                 `json:"agent_id"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt   *time.Time             `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time             `json:"updated_at,omitempty"`
+}
+
+// AgentList represents a paginated list of agents.
+type AgentList struct {
+	Agents []Agent `json:"agents"`
+	Total  int     `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+}
+
+// RegisterAgentRequest represents the request body for registering an agent.
+type RegisterAgentRequest struct {
+	AgentID     string                 `json:"agent_id"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CreateAgentMemoryRequest represents the request body for creating an
+// agent-owned memory.
+type CreateAgentMemoryRequest struct {
+	Content    string                 `json:"content"`
+	UserID     string                 `json:"user_id,omitempty"`
+	RunID      string                 `json:"run_id,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Visibility Visibility             `json:"visibility,omitempty"`
+}
+
+// ShareAgentMemoriesRequest represents the request body for sharing
+// memories between agents.
+type ShareAgentMemoriesRequest struct {
+	TargetAgentID string     `json:"target_agent_id"`
+	MemoryIDs     []MemoryID `json:"memory_ids,omitempty"`
+}
+
+// ShareAgentMemoriesResult represents the response data for a share
+// operation.
+type ShareAgentMemoriesResult struct {
+	SharedCount   int    `json:"shared_count"`
+	SourceAgentID string `json:"source_agent_id"`
+	TargetAgentID string `json:"target_agent_id"`
+}
+
+// Suppression hides memories from an agent's retrieval while keeping them
+// stored, e.g. sensitive facts a customer-facing bot must not surface but a
+// human agent's console may.
+type Suppression struct {
+	ID      string `json:"id,omitempty"`
+	AgentID string `json:"agent_id"`
+
+	// MemoryIDs are suppressed individually.
+	MemoryIDs []MemoryID `json:"memory_ids,omitempty"`
+
+	// Pattern, if set, suppresses every memory whose content matches this
+	// regular expression, including memories created later.
+	Pattern string `json:"pattern,omitempty"`
+
+	// UserID restricts the suppression to one user's memories.
+	UserID string `json:"user_id,omitempty"`
+
+	Reason    string     `json:"reason,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// SuppressionList represents a list of suppressions.
+type SuppressionList struct {
+	Suppressions []Suppression `json:"suppressions"`
+	Total        int           `json:"total"`
+}
+
+// =============================================================================
+// Runs
+// =============================================================================
+
+// RunStatus is the lifecycle state of a run.
+type RunStatus string
+
+const (
+	RunStatusOpen   RunStatus = "open"
+	RunStatusClosed RunStatus = "closed"
+)
+
+// Run represents a run (conversation session) identified by run_id.
+type Run struct {
+	RunID          string                 `json:"run_id"`
+	UserID         string                 `json:"user_id,omitempty"`
+	AgentID        string                 `json:"agent_id,omitempty"`
+	Status         RunStatus              `json:"status"`
+	MemoryCount    int                    `json:"memory_count"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	StartedAt      *time.Time             `json:"started_at,omitempty"`
+	LastActivityAt *time.Time             `json:"last_activity_at,omitempty"`
+	ClosedAt       *time.Time             `json:"closed_at,omitempty"`
+}
+
+// CloseRunRequest represents the request body for closing a run.
+type CloseRunRequest struct {
+	// Consolidate controls server-side consolidation of the run's
+	// memories. Defaults to true.
+	Consolidate *bool `json:"consolidate,omitempty"`
+
+	// Metadata is merged into the run's metadata, e.g. an outcome label.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Summarize stores an episodic summary of the run as a memory that
+	// links to the run's memories; see RunSummaryOf. The summary is
+	// marked KindSummary, so HierarchicalSearch retrieves it first.
+	Summarize bool `json:"summarize,omitempty"`
+}
+
+// RunSummaryMetadataField is the metadata field of a run summary memory
+// that links it to the summarized run and memories.
+const RunSummaryMetadataField = "summary_of"
+
+// RunSummaryLink is the link from a run summary memory to its run.
+type RunSummaryLink struct {
+	RunID     string     `json:"run_id"`
+	MemoryIDs []MemoryID `json:"memory_ids"`
+}
+
+// CloseRunResult represents the response data for closing a run.
+type CloseRunResult struct {
+	Run Run `json:"run"`
+
+	// Consolidated are the memories created or updated by consolidation.
+	Consolidated []Memory `json:"consolidated,omitempty"`
+
+	// RemovedCount is the number of redundant run memories removed.
+	RemovedCount int `json:"removed_count"`
+
+	// Summary is the run summary memory when the request asked for one.
+	Summary *Memory `json:"summary,omitempty"`
+}
+
+// =============================================================================
+// Jobs
+// =============================================================================
+
+// JobStatus is the state of a background job.
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Done reports whether the job has finished, successfully or not.
+func (s JobStatus) Done() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCancelled
+}
+
+// JobType is the kind of work a background job does.
+type JobType string
+
+const (
+	// JobExtraction extracts memories from content, e.g. an async
+	// CreateMemory with Infer set.
+	JobExtraction JobType = "extraction"
+
+	// JobReembedding recomputes embeddings, e.g. after an embedding
+	// model change.
+	JobReembedding JobType = "reembedding"
+
+	// JobConsolidation merges and promotes memories, e.g. when a run is
+	// closed.
+	JobConsolidation JobType = "consolidation"
+
+	// JobMove re-scopes memories in bulk, see MoveMemories.
+	JobMove JobType = "move"
+
+	// JobRehydration restores memories from cold storage, see
+	// RehydrateMemories.
+	JobRehydration JobType = "rehydration"
+
+	// JobBackfill changes metadata in bulk, see BackfillMetadata, and
+	// JobBackfillRollback undoes such a change, see RollbackBackfill.
+	JobBackfill         JobType = "metadata_backfill"
+	JobBackfillRollback JobType = "metadata_backfill_rollback"
+)
+
+// JobProgress is how far a job working through many items has come.
+type JobProgress struct {
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	Failed    int `json:"failed,omitempty"`
+}
+
+// Fraction returns the processed share of the items in [0, 1], or 0 when
+// the total is not known yet.
+func (p *JobProgress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return min(float64(p.Processed)/float64(p.Total), 1)
+}
+
+// Job represents a background job, such as an async memory creation.
+type Job struct {
+	JobID     string     `json:"job_id"`
+	Type      JobType    `json:"type,omitempty"`
+	Status    JobStatus  `json:"status"`
+	UserID    string     `json:"user_id,omitempty"`
+	AgentID   string     `json:"agent_id,omitempty"`
+	Attempts  int        `json:"attempts,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+
+	// Result holds the created memories once the job has succeeded.
+	Result []CreatedMemory `json:"result,omitempty"`
+
+	// Progress is set on jobs working through many items, such as bulk
+	// moves and backfills.
+	Progress *JobProgress `json:"progress,omitempty"`
+
+	// Error describes why the job failed.
+	Error string `json:"error,omitempty"`
+}
+
+// JobList represents a paginated list of jobs.
+type JobList struct {
+	Jobs   []Job `json:"jobs"`
+	Total  int   `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// =============================================================================
+// Retrieval Profiles
+// =============================================================================
+
+// RetrievalProfile is a named, server-side set of retrieval settings that
+// searches select with SearchMemoryRequest.Profile, so agents with
+// different needs can search the same data differently.
+type RetrievalProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Weights blend the ranking signals. Zero weights disable a signal.
+	Weights RetrievalWeights `json:"weights"`
+
+	// Filters are applied to every search using the profile, in addition
+	// to the request's own filters.
+	Filters map[string]interface{} `json:"filters,omitempty"`
+
+	// Budget bounds the cost of a search.
+	Budget RetrievalBudget `json:"budget"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// RetrievalWeights are the relative weights of the ranking signals.
+type RetrievalWeights struct {
+	Vector     float64 `json:"vector"`
+	Keyword    float64 `json:"keyword"`
+	Recency    float64 `json:"recency"`
+	Importance float64 `json:"importance"`
+
+	// Trust weighs the trust score of each result's source, so that
+	// stated facts outrank conflicting inferences. Zero disables it.
+	Trust float64 `json:"trust,omitempty"`
+
+	// Graph weighs the proximity in the knowledge graph of memories a
+	// SearchModeGraph search reached through it. Zero disables it.
+	Graph float64 `json:"graph,omitempty"`
+}
+
+// RetrievalBudget bounds the cost of a search. Zero values mean no limit.
+type RetrievalBudget struct {
+	MaxResults int `json:"max_results,omitempty"`
+
+	// MaxTokens caps the total content length of the results, in tokens.
+	MaxTokens int `json:"max_tokens,omitempty"`
+
+	// TimeoutMillis is the latency budget of the search.
+	TimeoutMillis int `json:"timeout_ms,omitempty"`
+}
+
+// RetrievalProfileList represents a list of retrieval profiles.
+type RetrievalProfileList struct {
+	Profiles []RetrievalProfile `json:"profiles"`
+	Total    int                `json:"total"`
+}
+
+// =============================================================================
+// Metadata Schemas
+// =============================================================================
+
+// MetadataSchema constrains the metadata of the memories matching
+// Filter, or of all memories when Filter is empty. The server rejects
+// writes whose metadata does not conform.
+type MetadataSchema struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Filter      MetadataFilter `json:"filter,omitempty"`
+
+	// Fields constrains metadata fields by name.
+	Fields map[string]MetadataFieldSchema `json:"fields"`
+
+	// Strict rejects metadata fields not in Fields.
+	Strict bool `json:"strict,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// MetadataFieldSchema constrains one metadata field.
+type MetadataFieldSchema struct {
+	// Type is the field's JSON type: "string", "number", "boolean",
+	// "array" or "object". Empty allows any.
+	Type string `json:"type,omitempty"`
+
+	Required bool `json:"required,omitempty"`
+
+	// Enum lists the values allowed. Empty allows any.
+	Enum []interface{} `json:"enum,omitempty"`
+}
+
+// MetadataSchemaList represents a list of metadata schemas.
+type MetadataSchemaList struct {
+	Schemas []MetadataSchema `json:"schemas"`
+	Total   int              `json:"total"`
+}
+
+// =============================================================================
+// Saved Searches
+// =============================================================================
+
+// SavedSearch is a named search stored on the server, run by name with
+// RunSavedSearch, so that dashboards and agents share one definition.
+type SavedSearch struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Request     SearchMemoryRequest `json:"request"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SavedSearchList represents a list of saved searches.
+type SavedSearchList struct {
+	Searches []SavedSearch `json:"searches"`
+	Total    int           `json:"total"`
+}
+
+// =============================================================================
+// Search Curation
+// =============================================================================
+
+// PinMatch is how a SearchPin's pattern is compared with search queries.
+type PinMatch string
+
+const (
+	// PinMatchExact matches queries equal to the pattern, ignoring case
+	// and surrounding whitespace.
+	PinMatchExact PinMatch = "exact"
+
+	// PinMatchContains matches queries containing the pattern.
+	PinMatchContains PinMatch = "contains"
+
+	// PinMatchRegex matches queries against the pattern as a regular
+	// expression.
+	PinMatchRegex PinMatch = "regex"
+)
+
+// SearchPin pins or boosts specific memories in the results of searches
+// whose query matches a pattern, to correct retrieval misses by hand.
+type SearchPin struct {
+	ID      string   `json:"id,omitempty"`
+	Pattern string   `json:"pattern"`
+	Match   PinMatch `json:"match"`
+
+	// MemoryIDs are the memories to promote, in order.
+	MemoryIDs []MemoryID `json:"memory_ids"`
+
+	// Boost is added to the memories' scores. Zero pins them to the top
+	// of the results regardless of score.
+	Boost float64 `json:"boost,omitempty"`
+
+	// UserID and AgentID restrict the pin to searches in that scope.
+	UserID  string `json:"user_id,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+
+	// Note records why the pin exists.
+	Note      string     `json:"note,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// SearchPinList represents a list of search pins.
+type SearchPinList struct {
+	Pins  []SearchPin `json:"pins"`
+	Total int         `json:"total"`
+}
+
+// =============================================================================
+// Webhooks
+// =============================================================================
+
+// Webhook delivers memory events to an HTTP endpoint. Receivers verify
+// deliveries with webhooks.VerifySignature using Secret.
+type Webhook struct {
+	ID  string `json:"id,omitempty"`
+	URL string `json:"url"`
+
+	// Events are the event types delivered; empty delivers all.
+	Events []webhooks.EventType `json:"events,omitempty"`
+
+	// UserID and AgentID restrict deliveries to events in that scope.
+	UserID  string `json:"user_id,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+
+	// Secret signs deliveries. The server generates one when it is
+	// empty; it is only returned when the webhook is created.
+	Secret string `json:"secret,omitempty"`
+
+	Description string     `json:"description,omitempty"`
+	Active      bool       `json:"active"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// WebhookList represents a list of webhooks.
+type WebhookList struct {
+	Webhooks []Webhook `json:"webhooks"`
+	Total    int       `json:"total"`
+}
+
+// =============================================================================
+// System Endpoints
+// =============================================================================
+
+// HealthResponse represents the health check response data.
+type HealthResponse struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SystemStatusResponse represents the system status response data.
+type SystemStatusResponse struct {
+	Status      string    `json:"status"`
+	Version     string    `json:"version"`
+	StorageType string    `json:"storage_type"`
+	LLMProvider string    `json:"llm_provider"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// =============================================================================
+// List Parameters
+// =============================================================================
+
+// ListMemoriesParams contains parameters for listing memories.
+type ListMemoriesParams struct {
+	UserID  string
+	AgentID string
+	Limit   int
+	Offset  int
+	SortBy  string // created_at, updated_at, id
+	Order   string // asc, desc
+
+	// MemoryTypes restricts the list to memories of these types.
+	MemoryTypes []MemoryType
+
+	// Categories restricts the list to memories in any of these
+	// categories.
+	Categories []string
+
+	// Expiry selects memories by expiry. Empty lists active memories.
+	Expiry ExpiryFilter
+}
+
+// ExpiryFilter selects memories by whether they have expired. Expired
+// memories stay listable until the server's cleanup deletes them.
+type ExpiryFilter string
+
+const (
+	ExpiryActive  ExpiryFilter = "active"
+	ExpiryExpired ExpiryFilter = "expired"
+	ExpiryAll     ExpiryFilter = "all"
+)
+
+// ListJobsParams contains parameters for listing background jobs. Empty
+// fields do not filter.
+type ListJobsParams struct {
+	Status []JobStatus
+	Type   JobType
+	UserID string
+	Limit  int
+	Offset int
+}
+
+// DefaultListParams returns default list parameters.
+func DefaultListParams() ListMemoriesParams {
+	return ListMemoriesParams{
+		Limit:  100,
+		Offset: 0,
+		Order:  "desc",
+	}
+}
+
+// Pagination selects a page of results.
+type Pagination struct {
+	Limit  int    `json:"limit,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+	SortBy string `json:"sort_by,omitempty"` // created_at, updated_at, id
+	Order  string `json:"order,omitempty"`   // asc, desc
 }
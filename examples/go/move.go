@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// =============================================================================
+// Bulk Re-Scoping
+// =============================================================================
+
+// moveRequest is the request body of a move.
+type moveRequest struct {
+	Filters MetadataFilter `json:"filters"`
+	Target  MoveTarget     `json:"target"`
+	MoveOptions
+}
+
+// MoveMemories re-scopes the memories matching filter to target, e.g.
+// after an org restructure or to fix writes made under the wrong user.
+// The server updates the memories' index entries, re-embedding where the
+// scope is part of the embedded text, and records the move in the audit
+// log. Run with DryRun first to check what filter matches.
+//
+// Large moves run in the background: the result then carries a JobID.
+func (c *Client) MoveMemories(ctx context.Context, filter MetadataFilter, target MoveTarget, opts MoveOptions) (*MoveResult, error) {
+	if len(filter) == 0 {
+		return nil, errors.New("move needs a filter")
+	}
+	if target == (MoveTarget{}) {
+		return nil, errors.New("move needs a target scope")
+	}
+	if err := checkScope(target.Scope); err != nil {
+		return nil, err
+	}
+	req := &moveRequest{Filters: filter, Target: target, MoveOptions: opts}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/memories/move", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MoveResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("move memories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (Secret, error)
+
+// Token calls f.
+func (f TokenSourceFunc) Token(ctx context.Context) (Secret, error) {
+	return f(ctx)
+}
+
+// OAuth2Token is an OAuth2 access token, with the fields of
+// golang.org/x/oauth2.Token the client uses.
+type OAuth2Token struct {
+	AccessToken string
+
+	// TokenType is the type of the token; only bearer tokens, and tokens
+	// of unspecified type, are supported.
+	TokenType string
+
+	// Expiry is when the token expires; zero means it does not.
+	Expiry time.Time
+}
+
+// OAuth2TokenSource has the shape of golang.org/x/oauth2.TokenSource,
+// which this package cannot import. Wrapping a source of that package
+// takes one method:
+//
+//	type xoauth2Source struct{ oauth2.TokenSource }
+//
+//	func (s xoauth2Source) Token() (*OAuth2Token, error) {
+//		t, err := s.TokenSource.Token()
+//		if err != nil {
+//			return nil, err
+//		}
+//		return &OAuth2Token{AccessToken: t.AccessToken, TokenType: t.Type(), Expiry: t.Expiry}, nil
+//	}
+type OAuth2TokenSource interface {
+	Token() (*OAuth2Token, error)
+}
+
+// WithOAuth2TokenSource sends access tokens from ts, e.g. a token source
+// of golang.org/x/oauth2 for any grant. Like with WithTokenSource, a token
+// is cached until shortly before its expiry and a new one is requested
+// after the server rejects it with 401.
+func WithOAuth2TokenSource(ts OAuth2TokenSource) Option {
+	return WithTokenSource(TokenSourceFunc(func(context.Context) (Secret, error) {
+		t, err := ts.Token()
+		if err != nil {
+			return Secret{}, fmt.Errorf("oauth2: %w", err)
+		}
+		switch {
+		case t == nil || t.AccessToken == "":
+			return Secret{}, fmt.Errorf("oauth2: token source returned no access token")
+		case t.TokenType != "" && !strings.EqualFold(t.TokenType, "bearer"):
+			return Secret{}, fmt.Errorf("oauth2: unsupported token type %q", t.TokenType)
+		}
+		return Secret{Value: t.AccessToken, ExpiresAt: t.Expiry}, nil
+	}))
+}
+
+// ClientCredentials is a TokenSource obtaining access tokens with the
+// OAuth2 client credentials grant (RFC 6749, section 4.4), for services
+// authenticating as themselves rather than on behalf of a user. Its
+// fields follow golang.org/x/oauth2/clientcredentials.Config.
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+
+	// TokenURL is the authorization server's token endpoint.
+	TokenURL string
+
+	// Scopes are the requested scopes, if any.
+	Scopes []string
+
+	// EndpointParams are additional parameters of the token request, e.g.
+	// "audience" for some providers.
+	EndpointParams url.Values
+
+	// AuthInParams sends the client ID and secret in the request body
+	// instead of with HTTP basic authentication, for servers that do not
+	// support the latter.
+	AuthInParams bool
+
+	HTTPClient *http.Client
+}
+
+// WithOAuth2 sends access tokens obtained from tokenURL with the OAuth2
+// client credentials grant. Tokens are requested again shortly before
+// they expire, so that long-running workers do not fail mid-batch, and
+// after the server rejects one with 401.
+func WithOAuth2(clientID, clientSecret, tokenURL string, scopes ...string) Option {
+	return WithTokenSource(&ClientCredentials{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       scopes,
+	})
+}
+
+// tokenResponse is a token endpoint's response, successful or not.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token implements TokenSource. A token without a lifetime is valid until
+// the server rejects it.
+func (cc *ClientCredentials) Token(ctx context.Context) (Secret, error) {
+	if cc.TokenURL == "" {
+		return Secret{}, fmt.Errorf("oauth2: token URL not configured")
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cc.Scopes) > 0 {
+		form.Set("scope", strings.Join(cc.Scopes, " "))
+	}
+	for k, v := range cc.EndpointParams {
+		form[k] = v
+	}
+	if cc.AuthInParams {
+		form.Set("client_id", cc.ClientID)
+		form.Set("client_secret", cc.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Secret{}, fmt.Errorf("oauth2: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !cc.AuthInParams {
+		req.SetBasicAuth(url.QueryEscape(cc.ClientID), url.QueryEscape(cc.ClientSecret))
+	}
+
+	body, status, err := doSecretRequest(cc.HTTPClient, req)
+	if err != nil {
+		return Secret{}, fmt.Errorf("oauth2: %w", err)
+	}
+	var resp tokenResponse
+	if err := json.Unmarshal(body, &resp); err != nil && status == http.StatusOK {
+		return Secret{}, fmt.Errorf("oauth2: failed to parse response: %w", err)
+	}
+	switch {
+	case resp.Error != "":
+		return Secret{}, fmt.Errorf("oauth2: HTTP %d: %s", status, firstNonEmpty(resp.ErrorDescription, resp.Error))
+	case status != http.StatusOK:
+		return Secret{}, fmt.Errorf("oauth2: HTTP %d: %s", status, strings.TrimSpace(string(body)))
+	case resp.AccessToken == "":
+		return Secret{}, fmt.Errorf("oauth2: response has no access token")
+	case resp.TokenType != "" && !strings.EqualFold(resp.TokenType, "bearer"):
+		return Secret{}, fmt.Errorf("oauth2: unsupported token type %q", resp.TokenType)
+	}
+
+	secret := Secret{Value: resp.AccessToken}
+	if resp.ExpiresIn > 0 {
+		secret.ExpiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return secret, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingTokens is an OAuth2TokenSource issuing t1, t2, ... in turn.
+type countingTokens struct {
+	mu        sync.Mutex
+	n         int
+	tokenType string
+}
+
+func (ts *countingTokens) Token() (*OAuth2Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.n++
+	return &OAuth2Token{AccessToken: fmt.Sprintf("t%d", ts.n), TokenType: ts.tokenType, Expiry: time.Now().Add(time.Hour)}, nil
+}
+
+// authServer records the Authorization headers it receives and passes
+// requests bearing one of the accepted tokens on to a writeServer.
+type authServer struct {
+	*httptest.Server
+	writes *writeServer
+
+	mu       sync.Mutex
+	accepted map[string]bool
+	auth     []string
+}
+
+func newAuthServer(t *testing.T, accepted ...string) *authServer {
+	t.Helper()
+	as := &authServer{writes: newWriteServer(t, nil), accepted: make(map[string]bool)}
+	for _, token := range accepted {
+		as.accepted["Bearer "+token] = true
+	}
+	as.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		as.mu.Lock()
+		auth := r.Header.Get("Authorization")
+		as.auth = append(as.auth, auth)
+		ok := as.accepted[auth]
+		as.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"success":false,"message":"invalid token"}`)
+			return
+		}
+		as.writes.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(as.Close)
+	return as
+}
+
+func (as *authServer) requests() []string {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	return append([]string(nil), as.auth...)
+}
+
+func TestReauthorizeAfter401(t *testing.T) {
+	tests := []struct {
+		name     string
+		accepted []string
+		wantAuth []string
+		wantErr  bool
+	}{
+		// The first token was revoked; the write is sent again with a
+		// fresh one.
+		{"revoked token", []string{"t2"}, []string{"Bearer t1", "Bearer t2"}, false},
+		// A request is sent again at most once.
+		{"rejected again", nil, []string{"Bearer t1", "Bearer t2"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := newAuthServer(t, tt.accepted...)
+			c := NewClient(as.URL, "", WithOAuth2TokenSource(&countingTokens{}))
+
+			_, err := c.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"})
+			var apiErr *Error
+			if tt.wantErr != (err != nil) || (err != nil && (!errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized)) {
+				t.Errorf("CreateMemory: %v, want error %t", err, tt.wantErr)
+			}
+			if got := as.requests(); fmt.Sprint(got) != fmt.Sprint(tt.wantAuth) {
+				t.Errorf("server got Authorization %q, want %q", got, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestReauthorizeSendsBodyAgain(t *testing.T) {
+	as := newAuthServer(t, "t2")
+	c := NewClient(as.URL, "", WithOAuth2TokenSource(&countingTokens{}))
+	if _, err := c.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"}); err != nil {
+		t.Fatalf("CreateMemory: %v", err)
+	}
+	if w := as.writes.writes; len(w) != 1 || w[0].Content != "hello" {
+		t.Errorf("retried write arrived as %+v", w)
+	}
+}
+
+func TestReauthorizeSkipsUnchangedToken(t *testing.T) {
+	as := newAuthServer(t)
+	c := NewClient(as.URL, "", WithBearerToken("static"))
+	if _, err := c.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"}); err == nil {
+		t.Fatal("CreateMemory with a rejected token succeeded")
+	}
+	if got := as.requests(); len(got) != 1 {
+		t.Errorf("server got %d requests, want 1 since the token did not change", len(got))
+	}
+}
+
+func TestOAuth2TokenSourceRejectsNonBearerTokens(t *testing.T) {
+	as := newAuthServer(t, "t1")
+	c := NewClient(as.URL, "", WithOAuth2TokenSource(&countingTokens{tokenType: "mac"}))
+	_, err := c.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"})
+	if err == nil || !strings.Contains(err.Error(), `unsupported token type "mac"`) {
+		t.Errorf("CreateMemory: %v, want an unsupported token type error", err)
+	}
+	if got := as.requests(); len(got) != 0 {
+		t.Errorf("server got %d requests, want none", len(got))
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	var tokenRequests int
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "svc" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad client"}`)
+			return
+		}
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "powermem.write" {
+			t.Errorf("token request form %v", r.PostForm)
+		}
+		fmt.Fprintf(w, `{"access_token":"t%d","token_type":"Bearer","expires_in":3600}`, tokenRequests)
+	}))
+	defer tokenSrv.Close()
+
+	as := newAuthServer(t, "t1")
+	c := NewClient(as.URL, "", WithOAuth2("svc", "s3cret", tokenSrv.URL, "powermem.write"))
+	for i := 0; i < 2; i++ {
+		if _, err := c.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"}); err != nil {
+			t.Fatalf("CreateMemory: %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token endpoint got %d requests, want 1 since the token is cached", tokenRequests)
+	}
+
+	bad := NewClient(as.URL, "", WithOAuth2("svc", "wrong", tokenSrv.URL))
+	if _, err := bad.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"}); err == nil || !strings.Contains(err.Error(), "bad client") {
+		t.Errorf("CreateMemory with a rejected client: %v, want the token endpoint's error", err)
+	}
+}
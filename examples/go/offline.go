@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueuedOffline is returned by CreateMemory when the server is
+// unreachable and the write was queued on disk instead. The outcome is
+// reported to OfflineQueueOptions.OnDelivery once it has been uploaded.
+var ErrQueuedOffline = errors.New("powermem: server unreachable, write queued offline")
+
+// errOfflineQueueNotConfigured is returned by OfflineQueue without
+// WithOfflineQueue.
+var errOfflineQueueNotConfigured = errors.New("powermem: offline queue is not configured")
+
+// OfflineQueueOptions configures the write-behind queue used by
+// CreateMemory while the server is unreachable.
+type OfflineQueueOptions struct {
+	// Dir receives queued writes as NDJSON files. Writes left by a
+	// previous process are uploaded too. It must not be shared with an
+	// Ingestor's SpillDir.
+	Dir string
+
+	// RetryInterval is the initial delay between attempts to reach the
+	// server. It doubles while the server stays unreachable, up to
+	// MaxRetryInterval. Defaults to 1s and 1m.
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+
+	// MaxAttempts bounds upload attempts per write rejected with 429
+	// once the server is reachable. Default 5.
+	MaxAttempts int
+
+	// OnDelivery, if set, is called from the flush goroutine for every
+	// queued write once it has been uploaded or has permanently failed.
+	OnDelivery func(DeliveryStatus)
+}
+
+// WithOfflineQueue makes CreateMemory queue writes on disk while the
+// server is unreachable and upload them in order once it is back.
+func WithOfflineQueue(opts OfflineQueueOptions) Option {
+	return func(c *Client) {
+		c.offlineOpts = &opts
+	}
+}
+
+// OfflineQueue holds writes made while the server was unreachable and
+// uploads them in the background once connectivity returns.
+type OfflineQueue struct {
+	client *Client
+	opts   OfflineQueueOptions
+	spill  *spillLog
+
+	pending atomic.Int64
+	nextID  atomic.Uint64
+
+	// flushMu serializes flushes, which keeps writes in order.
+	flushMu sync.Mutex
+
+	quit      chan struct{}
+	closeOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewOfflineQueue opens the queue in opts.Dir and starts uploading writes
+// through c, including writes left by a previous process.
+func NewOfflineQueue(c *Client, opts OfflineQueueOptions) (*OfflineQueue, error) {
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = time.Second
+	}
+	if opts.MaxRetryInterval <= 0 {
+		opts.MaxRetryInterval = time.Minute
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+
+	spill, recovered, err := openSpillLog(opts.Dir, "spill-")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &OfflineQueue{
+		client: c,
+		opts:   opts,
+		spill:  spill,
+		quit:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	q.nextID.Store(uint64(time.Now().UnixNano()))
+	q.pending.Add(int64(recovered))
+
+	q.wg.Add(1)
+	go q.run()
+	return q, nil
+}
+
+// Pending returns the number of queued writes not yet uploaded.
+func (q *OfflineQueue) Pending() int {
+	return int(q.pending.Load())
+}
+
+// Flush uploads queued writes now rather than at the next retry. It
+// returns the connectivity error if the server is still unreachable.
+func (q *OfflineQueue) Flush(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(q.ctx, cancel)
+	defer stop()
+	return q.flush(ctx)
+}
+
+// Close stops the background uploads, cancelling an upload in flight when
+// ctx is done. Queued writes stay on disk for the next process.
+func (q *OfflineQueue) Close(ctx context.Context) error {
+	q.closeOnce.Do(func() { close(q.quit) })
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	q.cancel()
+	<-done
+
+	// Wait for a concurrent Flush to stop before sealing the log.
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+	q.spill.close()
+	return err
+}
+
+// create sends req through sender, with sender's defaults and receipt
+// setting applied, queueing it when the request could not be sent to the
+// server. Failures after the request was sent are returned instead, since
+// the server may have stored the write and uploading it again would store
+// it twice. While earlier writes are queued, req is queued behind them to
+// keep writes in order.
+func (q *OfflineQueue) create(ctx context.Context, sender *Client, req *CreateMemoryRequest) ([]CreatedMemory, error) {
+	req, err := sender.prepareCreate(req)
+	if err != nil {
+		return nil, err
+	}
+	if q.Pending() == 0 {
+		memories, err := sender.postMemory(ctx, req)
+		if err == nil || !requestNotSent(err) || ctx.Err() != nil {
+			return memories, err
+		}
+	}
+
+	item := &ingestItem{
+		ID:         q.nextID.Add(1),
+		Request:    req,
+		EnqueuedAt: time.Now(),
+		sender:     sender,
+	}
+	q.pending.Add(1)
+	if err := q.spill.append(item); err != nil {
+		q.pending.Add(-1)
+		return nil, err
+	}
+	return nil, ErrQueuedOffline
+}
+
+// run flushes the queue periodically, backing off while the server is
+// unreachable.
+func (q *OfflineQueue) run() {
+	defer q.wg.Done()
+	delay := q.opts.RetryInterval
+	for {
+		timer := time.NewTimer(jitter(delay))
+		select {
+		case <-q.quit:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := q.flush(q.ctx); err != nil {
+			delay = min(delay*2, q.opts.MaxRetryInterval)
+		} else {
+			delay = q.opts.RetryInterval
+		}
+	}
+}
+
+// flush uploads queued writes in order until the queue is empty or the
+// server is unreachable, in which case the remaining writes stay queued
+// and the connectivity error is returned.
+func (q *OfflineQueue) flush(ctx context.Context) error {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+	for {
+		seg, err := q.spill.next()
+		if err != nil {
+			return err
+		}
+		if seg == "" {
+			return nil
+		}
+		items, err := q.spill.read(seg)
+		if err != nil {
+			return err
+		}
+
+		for i, item := range items {
+			status, err := q.deliver(ctx, item)
+			if err != nil {
+				q.spill.rewrite(seg, items[i:])
+				return err
+			}
+			q.pending.Add(-1)
+			if q.opts.OnDelivery != nil {
+				q.client.guard("OnDelivery", func() { q.opts.OnDelivery(status) })
+			}
+		}
+		q.spill.remove(seg)
+	}
+}
+
+// deliver uploads item through the client it was submitted through,
+// retrying writes rejected with 429. It returns an error, leaving item
+// queued, only if the request could not be sent or ctx is done; other
+// failures are reported in the status.
+func (q *OfflineQueue) deliver(ctx context.Context, item *ingestItem) (DeliveryStatus, error) {
+	status := DeliveryStatus{
+		Request:    item.Request,
+		Spilled:    true,
+		Priority:   item.Priority,
+		EnqueuedAt: item.EnqueuedAt,
+	}
+
+	backoff := 200 * time.Millisecond
+	for {
+		status.Attempts++
+		status.Memories, status.Err = q.senderOf(item).postMemory(ctx, item.Request)
+		if status.Err != nil && (ctx.Err() != nil || requestNotSent(status.Err)) {
+			return status, status.Err
+		}
+		if status.Err == nil || status.Attempts >= q.opts.MaxAttempts || !isTransient(status.Err) {
+			return status, nil
+		}
+		if err := sleepContext(ctx, jitter(backoff)); err != nil {
+			return status, err
+		}
+		backoff *= 2
+	}
+}
+
+// senderOf returns the client item is uploaded through. Writes left by a
+// previous process go through the queue's client.
+func (q *OfflineQueue) senderOf(item *ingestItem) *Client {
+	if item.sender != nil {
+		return item.sender
+	}
+	return q.client
+}
+
+// OfflineQueue returns the client's offline queue, opening it on first
+// use, e.g. to upload writes left by a previous process at startup. It
+// fails unless the client was created with WithOfflineQueue.
+func (c *Client) OfflineQueue() (*OfflineQueue, error) {
+	if c.offlineOpts == nil {
+		return nil, errOfflineQueueNotConfigured
+	}
+	st := c.state()
+	st.offlineOnce.Do(func() {
+		q, err := NewOfflineQueue(c, *c.offlineOpts)
+		if err == nil {
+			if err = c.addBackground("offline queue", q.Close); err != nil {
+				q.Close(context.Background())
+				q = nil
+			}
+		}
+		st.offline, st.offlineErr = q, err
+	})
+	return st.offline, st.offlineErr
+}
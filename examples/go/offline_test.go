@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOfflineQueueRecoversTornSegment(t *testing.T) {
+	ws := newWriteServer(t, nil)
+	dir := t.TempDir()
+	writeSegment(t, dir, "spill-", 1, spillRecord(t, 1, "recovered", PriorityNormal), `{"id":2,"request":{"content":"tor`)
+
+	c := NewClient(ws.URL, "", WithOfflineQueue(OfflineQueueOptions{Dir: dir, RetryInterval: time.Hour}))
+	defer closeClient(t, c)
+	q, err := c.OfflineQueue()
+	if err != nil {
+		t.Fatalf("OfflineQueue: %v", err)
+	}
+	if got := q.Pending(); got != 1 {
+		t.Errorf("Pending() = %d after recovery, want 1", got)
+	}
+	if err := q.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := q.Pending(); got != 0 {
+		t.Fatalf("Pending() = %d after Flush, want 0", got)
+	}
+
+	// With nothing pending, writes go straight to the server again.
+	if _, err := c.CreateMemory(&CreateMemoryRequest{Content: "direct", UserID: "u1"}); err != nil {
+		t.Fatalf("CreateMemory: %v", err)
+	}
+	if len(ws.writes) != 2 || ws.writes[0].Content != "recovered" || ws.writes[1].Content != "direct" {
+		t.Errorf("server got %+v, want the recovered and the direct write", ws.writes)
+	}
+}
+
+func TestOfflineQueueQueuesUnsentWrites(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var delivered []DeliveryStatus
+	c := NewClient("http://"+addr, "", WithOfflineQueue(OfflineQueueOptions{
+		Dir:           t.TempDir(),
+		RetryInterval: time.Hour,
+		OnDelivery:    func(s DeliveryStatus) { delivered = append(delivered, s) },
+	}))
+	defer closeClient(t, c)
+
+	child := c.With(WithDefaultMetadata(map[string]interface{}{"app": "child"}))
+	if _, err := child.CreateMemory(&CreateMemoryRequest{Content: "offline", UserID: "u1"}); !errors.Is(err, ErrQueuedOffline) {
+		t.Fatalf("CreateMemory with the server down: %v, want ErrQueuedOffline", err)
+	}
+	if _, err := child.CreateMemory(&CreateMemoryRequest{Content: "x", UserID: "u1", Importance: 2}); err == nil || errors.Is(err, ErrQueuedOffline) {
+		t.Errorf("invalid write: %v, want a validation error", err)
+	}
+
+	// Bring the server up on the address the client uses.
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	ws := &writeServer{}
+	ws.Server = httptest.NewUnstartedServer(http.HandlerFunc(ws.serve))
+	ws.Listener.Close()
+	ws.Listener = l
+	ws.Start()
+	defer ws.Close()
+
+	q, _ := c.OfflineQueue()
+	if err := q.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(delivered) != 1 || delivered[0].Err != nil {
+		t.Fatalf("deliveries %+v, want one successful delivery", delivered)
+	}
+	if got := ws.writes[0].Metadata["app"]; got != "child" {
+		t.Errorf("metadata app = %v, want child", got)
+	}
+}
+
+func TestOfflineQueueReturnsErrorsAfterSending(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}},
+		{"gateway timeout", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}},
+		{"server wait timeout", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(300 * time.Millisecond)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			c := NewClientWithTimeout(srv.URL, "", 100*time.Millisecond, WithOfflineQueue(OfflineQueueOptions{Dir: t.TempDir(), RetryInterval: time.Hour}))
+			defer closeClient(t, c)
+
+			// The server may have stored the write; queueing it could
+			// store it twice.
+			_, err := c.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"})
+			if err == nil || errors.Is(err, ErrQueuedOffline) {
+				t.Errorf("CreateMemory: %v, want the server's error", err)
+			}
+			if q, _ := c.OfflineQueue(); q.Pending() != 0 {
+				t.Errorf("Pending() = %d, want 0", q.Pending())
+			}
+		})
+	}
+}
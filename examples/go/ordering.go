@@ -0,0 +1,189 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OrderingStrategy decides how the Ingestor groups and orders pending
+// writes before uploading them. Grouping writes by scope lets them share
+// one batch request, and placing similar content next to each other makes
+// server-side deduplication more effective.
+type OrderingStrategy interface {
+	// Group partitions writes into batches, each given as indices into
+	// writes in upload order. Every index must appear exactly once.
+	// Writes whose user, agent, run or infer settings differ are split
+	// into separate requests even if grouped together.
+	Group(writes []*CreateMemoryRequest) [][]int
+}
+
+// OrderingFunc adapts a function to the OrderingStrategy interface.
+type OrderingFunc func(writes []*CreateMemoryRequest) [][]int
+
+// Group implements OrderingStrategy.
+func (f OrderingFunc) Group(writes []*CreateMemoryRequest) [][]int {
+	return f(writes)
+}
+
+// FIFOOrdering uploads writes in submission order, grouping consecutive
+// writes that share a scope.
+var FIFOOrdering OrderingStrategy = OrderingFunc(func(writes []*CreateMemoryRequest) [][]int {
+	var groups [][]int
+	for i, w := range writes {
+		if n := len(groups); n > 0 && batchKeyOf(writes[groups[n-1][0]]) == batchKeyOf(w) {
+			groups[n-1] = append(groups[n-1], i)
+			continue
+		}
+		groups = append(groups, []int{i})
+	}
+	return groups
+})
+
+// ScopeSimilarityOrdering groups writes by scope and orders each group so
+// that writes with similar content are adjacent, using a greedy
+// nearest-neighbour chain over word-set Jaccard similarity. It is the
+// default strategy.
+type ScopeSimilarityOrdering struct{}
+
+// Group implements OrderingStrategy.
+func (ScopeSimilarityOrdering) Group(writes []*CreateMemoryRequest) [][]int {
+	byKey := make(map[batchKey][]int)
+	var keys []batchKey
+	for i, w := range writes {
+		k := batchKeyOf(w)
+		if _, ok := byKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], i)
+	}
+
+	groups := make([][]int, 0, len(keys))
+	for _, k := range keys {
+		groups = append(groups, chainBySimilarity(writes, byKey[k]))
+	}
+	return groups
+}
+
+// chainBySimilarity orders idx starting from the first write, repeatedly
+// appending the most similar remaining write.
+func chainBySimilarity(writes []*CreateMemoryRequest, idx []int) []int {
+	if len(idx) <= 2 {
+		return idx
+	}
+	words := make(map[int]map[string]struct{}, len(idx))
+	for _, i := range idx {
+		words[i] = wordSet(writes[i].Content)
+	}
+
+	remaining := append([]int(nil), idx[1:]...)
+	ordered := []int{idx[0]}
+	for len(remaining) > 0 {
+		last := words[ordered[len(ordered)-1]]
+		best, bestScore := 0, -1.0
+		for j, cand := range remaining {
+			if score := jaccard(last, words[cand]); score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		ordered = append(ordered, remaining[best])
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return ordered
+}
+
+// wordSet returns the lower-cased words of s.
+func wordSet(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}) {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// jaccard returns |a ∩ b| / |a ∪ b|.
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	inter := 0
+	for w := range a {
+		if _, ok := b[w]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// batchKey identifies writes that can share one batch request.
+type batchKey struct {
+	userID, agentID, runID string
+	infer                  string
+
+	// single is set to the write itself for writes the batch endpoint
+	// cannot carry, so that each is a batch of its own.
+	single *CreateMemoryRequest
+}
+
+func batchKeyOf(w *CreateMemoryRequest) batchKey {
+	if !batchable(w) {
+		return batchKey{single: w}
+	}
+	k := batchKey{userID: w.UserID, agentID: w.AgentID, runID: w.RunID, infer: "default"}
+	if w.Infer != nil {
+		k.infer = strconv.FormatBool(*w.Infer)
+	}
+	return k
+}
+
+// batchable reports whether w can be sent as a BatchMemoryItem:
+// conversations, receipts and asynchronous writes have no batch
+// equivalent.
+func batchable(w *CreateMemoryRequest) bool {
+	return len(w.Messages) == 0 && !w.Receipt && !w.Async
+}
+
+// splitGroups enforces batch constraints on a strategy's output: each
+// batch shares one batch key and holds at most size writes. Indices the
+// strategy omitted are appended as single-write batches.
+func splitGroups(writes []*CreateMemoryRequest, groups [][]int, size int) [][]int {
+	seen := make([]bool, len(writes))
+	var out [][]int
+	for _, g := range groups {
+		byKey := make(map[batchKey][]int)
+		var keys []batchKey
+		for _, i := range g {
+			if i < 0 || i >= len(writes) || seen[i] {
+				continue
+			}
+			seen[i] = true
+			k := batchKeyOf(writes[i])
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], i)
+		}
+		for _, k := range keys {
+			part := byKey[k]
+			for len(part) > size {
+				out = append(out, part[:size])
+				part = part[size:]
+			}
+			out = append(out, part)
+		}
+	}
+
+	var missing []int
+	for i, ok := range seen {
+		if !ok {
+			missing = append(missing, i)
+		}
+	}
+	sort.Ints(missing)
+	for _, i := range missing {
+		out = append(out, []int{i})
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a panic recovered by the client: in a callback it calls,
+// such as an event handler, OnError or OnDelivery hook, middleware or
+// credential store, or while decoding malformed server data. The client
+// turns such panics into errors rather than let them crash the process
+// from one of its background goroutines.
+type PanicError struct {
+	// Op is the operation that panicked, e.g. "event handler".
+	Op string
+
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("powermem: panic in %s: %v", e.Op, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func newPanicError(op string, v interface{}) *PanicError {
+	return &PanicError{Op: op, Value: v, Stack: debug.Stack()}
+}
+
+// WithPanicHandler calls fn with every panic the client recovers, e.g. to
+// log the stack trace or count panics in metrics. The panic is also
+// returned as an error by the call that caused it or, in a background
+// goroutine, reported to the component's error hook where it has one.
+// fn is called from the goroutine that panicked; a panic in fn itself is
+// discarded.
+func WithPanicHandler(fn func(*PanicError)) Option {
+	return func(c *Client) {
+		c.onPanic = fn
+	}
+}
+
+// recoverPanic recovers a panic of the function deferring it, which must
+// defer it directly, and turns it into a *PanicError stored in *err, if
+// err is non-nil, and reported to the panic handler.
+func (c *Client) recoverPanic(op string, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	pe := newPanicError(op, v)
+	c.reportPanic(pe)
+	if err != nil {
+		*err = pe
+	}
+}
+
+// reportPanic calls the panic handler, if any.
+func (c *Client) reportPanic(pe *PanicError) {
+	if c.onPanic == nil {
+		return
+	}
+	defer func() { recover() }()
+	c.onPanic(pe)
+}
+
+// guard calls fn, returning a panic in it as a *PanicError.
+func (c *Client) guard(op string, fn func()) (err error) {
+	defer c.recoverPanic(op, &err)
+	fn()
+	return nil
+}
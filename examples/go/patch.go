@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// patchRequest is the request body of PatchMemory. Memory holds the
+// masked fields, with null for cleared ones.
+type patchRequest struct {
+	UpdateMask []PatchField           `json:"update_mask"`
+	Memory     map[string]interface{} `json:"memory"`
+	UserID     string                 `json:"user_id,omitempty"`
+	AgentID    string                 `json:"agent_id,omitempty"`
+}
+
+// PatchMemory changes only the fields of a memory named in patch.Mask,
+// e.g. its metadata without resending its content, and clears masked
+// fields left at their zero value:
+//
+//	// Set metadata "status" and remove "ticket", keeping other keys.
+//	client.PatchMemory(ctx, id, MemoryPatch{
+//		Mask:     []PatchField{PatchMetadataKey("status"), PatchMetadataKey("ticket")},
+//		Metadata: map[string]interface{}{"status": "resolved"},
+//	})
+//
+// Patching PatchMetadata replaces the whole metadata, merged over the
+// client's default metadata. Content cannot be cleared.
+func (c *Client) PatchMemory(ctx context.Context, memoryID MemoryID, patch MemoryPatch) (*Memory, error) {
+	body, err := c.patchBody(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/api/v1/memories/%s", memoryID.String())
+	respBody, err := c.doRequestContext(ctx, http.MethodPatch, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Memory]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("patch memory failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// patchBody validates patch and returns its request body.
+func (c *Client) patchBody(patch MemoryPatch) (*patchRequest, error) {
+	if len(patch.Mask) == 0 {
+		return nil, errors.New("patch mask is empty")
+	}
+	body := &patchRequest{
+		UpdateMask: patch.Mask,
+		Memory:     map[string]interface{}{},
+		UserID:     patch.UserID,
+		AgentID:    patch.AgentID,
+	}
+	// orNil returns v, or nil, which clears the field, if v is zero.
+	orNil := func(v interface{}, zero bool) interface{} {
+		if zero {
+			return nil
+		}
+		return v
+	}
+	var keys map[string]interface{}
+
+	for _, field := range patch.Mask {
+		switch field {
+		case PatchContent:
+			if strings.TrimSpace(patch.Content) == "" {
+				return nil, errors.New("content cannot be cleared")
+			}
+			body.Memory["content"] = patch.Content
+		case PatchMetadata:
+			md := patch.Metadata
+			if c.defaultMetadata != nil {
+				md = mergeMetadata(c.defaultMetadata, md)
+			}
+			body.Memory["metadata"] = orNil(md, len(md) == 0)
+		case PatchVisibility:
+			if err := checkVisibility(patch.Visibility); err != nil {
+				return nil, err
+			}
+			body.Memory["visibility"] = orNil(patch.Visibility, patch.Visibility == "")
+		case PatchImportance:
+			if err := checkImportance(patch.Importance); err != nil {
+				return nil, err
+			}
+			body.Memory["importance"] = orNil(patch.Importance, patch.Importance == 0)
+		case PatchExpiresAt:
+			body.Memory["expires_at"] = orNil(patch.ExpiresAt, patch.ExpiresAt == nil)
+		case PatchMemoryType:
+			if err := checkMemoryType(patch.MemoryType); err != nil {
+				return nil, err
+			}
+			body.Memory["memory_type"] = orNil(patch.MemoryType, patch.MemoryType == "")
+		case PatchPinned:
+			body.Memory["pinned"] = patch.Pinned
+		default:
+			key, ok := strings.CutPrefix(string(field), string(PatchMetadata)+".")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid patch field %q", field)
+			}
+			if keys == nil {
+				keys = map[string]interface{}{}
+			}
+			// A key missing from Metadata is sent as null, clearing it.
+			keys[key] = patch.Metadata[key]
+		}
+	}
+
+	if keys != nil {
+		if _, whole := body.Memory["metadata"]; whole {
+			return nil, fmt.Errorf("patch mask has both %q and single metadata keys", PatchMetadata)
+		}
+		body.Memory["metadata"] = keys
+	}
+	return body, nil
+}
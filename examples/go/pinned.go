@@ -0,0 +1,19 @@
+package main
+
+import "context"
+
+// PinMemory pins a memory: inference no longer updates, supersedes or
+// deletes it when new facts arrive, and every search it matches returns
+// it, e.g. for allergies or standing instructions. Explicit updates and
+// deletes still apply.
+func (c *Client) PinMemory(ctx context.Context, memoryID MemoryID) (*Memory, error) {
+	pinned := true
+	return c.updateMemory(ctx, memoryID, &UpdateMemoryRequest{Pinned: &pinned})
+}
+
+// UnpinMemory unpins a memory, returning it to inference and normal
+// ranking.
+func (c *Client) UnpinMemory(ctx context.Context, memoryID MemoryID) (*Memory, error) {
+	pinned := false
+	return c.updateMemory(ctx, memoryID, &UpdateMemoryRequest{Pinned: &pinned})
+}
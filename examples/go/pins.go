@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// =============================================================================
+// Search Curation (Admin)
+// =============================================================================
+
+// CreateSearchPin creates a pin that promotes memories in the results of
+// matching searches. Pins take effect immediately, with no re-embedding
+// or retraining.
+func (c *Client) CreateSearchPin(ctx context.Context, pin *SearchPin) (*SearchPin, error) {
+	if pin.Pattern == "" || len(pin.MemoryIDs) == 0 {
+		return nil, errors.New("search pin needs a pattern and at least one memory ID")
+	}
+	if pin.Match == "" {
+		withMatch := *pin
+		withMatch.Match = PinMatchExact
+		pin = &withMatch
+	}
+	if pin.Match == PinMatchRegex {
+		if _, err := regexp.Compile(pin.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid search pin pattern: %w", err)
+		}
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/admin/search-pins", pin)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[SearchPin]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("create search pin failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListSearchPins retrieves the search pins, optionally only those scoped
+// to userID.
+func (c *Client) ListSearchPins(ctx context.Context, userID string) (*SearchPinList, error) {
+	path := "/api/v1/admin/search-pins"
+	if userID != "" {
+		params := url.Values{}
+		params.Set("user_id", userID)
+		path += "?" + params.Encode()
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[SearchPinList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list search pins failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteSearchPin deletes a search pin.
+func (c *Client) DeleteSearchPin(ctx context.Context, pinID string) error {
+	path := fmt.Sprintf("/api/v1/admin/search-pins/%s", url.PathEscape(pinID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete search pin failed: %s", resp.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+// Priority orders queued writes in the Ingestor. Higher-priority writes are
+// always uploaded first, so a bulk backfill sharing a client with a live
+// agent cannot starve the agent's writes.
+type Priority int
+
+const (
+	// PriorityBackground is for bulk imports and backfills.
+	PriorityBackground Priority = -1
+
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+
+	// PriorityInteractive is for writes on the path of a live
+	// conversation turn.
+	PriorityInteractive Priority = 1
+)
+
+// priorities lists all priorities from highest to lowest.
+var priorities = []Priority{PriorityInteractive, PriorityNormal, PriorityBackground}
+
+// index returns the position of p in priorities. Unknown values are
+// clamped to the nearest defined priority.
+func (p Priority) index() int {
+	switch {
+	case p >= PriorityInteractive:
+		return 0
+	case p <= PriorityBackground:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	return [...]string{"interactive", "normal", "background"}[p.index()]
+}
+
+// WithWritePriority sets the priority of writes queued through
+// CreateMemoryAsyncNoWait. It is typically applied to a child client:
+//
+//	backfill := client.With(WithWritePriority(PriorityBackground))
+func WithWritePriority(p Priority) Option {
+	return func(c *Client) {
+		c.writePriority = p
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// =============================================================================
+// Retrieval Profiles
+// =============================================================================
+
+// PutRetrievalProfile creates or replaces the retrieval profile named
+// profile.Name.
+func (c *Client) PutRetrievalProfile(ctx context.Context, profile *RetrievalProfile) (*RetrievalProfile, error) {
+	if profile.Name == "" {
+		return nil, errors.New("retrieval profile name is required")
+	}
+	path := fmt.Sprintf("/api/v1/retrieval-profiles/%s", url.PathEscape(profile.Name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, path, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RetrievalProfile]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("put retrieval profile failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GetRetrievalProfile retrieves a retrieval profile by name.
+func (c *Client) GetRetrievalProfile(ctx context.Context, name string) (*RetrievalProfile, error) {
+	path := fmt.Sprintf("/api/v1/retrieval-profiles/%s", url.PathEscape(name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RetrievalProfile]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get retrieval profile failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListRetrievalProfiles retrieves all retrieval profiles.
+func (c *Client) ListRetrievalProfiles(ctx context.Context) (*RetrievalProfileList, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/retrieval-profiles", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RetrievalProfileList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list retrieval profiles failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteRetrievalProfile deletes a retrieval profile. Searches naming it
+// fail afterwards.
+func (c *Client) DeleteRetrievalProfile(ctx context.Context, name string) error {
+	path := fmt.Sprintf("/api/v1/retrieval-profiles/%s", url.PathEscape(name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete retrieval profile failed: %s", resp.Message)
+	}
+
+	return nil
+}
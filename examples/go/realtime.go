@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRealtimeDisconnected is returned by RealtimeConn.Search when the
+// connection is lost before the response arrives. The connection
+// reconnects on its own; the search may be retried.
+var ErrRealtimeDisconnected = errors.New("powermem: realtime connection lost")
+
+// RealtimeOptions configures a realtime connection.
+type RealtimeOptions struct {
+	// SubscribeParams selects the memory events pushed on Events when
+	// Subscribe is set. Its ReconnectDelay, MaxReconnectDelay and OnError
+	// apply to the connection either way.
+	SubscribeParams
+	Subscribe bool
+
+	// PingInterval is how often the connection is probed when idle.
+	// Defaults to 30s.
+	PingInterval time.Duration
+
+	// PongTimeout is how long the server may stay silent after a ping
+	// before the connection is considered dead and replaced. Defaults to
+	// 10s.
+	PongTimeout time.Duration
+}
+
+// RealtimeConn is a WebSocket connection to the realtime API, for
+// low-latency use by agents: memory events are pushed as they happen and
+// searches run over the same connection without per-request overhead.
+// When the connection drops it reconnects with backoff and resumes the
+// event subscription after the last event received.
+type RealtimeConn struct {
+	c      *Client
+	opts   RealtimeOptions
+	events chan MemoryEvent
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	nextID atomic.Uint64
+
+	mu      sync.Mutex
+	conn    *wsConn
+	pending map[string]chan realtimeReply
+	token   string
+}
+
+// realtimeMessage is the envelope of every realtime message. Requests and
+// their replies share an ID; pushed events have none.
+type realtimeMessage struct {
+	ID    string          `json:"id,omitempty"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// realtimeReply is the reply to a request, or the error that lost it.
+type realtimeReply struct {
+	msg realtimeMessage
+	err error
+}
+
+// DialRealtime opens a realtime connection. Close it when done.
+func (c *Client) DialRealtime(ctx context.Context, opts RealtimeOptions) (*RealtimeConn, error) {
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = 30 * time.Second
+	}
+	if opts.PongTimeout <= 0 {
+		opts.PongTimeout = 10 * time.Second
+	}
+	rc := &RealtimeConn{
+		c:       c,
+		opts:    opts,
+		events:  make(chan MemoryEvent, 64),
+		done:    make(chan struct{}),
+		pending: make(map[string]chan realtimeReply),
+		token:   opts.ResumeToken,
+	}
+	rc.ctx, rc.cancel = context.WithCancel(context.Background())
+
+	conn, err := rc.connect(ctx)
+	if err != nil {
+		rc.cancel()
+		return nil, err
+	}
+	untrack, err := c.trackBackground("realtime connection", rc.closeContext)
+	if err != nil {
+		conn.close()
+		rc.cancel()
+		return nil, err
+	}
+	go func() {
+		defer untrack()
+		defer rc.recoverRun()
+		rc.run(conn)
+	}()
+	return rc, nil
+}
+
+// Events returns the memory events pushed by the server. It is closed
+// when the connection is closed or the server rejects it. When
+// subscribed, consumers must drain it: a full channel stalls the
+// connection, including searches.
+func (rc *RealtimeConn) Events() <-chan MemoryEvent {
+	return rc.events
+}
+
+// Search runs a search over the realtime connection.
+func (rc *RealtimeConn) Search(ctx context.Context, req *SearchMemoryRequest) (*SearchResults, error) {
+	reply, err := rc.request(ctx, "search", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var results SearchResults
+	if err := json.Unmarshal(reply.Data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	rc.c.processSearchResults(&results, req)
+	return &results, nil
+}
+
+// Close closes the connection gracefully and waits for it to shut down.
+func (rc *RealtimeConn) Close() error {
+	return rc.closeContext(context.Background())
+}
+
+// closeContext closes the connection, waiting for it to stop at most
+// until ctx is done.
+func (rc *RealtimeConn) closeContext(ctx context.Context) error {
+	rc.cancel()
+	rc.mu.Lock()
+	if rc.conn != nil {
+		rc.conn.close()
+	}
+	rc.mu.Unlock()
+	select {
+	case <-rc.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// request sends a request and waits for its reply.
+func (rc *RealtimeConn) request(ctx context.Context, typ string, body interface{}) (realtimeMessage, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return realtimeMessage{}, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	id := strconv.FormatUint(rc.nextID.Add(1), 10)
+	ch := make(chan realtimeReply, 1)
+
+	rc.mu.Lock()
+	conn := rc.conn
+	if conn == nil {
+		rc.mu.Unlock()
+		return realtimeMessage{}, ErrRealtimeDisconnected
+	}
+	rc.pending[id] = ch
+	rc.mu.Unlock()
+	defer func() {
+		rc.mu.Lock()
+		delete(rc.pending, id)
+		rc.mu.Unlock()
+	}()
+
+	if err := rc.send(conn, realtimeMessage{ID: id, Type: typ, Data: data}); err != nil {
+		return realtimeMessage{}, ErrRealtimeDisconnected
+	}
+	select {
+	case reply := <-ch:
+		if reply.err != nil {
+			return realtimeMessage{}, reply.err
+		}
+		if reply.msg.Type == "error" {
+			return realtimeMessage{}, fmt.Errorf("%s failed: %s", typ, reply.msg.Error)
+		}
+		return reply.msg, nil
+	case <-ctx.Done():
+		return realtimeMessage{}, ctx.Err()
+	case <-rc.done:
+		return realtimeMessage{}, ErrRealtimeDisconnected
+	}
+}
+
+func (rc *RealtimeConn) send(conn *wsConn, msg realtimeMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return conn.write(wsText, data)
+}
+
+// connect dials the realtime endpoint and subscribes to events, resuming
+// after the last event received.
+func (rc *RealtimeConn) connect(ctx context.Context) (*wsConn, error) {
+	header := http.Header{}
+	if err := rc.c.setAuth(ctx, header); err != nil {
+		return nil, err
+	}
+
+	conn, err := dialWebSocket(ctx, rc.ctx, rc.c.httpClient(), rc.c.BaseURL+"/api/v1/realtime", header)
+	if err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			rc.c.invalidateAuth()
+		}
+		return nil, err
+	}
+
+	if rc.opts.Subscribe {
+		rc.mu.Lock()
+		token := rc.token
+		rc.mu.Unlock()
+		sub := map[string]interface{}{
+			"user_id":      rc.opts.UserID,
+			"agent_id":     rc.opts.AgentID,
+			"run_id":       rc.opts.RunID,
+			"types":        rc.opts.Types,
+			"resume_token": token,
+		}
+		data, _ := json.Marshal(sub)
+		if err := rc.send(conn, realtimeMessage{Type: "subscribe", Data: data}); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("failed to subscribe: %w", err)
+		}
+	}
+
+	rc.mu.Lock()
+	rc.conn = conn
+	rc.mu.Unlock()
+	return conn, nil
+}
+
+// run serves connections until the RealtimeConn is closed or the server
+// rejects it, reconnecting whenever a connection drops.
+func (rc *RealtimeConn) run(conn *wsConn) {
+	defer close(rc.done)
+	defer close(rc.events)
+
+	backoff := newReconnectBackoff(rc.opts.SubscribeParams)
+	for {
+		var err error
+		if conn != nil {
+			err = rc.serve(conn)
+			conn.close()
+			rc.disconnected()
+			backoff.reset()
+		}
+		if rc.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			rc.reportError(err)
+		}
+		if permanentStreamError(err) {
+			return
+		}
+		if !backoff.wait(rc.ctx, 0) {
+			return
+		}
+
+		conn, err = rc.connect(rc.ctx)
+		if err != nil {
+			conn = nil
+			if rc.ctx.Err() != nil {
+				return
+			}
+			rc.reportError(err)
+			if permanentStreamError(err) {
+				return
+			}
+		}
+	}
+}
+
+// recoverRun, deferred by the connection's goroutine, closes the
+// connection and reports a panic while reading it, e.g. on malformed
+// server data, instead of crashing the process.
+func (rc *RealtimeConn) recoverRun() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	pe := newPanicError("realtime connection", v)
+	rc.c.reportPanic(pe)
+	rc.reportError(pe)
+	rc.Close()
+}
+
+// reportError calls OnError, if set, recovering a panic in it.
+func (rc *RealtimeConn) reportError(err error) {
+	if rc.opts.OnError != nil {
+		rc.c.guard("OnError", func() { rc.opts.OnError(err) })
+	}
+}
+
+// disconnected fails the requests waiting on the lost connection.
+func (rc *RealtimeConn) disconnected() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.conn = nil
+	for id, ch := range rc.pending {
+		ch <- realtimeReply{err: ErrRealtimeDisconnected}
+		delete(rc.pending, id)
+	}
+}
+
+// serve reads messages from conn until it fails, probing it with pings
+// and dropping it when the server stops answering.
+func (rc *RealtimeConn) serve(conn *wsConn) error {
+	var lastSeen atomic.Int64
+	lastSeen.Store(time.Now().UnixNano())
+	conn.onPong = func() { lastSeen.Store(time.Now().UnixNano()) }
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(rc.opts.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			silent := time.Since(time.Unix(0, lastSeen.Load()))
+			if silent > rc.opts.PingInterval+rc.opts.PongTimeout {
+				conn.close()
+				return
+			}
+			if silent >= rc.opts.PingInterval {
+				conn.write(wsPing, nil)
+			}
+		}
+	}()
+
+	for {
+		data, err := conn.read()
+		if err != nil {
+			if rc.ctx.Err() != nil {
+				return rc.ctx.Err()
+			}
+			return fmt.Errorf("realtime connection: %w", err)
+		}
+		lastSeen.Store(time.Now().UnixNano())
+
+		var msg realtimeMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			rc.reportError(fmt.Errorf("failed to parse realtime message: %w", err))
+			continue
+		}
+		if msg.Type == "event" {
+			if err := rc.deliver(msg.Data); err != nil {
+				return err
+			}
+			continue
+		}
+		rc.mu.Lock()
+		if ch, ok := rc.pending[msg.ID]; ok {
+			ch <- realtimeReply{msg: msg}
+			delete(rc.pending, msg.ID)
+		}
+		rc.mu.Unlock()
+	}
+}
+
+// deliver pushes an event to Events.
+func (rc *RealtimeConn) deliver(data json.RawMessage) error {
+	var event MemoryEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		rc.reportError(fmt.Errorf("failed to parse change event: %w", err))
+		return nil
+	}
+	select {
+	case rc.events <- event:
+	case <-rc.ctx.Done():
+		return rc.ctx.Err()
+	}
+	if event.ResumeToken != "" {
+		rc.mu.Lock()
+		rc.token = event.ResumeToken
+		rc.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidReceipt is returned when a receipt does not match the memory it
+// was issued for or its signature does not verify.
+var ErrInvalidReceipt = errors.New("powermem: invalid receipt")
+
+// receiptVersion prefixes the signed message so the format can evolve.
+const receiptVersion = "powermem-receipt-v1"
+
+// Receipt is the server's signed statement that a memory with the given
+// content was stored at the given time. Keep receipts alongside the data
+// they cover to later prove when a fact was stored and that it has not
+// been altered since.
+type Receipt struct {
+	MemoryID MemoryID `json:"memory_id"`
+
+	// ContentHash is the hex-encoded SHA-256 of the stored content.
+	ContentHash string `json:"content_hash"`
+
+	// Timestamp is the RFC 3339 time the memory was stored. It is kept as
+	// sent because the signature covers its exact text.
+	Timestamp string `json:"timestamp"`
+
+	// KeyID identifies the server key that signed the receipt.
+	KeyID string `json:"key_id"`
+
+	// Signature is the base64-encoded Ed25519 signature of the message
+	// returned by SignedMessage.
+	Signature string `json:"signature"`
+}
+
+// Time parses the receipt's timestamp.
+func (r *Receipt) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, r.Timestamp)
+}
+
+// SignedMessage returns the bytes covered by the receipt's signature.
+func (r *Receipt) SignedMessage() []byte {
+	return []byte(receiptVersion + "\n" + r.MemoryID.String() + "\n" + r.ContentHash + "\n" + r.Timestamp)
+}
+
+// ContentHash returns the hex-encoded SHA-256 of content, as used in
+// receipts.
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReceiptVerifier verifies receipts against the server's public keys.
+type ReceiptVerifier struct {
+	// Keys maps key IDs to Ed25519 public keys. Keep retired keys here
+	// to verify receipts they signed.
+	Keys map[string]ed25519.PublicKey
+}
+
+// Verify checks that r was signed by a known key and covers memoryID and
+// content. It returns an error wrapping ErrInvalidReceipt if not.
+func (v *ReceiptVerifier) Verify(memoryID MemoryID, content string, r *Receipt) error {
+	if r == nil {
+		return fmt.Errorf("%w: memory %s has no receipt", ErrInvalidReceipt, memoryID)
+	}
+	if r.MemoryID != memoryID {
+		return fmt.Errorf("%w: receipt is for memory %s, not %s", ErrInvalidReceipt, r.MemoryID, memoryID)
+	}
+	if r.ContentHash != ContentHash(content) {
+		return fmt.Errorf("%w: content of memory %s does not match receipt", ErrInvalidReceipt, memoryID)
+	}
+	if _, err := r.Time(); err != nil {
+		return fmt.Errorf("%w: bad timestamp: %v", ErrInvalidReceipt, err)
+	}
+	key, ok := v.Keys[r.KeyID]
+	if !ok {
+		return fmt.Errorf("%w: unknown key %q", ErrInvalidReceipt, r.KeyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(r.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: bad signature encoding: %v", ErrInvalidReceipt, err)
+	}
+	if !ed25519.Verify(key, r.SignedMessage(), sig) {
+		return fmt.Errorf("%w: signature of memory %s does not verify", ErrInvalidReceipt, memoryID)
+	}
+	return nil
+}
+
+// VerifyMemory checks r against a memory read back from the server.
+func (v *ReceiptVerifier) VerifyMemory(m Memory, r *Receipt) error {
+	return v.Verify(m.MemoryID, m.Content, r)
+}
+
+// VerifyCreated checks the receipt returned with a created memory.
+func (v *ReceiptVerifier) VerifyCreated(m CreatedMemory) error {
+	return v.Verify(m.MemoryID, m.Content, m.Receipt)
+}
+
+// WithReceipts requests a signed receipt for every memory created with
+// CreateMemory and verifies it with v. A missing or invalid receipt fails
+// the call with ErrInvalidReceipt; the created memories are still returned.
+func WithReceipts(v *ReceiptVerifier) Option {
+	return func(c *Client) {
+		c.receipts = v
+	}
+}
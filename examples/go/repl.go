@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oceanbase/powermem/examples/go/experimental"
+)
+
+const replHelp = `Commands:
+  add TEXT          create a memory from TEXT
+  search QUERY      search memories
+  get ID            show a memory
+  list              list memories
+  delete ID         delete a memory
+  user ID           set the user of later commands
+  agent ID          set the agent of later commands (- for none)
+  infer on|off      toggle fact extraction on add
+  explain on|off    toggle score breakdowns on search
+  graph on|off      toggle knowledge graph expansion on search
+  limit N           set the number of search and list results
+  status            show the settings
+  help              show this help
+  quit              leave the REPL
+`
+
+// repl is an interactive session of "repl": its client and the settings
+// applied to each command.
+type repl struct {
+	c   *Client
+	out io.Writer
+
+	userID  string
+	agentID string
+	infer   bool
+	explain bool
+	graph   bool
+	limit   int
+}
+
+// runREPL implements "repl [flags]", an interactive shell for trying
+// memory behavior against a server. It reads commands until quit, end of
+// input or an interrupt.
+func runREPL(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	r := &repl{out: os.Stdout}
+	fs.StringVar(&r.userID, "user", "repl", "user `ID` of the memories")
+	fs.StringVar(&r.agentID, "agent", "", "agent `ID` of the memories")
+	fs.BoolVar(&r.infer, "infer", true, "extract facts from added text")
+	fs.BoolVar(&r.explain, "explain", true, "show score breakdowns on search")
+	fs.BoolVar(&r.graph, "graph", false, "expand searches through the knowledge graph")
+	fs.IntVar(&r.limit, "limit", 10, "number of search and list results")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: repl [flags]\n\n%s\n", replHelp)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	r.c = clientFromEnv().With(WithExperimental(experimental.Graph))
+
+	// Read in the background so that an interrupt ends the session
+	// without waiting for a line.
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+
+	prompt := isTerminal(os.Stdin)
+	if prompt {
+		fmt.Fprintf(r.out, "PowerMem REPL on %s, user %s. Type help for commands.\n", r.c.BaseURL, r.userID)
+	}
+	for {
+		if prompt {
+			fmt.Fprint(r.out, "powermem> ")
+		}
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(r.out)
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			line = strings.TrimSpace(l)
+		}
+
+		name, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		switch name {
+		case "":
+			continue
+		case "quit", "exit":
+			return nil
+		}
+		if err := r.exec(ctx, name, arg); err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+		}
+	}
+}
+
+// exec runs the REPL command name with its argument.
+func (r *repl) exec(ctx context.Context, name, arg string) error {
+	switch name {
+	case "add":
+		return r.add(ctx, arg)
+	case "search":
+		return r.search(ctx, arg)
+	case "get":
+		return r.get(ctx, arg)
+	case "list":
+		return r.list()
+	case "delete":
+		id, err := parseREPLMemoryID(arg)
+		if err != nil {
+			return err
+		}
+		if err := r.c.DeleteMemory(id, r.userID, r.agentID); err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "deleted %s\n", id)
+	case "user":
+		if arg == "" {
+			return fmt.Errorf("usage: user ID")
+		}
+		r.userID = arg
+	case "agent":
+		if arg == "-" {
+			arg = ""
+		}
+		r.agentID = arg
+	case "infer":
+		return parseOnOff(arg, &r.infer)
+	case "explain":
+		return parseOnOff(arg, &r.explain)
+	case "graph":
+		return parseOnOff(arg, &r.graph)
+	case "limit":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("usage: limit N")
+		}
+		r.limit = n
+	case "status":
+		fmt.Fprintf(r.out, "server %s\nuser %s\nagent %s\ninfer %t\nexplain %t\ngraph %t\nlimit %d\n",
+			r.c.BaseURL, r.userID, r.agentID, r.infer, r.explain, r.graph, r.limit)
+	case "help":
+		fmt.Fprint(r.out, replHelp)
+	default:
+		return fmt.Errorf("unknown command %q; type help for commands", name)
+	}
+	return nil
+}
+
+// add creates a memory from text and prints what the server stored.
+func (r *repl) add(ctx context.Context, text string) error {
+	if text == "" {
+		return fmt.Errorf("usage: add TEXT")
+	}
+	infer := r.infer
+	created, err := r.c.createMemory(ctx, &CreateMemoryRequest{
+		Content: text,
+		UserID:  r.userID,
+		AgentID: r.agentID,
+		Infer:   &infer,
+	})
+	if err != nil {
+		return err
+	}
+	if len(created) == 0 {
+		fmt.Fprintln(r.out, "nothing stored")
+	}
+	for _, m := range created {
+		switch {
+		case m.Skipped():
+			fmt.Fprintf(r.out, "skipped (%s)\n", m.Skip.Reason)
+		case m.JobID != "":
+			fmt.Fprintf(r.out, "queued in job %s\n", m.JobID)
+		case m.PreviousContent != "":
+			fmt.Fprintf(r.out, "updated %s: %s (was: %s)\n", m.MemoryID, m.Content, m.PreviousContent)
+		default:
+			fmt.Fprintf(r.out, "created %s: %s\n", m.MemoryID, m.Content)
+		}
+		for _, id := range m.SupersededIDs {
+			fmt.Fprintf(r.out, "  supersedes %s\n", id)
+		}
+		for _, e := range m.Entities {
+			fmt.Fprintf(r.out, "  entity %s (%s)\n", e.Name, e.Type)
+		}
+		for _, rel := range m.Relations {
+			fmt.Fprintf(r.out, "  relation %s -[%s]-> %s\n", rel.Source, rel.Type, rel.Target)
+		}
+	}
+	return nil
+}
+
+// search prints the results for query, each followed by its score
+// breakdown when explain is on and the graph path it was reached by, if
+// any.
+func (r *repl) search(ctx context.Context, query string) error {
+	if query == "" {
+		return fmt.Errorf("usage: search QUERY")
+	}
+	results, err := r.c.searchMemories(ctx, &SearchMemoryRequest{
+		Query:    query,
+		UserID:   r.userID,
+		AgentID:  r.agentID,
+		Limit:    r.limit,
+		Explain:  r.explain,
+		UseGraph: r.graph,
+	})
+	if err != nil {
+		return err
+	}
+	if len(results.Results) == 0 {
+		fmt.Fprintln(r.out, "no results")
+	}
+	for _, res := range results.Results {
+		fmt.Fprintf(r.out, "%.3f  %s  %s\n", res.Score, res.MemoryID, res.Content)
+		if r.explain {
+			if b := scoreBreakdown(res); b != "" {
+				fmt.Fprintf(r.out, "       %s\n", b)
+			}
+		}
+		if res.GraphPath != nil && res.GraphPath.Found {
+			fmt.Fprintf(r.out, "       via %s\n", res.GraphPath)
+		}
+	}
+	return nil
+}
+
+// get prints a memory as JSON.
+func (r *repl) get(ctx context.Context, arg string) error {
+	id, err := parseREPLMemoryID(arg)
+	if err != nil {
+		return err
+	}
+	m, err := r.c.GetMemoryWithOptions(ctx, id, GetMemoryOptions{UserID: r.userID, AgentID: r.agentID})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(r.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// list prints the user's memories.
+func (r *repl) list() error {
+	params := DefaultListParams()
+	params.UserID, params.AgentID, params.Limit = r.userID, r.agentID, r.limit
+	list, err := r.c.ListMemories(params)
+	if err != nil {
+		return err
+	}
+	for _, m := range list.Memories {
+		fmt.Fprintf(r.out, "%s  %s\n", m.MemoryID, m.Content)
+	}
+	fmt.Fprintf(r.out, "%d of %d memories\n", len(list.Memories), list.Total)
+	return nil
+}
+
+// scoreBreakdown formats the ranking signals of res: the server's
+// breakdown, sorted by name, then the rerank score, relevance, trust and
+// importance when set.
+func scoreBreakdown(res SearchResult) string {
+	var parts []string
+	names := make([]string, 0, len(res.ScoreBreakdown))
+	for name := range res.ScoreBreakdown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%.3f", name, res.ScoreBreakdown[name]))
+	}
+	for _, f := range []struct {
+		name  string
+		value *float64
+	}{
+		{"rerank", res.RerankScore}, {"relevance", res.Relevance}, {"trust", res.Trust},
+	} {
+		if f.value != nil {
+			parts = append(parts, fmt.Sprintf("%s=%.3f", f.name, *f.value))
+		}
+	}
+	if res.Importance != 0 {
+		parts = append(parts, fmt.Sprintf("importance=%.2f", res.Importance))
+	}
+	if res.Pinned {
+		parts = append(parts, "pinned")
+	}
+	return strings.Join(parts, "  ")
+}
+
+func parseREPLMemoryID(arg string) (MemoryID, error) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory ID %q", arg)
+	}
+	return MemoryID(n), nil
+}
+
+func parseOnOff(arg string, v *bool) error {
+	switch arg {
+	case "on":
+		*v = true
+	case "off":
+		*v = false
+	default:
+		return fmt.Errorf("want on or off, got %q", arg)
+	}
+	return nil
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// pipe or file, so that scripted sessions get no prompts.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// =============================================================================
+// Retention Policies
+// =============================================================================
+
+// PutRetentionPolicy creates or replaces the retention policy named
+// policy.Name.
+func (c *Client) PutRetentionPolicy(ctx context.Context, policy *RetentionPolicy) (*RetentionPolicy, error) {
+	if policy.Name == "" {
+		return nil, errors.New("retention policy name is required")
+	}
+	if policy.MaxAgeDays < 0 || policy.MaxMemories < 0 {
+		return nil, errors.New("retention policy bounds must not be negative")
+	}
+	if policy.MaxAgeDays == 0 && policy.MaxMemories == 0 {
+		return nil, errors.New("retention policy needs MaxAgeDays or MaxMemories")
+	}
+	path := fmt.Sprintf("/api/v1/retention-policies/%s", url.PathEscape(policy.Name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, path, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RetentionPolicy]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("put retention policy failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListRetentionPolicies retrieves all retention policies.
+func (c *Client) ListRetentionPolicies(ctx context.Context) (*RetentionPolicyList, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/retention-policies", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[RetentionPolicyList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list retention policies failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteRetentionPolicy deletes a retention policy. Memories it already
+// deleted or archived stay so.
+func (c *Client) DeleteRetentionPolicy(ctx context.Context, name string) error {
+	path := fmt.Sprintf("/api/v1/retention-policies/%s", url.PathEscape(name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete retention policy failed: %s", resp.Message)
+	}
+
+	return nil
+}
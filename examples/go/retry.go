@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls automatic retries of failed requests.
+//
+// Only idempotent methods (GET, HEAD, PUT, DELETE, OPTIONS) are retried,
+// and only on transport errors or HTTP 429, 502, 503 and 504.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first.
+	// Values <= 1 disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. It doubles on
+	// each subsequent retry, with full jitter.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+
+	// Budget, if set, limits retries across all requests of the client so
+	// that an outage does not multiply load on the server.
+	Budget *RetryBudget
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and exponential
+// backoff starting at 100ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Budget:         NewRetryBudget(0.2, 10),
+	}
+}
+
+// WithRetry enables automatic retries according to policy.
+func WithRetry(policy RetryPolicy) Option {
+	return WithMiddleware(retryMiddleware(policy))
+}
+
+// RetryBudget is a token bucket shared by all requests of a client. Each
+// request deposits ratio tokens and each retry withdraws one, so retries
+// are limited to roughly ratio times the request rate. The bucket holds at
+// most maxTokens tokens and starts full.
+type RetryBudget struct {
+	mu        sync.Mutex
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+// NewRetryBudget creates a retry budget allowing ratio retries per request
+// with a burst of up to maxTokens retries.
+func NewRetryBudget(ratio, maxTokens float64) *RetryBudget {
+	return &RetryBudget{ratio: ratio, maxTokens: maxTokens, tokens: maxTokens}
+}
+
+func (b *RetryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// attemptCounterKey is the context key under which retryMiddleware reports
+// the number of attempts made for a request.
+type attemptCounterKey struct{}
+
+// withAttemptCounter returns a context that records how many attempts the
+// retry middleware made for the request it is attached to.
+func withAttemptCounter(ctx context.Context) (context.Context, *atomic.Int32) {
+	counter := new(atomic.Int32)
+	return context.WithValue(ctx, attemptCounterKey{}, counter), counter
+}
+
+// retryMiddleware retries idempotent requests according to policy.
+func retryMiddleware(policy RetryPolicy) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			counter, _ := req.Context().Value(attemptCounterKey{}).(*atomic.Int32)
+			if policy.Budget != nil {
+				policy.Budget.deposit()
+			}
+
+			backoff := policy.InitialBackoff
+			for attempt := 1; ; attempt++ {
+				if counter != nil {
+					counter.Store(int32(attempt))
+				}
+
+				resp, err := next(req)
+				if attempt >= policy.MaxAttempts || !isIdempotent(req.Method) || !shouldRetry(resp, err) {
+					return resp, err
+				}
+				if req.Body != nil && req.GetBody == nil {
+					return resp, err
+				}
+				if policy.Budget != nil && !policy.Budget.withdraw() {
+					return resp, err
+				}
+				if resp != nil {
+					resp.Body.Close()
+				}
+
+				if err := sleepContext(req.Context(), jitter(backoff)); err != nil {
+					return nil, err
+				}
+				backoff *= 2
+				if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+					backoff = policy.MaxBackoff
+				}
+
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					req = req.Clone(req.Context())
+					req.Body = body
+				}
+			}
+		}
+	}
+}
+
+// isIdempotent reports whether requests with method may be safely retried.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether a request outcome is transient.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// jitter returns a random duration in [0, d].
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// sleepContext sleeps for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
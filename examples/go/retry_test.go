@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status and answers
+// the rest successfully, recording request bodies.
+type flakyServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	n        int
+	failures int
+	status   int
+	bodies   []string
+}
+
+func newFlakyServer(t *testing.T, failures, status int) *flakyServer {
+	t.Helper()
+	fs := &flakyServer{failures: failures, status: status}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fs.mu.Lock()
+		fs.n++
+		fs.bodies = append(fs.bodies, string(body))
+		fail := fs.n <= fs.failures
+		fs.mu.Unlock()
+		if fail {
+			w.WriteHeader(fs.status)
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": http.StatusText(fs.status)})
+			return
+		}
+		var data interface{} = MemoryList{}
+		if r.Method == http.MethodPut || r.Method == http.MethodPost {
+			data = Memory{MemoryID: 1, Content: "updated"}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *flakyServer) requests() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.n
+}
+
+// fastRetries is a retry policy with 3 attempts and no real waiting.
+func fastRetries() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		call         func(c *Client) error
+		wantRequests int
+		wantErr      bool
+	}{
+		{"get recovers", 1, http.StatusServiceUnavailable, getMemories, 2, false},
+		{"get gives up", 5, http.StatusBadGateway, getMemories, 3, true},
+		{"get rate limited", 2, http.StatusTooManyRequests, getMemories, 3, false},
+		{"get bad request", 1, http.StatusBadRequest, getMemories, 1, true},
+		{"get server error", 1, http.StatusInternalServerError, getMemories, 1, true},
+		{"put recovers", 1, http.StatusServiceUnavailable, updateMemory, 2, false},
+		// A POST may have been processed before the gateway failed.
+		{"post not retried", 1, http.StatusServiceUnavailable, func(c *Client) error {
+			_, err := c.CreateMemory(&CreateMemoryRequest{Content: "hello", UserID: "u1"})
+			return err
+		}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlakyServer(t, tt.failures, tt.status)
+			c := NewClient(fs.URL, "", WithRetry(fastRetries()))
+			if err := tt.call(c); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got := fs.requests(); got != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func getMemories(c *Client) error {
+	_, err := c.GetUserMemories("u1", 0, 0)
+	return err
+}
+
+func updateMemory(c *Client) error {
+	_, err := c.UpdateMemory(1, &UpdateMemoryRequest{Content: "updated"})
+	return err
+}
+
+func TestRetryResendsBody(t *testing.T) {
+	fs := newFlakyServer(t, 1, http.StatusServiceUnavailable)
+	c := NewClient(fs.URL, "", WithRetry(fastRetries()))
+	if err := updateMemory(c); err != nil {
+		t.Fatalf("UpdateMemory: %v", err)
+	}
+	if len(fs.bodies) != 2 || fs.bodies[0] == "" || fs.bodies[1] != fs.bodies[0] {
+		t.Errorf("bodies %q, want the same body twice", fs.bodies)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	fs := newFlakyServer(t, 100, http.StatusServiceUnavailable)
+	policy := fastRetries()
+	// One retry in the bucket, and requests earn no more.
+	policy.Budget = NewRetryBudget(0, 1)
+	c := NewClient(fs.URL, "", WithRetry(policy))
+
+	getMemories(c)
+	if got := fs.requests(); got != 2 {
+		t.Errorf("first request: server got %d requests, want 2", got)
+	}
+	getMemories(c)
+	if got := fs.requests(); got != 3 {
+		t.Errorf("second request: server got %d requests in total, want 3 once the budget is spent", got)
+	}
+}
+
+func TestRetryBudgetRefills(t *testing.T) {
+	b := NewRetryBudget(0.5, 2)
+	if !b.withdraw() || !b.withdraw() {
+		t.Fatal("a new budget should allow maxTokens retries")
+	}
+	if b.withdraw() {
+		t.Fatal("withdraw from an empty budget succeeded")
+	}
+	b.deposit()
+	if b.withdraw() {
+		t.Fatal("half a token allowed a retry")
+	}
+	b.deposit()
+	b.deposit()
+	if !b.withdraw() {
+		t.Fatal("a full token did not allow a retry")
+	}
+	for i := 0; i < 10; i++ {
+		b.deposit()
+	}
+	if !b.withdraw() || !b.withdraw() || b.withdraw() {
+		t.Error("the budget holds more than maxTokens")
+	}
+}
+
+func TestDeleteMemoryIsIdempotentUnderRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+	}{
+		// The first attempt deleted the memory but its response was lost.
+		{"retried", []int{http.StatusServiceUnavailable, http.StatusNotFound}, false},
+		{"missing", []int{http.StatusNotFound}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[n]
+				n++
+				w.WriteHeader(status)
+				json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "code": "MEMORY_NOT_FOUND", "message": http.StatusText(status)})
+			}))
+			defer srv.Close()
+			c := NewClient(srv.URL, "", WithRetry(fastRetries()))
+			if _, err := c.DeleteMemoryWithOptions(context.Background(), 1, DeleteMemoryOptions{}); (err != nil) != tt.wantErr {
+				t.Errorf("DeleteMemoryWithOptions: %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// =============================================================================
+// Run / Session Lifecycle
+// =============================================================================
+
+// GetRun retrieves the metadata of a run (conversation session).
+func (c *Client) GetRun(ctx context.Context, runID string) (*Run, error) {
+	path := fmt.Sprintf("/api/v1/runs/%s", url.PathEscape(runID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Run]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get run failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GetRunMemories retrieves all memories recorded during a run.
+func (c *Client) GetRunMemories(runID string, limit, offset int) (*MemoryList, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+
+	path := fmt.Sprintf("/api/v1/runs/%s/memories", url.PathEscape(runID))
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	respBody, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemoryList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get run memories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// CloseRun marks a run as closed. Unless req.Consolidate is false, the
+// server consolidates the run's memories, merging duplicates and
+// promoting lasting facts out of the session. req may be nil.
+func (c *Client) CloseRun(ctx context.Context, runID string, req *CloseRunRequest) (*CloseRunResult, error) {
+	if req == nil {
+		req = &CloseRunRequest{}
+	}
+	path := fmt.Sprintf("/api/v1/runs/%s/close", url.PathEscape(runID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, path, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[CloseRunResult]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("close run failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteRunMemories deletes all memories of a run.
+func (c *Client) DeleteRunMemories(ctx context.Context, runID string) (*ScopedDeleteResult, error) {
+	return c.deleteScoped(ctx, fmt.Sprintf("/api/v1/runs/%s/memories", url.PathEscape(runID)))
+}
+
+// RunSummaryOf returns the run summary link stored in metadata, and false
+// if the memory is not a run summary. Fetch the summarized memories with
+// GetMemory to drill down from a summary to the raw turns.
+func RunSummaryOf(metadata map[string]interface{}) (*RunSummaryLink, bool) {
+	raw, ok := metadata[RunSummaryMetadataField]
+	if !ok {
+		return nil, false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	var link RunSummaryLink
+	if err := json.Unmarshal(data, &link); err != nil || link.RunID == "" {
+		return nil, false
+	}
+	return &link, true
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// =============================================================================
+// Saved Searches
+// =============================================================================
+
+// runSavedSearchRequest is the request body of RunSavedSearch.
+type runSavedSearchRequest struct {
+	UserID  string `json:"user_id,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+}
+
+// PutSavedSearch creates or replaces the saved search named search.Name.
+func (c *Client) PutSavedSearch(ctx context.Context, search *SavedSearch) (*SavedSearch, error) {
+	if search.Name == "" {
+		return nil, errors.New("saved search name is required")
+	}
+	if search.Request.Query == "" {
+		return nil, errors.New("saved search needs a query")
+	}
+	path := fmt.Sprintf("/api/v1/saved-searches/%s", url.PathEscape(search.Name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, path, search)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[SavedSearch]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("put saved search failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListSavedSearches retrieves all saved searches.
+func (c *Client) ListSavedSearches(ctx context.Context) (*SavedSearchList, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/saved-searches", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[SavedSearchList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list saved searches failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteSavedSearch deletes a saved search.
+func (c *Client) DeleteSavedSearch(ctx context.Context, name string) error {
+	path := fmt.Sprintf("/api/v1/saved-searches/%s", url.PathEscape(name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete saved search failed: %s", resp.Message)
+	}
+
+	return nil
+}
+
+// RunSavedSearch runs the saved search named name for userID and
+// agentID, which override the scope saved with the search when set.
+func (c *Client) RunSavedSearch(ctx context.Context, name, userID, agentID string) (*SearchResults, error) {
+	path := fmt.Sprintf("/api/v1/saved-searches/%s/run", url.PathEscape(name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, path, &runSavedSearchRequest{UserID: userID, AgentID: agentID})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[SearchResults]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("run saved search failed: %s", resp.Message)
+	}
+
+	c.processSearchResults(&resp.Data, &SearchMemoryRequest{UserID: userID, AgentID: agentID})
+	return &resp.Data, nil
+}
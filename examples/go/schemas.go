@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// =============================================================================
+// Metadata Schemas
+// =============================================================================
+
+// metadataFieldTypes are the types a MetadataFieldSchema may name.
+var metadataFieldTypes = []string{"", "string", "number", "boolean", "array", "object"}
+
+// PutMetadataSchema creates or replaces the metadata schema named
+// schema.Name. Existing memories are not checked against it.
+func (c *Client) PutMetadataSchema(ctx context.Context, schema *MetadataSchema) (*MetadataSchema, error) {
+	if schema.Name == "" {
+		return nil, errors.New("metadata schema name is required")
+	}
+	for field, fs := range schema.Fields {
+		if !slices.Contains(metadataFieldTypes, fs.Type) {
+			return nil, fmt.Errorf("metadata schema field %q: invalid type %q", field, fs.Type)
+		}
+	}
+	path := fmt.Sprintf("/api/v1/metadata-schemas/%s", url.PathEscape(schema.Name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, path, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MetadataSchema]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("put metadata schema failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListMetadataSchemas retrieves all metadata schemas.
+func (c *Client) ListMetadataSchemas(ctx context.Context) (*MetadataSchemaList, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/metadata-schemas", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MetadataSchemaList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list metadata schemas failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteMetadataSchema deletes a metadata schema; writes are no longer
+// checked against it.
+func (c *Client) DeleteMetadataSchema(ctx context.Context, name string) error {
+	path := fmt.Sprintf("/api/v1/metadata-schemas/%s", url.PathEscape(name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete metadata schema failed: %s", resp.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import "fmt"
+
+// WithDefaultScope sets the scope of every create request that sets none,
+// e.g. ScopeAgent for a client used only for agent knowledge.
+func WithDefaultScope(s Scope) Option {
+	return func(c *Client) {
+		c.defaultScope = s
+	}
+}
+
+// Valid reports whether s is a known scope or empty.
+func (s Scope) Valid() bool {
+	switch s {
+	case "", ScopeUser, ScopeAgent, ScopeRun, ScopeGlobal:
+		return true
+	}
+	return false
+}
+
+// checkScope validates the scope of a write request.
+func checkScope(s Scope) error {
+	if !s.Valid() {
+		return fmt.Errorf("invalid scope %q", s)
+	}
+	return nil
+}
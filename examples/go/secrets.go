@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultSecretTTL is how long a secret is cached when its provider does
+// not report an expiry of its own.
+const DefaultSecretTTL = 5 * time.Minute
+
+// secretRefreshMargin is how long before expiry a cached secret is
+// refreshed, so requests in flight do not race the expiry.
+const secretRefreshMargin = 30 * time.Second
+
+// Secret is a credential with an optional expiry.
+type Secret struct {
+	Value string
+
+	// ExpiresAt is when the secret must be fetched again. The zero value
+	// means it is valid until the server rejects it.
+	ExpiresAt time.Time
+}
+
+// expiresWithin reports whether s expires within d from now.
+func (s Secret) expiresWithin(d time.Duration) bool {
+	return !s.ExpiresAt.IsZero() && time.Until(s.ExpiresAt) < d
+}
+
+// SecretProvider fetches the API key from a secrets manager. Unlike a
+// CredentialStore it reports how long the secret is valid, so dynamic
+// credentials are refreshed on expiry without restarting the process.
+type SecretProvider interface {
+	Secret(ctx context.Context) (Secret, error)
+}
+
+// WithSecretProvider reads the API key from p whenever the client's APIKey
+// is empty. The key is cached until shortly before it expires, and re-read
+// after the server rejects it with 401.
+func WithSecretProvider(p SecretProvider) Option {
+	return func(c *Client) {
+		c.credentials = &credentialSource{fetch: p.Secret, what: "API key from credential store"}
+	}
+}
+
+// expiry returns the expiry of a secret fetched now that is valid for ttl,
+// falling back to fallback and then DefaultSecretTTL.
+func expiry(ttl, fallback time.Duration) time.Time {
+	if ttl <= 0 {
+		ttl = fallback
+	}
+	if ttl <= 0 {
+		ttl = DefaultSecretTTL
+	}
+	return time.Now().Add(ttl)
+}
+
+// =============================================================================
+// HashiCorp Vault
+// =============================================================================
+
+// VaultSecretProvider reads the API key from HashiCorp Vault over its HTTP
+// API.
+type VaultSecretProvider struct {
+	// Address is the Vault server URL. Defaults to $VAULT_ADDR.
+	Address string
+
+	// Token authenticates to Vault. Defaults to $VAULT_TOKEN.
+	Token string
+
+	// Namespace is the Vault Enterprise namespace, if any. Defaults to
+	// $VAULT_NAMESPACE.
+	Namespace string
+
+	// Path is the API path below /v1/, e.g. "secret/data/powermem" for a
+	// KV v2 secret.
+	Path string
+
+	// Field is the key of the API key within the secret's data. Defaults
+	// to "api_key".
+	Field string
+
+	// TTL is used when Vault reports no lease duration, as for KV
+	// secrets. Defaults to DefaultSecretTTL.
+	TTL time.Duration
+
+	HTTPClient *http.Client
+}
+
+// vaultResponse is the subset of a Vault read response used here.
+type vaultResponse struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+	Errors        []string               `json:"errors"`
+}
+
+// Secret implements SecretProvider.
+func (v *VaultSecretProvider) Secret(ctx context.Context) (Secret, error) {
+	addr := v.Address
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return Secret{}, fmt.Errorf("vault: address not configured")
+	}
+	token := v.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	namespace := v.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	field := v.Field
+	if field == "" {
+		field = "api_key"
+	}
+
+	url := strings.TrimRight(addr, "/") + "/v1/" + strings.TrimLeft(v.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Secret{}, fmt.Errorf("vault: failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	body, status, err := doSecretRequest(v.HTTPClient, req)
+	if err != nil {
+		return Secret{}, fmt.Errorf("vault: %w", err)
+	}
+	if status == http.StatusNotFound {
+		return Secret{}, ErrCredentialNotFound
+	}
+	var resp vaultResponse
+	if err := json.Unmarshal(body, &resp); err != nil && status == http.StatusOK {
+		return Secret{}, fmt.Errorf("vault: failed to parse response: %w", err)
+	}
+	if status != http.StatusOK {
+		return Secret{}, fmt.Errorf("vault: HTTP %d: %s", status, strings.Join(resp.Errors, "; "))
+	}
+
+	// KV v2 nests the secret's fields one level deeper than other engines.
+	data := resp.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[field].(string)
+	if !ok || value == "" {
+		return Secret{}, fmt.Errorf("vault: field %q not found at %s: %w", field, v.Path, ErrCredentialNotFound)
+	}
+	return Secret{
+		Value:     value,
+		ExpiresAt: expiry(time.Duration(resp.LeaseDuration)*time.Second, v.TTL),
+	}, nil
+}
+
+// =============================================================================
+// AWS Secrets Manager
+// =============================================================================
+
+// AWSSecretsManagerProvider reads the API key from AWS Secrets Manager.
+// Requests are signed with Signature Version 4 using static credentials,
+// which default to the standard AWS_* environment variables.
+type AWSSecretsManagerProvider struct {
+	// Region defaults to $AWS_REGION.
+	Region string
+
+	// SecretID is the name or ARN of the secret.
+	SecretID string
+
+	// JSONKey selects a field when the secret string is a JSON object.
+	// When empty the whole secret string is the API key.
+	JSONKey string
+
+	// TTL is how long a fetched secret is cached. Defaults to
+	// DefaultSecretTTL.
+	TTL time.Duration
+
+	// AccessKeyID, SecretAccessKey and SessionToken default to
+	// $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and $AWS_SESSION_TOKEN.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides the regional endpoint, e.g. for VPC endpoints.
+	Endpoint string
+
+	HTTPClient *http.Client
+}
+
+// Secret implements SecretProvider.
+func (a *AWSSecretsManagerProvider) Secret(ctx context.Context) (Secret, error) {
+	region := firstNonEmpty(a.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return Secret{}, fmt.Errorf("secretsmanager: region not configured")
+	}
+	creds := awsCredentials{
+		accessKeyID:     firstNonEmpty(a.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID")),
+		secretAccessKey: firstNonEmpty(a.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		sessionToken:    firstNonEmpty(a.SessionToken, os.Getenv("AWS_SESSION_TOKEN")),
+	}
+	if creds.accessKeyID == "" || creds.secretAccessKey == "" {
+		return Secret{}, fmt.Errorf("secretsmanager: AWS credentials not configured")
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+
+	payload, err := json.Marshal(map[string]string{"SecretId": a.SecretID})
+	if err != nil {
+		return Secret{}, fmt.Errorf("secretsmanager: failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return Secret{}, fmt.Errorf("secretsmanager: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, payload, creds, region, "secretsmanager", time.Now())
+
+	body, status, err := doSecretRequest(a.HTTPClient, req)
+	if err != nil {
+		return Secret{}, fmt.Errorf("secretsmanager: %w", err)
+	}
+	var resp struct {
+		SecretString string `json:"SecretString"`
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil && status == http.StatusOK {
+		return Secret{}, fmt.Errorf("secretsmanager: failed to parse response: %w", err)
+	}
+	if status != http.StatusOK {
+		if strings.HasSuffix(resp.Type, "ResourceNotFoundException") {
+			return Secret{}, ErrCredentialNotFound
+		}
+		return Secret{}, fmt.Errorf("secretsmanager: HTTP %d (%s): %s", status, resp.Type, resp.Message)
+	}
+
+	value := resp.SecretString
+	if a.JSONKey != "" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return Secret{}, fmt.Errorf("secretsmanager: secret %s is not a JSON object: %w", a.SecretID, err)
+		}
+		value, _ = fields[a.JSONKey].(string)
+	}
+	if value == "" {
+		return Secret{}, fmt.Errorf("secretsmanager: secret %s has no API key: %w", a.SecretID, ErrCredentialNotFound)
+	}
+	return Secret{Value: value, ExpiresAt: expiry(a.TTL, 0)}, nil
+}
+
+// awsCredentials are static AWS credentials.
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// signV4 signs req with AWS Signature Version 4.
+func signV4(req *http.Request, payload []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	// Sign every header set above plus Host; header names are sorted.
+	names := []string{"content-type", "host", "x-amz-date"}
+	if creds.sessionToken != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	names = append(names, "x-amz-target")
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(payload),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// doSecretRequest executes req and returns the response body and status.
+func doSecretRequest(client *http.Client, req *http.Request) ([]byte, int, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
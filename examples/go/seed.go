@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SeedMetadataField is the metadata key recording the scenario a seeded
+// memory came from, so that seeded data can be found and removed with
+// DeleteMemories.
+const SeedMetadataField = "seed_scenario"
+
+//go:embed fixtures/*.json
+var fixtureFS embed.FS
+
+// Scenario is a curated multi-user memory dataset for demos, tutorials
+// and integration tests.
+type Scenario struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Memories    []ScenarioMemory `json:"memories"`
+}
+
+// ScenarioMemory is a memory of a Scenario.
+type ScenarioMemory struct {
+	UserID     string                 `json:"user_id,omitempty"`
+	AgentID    string                 `json:"agent_id,omitempty"`
+	RunID      string                 `json:"run_id,omitempty"`
+	Content    string                 `json:"content"`
+	Scope      Scope                  `json:"scope,omitempty"`
+	MemoryType MemoryType             `json:"memory_type,omitempty"`
+	Importance float64                `json:"importance,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+
+	// Age is how long before seeding the memory was created, as a
+	// duration such as "36h" or a number of days such as "14d", so that
+	// the data looks recent whenever it is seeded.
+	Age string `json:"age,omitempty"`
+}
+
+// SeedOptions configures SeedScenario.
+type SeedOptions struct {
+	// Namespace prefixes every user and agent ID of the scenario, e.g.
+	// with a test name, so that concurrent seeds do not collide.
+	Namespace string
+
+	// Now is the time memory ages are measured from. Defaults to the
+	// current time.
+	Now time.Time
+
+	// Infer runs server-side inference on the seeded memories. It
+	// defaults to false so that the curated content is stored verbatim.
+	Infer bool
+}
+
+// SeedResult reports the outcome of SeedScenario.
+type SeedResult struct {
+	// Users and Agents are the seeded IDs, namespaced.
+	Users  []string
+	Agents []string
+
+	Created int
+	Failed  int
+}
+
+// Scenarios returns the names of the built-in scenarios.
+func Scenarios() []string {
+	entries, _ := fixtureFS.ReadDir("fixtures")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	return names
+}
+
+// LoadScenario returns the built-in scenario called name.
+func LoadScenario(name string) (*Scenario, error) {
+	data, err := fixtureFS.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown scenario %q; have %s", name, strings.Join(Scenarios(), ", "))
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario parses and validates a scenario in the JSON form of the
+// built-in ones, e.g. a project's own fixtures.
+func ParseScenario(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if s.Name == "" {
+		return nil, errors.New("scenario has no name")
+	}
+	for i, m := range s.Memories {
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("scenario %s: memory %d: %w", s.Name, i+1, err)
+		}
+	}
+	return &s, nil
+}
+
+// SeedScenario creates the memories of s, backdated by their ages and
+// tagged with SeedMetadataField. Consecutive memories of the same owner
+// are uploaded in one batch.
+func (c *Client) SeedScenario(ctx context.Context, s *Scenario, opts SeedOptions) (*SeedResult, error) {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	result := &SeedResult{}
+	seenUsers, seenAgents := map[string]bool{}, map[string]bool{}
+
+	var batch *BatchCreateMemoryRequest
+	flush := func() error {
+		if batch == nil {
+			return nil
+		}
+		res, err := c.BatchCreateMemories(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to seed scenario %s: %w", s.Name, err)
+		}
+		result.Created += len(batch.Memories) - len(res.Failed)
+		result.Failed += len(res.Failed)
+		batch = nil
+		return nil
+	}
+
+	for _, m := range s.Memories {
+		userID, agentID, runID := namespaced(opts.Namespace, m.UserID), namespaced(opts.Namespace, m.AgentID), m.RunID
+		if userID != "" && !seenUsers[userID] {
+			seenUsers[userID] = true
+			result.Users = append(result.Users, userID)
+		}
+		if agentID != "" && !seenAgents[agentID] {
+			seenAgents[agentID] = true
+			result.Agents = append(result.Agents, agentID)
+		}
+
+		if batch != nil && (len(batch.Memories) == MaxBatchSize ||
+			batch.UserID != userID || batch.AgentID != agentID || batch.RunID != runID) {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+		if batch == nil {
+			infer := opts.Infer
+			batch = &BatchCreateMemoryRequest{UserID: userID, AgentID: agentID, RunID: runID, Infer: &infer}
+		}
+
+		age, _ := parseAge(m.Age)
+		createdAt := opts.Now.Add(-age)
+		batch.Memories = append(batch.Memories, BatchMemoryItem{
+			Content:    m.Content,
+			Metadata:   mergeMetadata(m.Metadata, map[string]interface{}{SeedMetadataField: s.Name}),
+			Scope:      m.Scope,
+			MemoryType: m.MemoryType,
+			Importance: m.Importance,
+			Source:     SourceImported,
+			CreatedAt:  &createdAt,
+		})
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// UnseedScenario deletes the memories SeedScenario created for s in
+// namespace, leaving other memories of the same users and agents, and
+// returns how many were deleted.
+func (c *Client) UnseedScenario(ctx context.Context, s *Scenario, namespace string) (int, error) {
+	type owner struct{ userID, agentID, runID string }
+	seen := map[owner]bool{}
+	deleted := 0
+	for _, m := range s.Memories {
+		o := owner{namespaced(namespace, m.UserID), namespaced(namespace, m.AgentID), m.RunID}
+		if seen[o] {
+			continue
+		}
+		seen[o] = true
+		res, err := c.DeleteMemories(ctx, DeleteFilter{
+			UserID:   o.userID,
+			AgentID:  o.agentID,
+			RunID:    o.runID,
+			Metadata: map[string]interface{}{SeedMetadataField: s.Name},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += res.Count
+	}
+	return deleted, nil
+}
+
+// validate checks that m can be seeded.
+func (m ScenarioMemory) validate() error {
+	if strings.TrimSpace(m.Content) == "" {
+		return errors.New("content is empty")
+	}
+	if m.UserID == "" && m.AgentID == "" && m.RunID == "" {
+		return errors.New("no user, agent or run")
+	}
+	if _, err := parseAge(m.Age); err != nil {
+		return err
+	}
+	if err := checkScope(m.Scope); err != nil {
+		return err
+	}
+	if err := checkMemoryType(m.MemoryType); err != nil {
+		return err
+	}
+	return checkImportance(m.Importance)
+}
+
+// parseAge parses a ScenarioMemory age: a time.Duration or a number of
+// days followed by "d". The empty age is zero.
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(age, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", age)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", age)
+	}
+	return d, nil
+}
+
+func namespaced(namespace, id string) string {
+	if id == "" {
+		return ""
+	}
+	return namespace + id
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Session holds what an interactive agent reads on every turn for one
+// user, pre-loaded by OpenSession: the profile summary, pinned memories
+// and a cache of the user's hot memories. A turn then costs a single
+// search round trip instead of about three. A Session is safe for
+// concurrent use.
+type Session struct {
+	c      *Client
+	userID string
+
+	mu     sync.RWMutex
+	snap   SessionSnapshot
+	pinned map[MemoryID]bool
+	hot    map[MemoryID]Memory
+}
+
+// TurnContext is the memory context of one agent turn.
+type TurnContext struct {
+	ProfileSummary string
+	Pinned         []Memory
+
+	// Results are the memories matching the turn's query, without the
+	// pinned memories, which the context already holds.
+	Results *SearchResults
+}
+
+// OpenSession pre-loads userID's session in one round trip. Keep the
+// session across turns and Refresh it when it may have gone stale, e.g.
+// at the start of a new conversation.
+func (c *Client) OpenSession(ctx context.Context, userID string) (*Session, error) {
+	s := &Session{c: c, userID: userID}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh reloads the session from the server.
+func (s *Session) Refresh(ctx context.Context) error {
+	path := fmt.Sprintf("/api/v1/users/%s/session", url.PathEscape(s.userID))
+	respBody, err := s.c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[SessionSnapshot]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("open session failed: %s", resp.Message)
+	}
+
+	snap := resp.Data
+	if snap.LoadedAt.IsZero() {
+		snap.LoadedAt = time.Now()
+	}
+	pinned := make(map[MemoryID]bool, len(snap.Pinned))
+	hot := make(map[MemoryID]Memory, len(snap.Pinned)+len(snap.Hot))
+	for _, m := range snap.Hot {
+		hot[m.MemoryID] = m
+	}
+	for _, m := range snap.Pinned {
+		pinned[m.MemoryID] = true
+		hot[m.MemoryID] = m
+	}
+
+	s.mu.Lock()
+	s.snap, s.pinned, s.hot = snap, pinned, hot
+	s.mu.Unlock()
+	return nil
+}
+
+// UserID returns the user the session belongs to.
+func (s *Session) UserID() string {
+	return s.userID
+}
+
+// LoadedAt returns when the session was last loaded.
+func (s *Session) LoadedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snap.LoadedAt
+}
+
+// ProfileSummary returns the user's profile summary.
+func (s *Session) ProfileSummary() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snap.ProfileSummary
+}
+
+// Pinned returns the user's pinned memories.
+func (s *Session) Pinned() []Memory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Memory(nil), s.snap.Pinned...)
+}
+
+// Memory returns a memory from the session's hot cache without a round
+// trip.
+func (s *Session) Memory(id MemoryID) (Memory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.hot[id]
+	return m, ok
+}
+
+// Turn returns the memory context for a turn about query: the pre-loaded
+// profile summary and pinned memories, and up to limit search results (0
+// for the server default), in a single round trip.
+func (s *Session) Turn(ctx context.Context, query string, limit int) (*TurnContext, error) {
+	results, err := s.c.searchMemories(ctx, &SearchMemoryRequest{Query: query, UserID: s.userID, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	kept := results.Results[:0]
+	for _, r := range results.Results {
+		if !s.pinned[r.MemoryID] {
+			kept = append(kept, r)
+		}
+	}
+	results.Total -= len(results.Results) - len(kept)
+	results.Results = kept
+
+	return &TurnContext{
+		ProfileSummary: s.snap.ProfileSummary,
+		Pinned:         append([]Memory(nil), s.snap.Pinned...),
+		Results:        results,
+	}, nil
+}
+
+// Remember creates a memory for the session's user and adds the created
+// memories to the hot cache, and to the pinned memories if req.Pinned is
+// set. req.UserID is overridden.
+func (s *Session) Remember(ctx context.Context, req *CreateMemoryRequest) ([]CreatedMemory, error) {
+	scoped := *req
+	scoped.UserID = s.userID
+	created, err := s.c.createMemory(ctx, &scoped)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for _, m := range created {
+		if m.MemoryID == 0 || m.Skipped() {
+			continue
+		}
+		s.hot[m.MemoryID] = Memory{
+			MemoryID:   m.MemoryID,
+			Content:    m.Content,
+			UserID:     m.UserID,
+			AgentID:    m.AgentID,
+			RunID:      m.RunID,
+			Metadata:   m.Metadata,
+			Visibility: scoped.Visibility,
+			Source:     scoped.Source,
+			MemoryType: scoped.MemoryType,
+			Importance: scoped.Importance,
+			Pinned:     scoped.Pinned,
+			CreatedAt:  &now,
+			UpdatedAt:  &now,
+		}
+		if scoped.Pinned {
+			s.pinned[m.MemoryID] = true
+			s.snap.Pinned = append(s.snap.Pinned, s.hot[m.MemoryID])
+		}
+		for _, id := range m.SupersededIDs {
+			delete(s.hot, id)
+		}
+	}
+	s.mu.Unlock()
+	return created, nil
+}
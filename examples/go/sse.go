@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseEvent is a server-sent event.
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// sseReader parses a text/event-stream body.
+type sseReader struct {
+	scanner *bufio.Scanner
+
+	// retry is the reconnection delay last requested by the server, or
+	// zero.
+	retry time.Duration
+}
+
+func newSSEReader(r io.Reader) *sseReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &sseReader{scanner: scanner}
+}
+
+// next returns the next event, or io.EOF when the stream ends. Comments
+// and events without data, such as keep-alives, are skipped.
+func (r *sseReader) next() (sseEvent, error) {
+	var ev sseEvent
+	var data []string
+	hasData := false
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if hasData {
+				ev.Data = strings.Join(data, "\n")
+				return ev, nil
+			}
+			ev = sseEvent{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			ev.ID = value
+		case "event":
+			ev.Event = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				r.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return sseEvent{}, err
+	}
+	if hasData {
+		ev.Data = strings.Join(data, "\n")
+		return ev, nil
+	}
+	return sseEvent{}, io.EOF
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// maxBundleExchanges bounds the number of request/response pairs kept by a
+// support bundle; older pairs are dropped first.
+const maxBundleExchanges = 1000
+
+// SupportBundle captures sanitized request/response pairs while active and
+// packages them with client configuration and server status into a zip
+// file suitable for attaching to bug reports.
+//
+// Credential headers and memory content are always redacted.
+type SupportBundle struct {
+	client  *Client
+	dir     string
+	started time.Time
+
+	mu        sync.Mutex
+	exchanges []bundleExchange
+	dropped   int
+}
+
+// bundleExchange is one captured request/response pair.
+type bundleExchange struct {
+	Time            time.Time   `json:"time"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	Status          int         `json:"status,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+
+	// ResponseTruncated is set when the body is a prefix of the response,
+	// and ResponseStream when the response was a stream, recorded without
+	// its body.
+	ResponseTruncated bool `json:"response_truncated,omitempty"`
+	ResponseStream    bool `json:"response_stream,omitempty"`
+
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// bundleConfig is the client configuration recorded in a support bundle.
+type bundleConfig struct {
+	BaseURL         string    `json:"base_url"`
+	APIKeySet       bool      `json:"api_key_set"`
+	Timeout         string    `json:"timeout"`
+	Middleware      int       `json:"middleware"`
+	DefaultMetadata []string  `json:"default_metadata_keys,omitempty"`
+	GoVersion       string    `json:"go_version"`
+	OS              string    `json:"os"`
+	Arch            string    `json:"arch"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	Exchanges       int       `json:"exchanges"`
+	Dropped         int       `json:"dropped_exchanges"`
+}
+
+// StartSupportBundle starts capturing traffic of c and all clients sharing
+// its state. Call Finish on the returned bundle to write the zip file into
+// dir. Only one bundle may be active at a time.
+func (c *Client) StartSupportBundle(dir string) (*SupportBundle, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create support bundle directory: %w", err)
+	}
+	b := &SupportBundle{client: c, dir: dir, started: time.Now()}
+	if !c.state().bundle.CompareAndSwap(nil, b) {
+		return nil, fmt.Errorf("a support bundle is already active")
+	}
+	return b, nil
+}
+
+// Finish stops capturing, queries the server status and writes the bundle
+// to a zip file, returning its path.
+func (b *SupportBundle) Finish(ctx context.Context) (string, error) {
+	b.client.state().bundle.CompareAndSwap(b, nil)
+
+	var status interface{}
+	if st, err := b.client.status(ctx); err != nil {
+		status = map[string]string{"error": err.Error()}
+	} else {
+		status = st
+	}
+
+	b.mu.Lock()
+	exchanges := b.exchanges
+	dropped := b.dropped
+	b.mu.Unlock()
+
+	cfg := bundleConfig{
+		BaseURL:    b.client.BaseURL,
+		APIKeySet:  b.client.APIKey != "",
+		Timeout:    b.client.httpClient().Timeout.String(),
+		Middleware: len(b.client.middleware),
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		StartedAt:  b.started,
+		FinishedAt: time.Now(),
+		Exchanges:  len(exchanges),
+		Dropped:    dropped,
+	}
+	for k := range b.client.defaultMetadata {
+		cfg.DefaultMetadata = append(cfg.DefaultMetadata, k)
+	}
+
+	path := filepath.Join(b.dir, fmt.Sprintf("powermem-support-%s.zip", b.started.UTC().Format("20060102T150405Z")))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create support bundle: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	if err := writeZipJSON(zw, "config.json", cfg); err != nil {
+		return "", err
+	}
+	if err := writeZipJSON(zw, "status.json", status); err != nil {
+		return "", err
+	}
+	w, err := zw.Create("exchanges.ndjson")
+	if err != nil {
+		return "", fmt.Errorf("failed to write support bundle: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	for _, ex := range exchanges {
+		if err := enc.Encode(ex); err != nil {
+			return "", fmt.Errorf("failed to write support bundle: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write support bundle: %w", err)
+	}
+	return path, f.Close()
+}
+
+// record appends an exchange, dropping the oldest when full.
+func (b *SupportBundle) record(ex bundleExchange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.exchanges) >= maxBundleExchanges {
+		b.exchanges = b.exchanges[1:]
+		b.dropped++
+	}
+	b.exchanges = append(b.exchanges, ex)
+}
+
+// writeZipJSON writes v as indented JSON to a new file in zw.
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+	return nil
+}
+
+// captureMiddleware records exchanges into the active support bundle, if
+// any. It runs innermost so it sees the request exactly as sent. Response
+// bodies are recorded up to maxCapturedBody bytes, and not at all for
+// streams.
+func (c *Client) captureMiddleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		b := c.state().bundle.Load()
+		if b == nil {
+			return next(req)
+		}
+
+		ex := bundleExchange{
+			Time:           time.Now(),
+			Method:         req.Method,
+			URL:            req.URL.String(),
+			RequestHeaders: redactHeaders(req.Header),
+		}
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				raw, _ := io.ReadAll(body)
+				body.Close()
+				ex.RequestBody = redactBody(raw, true)
+			}
+		}
+
+		resp, err := next(req)
+		ex.DurationMS = float64(time.Since(ex.Time).Microseconds()) / 1000
+		if err != nil {
+			ex.Error = err.Error()
+			b.record(ex)
+			return resp, err
+		}
+
+		raw, truncated, readErr := peekBody(req, resp)
+		if readErr != nil {
+			ex.Status = resp.StatusCode
+			ex.Error = readErr.Error()
+			b.record(ex)
+			return nil, readErr
+		}
+		ex.Status = resp.StatusCode
+		ex.ResponseHeaders = redactHeaders(resp.Header)
+		ex.ResponseBody = redactBody(raw, true)
+		ex.ResponseTruncated = truncated
+		ex.ResponseStream = isStreaming(req, resp)
+		b.record(ex)
+		return resp, nil
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// =============================================================================
+// Suppressions
+// =============================================================================
+
+// SuppressMemories hides memories from the retrieval of s.AgentID. The
+// memories stay stored and visible to other agents and to direct reads by
+// ID.
+func (c *Client) SuppressMemories(ctx context.Context, s *Suppression) (*Suppression, error) {
+	if s.AgentID == "" {
+		return nil, errors.New("suppression needs an agent ID")
+	}
+	if len(s.MemoryIDs) == 0 && s.Pattern == "" {
+		return nil, errors.New("suppression needs memory IDs or a pattern")
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid suppression pattern: %w", err)
+		}
+	}
+	path := fmt.Sprintf("/api/v1/agents/%s/suppressions", url.PathEscape(s.AgentID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, path, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Suppression]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("suppress memories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListSuppressions retrieves the suppressions of an agent.
+func (c *Client) ListSuppressions(ctx context.Context, agentID string) (*SuppressionList, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/suppressions", url.PathEscape(agentID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[SuppressionList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list suppressions failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteSuppression lifts a suppression, making its memories retrievable
+// by the agent again.
+func (c *Client) DeleteSuppression(ctx context.Context, agentID, suppressionID string) error {
+	path := fmt.Sprintf("/api/v1/agents/%s/suppressions/%s", url.PathEscape(agentID), url.PathEscape(suppressionID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete suppression failed: %s", resp.Message)
+	}
+
+	return nil
+}
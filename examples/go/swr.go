@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/internal/offline"
+)
+
+// CacheStatus reports how SearchMemoriesSWR answered a search.
+type CacheStatus string
+
+const (
+	// CacheMiss means the results were fetched from the server.
+	CacheMiss CacheStatus = "miss"
+
+	// CacheFresh means cached results younger than FreshFor were served.
+	CacheFresh CacheStatus = "fresh"
+
+	// CacheStale means cached results were served while a refresh runs in
+	// the background.
+	CacheStale CacheStatus = "stale"
+)
+
+// SWROptions configures stale-while-revalidate search.
+type SWROptions struct {
+	// FreshFor is how long cached results are served without a refresh.
+	// With the default of zero every cache hit triggers a refresh.
+	FreshFor time.Duration
+
+	// MaxStale is the age beyond which cached results are not served and
+	// the search waits for the server. Defaults to 5 minutes.
+	MaxStale time.Duration
+
+	// MaxEntries bounds the cache; the least recently used entries are
+	// evicted. Defaults to 1000.
+	MaxEntries int
+
+	// OnRefresh, if set, is called from a background goroutine after each
+	// background refresh, e.g. to update a UI that showed stale results.
+	OnRefresh func(req *SearchMemoryRequest, results *SearchResults, err error)
+}
+
+// WithStaleWhileRevalidate configures the cache behind SearchMemoriesSWR.
+func WithStaleWhileRevalidate(opts SWROptions) Option {
+	return func(c *Client) {
+		c.swrOpts = &opts
+	}
+}
+
+// SearchMemoriesSWR searches with stale-while-revalidate caching: when
+// results for the same request are cached they are returned immediately
+// and refreshed in the background, which bounds the worst-case latency of
+// repeated queries, e.g. for interactive agents. Results older than
+// MaxStale, and first-time queries, are fetched synchronously.
+func (c *Client) SearchMemoriesSWR(ctx context.Context, req *SearchMemoryRequest) (*SearchResults, CacheStatus, error) {
+	cache, err := c.searchCache()
+	if err != nil {
+		// A closed client no longer refreshes in the background.
+		results, err := c.searchMemories(ctx, req)
+		return results, CacheMiss, err
+	}
+
+	key := c.searchCacheKey(req)
+	if results, age, ok := cache.get(key); ok {
+		if age <= cache.opts.FreshFor {
+			return results, CacheFresh, nil
+		}
+		if age <= cache.opts.MaxStale {
+			cache.refresh(c, key, req)
+			return results, CacheStale, nil
+		}
+	}
+
+	results, err := c.searchMemories(ctx, req)
+	if err != nil {
+		return nil, CacheMiss, err
+	}
+	cache.put(key, results)
+	return copySearchResults(results), CacheMiss, nil
+}
+
+// searchCache returns the client's search cache, creating it on first use.
+func (c *Client) searchCache() (*searchCache, error) {
+	st := c.state()
+	st.searchCacheOnce.Do(func() {
+		opts := SWROptions{}
+		if c.swrOpts != nil {
+			opts = *c.swrOpts
+		}
+		cache := newSearchCache(opts)
+		if err := c.addBackground("search cache", cache.close); err != nil {
+			cache.close(context.Background())
+			st.searchCacheErr = err
+			return
+		}
+		st.searchCache = cache
+	})
+	return st.searchCache, st.searchCacheErr
+}
+
+// searchCacheKey returns the signature of req. It includes the API key so
+// that children with different credentials never share results, and
+// MinRelevance, which is not sent to the server.
+func (c *Client) searchCacheKey(req *SearchMemoryRequest) string {
+	data, _ := json.Marshal(req)
+	h := sha256.New()
+	h.Write([]byte(c.APIKey + "\x00"))
+	h.Write(data)
+	fmt.Fprintf(h, "\x00%g", req.MinRelevance)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// searchCache is an LRU cache of search results with background refresh.
+type searchCache struct {
+	opts    SWROptions
+	results *offline.Cache[*SearchResults]
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+	closed     bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newSearchCache(opts SWROptions) *searchCache {
+	if opts.MaxStale <= 0 {
+		opts.MaxStale = 5 * time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1000
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &searchCache{
+		opts:       opts,
+		results:    offline.NewCache[*SearchResults](opts.MaxEntries),
+		refreshing: make(map[string]bool),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// get returns a copy of the cached results for key and their age.
+func (s *searchCache) get(key string) (*SearchResults, time.Duration, bool) {
+	results, fetchedAt, ok := s.results.Get(key)
+	if !ok {
+		return nil, 0, false
+	}
+	return copySearchResults(results), time.Since(fetchedAt), true
+}
+
+// put stores results under key, evicting the least recently used entry
+// when full.
+func (s *searchCache) put(key string, results *SearchResults) {
+	s.results.Put(key, results)
+}
+
+// refresh re-runs req in the background unless a refresh of key is
+// already running.
+func (s *searchCache) refresh(c *Client, key string, req *SearchMemoryRequest) {
+	s.mu.Lock()
+	if s.closed || s.refreshing[key] {
+		s.mu.Unlock()
+		return
+	}
+	s.refreshing[key] = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	req = copySearchRequest(req)
+	go func() {
+		defer s.wg.Done()
+		results, err := c.searchMemories(s.ctx, req)
+		if err == nil {
+			s.put(key, results)
+		}
+		s.mu.Lock()
+		delete(s.refreshing, key)
+		s.mu.Unlock()
+		if s.opts.OnRefresh != nil {
+			c.guard("OnRefresh", func() { s.opts.OnRefresh(req, copySearchResults(results), err) })
+		}
+	}()
+}
+
+// close waits for running refreshes, cancelling them when ctx is done.
+func (s *searchCache) close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	defer s.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// copySearchResults returns a copy of r whose result slice the caller may
+// modify.
+func copySearchResults(r *SearchResults) *SearchResults {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	cp.Results = append([]SearchResult(nil), r.Results...)
+	return &cp
+}
+
+// copySearchRequest returns a copy of req that outlives the caller's
+// modifications of it.
+func copySearchRequest(req *SearchMemoryRequest) *SearchMemoryRequest {
+	cp := *req
+	return &cp
+}
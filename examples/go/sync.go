@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oceanbase/powermem/examples/go/internal/offline"
+	"github.com/oceanbase/powermem/examples/go/webhooks"
+)
+
+// ErrNotSynced is returned by SearchLocal for a user whose memories have
+// not been synced yet.
+var ErrNotSynced = errors.New("powermem: user not synced")
+
+// SyncOptions configures a SyncManager.
+type SyncOptions struct {
+	// UserIDs are the users whose memories are kept locally.
+	UserIDs []string
+
+	// Interval is the delay between syncs. Default 1 minute.
+	Interval time.Duration
+
+	// Path, if set, is a file where the local copy is saved after each
+	// sync and loaded from on start, so it survives restarts during an
+	// outage.
+	Path string
+
+	// OnSync, if set, is called from the sync goroutine after each sync
+	// of a user with the number of memories changed.
+	OnSync func(userID string, changed int, err error)
+}
+
+// SyncManager keeps a local copy of the memories of a set of users,
+// pulling changes from the change feed in the background, so that
+// searches can be answered locally while the API is down.
+type SyncManager struct {
+	client *Client
+	opts   SyncOptions
+
+	mu    sync.RWMutex
+	users map[string]*syncedUser
+
+	quit      chan struct{}
+	closeOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// syncedUser is the local copy of one user's memories.
+type syncedUser struct {
+	Memories    map[MemoryID]Memory `json:"memories"`
+	ResumeToken string              `json:"resume_token"`
+	SyncedAt    time.Time           `json:"synced_at"`
+}
+
+// NewSyncManager loads the local copy saved at opts.Path, if any, and
+// starts syncing the users' memories through c. Client.Close stops it.
+func NewSyncManager(c *Client, opts SyncOptions) (*SyncManager, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &SyncManager{
+		client: c,
+		opts:   opts,
+		users:  make(map[string]*syncedUser),
+		quit:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if err := m.loadSnapshot(); err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := c.addBackground("sync manager", m.Close); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	m.wg.Add(1)
+	go m.run()
+	return m, nil
+}
+
+// SyncedAt returns when userID was last synced, or the zero time if it
+// has not been.
+func (m *SyncManager) SyncedAt(userID string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if u := m.users[userID]; u != nil {
+		return u.SyncedAt
+	}
+	return time.Time{}
+}
+
+// Sync pulls the changes of every user now rather than at the next
+// interval. It returns the first error encountered.
+func (m *SyncManager) Sync(ctx context.Context) error {
+	var firstErr error
+	changedAny := false
+	for _, userID := range m.opts.UserIDs {
+		changed, err := m.syncUser(ctx, userID)
+		if m.opts.OnSync != nil {
+			m.client.guard("OnSync", func() { m.opts.OnSync(userID, changed, err) })
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		changedAny = changedAny || changed > 0
+	}
+	if changedAny {
+		if err := m.saveSnapshot(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SearchLocal searches the local copy of userID's memories by word
+// overlap with query, returning at most limit results (0 for 10). It
+// serves degraded-mode operation; scores are not comparable with those
+// of SearchMemories.
+func (m *SyncManager) SearchLocal(userID, query string, limit int) (*SearchResults, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	u := m.users[userID]
+	if u == nil || u.SyncedAt.IsZero() {
+		return nil, ErrNotSynced
+	}
+
+	terms := wordSet(query)
+	var results []SearchResult
+	for _, mem := range u.Memories {
+		score := termOverlap(terms, wordSet(mem.Content))
+		if score == 0 {
+			continue
+		}
+		results = append(results, SearchResult{
+			MemoryID:   mem.MemoryID,
+			Content:    mem.Content,
+			Score:      score,
+			Metadata:   mem.Metadata,
+			UserID:     mem.UserID,
+			AgentID:    mem.AgentID,
+			Visibility: mem.Visibility,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].MemoryID > results[j].MemoryID
+	})
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return &SearchResults{Results: results, Total: total, Query: query}, nil
+}
+
+// Search runs req against the server, falling back to SearchLocal when
+// the server is unreachable. local reports whether the fallback answered.
+func (m *SyncManager) Search(ctx context.Context, req *SearchMemoryRequest) (results *SearchResults, local bool, err error) {
+	results, err = m.client.searchMemories(ctx, req)
+	if err == nil || !serverUnreachable(err) || ctx.Err() != nil {
+		return results, false, err
+	}
+	results, localErr := m.SearchLocal(req.UserID, req.Query, req.Limit)
+	if localErr != nil {
+		return nil, false, err
+	}
+	return results, true, nil
+}
+
+// serverUnreachable reports whether err means the server could not
+// answer: a network failure, a timeout, or a gateway reporting the server
+// down. Unlike requestNotSent it includes failures after the request was
+// sent, which is fine for falling back to a local copy on reads.
+func serverUnreachable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return offline.GatewayDown(apiErr.StatusCode)
+	}
+	return offline.NoResponse(err)
+}
+
+// Close stops syncing, cancelling a sync in flight when ctx is done.
+func (m *SyncManager) Close(ctx context.Context) error {
+	m.closeOnce.Do(func() { close(m.quit) })
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	defer m.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// run syncs every Interval until the manager is closed.
+func (m *SyncManager) run() {
+	defer m.wg.Done()
+	for {
+		m.Sync(m.ctx)
+
+		timer := time.NewTimer(m.opts.Interval)
+		select {
+		case <-m.quit:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// syncUser applies the changes made to userID's memories since the last
+// sync, loading all of them on the first sync or when the resume token
+// has expired.
+func (m *SyncManager) syncUser(ctx context.Context, userID string) (int, error) {
+	m.mu.RLock()
+	var token string
+	if u := m.users[userID]; u != nil {
+		token = u.ResumeToken
+	}
+	m.mu.RUnlock()
+
+	feed := &changeSubscription{c: m.client, params: SubscribeParams{UserID: userID}, token: token}
+	if token != "" {
+		changed, err := m.pull(ctx, userID, feed)
+		if !permanentStreamError(err) {
+			return changed, err
+		}
+		feed.token = ""
+	}
+	return m.reload(ctx, userID, feed)
+}
+
+// pull applies the change feed from feed's token onwards.
+func (m *SyncManager) pull(ctx context.Context, userID string, feed *changeSubscription) (int, error) {
+	changed := 0
+	for {
+		if err := feed.fetch(ctx, 0); err != nil {
+			return changed, err
+		}
+		events := feed.pending
+		feed.pending = nil
+
+		m.mu.Lock()
+		u := m.users[userID]
+		for _, ev := range events {
+			switch ev.Type {
+			case webhooks.MemoryDeleted, webhooks.MemoryExpired:
+				delete(u.Memories, ev.Memory.MemoryID)
+			default:
+				for _, id := range ev.SourceIDs {
+					delete(u.Memories, id)
+				}
+				u.Memories[ev.Memory.MemoryID] = ev.Memory
+			}
+			if ev.ResumeToken != "" {
+				feed.token = ev.ResumeToken
+			}
+		}
+		u.ResumeToken = feed.token
+		u.SyncedAt = time.Now()
+		m.mu.Unlock()
+
+		changed += len(events)
+		if len(events) == 0 {
+			return changed, nil
+		}
+	}
+}
+
+// reload replaces the local copy of userID's memories with all of them. The
+// feed position is taken first, so changes made while listing are applied
+// by the next pull.
+func (m *SyncManager) reload(ctx context.Context, userID string, feed *changeSubscription) (int, error) {
+	if err := feed.fetch(ctx, 0); err != nil {
+		return 0, err
+	}
+	feed.pending = nil
+
+	memories := make(map[MemoryID]Memory)
+	page := Pagination{Limit: 100}
+	for {
+		list, err := m.client.QueryByMetadata(ctx, MetaEq("user_id", userID), page)
+		if err != nil {
+			return 0, err
+		}
+		for _, mem := range list.Memories {
+			memories[mem.MemoryID] = mem
+		}
+		page.Offset += len(list.Memories)
+		if len(list.Memories) < page.Limit || page.Offset >= list.Total {
+			break
+		}
+	}
+
+	m.mu.Lock()
+	m.users[userID] = &syncedUser{Memories: memories, ResumeToken: feed.token, SyncedAt: time.Now()}
+	m.mu.Unlock()
+	return len(memories), nil
+}
+
+// loadSnapshot reads the local copy saved at Path.
+func (m *SyncManager) loadSnapshot() error {
+	if m.opts.Path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read sync snapshot: %w", err)
+	}
+	if err := json.Unmarshal(data, &m.users); err != nil {
+		return fmt.Errorf("failed to parse sync snapshot: %w", err)
+	}
+	return nil
+}
+
+// saveSnapshot writes the local copy to Path, atomically replacing the
+// previous one.
+func (m *SyncManager) saveSnapshot() error {
+	if m.opts.Path == "" {
+		return nil
+	}
+	m.mu.RLock()
+	data, err := json.Marshal(m.users)
+	m.mu.RUnlock()
+	if err == nil {
+		err = writeFileAtomic(m.opts.Path, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save sync snapshot: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path through a temporary file renamed
+// over it, so that readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// termOverlap returns the fraction of query terms found in doc.
+func termOverlap(query, doc map[string]struct{}) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	found := 0
+	for w := range query {
+		if _, ok := doc[w]; ok {
+			found++
+		}
+	}
+	return float64(found) / float64(len(query))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// =============================================================================
+// Storage Tiering
+// =============================================================================
+
+// SetColdStorage configures the bucket receiving archived memories.
+func (c *Client) SetColdStorage(ctx context.Context, cfg *ColdStorageConfig) (*ColdStorageConfig, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("cold storage needs an endpoint and a bucket")
+	}
+	if _, err := url.Parse(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid cold storage endpoint: %w", err)
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, "/api/v1/storage/cold", cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[ColdStorageConfig]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("set cold storage failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GetColdStorage retrieves the cold storage configuration, without the
+// secret key.
+func (c *Client) GetColdStorage(ctx context.Context) (*ColdStorageConfig, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/storage/cold", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[ColdStorageConfig]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get cold storage failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// PutTieringRule creates or replaces the tiering rule named rule.Name.
+// The server applies rules periodically, archiving content and vectors
+// of matching memories to cold storage.
+func (c *Client) PutTieringRule(ctx context.Context, rule *TieringRule) (*TieringRule, error) {
+	if rule.Name == "" {
+		return nil, errors.New("tiering rule name is required")
+	}
+	if rule.AfterDays <= 0 {
+		return nil, errors.New("tiering rule needs a positive AfterDays")
+	}
+	path := fmt.Sprintf("/api/v1/storage/tiering-rules/%s", url.PathEscape(rule.Name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, path, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[TieringRule]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("put tiering rule failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListTieringRules retrieves all tiering rules.
+func (c *Client) ListTieringRules(ctx context.Context) (*TieringRuleList, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/storage/tiering-rules", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[TieringRuleList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list tiering rules failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteTieringRule deletes a tiering rule. Memories already archived by
+// it stay in cold storage.
+func (c *Client) DeleteTieringRule(ctx context.Context, name string) error {
+	path := fmt.Sprintf("/api/v1/storage/tiering-rules/%s", url.PathEscape(name))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete tiering rule failed: %s", resp.Message)
+	}
+
+	return nil
+}
+
+// rehydrateRequest is the request body of a rehydration.
+type rehydrateRequest struct {
+	MemoryIDs []MemoryID `json:"memory_ids"`
+}
+
+// RehydrateMemories restores memories from cold storage to the hot tier
+// ahead of use, e.g. before a search with IncludeCold unset. Reading a
+// cold memory by ID rehydrates it anyway. Rehydration runs in the
+// background; wait for the returned job with WaitForJob.
+func (c *Client) RehydrateMemories(ctx context.Context, memoryIDs []MemoryID) (*Job, error) {
+	if len(memoryIDs) == 0 {
+		return nil, errors.New("no memories to rehydrate")
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/storage/rehydrate", &rehydrateRequest{MemoryIDs: memoryIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Job]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("rehydrate memories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
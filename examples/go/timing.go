@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestPhase is a phase of an HTTP request.
+type RequestPhase string
+
+const (
+	// PhaseConnect is obtaining a connection: waiting for a pooled one
+	// or dialing the server.
+	PhaseConnect RequestPhase = "connect"
+
+	// PhaseDNS is resolving the server's host name.
+	PhaseDNS RequestPhase = "dns"
+
+	// PhaseTLS is the TLS handshake.
+	PhaseTLS RequestPhase = "tls"
+
+	// PhaseSend is writing the request.
+	PhaseSend RequestPhase = "send"
+
+	// PhaseServerWait is waiting for the first byte of the response,
+	// i.e. the server's processing time, including inference.
+	PhaseServerWait RequestPhase = "server_wait"
+
+	// PhaseBodyRead is reading the response body.
+	PhaseBodyRead RequestPhase = "body_read"
+)
+
+// RequestTimings is the time a request spent in each phase, summed over
+// its attempts when retried.
+type RequestTimings struct {
+	DNS        time.Duration `json:"dns"`
+	Connect    time.Duration `json:"connect"`
+	TLS        time.Duration `json:"tls"`
+	Send       time.Duration `json:"send"`
+	ServerWait time.Duration `json:"server_wait"`
+	BodyRead   time.Duration `json:"body_read"`
+	Total      time.Duration `json:"total"`
+
+	// ReusedConn is set when the last attempt used a pooled connection,
+	// skipping DNS, connect and TLS.
+	ReusedConn bool `json:"reused_conn"`
+}
+
+// TimeoutError is returned when a request times out, by the HTTP
+// client's timeout or ctx's deadline. It attributes the time spent to
+// the phases of the request, so that network trouble (DNS, connect, TLS)
+// can be told from a slow server (ServerWait), e.g. during inference.
+type TimeoutError struct {
+	Method string
+	Path   string
+
+	// Phase is the phase in progress when the request timed out.
+	Phase RequestPhase
+
+	Timings RequestTimings
+
+	// Err is the underlying error, e.g. context.DeadlineExceeded.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	t := e.Timings
+	return fmt.Sprintf("%s %s timed out during %s after %s (dns %s, connect %s, tls %s, send %s, server wait %s, body read %s): %v",
+		e.Method, e.Path, e.Phase, t.Total, t.DNS, t.Connect, t.TLS, t.Send, t.ServerWait, t.BodyRead, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// Timeout implements net.Error.
+func (e *TimeoutError) Timeout() bool { return true }
+
+// Temporary implements net.Error.
+func (e *TimeoutError) Temporary() bool { return true }
+
+// isTimeout reports whether err is a deadline or network timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// requestTrace tracks the phases of a request through httptrace.
+type requestTrace struct {
+	method, path string
+
+	mu         sync.Mutex
+	start      time.Time
+	phase      RequestPhase
+	phaseStart time.Time
+	timings    RequestTimings
+}
+
+type requestTraceKey struct{}
+
+// withRequestTrace returns ctx with a trace of the request's phases.
+func withRequestTrace(ctx context.Context, method, path string) context.Context {
+	now := time.Now()
+	t := &requestTrace{method: method, path: path, start: now, phase: PhaseConnect, phaseStart: now}
+	ctx = context.WithValue(ctx, requestTraceKey{}, t)
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn:              func(string) { t.enter(PhaseConnect) },
+		DNSStart:             func(httptrace.DNSStartInfo) { t.enter(PhaseDNS) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.enter(PhaseConnect) },
+		TLSHandshakeStart:    func() { t.enter(PhaseTLS) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.enter(PhaseConnect) },
+		GotConn:              t.gotConn,
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.enter(PhaseServerWait) },
+		GotFirstResponseByte: func() { t.enter(PhaseBodyRead) },
+	})
+}
+
+// requestTraceFrom returns the trace of the request bound to ctx, if any.
+func requestTraceFrom(ctx context.Context) *requestTrace {
+	t, _ := ctx.Value(requestTraceKey{}).(*requestTrace)
+	return t
+}
+
+func (t *requestTrace) gotConn(info httptrace.GotConnInfo) {
+	t.enter(PhaseSend)
+	t.mu.Lock()
+	t.timings.ReusedConn = info.Reused
+	t.mu.Unlock()
+}
+
+// enter ends the current phase and starts p.
+func (t *requestTrace) enter(p RequestPhase) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.add(now)
+	t.phase, t.phaseStart = p, now
+}
+
+// add adds the time spent in the current phase up to now.
+func (t *requestTrace) add(now time.Time) {
+	d := now.Sub(t.phaseStart)
+	switch t.phase {
+	case PhaseConnect:
+		t.timings.Connect += d
+	case PhaseDNS:
+		t.timings.DNS += d
+	case PhaseTLS:
+		t.timings.TLS += d
+	case PhaseSend:
+		t.timings.Send += d
+	case PhaseServerWait:
+		t.timings.ServerWait += d
+	case PhaseBodyRead:
+		t.timings.BodyRead += d
+	}
+}
+
+// timeoutError returns err as a *TimeoutError attributed to the phase in
+// progress.
+func (t *requestTrace) timeoutError(err error) *TimeoutError {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.add(now)
+	t.phaseStart = now
+	timings := t.timings
+	timings.Total = now.Sub(t.start)
+	return &TimeoutError{Method: t.method, Path: t.path, Phase: t.phase, Timings: timings, Err: err}
+}
+
+// timeoutErrorOf returns err as a *TimeoutError if it is a timeout of the
+// traced request bound to ctx, else err.
+func timeoutErrorOf(ctx context.Context, err error) error {
+	t := requestTraceFrom(ctx)
+	if t == nil || !isTimeout(err) {
+		return err
+	}
+	return t.timeoutError(err)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TranscriptMetadataField is the metadata field holding the transcript of
+// an audio memory.
+const TranscriptMetadataField = "transcript"
+
+// ErrNoTranscriber is returned by CreateAudioMemory when the client has no
+// Transcriber configured.
+var ErrNoTranscriber = errors.New("powermem: no transcriber configured")
+
+// Transcriber converts speech to text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio []byte, name, contentType string) (*Transcript, error)
+}
+
+// Transcript is the text of a recording.
+type Transcript struct {
+	Text     string              `json:"text"`
+	Language string              `json:"language,omitempty"`
+	Duration float64             `json:"duration,omitempty"`
+	Segments []TranscriptSegment `json:"segments,omitempty"`
+}
+
+// TranscriptSegment is a stretch of speech. Start and End are offsets into
+// the recording in seconds.
+type TranscriptSegment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Speaker string  `json:"speaker,omitempty"`
+	Text    string  `json:"text"`
+}
+
+// Content returns the transcript as memory content: one timestamped line
+// per segment when speakers are known, so that who said what survives,
+// and the plain text otherwise.
+func (t *Transcript) Content() string {
+	labelled := false
+	for _, s := range t.Segments {
+		if s.Speaker != "" {
+			labelled = true
+			break
+		}
+	}
+	if !labelled {
+		return strings.TrimSpace(t.Text)
+	}
+
+	var b strings.Builder
+	for i, s := range t.Segments {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		secs := int(s.Start)
+		fmt.Fprintf(&b, "[%02d:%02d] ", secs/60, secs%60)
+		if s.Speaker != "" {
+			b.WriteString(s.Speaker + ": ")
+		}
+		b.WriteString(strings.TrimSpace(s.Text))
+	}
+	return b.String()
+}
+
+// WithTranscriber transcribes recordings passed to CreateAudioMemory with t.
+func WithTranscriber(t Transcriber) Option {
+	return func(c *Client) {
+		c.transcriber = t
+	}
+}
+
+// CreateAudioMemory turns a recording, such as a voice memo, into a memory.
+// The configured Transcriber converts it to text, which becomes the
+// memory's content after req.Content; the timestamped segments and speaker
+// labels are kept in the transcript metadata field. If a BlobStore is
+// configured the recording itself is stored as an attachment.
+func (c *Client) CreateAudioMemory(ctx context.Context, req *CreateMemoryRequest, name, contentType string, audio io.Reader) ([]CreatedMemory, error) {
+	if c.transcriber == nil {
+		return nil, ErrNoTranscriber
+	}
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	transcript, err := c.transcriber.Transcribe(ctx, data, name, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcribe audio: %w", err)
+	}
+	text := transcript.Content()
+	if text == "" && req.Content == "" {
+		return nil, errors.New("no speech found in audio")
+	}
+
+	enriched := *req
+	enriched.Content = strings.TrimSpace(req.Content + "\n\n" + text)
+	extra := map[string]interface{}{TranscriptMetadataField: transcript}
+	if c.blobs != nil {
+		att, err := c.UploadAttachment(ctx, name, contentType, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		extra[AttachmentMetadataField] = att.metadata()
+	}
+	enriched.Metadata = mergeMetadata(req.Metadata, extra)
+
+	return c.createMemory(ctx, &enriched)
+}
+
+// =============================================================================
+// Whisper API
+// =============================================================================
+
+// WhisperAPITranscriber transcribes recordings with the OpenAI audio
+// transcription API. Speaker labels are returned by diarizing models
+// only.
+type WhisperAPITranscriber struct {
+	// APIKey defaults to $OPENAI_API_KEY.
+	APIKey string
+
+	// Model defaults to "whisper-1". Use a diarizing model such as
+	// "gpt-4o-transcribe-diarize" to get speaker labels.
+	Model string
+
+	// Language is an optional ISO-639-1 hint.
+	Language string
+
+	// BaseURL defaults to "https://api.openai.com/v1", and may point at
+	// any compatible API.
+	BaseURL string
+
+	HTTPClient *http.Client
+}
+
+// Transcribe implements Transcriber.
+func (w *WhisperAPITranscriber) Transcribe(ctx context.Context, audio []byte, name, _ string) (*Transcript, error) {
+	apiKey := firstNonEmpty(w.APIKey, os.Getenv("OPENAI_API_KEY"))
+	if apiKey == "" {
+		return nil, errors.New("openai: API key not configured")
+	}
+	model := firstNonEmpty(w.Model, "whisper-1")
+	format := "verbose_json"
+	if strings.Contains(model, "diarize") {
+		format = "diarized_json"
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("model", model)
+	mw.WriteField("response_format", format)
+	if format == "verbose_json" {
+		mw.WriteField("timestamp_granularities[]", "segment")
+	}
+	if w.Language != "" {
+		mw.WriteField("language", w.Language)
+	}
+	part, err := mw.CreateFormFile("file", firstNonEmpty(filepath.Base(name), "audio"))
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to build request: %w", err)
+	}
+	part.Write(audio)
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("openai: failed to build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(firstNonEmpty(w.BaseURL, "https://api.openai.com/v1"), "/")+"/audio/transcriptions", &body)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	var transcript Transcript
+	if err := doOpenAI(w.HTTPClient, req, &transcript); err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
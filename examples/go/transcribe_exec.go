@@ -0,0 +1,109 @@
+//go:build !js && !wasip1 && !tinygo
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// =============================================================================
+// whisper.cpp
+// =============================================================================
+
+// WhisperCppTranscriber transcribes recordings locally with the whisper.cpp
+// command-line tool. Input must be in a format the tool reads, typically
+// 16 kHz WAV.
+type WhisperCppTranscriber struct {
+	// Binary defaults to "whisper-cli" on the PATH.
+	Binary string
+
+	// Model is the path of the ggml model file.
+	Model string
+
+	// Language defaults to "auto".
+	Language string
+
+	// Diarize enables tinydiarize speaker-turn detection, which needs a
+	// tdrz model. Turns are labelled "Speaker 1" and "Speaker 2"
+	// alternately, which suits two-party recordings.
+	Diarize bool
+}
+
+// whisperCppOutput is the subset of whisper.cpp's JSON output used here.
+type whisperCppOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets struct {
+			From int64 `json:"from"`
+			To   int64 `json:"to"`
+		} `json:"offsets"`
+		Text            string `json:"text"`
+		SpeakerTurnNext bool   `json:"speaker_turn_next"`
+	} `json:"transcription"`
+}
+
+// Transcribe implements Transcriber.
+func (w *WhisperCppTranscriber) Transcribe(ctx context.Context, audio []byte, name, _ string) (*Transcript, error) {
+	dir, err := os.MkdirTemp("", "powermem-whisper-*")
+	if err != nil {
+		return nil, fmt.Errorf("whisper.cpp: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input"+filepath.Ext(name))
+	if err := os.WriteFile(input, audio, 0o600); err != nil {
+		return nil, fmt.Errorf("whisper.cpp: %w", err)
+	}
+	outBase := filepath.Join(dir, "output")
+	args := []string{"-m", w.Model, "-f", input, "-l", firstNonEmpty(w.Language, "auto"), "-oj", "-of", outBase, "-np"}
+	if w.Diarize {
+		args = append(args, "-tdrz")
+	}
+
+	cmd := exec.CommandContext(ctx, firstNonEmpty(w.Binary, "whisper-cli"), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("whisper.cpp: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	data, err := os.ReadFile(outBase + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("whisper.cpp: %w", err)
+	}
+	var out whisperCppOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("whisper.cpp: failed to parse output: %w", err)
+	}
+
+	transcript := &Transcript{Language: out.Result.Language}
+	speaker := 1
+	var text []string
+	for _, seg := range out.Transcription {
+		s := TranscriptSegment{
+			Start: float64(seg.Offsets.From) / 1000,
+			End:   float64(seg.Offsets.To) / 1000,
+			Text:  strings.TrimSpace(seg.Text),
+		}
+		if w.Diarize {
+			s.Speaker = fmt.Sprintf("Speaker %d", speaker)
+			if seg.SpeakerTurnNext {
+				speaker = 3 - speaker
+			}
+		}
+		transcript.Segments = append(transcript.Segments, s)
+		text = append(text, s.Text)
+		transcript.Duration = s.End
+	}
+	transcript.Text = strings.Join(text, " ")
+	return transcript, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// =============================================================================
+// Source Trust
+// =============================================================================
+
+// GetTrustScores retrieves the trust score of each memory source.
+func (c *Client) GetTrustScores(ctx context.Context) (TrustScores, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/trust-scores", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[TrustScores]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get trust scores failed: %s", resp.Message)
+	}
+
+	return resp.Data, nil
+}
+
+// SetTrustScores sets the trust scores of the given sources and returns
+// the scores of all sources. Searches weigh a result's score by the trust
+// of its source, per the retrieval profile's Trust weight or the request's
+// TrustWeight, so that when a stated preference and a model inference
+// conflict the stated one ranks first.
+func (c *Client) SetTrustScores(ctx context.Context, scores TrustScores) (TrustScores, error) {
+	for source, score := range scores {
+		if score < 0 || score > 1 {
+			return nil, fmt.Errorf("trust score of %q must be between 0 and 1, got %g", source, score)
+		}
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, "/api/v1/trust-scores", scores)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[TrustScores]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("set trust scores failed: %s", resp.Message)
+	}
+
+	return resp.Data, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// MetadataValidator is implemented by metadata structs that check their
+// own fields. SetTypedMetadata validates before writing and
+// GetTypedMetadata after reading, so invalid metadata fails at the call
+// instead of deep inside application code.
+type MetadataValidator interface {
+	Validate() error
+}
+
+// EncodeMetadata returns v, a struct or map, as memory metadata. Fields are
+// named by their json tags. v must encode to a JSON object.
+func EncodeMetadata(v any) (map[string]interface{}, error) {
+	if err := validateMetadata(v); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	var md map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&md); err != nil || md == nil {
+		return nil, fmt.Errorf("invalid metadata: %T does not encode to a JSON object", v)
+	}
+	return md, nil
+}
+
+// DecodeMetadata returns metadata as a T, typically a struct with json
+// tags. Keys T does not declare are ignored, so other metadata, e.g. the
+// client's default metadata, does not get in the way.
+func DecodeMetadata[T any](metadata map[string]interface{}) (T, error) {
+	var v T
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return v, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("failed to decode metadata as %T: %w", v, err)
+	}
+	if err := validateMetadata(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// SetTypedMetadata merges v, a struct or map, into req's metadata, its
+// fields taking precedence over keys already set:
+//
+//	type Preference struct {
+//		Topic      string  `json:"topic"`
+//		Confidence float64 `json:"confidence"`
+//	}
+//	err := SetTypedMetadata(req, Preference{Topic: "food", Confidence: 0.9})
+func SetTypedMetadata(req *CreateMemoryRequest, v any) error {
+	md, err := EncodeMetadata(v)
+	if err != nil {
+		return err
+	}
+	req.Metadata = mergeMetadata(req.Metadata, md)
+	return nil
+}
+
+// GetTypedMetadata returns the metadata of m as a T; see DecodeMetadata.
+//
+//	pref, err := GetTypedMetadata[Preference](memory)
+func GetTypedMetadata[T any](m *Memory) (T, error) {
+	return DecodeMetadata[T](m.Metadata)
+}
+
+// validateMetadata calls Validate if v implements MetadataValidator,
+// with a value or pointer receiver.
+func validateMetadata(v any) error {
+	validator, ok := v.(MetadataValidator)
+	if rv := reflect.ValueOf(v); !ok && rv.IsValid() {
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		validator, ok = p.Interface().(MetadataValidator)
+	}
+	if !ok {
+		return nil
+	}
+	if err := validator.Validate(); err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+	return nil
+}
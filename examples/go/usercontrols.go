@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// =============================================================================
+// End-User Memory Controls
+// =============================================================================
+
+// UserMemoryControls are the operations a settings UI offers to the user
+// whose memories they are: browse by category, edit or delete a memory,
+// disable topics and turn memory off. Every call is scoped to the user, so
+// it cannot reach other users' memories.
+type UserMemoryControls struct {
+	c      *Client
+	userID string
+}
+
+// UserControls returns the memory controls of userID.
+func (c *Client) UserControls(userID string) *UserMemoryControls {
+	return &UserMemoryControls{c: c, userID: userID}
+}
+
+// Categories lists the user's memories grouped into human-friendly
+// categories, with up to perCategory memories each (0 for the server
+// default).
+func (u *UserMemoryControls) Categories(ctx context.Context, perCategory int) (*CategorizedMemories, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/memories/categories", url.PathEscape(u.userID))
+	if perCategory > 0 {
+		params := url.Values{}
+		params.Set("limit", strconv.Itoa(perCategory))
+		path += "?" + params.Encode()
+	}
+
+	respBody, err := u.c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[CategorizedMemories]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list memory categories failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// Edit replaces the content of one of the user's memories, e.g. to
+// correct a fact the assistant got wrong.
+func (u *UserMemoryControls) Edit(ctx context.Context, memoryID MemoryID, content string) (*Memory, error) {
+	return u.c.updateMemory(ctx, memoryID, &UpdateMemoryRequest{Content: content, UserID: u.userID})
+}
+
+// Delete deletes one of the user's memories.
+func (u *UserMemoryControls) Delete(ctx context.Context, memoryID MemoryID) error {
+	_, err := u.c.DeleteMemoryWithOptions(ctx, memoryID, DeleteMemoryOptions{UserID: u.userID})
+	return err
+}
+
+// Settings retrieves the user's memory settings.
+func (u *UserMemoryControls) Settings(ctx context.Context) (*MemorySettings, error) {
+	respBody, err := u.c.doRequestContext(ctx, http.MethodGet, u.settingsPath(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemorySettings]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get memory settings failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// UpdateSettings replaces the user's memory settings.
+func (u *UserMemoryControls) UpdateSettings(ctx context.Context, settings *MemorySettings) (*MemorySettings, error) {
+	scoped := *settings
+	scoped.UserID = u.userID
+
+	respBody, err := u.c.doRequestContext(ctx, http.MethodPut, u.settingsPath(), &scoped)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[MemorySettings]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("update memory settings failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// SetOptOut turns memory off for the user, or back on. While off, the
+// server rejects writes for the user.
+func (u *UserMemoryControls) SetOptOut(ctx context.Context, optOut bool) (*MemorySettings, error) {
+	return u.modifySettings(ctx, func(s *MemorySettings) { s.OptedOut = optOut })
+}
+
+// DisableTopic stops the assistant from remembering anything in topic,
+// e.g. TopicHealth. Writes classified into it are skipped at ingest.
+func (u *UserMemoryControls) DisableTopic(ctx context.Context, topic string) (*MemorySettings, error) {
+	return u.modifySettings(ctx, func(s *MemorySettings) {
+		if !slices.Contains(s.DisabledTopics, topic) {
+			s.DisabledTopics = append(s.DisabledTopics, topic)
+		}
+	})
+}
+
+// DisableCustomTopic defines a topic of the user's own, replacing an
+// earlier definition of the same topic, and disables it.
+func (u *UserMemoryControls) DisableCustomTopic(ctx context.Context, def TopicDefinition) (*MemorySettings, error) {
+	if def.Topic == "" || (def.Description == "" && len(def.Keywords) == 0) {
+		return nil, errors.New("custom topic needs a name and a description or keywords")
+	}
+	return u.modifySettings(ctx, func(s *MemorySettings) {
+		s.CustomTopics = slices.DeleteFunc(s.CustomTopics, func(d TopicDefinition) bool { return d.Topic == def.Topic })
+		s.CustomTopics = append(s.CustomTopics, def)
+		if !slices.Contains(s.DisabledTopics, def.Topic) {
+			s.DisabledTopics = append(s.DisabledTopics, def.Topic)
+		}
+	})
+}
+
+// EnableTopic re-enables a topic disabled with DisableTopic or
+// DisableCustomTopic. A custom topic keeps its definition.
+func (u *UserMemoryControls) EnableTopic(ctx context.Context, topic string) (*MemorySettings, error) {
+	return u.modifySettings(ctx, func(s *MemorySettings) {
+		s.DisabledTopics = slices.DeleteFunc(s.DisabledTopics, func(t string) bool { return t == topic })
+	})
+}
+
+// modifySettings applies fn to the current settings and stores them.
+func (u *UserMemoryControls) modifySettings(ctx context.Context, fn func(*MemorySettings)) (*MemorySettings, error) {
+	settings, err := u.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fn(settings)
+	return u.UpdateSettings(ctx, settings)
+}
+
+func (u *UserMemoryControls) settingsPath() string {
+	return fmt.Sprintf("/api/v1/users/%s/memory-settings", url.PathEscape(u.userID))
+}
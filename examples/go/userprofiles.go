@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// userProfileConcurrency bounds the requests GetUserProfiles has in flight.
+const userProfileConcurrency = 8
+
+// GetUserProfile returns the profile synthesized from userID's memories.
+func (c *Client) GetUserProfile(ctx context.Context, userID string) (*UserProfile, error) {
+	path := fmt.Sprintf("/api/v1/users/%s/profile", url.PathEscape(userID))
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[UserProfile]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("get user profile failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// GetUserProfiles returns the profiles of many users by user ID, e.g. for
+// a nightly personalization job, fetching them with a bounded number of
+// requests in flight so that the server is not flooded. Users without a
+// profile are left out.
+//
+// The profiles fetched are returned even when others fail; the error
+// joins the failures. Once ctx is done, the remaining users are skipped.
+func (c *Client) GetUserProfiles(ctx context.Context, userIDs []string) (map[string]*UserProfile, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		errs     []error
+		profiles = make(map[string]*UserProfile, len(userIDs))
+	)
+	sem := make(chan struct{}, userProfileConcurrency)
+	seen := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			mu.Lock()
+			errs = append(errs, ctx.Err())
+			mu.Unlock()
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			profile, err := c.GetUserProfile(ctx, userID)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				profiles[userID] = profile
+			case !IsNotFound(err):
+				errs = append(errs, fmt.Errorf("user %s: %w", userID, err))
+			}
+		}()
+	}
+	wg.Wait()
+	return profiles, errors.Join(errs...)
+}
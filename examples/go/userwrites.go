@@ -0,0 +1,26 @@
+package main
+
+// WithUserWriteSerialization serializes creates, batch creates and updates
+// per user ID within the client and its children, so that at most one
+// write per user is in flight at a time.
+//
+// Concurrent creates with inference for the same user race on the server:
+// each extracts facts without seeing the others', producing duplicate or
+// conflicting memories. Serializing them lets each inference run against
+// the memories the previous one stored. Writes of different users still
+// run in parallel, and writes without a user ID are not serialized.
+// Writers in other processes are not coordinated with.
+func WithUserWriteSerialization() Option {
+	return func(c *Client) {
+		c.serializeUserWrites = true
+	}
+}
+
+// lockUserWrites acquires the write lock of userID when writes are
+// serialized per user, and returns a function that releases it.
+func (c *Client) lockUserWrites(userID string) (unlock func()) {
+	if !c.serializeUserWrites || userID == "" {
+		return func() {}
+	}
+	return c.state().userWriteLocks.Lock(userID)
+}
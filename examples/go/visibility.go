@@ -0,0 +1,91 @@
+package main
+
+import "fmt"
+
+// Valid reports whether v is a known visibility level or empty.
+func (v Visibility) Valid() bool {
+	switch v {
+	case "", VisibilityPrivate, VisibilityAgent, VisibilityShared, VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+// visibleTo reports whether a memory with visibility v owned by ownerUser
+// and ownerAgent may be shown to a caller acting as callerUser and
+// callerAgent. Memories restricted to an agent are shown only to a caller
+// acting as that agent, so a caller that names no agent does not see
+// other agents' private memories. An empty user ID on either side is not
+// used to hide a memory: the server is authoritative and this check only
+// drops results a server without visibility support returned to the
+// wrong caller.
+func visibleTo(v Visibility, ownerUser, ownerAgent, callerUser, callerAgent string) bool {
+	sameUser := callerUser == "" || ownerUser == "" || ownerUser == callerUser
+	switch v {
+	case VisibilityPublic:
+		return true
+	case VisibilityAgent:
+		return ownerAgent == callerAgent
+	case VisibilityPrivate:
+		return sameUser && ownerAgent == callerAgent
+	default:
+		return sameUser
+	}
+}
+
+// VisibleTo reports whether m may be shown to a caller acting as userID
+// and agentID.
+func (m *Memory) VisibleTo(userID, agentID string) bool {
+	return visibleTo(m.Visibility, m.UserID, m.AgentID, userID, agentID)
+}
+
+// filterVisibleMemories removes the memories in list that the caller may
+// not see.
+func filterVisibleMemories(list *MemoryList, userID, agentID string) {
+	kept := list.Memories[:0]
+	for _, m := range list.Memories {
+		if m.VisibleTo(userID, agentID) {
+			kept = append(kept, m)
+		}
+	}
+	list.Total -= len(list.Memories) - len(kept)
+	list.Memories = kept
+}
+
+// filterVisibleResults removes the search results that the caller may not
+// see.
+func filterVisibleResults(results *SearchResults, userID, agentID string) {
+	kept := results.Results[:0]
+	for _, r := range results.Results {
+		if visibleTo(r.Visibility, r.UserID, r.AgentID, userID, agentID) {
+			kept = append(kept, r)
+		}
+	}
+	results.Total -= len(results.Results) - len(kept)
+	results.Results = kept
+}
+
+// checkVisibility validates the visibility of a write request.
+func checkVisibility(v Visibility) error {
+	if !v.Valid() {
+		return fmt.Errorf("invalid visibility %q", v)
+	}
+	return nil
+}
+
+// Valid reports whether t is a known memory type or empty.
+func (t MemoryType) Valid() bool {
+	switch t {
+	case "", MemoryEpisodic, MemorySemantic, MemoryProcedural, MemoryWorking:
+		return true
+	}
+	return false
+}
+
+// checkMemoryType validates the memory type of a write request.
+func checkMemoryType(t MemoryType) error {
+	if !t.Valid() {
+		return fmt.Errorf("invalid memory type %q", t)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestVisibleTo(t *testing.T) {
+	tests := []struct {
+		v                       Visibility
+		ownerUser, ownerAgent   string
+		callerUser, callerAgent string
+		want                    bool
+	}{
+		{VisibilityPrivate, "u1", "planner", "u1", "planner", true},
+		{VisibilityPrivate, "u1", "planner", "u1", "booker", false},
+		{VisibilityPrivate, "u1", "planner", "u2", "planner", false},
+		// A caller naming no agent is not the owning agent.
+		{VisibilityPrivate, "u1", "planner", "u1", "", false},
+		{VisibilityPrivate, "u1", "planner", "", "", false},
+		{VisibilityPrivate, "u1", "", "u1", "", true},
+		{VisibilityAgent, "u1", "planner", "u2", "planner", true},
+		{VisibilityAgent, "u1", "planner", "u1", "", false},
+		{VisibilityShared, "u1", "planner", "u1", "booker", true},
+		{VisibilityShared, "u1", "planner", "u2", "", false},
+		{"", "u1", "", "", "", true},
+		{VisibilityPublic, "u1", "planner", "u2", "", true},
+	}
+	for _, tt := range tests {
+		if got := visibleTo(tt.v, tt.ownerUser, tt.ownerAgent, tt.callerUser, tt.callerAgent); got != tt.want {
+			t.Errorf("visibleTo(%q, owner %q/%q, caller %q/%q) = %t, want %t",
+				tt.v, tt.ownerUser, tt.ownerAgent, tt.callerUser, tt.callerAgent, got, tt.want)
+		}
+	}
+}
+
+// visibilityServer answers every list, query and search with the same
+// memories, as a server ignoring visibility would.
+func visibilityServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	memories := []Memory{
+		{MemoryID: 1, UserID: "u1", AgentID: "planner", Visibility: VisibilityShared},
+		{MemoryID: 2, UserID: "u1", AgentID: "planner", Visibility: VisibilityPrivate},
+		{MemoryID: 3, UserID: "u2", AgentID: "planner", Visibility: VisibilityAgent},
+		{MemoryID: 4, UserID: "u2", AgentID: "booker", Visibility: VisibilityPublic},
+		{MemoryID: 5, UserID: "u1", Visibility: VisibilityPrivate},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data interface{} = MemoryList{Memories: memories, Total: len(memories)}
+		if r.URL.Path == "/api/v1/memories/search" {
+			results := SearchResults{Total: len(memories)}
+			for _, m := range memories {
+				results.Results = append(results.Results, SearchResult{MemoryID: m.MemoryID, UserID: m.UserID, AgentID: m.AgentID, Visibility: m.Visibility})
+			}
+			data = results
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVisibilityFiltering(t *testing.T) {
+	c := NewClient(visibilityServer(t).URL, "")
+
+	listIDs := func(list *MemoryList, err error) []MemoryID {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []MemoryID
+		for _, m := range list.Memories {
+			ids = append(ids, m.MemoryID)
+		}
+		if list.Total != len(ids) {
+			t.Errorf("Total = %d, want %d", list.Total, len(ids))
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name string
+		get  func() []MemoryID
+		want []MemoryID
+	}{
+		{"user memories", func() []MemoryID {
+			return listIDs(c.GetUserMemories("u1", 0, 0))
+		}, []MemoryID{1, 4, 5}},
+		{"agent memories", func() []MemoryID {
+			return listIDs(c.GetAgentMemories("planner", 0, 0))
+		}, []MemoryID{1, 2, 3, 4}},
+		{"metadata query", func() []MemoryID {
+			return listIDs(c.QueryByMetadata(context.Background(), MetaEq("category", "travel"), Pagination{}))
+		}, []MemoryID{1, 4, 5}},
+		{"metadata query as agent", func() []MemoryID {
+			return listIDs(c.QueryByMetadata(context.Background(), MetadataFilter{"user_id": "u1", "agent_id": "booker"}, Pagination{}))
+		}, []MemoryID{1, 4}},
+		{"search without agent", func() []MemoryID {
+			results, err := c.SearchMemories(&SearchMemoryRequest{Query: "hotel", UserID: "u1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []MemoryID
+			for _, r := range results.Results {
+				ids = append(ids, r.MemoryID)
+			}
+			return ids
+		}, []MemoryID{1, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.get(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got memories %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VisionMetadataField is the metadata field holding the vision analysis
+// of an image memory.
+const VisionMetadataField = "vision"
+
+// ErrNoVisionProvider is returned by CreateImageMemory when the client has
+// no VisionProvider configured.
+var ErrNoVisionProvider = errors.New("powermem: no vision provider configured")
+
+// VisionProvider turns an image into text: a caption, the text found in
+// it, or both.
+type VisionProvider interface {
+	Analyze(ctx context.Context, image []byte, contentType string) (*VisionResult, error)
+}
+
+// VisionResult is the text extracted from an image.
+type VisionResult struct {
+	Caption string       `json:"caption,omitempty"`
+	Regions []TextRegion `json:"regions,omitempty"`
+}
+
+// TextRegion is a line of text found in an image.
+type TextRegion struct {
+	Text string      `json:"text"`
+	Box  BoundingBox `json:"box"`
+
+	// Confidence is in [0, 1], or 0 if the provider does not report it.
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// BoundingBox locates a region in an image, in pixels from the top-left
+// corner.
+type BoundingBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// union returns the smallest box containing b and o.
+func (b BoundingBox) union(o BoundingBox) BoundingBox {
+	if b.Width == 0 && b.Height == 0 {
+		return o
+	}
+	x0, y0 := min(b.X, o.X), min(b.Y, o.Y)
+	x1, y1 := max(b.X+b.Width, o.X+o.Width), max(b.Y+b.Height, o.Y+o.Height)
+	return BoundingBox{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+// Text returns the searchable text of r: the caption followed by the text
+// found in the image.
+func (r *VisionResult) Text() string {
+	var b strings.Builder
+	b.WriteString(r.Caption)
+	if len(r.Regions) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("Text in image:")
+		for _, region := range r.Regions {
+			b.WriteString("\n")
+			b.WriteString(region.Text)
+		}
+	}
+	return b.String()
+}
+
+// WithVision enriches images passed to CreateImageMemory with p.
+func WithVision(p VisionProvider) Option {
+	return func(c *Client) {
+		c.vision = p
+	}
+}
+
+// CreateImageMemory turns an image into a searchable text memory. The
+// configured VisionProvider captions the image and extracts its text,
+// which becomes the memory's content after req.Content; the text regions
+// and their bounding boxes are kept in the vision metadata field. If a
+// BlobStore is configured the image itself is stored as an attachment.
+func (c *Client) CreateImageMemory(ctx context.Context, req *CreateMemoryRequest, name, contentType string, image io.Reader) ([]CreatedMemory, error) {
+	if c.vision == nil {
+		return nil, ErrNoVisionProvider
+	}
+	data, err := io.ReadAll(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	result, err := c.vision.Analyze(ctx, data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze image: %w", err)
+	}
+	text := result.Text()
+	if text == "" && req.Content == "" {
+		return nil, errors.New("no text could be extracted from image")
+	}
+
+	enriched := *req
+	enriched.Content = strings.TrimSpace(req.Content + "\n\n" + text)
+	extra := map[string]interface{}{VisionMetadataField: result}
+	if c.blobs != nil {
+		att, err := c.UploadAttachment(ctx, name, contentType, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		extra[AttachmentMetadataField] = att.metadata()
+	}
+	enriched.Metadata = mergeMetadata(req.Metadata, extra)
+
+	return c.createMemory(ctx, &enriched)
+}
+
+// =============================================================================
+// OpenAI Vision
+// =============================================================================
+
+// OpenAIVisionProvider captions images and transcribes their text with an
+// OpenAI vision model. Bounding boxes are the model's estimates.
+type OpenAIVisionProvider struct {
+	// APIKey defaults to $OPENAI_API_KEY.
+	APIKey string
+
+	// Model defaults to "gpt-4o-mini".
+	Model string
+
+	// BaseURL defaults to "https://api.openai.com/v1", and may point at
+	// any compatible API.
+	BaseURL string
+
+	HTTPClient *http.Client
+}
+
+const openAIVisionPrompt = `Describe this image for a searchable memory store. Reply with a JSON object:
+{"caption": "<one or two sentences>", "regions": [{"text": "<a line of text in the image>", "box": {"x": 0, "y": 0, "width": 0, "height": 0}}]}
+Boxes are in pixels from the top-left corner. Use an empty regions list if the image has no text.`
+
+// Analyze implements VisionProvider.
+func (o *OpenAIVisionProvider) Analyze(ctx context.Context, image []byte, contentType string) (*VisionResult, error) {
+	apiKey := firstNonEmpty(o.APIKey, os.Getenv("OPENAI_API_KEY"))
+	if apiKey == "" {
+		return nil, errors.New("openai: API key not configured")
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(image)
+	}
+
+	body := map[string]interface{}{
+		"model":           firstNonEmpty(o.Model, "gpt-4o-mini"),
+		"response_format": map[string]string{"type": "json_object"},
+		"messages": []map[string]interface{}{{
+			"role": "user",
+			"content": []map[string]interface{}{
+				{"type": "text", "text": openAIVisionPrompt},
+				{"type": "image_url", "image_url": map[string]string{
+					"url": "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(image),
+				}},
+			},
+		}},
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := openAIRequest(ctx, o.HTTPClient, o.BaseURL, apiKey, "/chat/completions", body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("openai: empty response")
+	}
+
+	var result VisionResult
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("openai: failed to parse model output: %w", err)
+	}
+	return &result, nil
+}
+
+// openAIRequest posts a JSON body to an OpenAI API endpoint and decodes
+// the JSON response into out.
+func openAIRequest(ctx context.Context, client *http.Client, baseURL, apiKey, path string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("openai: failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(firstNonEmpty(baseURL, "https://api.openai.com/v1"), "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("openai: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	return doOpenAI(client, req, out)
+}
+
+// doOpenAI executes an OpenAI API request and decodes the JSON response.
+func doOpenAI(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("openai: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("openai: failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("openai: HTTP %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("openai: failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build !js && !wasip1 && !tinygo
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// =============================================================================
+// Tesseract OCR
+// =============================================================================
+
+// TesseractProvider extracts text and its bounding boxes with a local
+// tesseract binary. It does not caption images.
+type TesseractProvider struct {
+	// Binary defaults to "tesseract" on the PATH.
+	Binary string
+
+	// Languages is passed to -l, e.g. "eng+deu". Defaults to tesseract's
+	// own default.
+	Languages string
+
+	// MinConfidence drops words recognized with lower confidence, in
+	// [0, 1].
+	MinConfidence float64
+}
+
+// Analyze implements VisionProvider.
+func (t *TesseractProvider) Analyze(ctx context.Context, image []byte, _ string) (*VisionResult, error) {
+	args := []string{"stdin", "stdout"}
+	if t.Languages != "" {
+		args = append(args, "-l", t.Languages)
+	}
+	args = append(args, "tsv")
+
+	cmd := exec.CommandContext(ctx, firstNonEmpty(t.Binary, "tesseract"), args...)
+	cmd.Stdin = bytes.NewReader(image)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractTSV(out, t.MinConfidence)
+}
+
+// parseTesseractTSV groups the words of tesseract's TSV output into lines.
+func parseTesseractTSV(tsv []byte, minConfidence float64) (*VisionResult, error) {
+	type lineKey struct{ page, block, par, line int }
+	var (
+		order []lineKey
+		lines = make(map[lineKey]*TextRegion)
+		words = make(map[lineKey]int)
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(tsv))
+	header := true
+	for scanner.Scan() {
+		if header {
+			header = false
+			continue
+		}
+		// level page block par line word left top width height conf text
+		fields := strings.SplitN(scanner.Text(), "\t", 12)
+		if len(fields) < 12 || fields[0] != "5" {
+			continue
+		}
+		text := strings.TrimSpace(fields[11])
+		n := make([]int, 10)
+		for i := range n {
+			n[i], _ = strconv.Atoi(fields[i])
+		}
+		conf, _ := strconv.ParseFloat(fields[10], 64)
+		conf /= 100
+		if text == "" || conf < minConfidence {
+			continue
+		}
+
+		key := lineKey{n[1], n[2], n[3], n[4]}
+		region, ok := lines[key]
+		if !ok {
+			region = &TextRegion{}
+			lines[key] = region
+			order = append(order, key)
+		}
+		if region.Text != "" {
+			region.Text += " "
+		}
+		region.Text += text
+		region.Box = region.Box.union(BoundingBox{X: n[6], Y: n[7], Width: n[8], Height: n[9]})
+		region.Confidence += conf
+		words[key]++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("tesseract: failed to read output: %w", err)
+	}
+
+	result := &VisionResult{Regions: make([]TextRegion, 0, len(order))}
+	for _, key := range order {
+		region := lines[key]
+		region.Confidence /= float64(words[key])
+		result.Regions = append(result.Regions, *region)
+	}
+	return result, nil
+}
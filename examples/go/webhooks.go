@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// =============================================================================
+// Webhooks
+// =============================================================================
+
+// CreateWebhook registers a webhook for memory events and returns it with
+// its signing secret, which is not returned again.
+func (c *Client) CreateWebhook(ctx context.Context, hook *Webhook) (*Webhook, error) {
+	if hook.URL == "" {
+		return nil, errors.New("webhook needs a URL")
+	}
+	if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", hook.URL)
+	}
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPost, "/api/v1/webhooks", hook)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Webhook]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("create webhook failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// UpdateWebhook replaces the configuration of the webhook webhookID. Its
+// signing secret is kept unless hook.Secret is set.
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID string, hook *Webhook) (*Webhook, error) {
+	if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", hook.URL)
+	}
+	path := fmt.Sprintf("/api/v1/webhooks/%s", url.PathEscape(webhookID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodPut, path, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[Webhook]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("update webhook failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// ListWebhooks retrieves the registered webhooks, without their secrets.
+func (c *Client) ListWebhooks(ctx context.Context) (*WebhookList, error) {
+	respBody, err := c.doRequestContext(ctx, http.MethodGet, "/api/v1/webhooks", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse[WebhookList]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("list webhooks failed: %s", resp.Message)
+	}
+
+	return &resp.Data, nil
+}
+
+// DeleteWebhook deletes a webhook; deliveries stop immediately.
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	path := fmt.Sprintf("/api/v1/webhooks/%s", url.PathEscape(webhookID))
+
+	respBody, err := c.doRequestContext(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp APIResponse[any]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("delete webhook failed: %s", resp.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+// Package webhooks helps build receivers for PowerMem webhook deliveries:
+// it verifies the signature of a delivery and decodes its event payload.
+//
+// A receiver verifies before trusting anything in the body:
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		body, _ := io.ReadAll(r.Body)
+//		if err := webhooks.VerifySignature(r.Header.Get(webhooks.SignatureHeader), body, secret); err != nil {
+//			http.Error(w, err.Error(), http.StatusUnauthorized)
+//			return
+//		}
+//		event, err := webhooks.ParseEvent(body)
+//		...
+//	}
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the HTTP header carrying the delivery signature.
+const SignatureHeader = "X-PowerMem-Signature"
+
+// DefaultTolerance is how old a delivery VerifySignature accepts, which
+// bounds replays of captured deliveries.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidHeader is returned for a malformed signature header.
+	ErrInvalidHeader = errors.New("webhooks: invalid signature header")
+
+	// ErrSignatureMismatch is returned when no signature in the header
+	// matches the body.
+	ErrSignatureMismatch = errors.New("webhooks: signature mismatch")
+
+	// ErrExpired is returned for a delivery older than the tolerance.
+	ErrExpired = errors.New("webhooks: signature timestamp outside tolerance")
+)
+
+// VerifySignature checks that body was sent by PowerMem with secret, and
+// recently. header is the SignatureHeader value, in the form
+//
+//	t=<unix seconds>,v1=<hex hmac-sha256 of "<t>.<body>">
+//
+// It may carry several v1 signatures while a secret is being rotated; any
+// match is accepted.
+func VerifySignature(header string, body []byte, secret string) error {
+	return VerifySignatureAt(header, body, secret, time.Now(), DefaultTolerance)
+}
+
+// VerifySignatureAt is VerifySignature with an explicit clock and
+// tolerance. A tolerance of zero disables the timestamp check.
+func VerifySignatureAt(header string, body []byte, secret string, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var sigs [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidHeader
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			sigs = append(sigs, sig)
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return ErrInvalidHeader
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(sec, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrExpired
+		}
+	}
+
+	want := sign(ts, body, secret)
+	for _, sig := range sigs {
+		if hmac.Equal(sig, want) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// Sign returns the signature header for body sent at t, e.g. to test a
+// receiver.
+func Sign(body []byte, secret string, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(sign(ts, body, secret))
+}
+
+func sign(ts string, body []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// =============================================================================
+// Event Payloads
+// =============================================================================
+
+// EventType identifies what happened.
+type EventType string
+
+const (
+	MemoryCreated EventType = "memory.created"
+	MemoryUpdated EventType = "memory.updated"
+	MemoryDeleted EventType = "memory.deleted"
+
+	// MemoryConsolidated reports a memory that replaced several others,
+	// listed in SourceIDs, which are deleted without events of their own.
+	MemoryConsolidated EventType = "memory.consolidated"
+
+	// MemoryExpired reports a memory deleted because it expired.
+	MemoryExpired EventType = "memory.expired"
+)
+
+// Event is a webhook delivery. Data holds the payload for Type; decode it
+// with Memory for memory events.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      EventType       `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// MemoryEvent is the payload of the memory.* events. For memory.deleted
+// and memory.expired only the IDs are set.
+type MemoryEvent struct {
+	MemoryID string                 `json:"memory_id"`
+	Content  string                 `json:"content,omitempty"`
+	UserID   string                 `json:"user_id,omitempty"`
+	AgentID  string                 `json:"agent_id,omitempty"`
+	RunID    string                 `json:"run_id,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// SourceIDs are the memories merged into this one, for
+	// memory.consolidated.
+	SourceIDs []string `json:"source_ids,omitempty"`
+}
+
+// UnmarshalJSON accepts memory IDs sent as JSON numbers or strings.
+func (m *MemoryEvent) UnmarshalJSON(data []byte) error {
+	type plain MemoryEvent
+	var raw struct {
+		plain
+		MemoryID  json.RawMessage   `json:"memory_id"`
+		SourceIDs []json.RawMessage `json:"source_ids"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = MemoryEvent(raw.plain)
+	m.MemoryID = strings.Trim(string(raw.MemoryID), `"`)
+	m.SourceIDs = nil
+	for _, id := range raw.SourceIDs {
+		m.SourceIDs = append(m.SourceIDs, strings.Trim(string(id), `"`))
+	}
+	return nil
+}
+
+// ParseEvent decodes a delivery body. Verify the signature first.
+func ParseEvent(body []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("webhooks: failed to parse event: %w", err)
+	}
+	if event.Type == "" {
+		return nil, errors.New("webhooks: event has no type")
+	}
+	return &event, nil
+}
+
+// Memory decodes the payload of a memory.* event.
+func (e *Event) Memory() (*MemoryEvent, error) {
+	if !strings.HasPrefix(string(e.Type), "memory.") {
+		return nil, fmt.Errorf("webhooks: %s is not a memory event", e.Type)
+	}
+	var m MemoryEvent
+	if err := json.Unmarshal(e.Data, &m); err != nil {
+		return nil, fmt.Errorf("webhooks: failed to parse %s payload: %w", e.Type, err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// A minimal RFC 6455 WebSocket client, enough for the realtime API: text
+// messages, ping/pong and close. Frames from the client are masked, as
+// the protocol requires.
+
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+
+	wsMaxMessageSize = 16 << 20
+	wsAcceptGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// errWebSocketClosed is returned by wsConn.read after a close frame.
+var errWebSocketClosed = errors.New("websocket closed")
+
+// wsConn is a client WebSocket connection. read must be called from one
+// goroutine; writes may be concurrent.
+type wsConn struct {
+	rwc    io.ReadWriteCloser
+	br     *bufio.Reader
+	cancel context.CancelFunc
+
+	// onPong is called from read for each pong received.
+	onPong func()
+
+	writeMu sync.Mutex
+}
+
+// dialWebSocket opens a WebSocket connection with an HTTP upgrade request.
+// ctx bounds the handshake; the connection lives until it is closed or
+// lifetime is done.
+func dialWebSocket(ctx, lifetime context.Context, client *http.Client, url string, header http.Header) (*wsConn, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate websocket key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	connCtx, cancel := context.WithCancel(lifetime)
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	req, err := http.NewRequestWithContext(connCtx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	// Client.Timeout would cut the connection; its lifetime is bound to
+	// ctx instead.
+	noTimeout := *client
+	noTimeout.Timeout = 0
+	resp, err := noTimeout.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer cancel()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return nil, parseError(resp, body)
+	}
+
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok || resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		resp.Body.Close()
+		cancel()
+		return nil, errors.New("websocket handshake failed")
+	}
+	return &wsConn{rwc: rwc, br: bufio.NewReader(rwc), cancel: cancel}, nil
+}
+
+// write sends a single-frame message.
+func (w *wsConn) write(opcode byte, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | opcode
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = 0x80 | byte(n)
+	case n <= 0xFFFF:
+		header[1] = 0x80 | 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 0x80 | 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	header = append(header, mask[:]...)
+
+	frame := make([]byte, len(header)+len(payload))
+	copy(frame, header)
+	for i, b := range payload {
+		frame[len(header)+i] = b ^ mask[i%4]
+	}
+
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	_, err := w.rwc.Write(frame)
+	return err
+}
+
+// read returns the next data message, answering pings and reassembling
+// fragmented messages. It returns errWebSocketClosed after the server
+// closes the connection.
+func (w *wsConn) read() ([]byte, error) {
+	var message []byte
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(w.br, head[:]); err != nil {
+			return nil, err
+		}
+		fin, opcode := head[0]&0x80 != 0, head[0]&0x0F
+		masked, n := head[1]&0x80 != 0, uint64(head[1]&0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(w.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(w.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		// Check n on its own first so that a huge length cannot wrap the
+		// sum around.
+		if n > wsMaxMessageSize || n+uint64(len(message)) > wsMaxMessageSize {
+			return nil, errors.New("websocket message too large")
+		}
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(w.br, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(w.br, payload); err != nil {
+			return nil, err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := w.write(wsPong, payload); err != nil {
+				return nil, err
+			}
+		case wsPong:
+			if w.onPong != nil {
+				w.onPong()
+			}
+		case wsClose:
+			w.write(wsClose, payload)
+			return nil, errWebSocketClosed
+		case wsText, wsBinary, wsContinuation:
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("unexpected websocket opcode %#x", opcode)
+		}
+	}
+}
+
+// close sends a normal closure frame and closes the connection.
+func (w *wsConn) close() error {
+	w.write(wsClose, []byte{0x03, 0xE8}) // 1000: normal closure
+	err := w.rwc.Close()
+	w.cancel()
+	return err
+}
@@ -0,0 +1,594 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseYAML parses the subset of YAML used by configuration files into
+// the values encoding/json produces: maps, slices, strings, numbers, bools
+// and nil. It supports block mappings and sequences, flow collections on
+// one line, plain and quoted scalars, literal (|) and folded (>) block
+// scalars and comments. Anchors, tags and multiple documents are not
+// supported. JSON, being valid YAML, parses as well.
+func parseYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		p.lines = append(p.lines, newYAMLLine(i+1, raw))
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) && p.lines[p.pos].text == "---" {
+		p.pos++
+		p.skipBlank()
+	}
+	if p.pos == len(p.lines) {
+		return nil, nil
+	}
+	first := p.lines[p.pos]
+	if first.indent > 0 {
+		return nil, first.errorf("unexpected indentation")
+	}
+	v, err := p.parseBlock(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.text == "---" {
+			return nil, l.errorf("multiple documents are not supported")
+		}
+		return nil, l.errorf("unexpected indentation")
+	}
+	return v, nil
+}
+
+// yamlLine is a line of a YAML document.
+type yamlLine struct {
+	num    int
+	raw    string
+	indent int
+
+	// text is the line without indentation and comment; empty for blank
+	// and comment lines.
+	text string
+	tab  bool
+}
+
+func newYAMLLine(num int, raw string) yamlLine {
+	l := yamlLine{num: num, raw: raw}
+	trimmed := strings.TrimLeft(raw, " ")
+	l.indent = len(raw) - len(trimmed)
+	l.text = strings.TrimRight(stripYAMLComment(trimmed), " \t")
+	if strings.HasPrefix(trimmed, "\t") {
+		l.tab = true
+		l.text = strings.TrimLeft(l.text, "\t ")
+	}
+	return l
+}
+
+func (l yamlLine) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", l.num, fmt.Sprintf(format, args...))
+}
+
+// stripYAMLComment removes a comment from s: a # at its start or after
+// a space, outside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case quote != 0:
+			if b == '\\' && quote == '"' || b == '\'' && quote == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+			} else if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:", rune(s[i-1])) {
+				quote = b
+			}
+		case b == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// skipBlank skips blank and comment lines.
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+// next returns the next non-blank line, if any.
+func (p *yamlParser) next() (yamlLine, bool) {
+	p.skipBlank()
+	if p.pos == len(p.lines) {
+		return yamlLine{}, false
+	}
+	return p.lines[p.pos], true
+}
+
+// checkIndent rejects a line indented with tabs, which YAML forbids.
+func (l yamlLine) checkIndent() error {
+	if l.tab {
+		return l.errorf("tabs are not allowed in indentation")
+	}
+	return nil
+}
+
+// parseBlock parses the mapping, sequence or scalar starting at the next
+// line, which is indented by indent.
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	l, _ := p.next()
+	if err := l.checkIndent(); err != nil {
+		return nil, err
+	}
+	if isYAMLSeqItem(l.text) {
+		return p.parseSeq(indent)
+	}
+	if _, _, ok := splitYAMLKey(l.text); ok {
+		return p.parseMap(indent)
+	}
+	p.pos++
+	return parseYAMLInline(l, l.text)
+}
+
+// isYAMLSeqItem reports whether text is a block sequence item.
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseMap parses a block mapping whose keys are indented by indent.
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+	for {
+		l, ok := p.next()
+		if !ok || l.indent < indent || l.indent == 0 && l.text == "---" {
+			return m, nil
+		}
+		if err := l.checkIndent(); err != nil {
+			return nil, err
+		}
+		if l.indent > indent {
+			return nil, l.errorf("unexpected indentation")
+		}
+		if isYAMLSeqItem(l.text) {
+			return nil, l.errorf("sequence item in a mapping")
+		}
+		key, value, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, l.errorf("expected a key: value pair")
+		}
+		k, err := parseYAMLKey(l, key)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := m[k]; dup {
+			return nil, l.errorf("duplicate key %q", k)
+		}
+		p.pos++
+		v, err := p.parseValue(l, indent, value)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+}
+
+// parseSeq parses a block sequence whose dashes are indented by indent.
+func (p *yamlParser) parseSeq(indent int) (interface{}, error) {
+	s := []interface{}{}
+	for {
+		l, ok := p.next()
+		if !ok || l.indent < indent || (l.indent == indent && !isYAMLSeqItem(l.text)) {
+			return s, nil
+		}
+		if err := l.checkIndent(); err != nil {
+			return nil, err
+		}
+		if l.indent > indent {
+			return nil, l.errorf("unexpected indentation")
+		}
+		if !isYAMLSeqItem(l.text) {
+			return nil, l.errorf("expected a sequence item")
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			v, err := p.parseValue(l, indent, "")
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+			continue
+		}
+		// Parse the rest of the line as if it were on a line of its own,
+		// indented to where it starts, e.g. the first key of a mapping
+		// whose other keys are aligned with it.
+		inner := l
+		inner.indent = l.indent + len(l.text) - len(rest)
+		inner.text = rest
+		p.lines[p.pos] = inner
+		v, err := p.parseBlock(inner.indent)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+}
+
+// parseValue parses the value of a mapping key or sequence item on line
+// l, indented by indent, given the text after the key or dash.
+func (p *yamlParser) parseValue(l yamlLine, indent int, value string) (interface{}, error) {
+	if value != "" {
+		if value[0] == '|' || value[0] == '>' {
+			return p.parseBlockScalar(l, indent, value)
+		}
+		return parseYAMLInline(l, value)
+	}
+	next, ok := p.next()
+	switch {
+	case !ok:
+		return nil, nil
+	case next.indent > indent:
+		return p.parseBlock(next.indent)
+	case next.indent == indent && isYAMLSeqItem(next.text) && !isYAMLSeqItem(l.text):
+		// A sequence may be indented as far as its mapping key.
+		return p.parseSeq(indent)
+	}
+	return nil, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar,
+// whose header is on line l.
+func (p *yamlParser) parseBlockScalar(l yamlLine, indent int, header string) (interface{}, error) {
+	folded := header[0] == '>'
+	chomp := strings.TrimSpace(header[1:])
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, l.errorf("unsupported block scalar header %q", header)
+	}
+
+	var lines []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		raw := p.lines[p.pos].raw
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		n := len(raw) - len(trimmed)
+		if blockIndent < 0 {
+			blockIndent = n
+		}
+		if n <= indent || n < blockIndent {
+			break
+		}
+		lines = append(lines, raw[blockIndent:])
+		p.pos++
+	}
+
+	// Trailing blank lines belong to the chomping, not the content.
+	content := lines
+	for len(content) > 0 && content[len(content)-1] == "" {
+		content = content[:len(content)-1]
+	}
+	var s string
+	if folded {
+		// A line break between lines becomes a space, unless empty lines
+		// follow it, which become line breaks in its place, or a line is
+		// more indented, which keeps it.
+		var b strings.Builder
+		prev, empty := "", 0
+		for i, line := range content {
+			if line == "" {
+				empty++
+				continue
+			}
+			switch {
+			case i == empty:
+				b.WriteString(strings.Repeat("\n", empty))
+			case strings.HasPrefix(line, " ") || strings.HasPrefix(prev, " "):
+				b.WriteString(strings.Repeat("\n", empty+1))
+			case empty > 0:
+				b.WriteString(strings.Repeat("\n", empty))
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(line)
+			prev, empty = line, 0
+		}
+		s = b.String()
+	} else {
+		s = strings.Join(content, "\n")
+	}
+	switch chomp {
+	case "":
+		if len(content) > 0 {
+			s += "\n"
+		}
+	case "+":
+		s += strings.Repeat("\n", len(lines)-len(content)+1)
+	}
+	return s, nil
+}
+
+// splitYAMLKey splits text at the colon ending a mapping key, outside
+// quotes and flow collections.
+func splitYAMLKey(text string) (key, value string, ok bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch b := text[i]; {
+		case quote != 0:
+			if b == '\\' && quote == '"' || b == '\'' && quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+			} else if b == quote {
+				quote = 0
+			}
+		case (b == '"' || b == '\'') && i == 0:
+			quote = b
+		case b == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseYAMLKey parses a mapping key, which must be a string.
+func parseYAMLKey(l yamlLine, key string) (string, error) {
+	v, err := parseYAMLInline(l, key)
+	if err != nil {
+		return "", err
+	}
+	switch k := v.(type) {
+	case string:
+		return k, nil
+	case nil:
+		return "", l.errorf("empty key")
+	}
+	// Keys like 1 or true are strings in JSON.
+	return key, nil
+}
+
+// parseYAMLInline parses a scalar or flow collection making up the rest
+// of line l.
+func parseYAMLInline(l yamlLine, s string) (interface{}, error) {
+	f := &yamlFlow{s: s}
+	v, err := f.value()
+	if err != nil {
+		return nil, l.errorf("%v", err)
+	}
+	f.space()
+	if f.i < len(f.s) {
+		return nil, l.errorf("unexpected %q", f.s[f.i:])
+	}
+	return v, nil
+}
+
+// yamlFlow parses flow collections and scalars within one line.
+type yamlFlow struct {
+	s string
+	i int
+
+	// depth is the nesting of flow collections, in which commas and
+	// brackets end plain scalars.
+	depth int
+}
+
+func (f *yamlFlow) space() {
+	for f.i < len(f.s) && (f.s[f.i] == ' ' || f.s[f.i] == '\t') {
+		f.i++
+	}
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+	f.space()
+	if f.i == len(f.s) {
+		return nil, nil
+	}
+	switch f.s[f.i] {
+	case '[':
+		return f.seq()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		return f.quoted()
+	case '&', '*', '!':
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported")
+	}
+	return resolveYAMLScalar(f.plain()), nil
+}
+
+func (f *yamlFlow) seq() (interface{}, error) {
+	f.i++
+	f.depth++
+	s := []interface{}{}
+	for {
+		f.space()
+		if f.i < len(f.s) && f.s[f.i] == ']' {
+			f.i++
+			f.depth--
+			return s, nil
+		}
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *yamlFlow) mapping() (interface{}, error) {
+	f.i++
+	f.depth++
+	m := map[string]interface{}{}
+	for {
+		f.space()
+		if f.i < len(f.s) && f.s[f.i] == '}' {
+			f.i++
+			f.depth--
+			return m, nil
+		}
+		k, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		f.space()
+		var v interface{}
+		if f.i < len(f.s) && f.s[f.i] == ':' {
+			f.i++
+			if v, err = f.value(); err != nil {
+				return nil, err
+			}
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		m[key] = v
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes the comma after a flow collection entry, leaving
+// the closing bracket.
+func (f *yamlFlow) separator(closing byte) error {
+	f.space()
+	switch {
+	case f.i == len(f.s):
+		return fmt.Errorf("missing %q", closing)
+	case f.s[f.i] == ',':
+		f.i++
+	case f.s[f.i] != closing:
+		return fmt.Errorf("expected ',' or %q", closing)
+	}
+	return nil
+}
+
+func (f *yamlFlow) quoted() (interface{}, error) {
+	quote := f.s[f.i]
+	for j := f.i + 1; j < len(f.s); j++ {
+		switch {
+		case quote == '"' && f.s[j] == '\\':
+			j++
+		case quote == '\'' && f.s[j] == '\'' && j+1 < len(f.s) && f.s[j+1] == '\'':
+			j++
+		case f.s[j] == quote:
+			body := f.s[f.i+1 : j]
+			f.i = j + 1
+			if quote == '\'' {
+				return strings.ReplaceAll(body, "''", "'"), nil
+			}
+			return unescapeYAML(body)
+		}
+	}
+	return nil, fmt.Errorf("unterminated quoted string")
+}
+
+// yamlEscapes maps the single-character escapes of double-quoted scalars
+// to what they stand for.
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+	'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"",
+	'/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028",
+	'P': "\u2029",
+}
+
+// unescapeYAML resolves the escapes of a double-quoted scalar, which
+// differ from Go's: e.g. \/ and \e are valid, octal escapes are not.
+func unescapeYAML(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("unterminated quoted string")
+		}
+		if e, ok := yamlEscapes[s[i]]; ok {
+			b.WriteString(e)
+			continue
+		}
+		n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+		if n == 0 {
+			return "", fmt.Errorf("invalid escape %q in quoted string", s[i-1:i+1])
+		}
+		if i+1+n > len(s) {
+			return "", fmt.Errorf("invalid escape %q in quoted string", s[i-1:])
+		}
+		code, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return "", fmt.Errorf("invalid escape %q in quoted string", s[i-1:i+1+n])
+		}
+		b.WriteRune(rune(code))
+		i += n
+	}
+	return b.String(), nil
+}
+
+// plain consumes a plain scalar.
+func (f *yamlFlow) plain() string {
+	start := f.i
+	for ; f.i < len(f.s); f.i++ {
+		b := f.s[f.i]
+		if f.depth > 0 && (b == ',' || b == ']' || b == '}') {
+			break
+		}
+		if f.depth > 0 && b == ':' && (f.i+1 == len(f.s) || f.s[f.i+1] == ' ') {
+			break
+		}
+	}
+	return strings.TrimSpace(f.s[start:f.i])
+}
+
+// resolveYAMLScalar returns the value of a plain scalar: nil, a bool, a
+// number or else the string itself.
+func resolveYAMLScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if c := s[0]; (c >= '0' && c <= '9') || ((c == '-' || c == '+' || c == '.') && len(s) > 1) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if strings.IndexFunc(s, func(r rune) bool {
+			return r != 'e' && r != 'E' && (r < '0' || r > '9') && !strings.ContainsRune("+-.", r)
+		}) < 0 {
+			if x, err := strconv.ParseFloat(s, 64); err == nil {
+				return x
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want interface{}
+	}{
+		// Scalars
+		{"plain string", "a: hello world", map[string]interface{}{"a": "hello world"}},
+		{"int", "a: 42", map[string]interface{}{"a": int64(42)}},
+		{"float", "a: -1.5e3", map[string]interface{}{"a": -1500.0}},
+		{"bool", "a: true\nb: False", map[string]interface{}{"a": true, "b": false}},
+		{"null", "a: ~\nb: null\nc:", map[string]interface{}{"a": nil, "b": nil, "c": nil}},
+		{"comment", "a: x # note\n# whole line\nb: y#z", map[string]interface{}{"a": "x", "b": "y#z"}},
+		{"empty document", "# nothing\n", nil},
+		{"document marker", "---\na: 1", map[string]interface{}{"a": int64(1)}},
+
+		// Quoting
+		{"quoted number", `a: "123"`, map[string]interface{}{"a": "123"}},
+		{"single quoted", `a: 'it''s'`, map[string]interface{}{"a": "it's"}},
+		{"single quoted hash", `a: 'it''s # not a comment' # comment`, map[string]interface{}{"a": "it's # not a comment"}},
+		{"single quoted backslash", `a: 'C:\temp\n'`, map[string]interface{}{"a": `C:\temp\n`}},
+		{"double quoted hash", `a: "say \"hi\" # still" # comment`, map[string]interface{}{"a": `say "hi" # still`}},
+		{"double quoted escapes", `a: "tab\there\nslash\/ esc\e nul\0 sp\ x"`, map[string]interface{}{"a": "tab\there\nslash/ esc\x1b nul\x00 sp x"}},
+		{"unicode escapes", `a: "\x41\u00e9\U0001F600"`, map[string]interface{}{"a": "Aé😀"}},
+		{"yaml-only escapes", `a: "\N\_\L\P"`, map[string]interface{}{"a": "\u0085\u00a0\u2028\u2029"}},
+		{"quoted key", `"a: b": 1` + "\n'it''s': 2", map[string]interface{}{"a: b": int64(1), "it's": int64(2)}},
+		{"numeric key", "1: one", map[string]interface{}{"1": "one"}},
+
+		// Block scalars
+		{"literal", "a: |\n  line 1\n  line 2\nb: 1", map[string]interface{}{"a": "line 1\nline 2\n", "b": int64(1)}},
+		{"literal strip", "a: |-\n  x\n\n", map[string]interface{}{"a": "x"}},
+		{"literal keep", "a: |+\n  x\n\n", map[string]interface{}{"a": "x\n\n\n"}},
+		{"literal comment", "a: |\n  # kept\n  x", map[string]interface{}{"a": "# kept\nx\n"}},
+		{"literal indented", "a: |\n  x\n    y\n  z", map[string]interface{}{"a": "x\n  y\nz\n"}},
+		{"folded", "a: >\n  one\n  two\n\n  three\n", map[string]interface{}{"a": "one two\nthree\n"}},
+		{"folded more indented", "a: >-\n  one\n    two\n  three", map[string]interface{}{"a": "one\n  two\nthree"}},
+		{"folded empty lines", "a: >\n  one\n\n\n  two", map[string]interface{}{"a": "one\n\ntwo\n"}},
+
+		// Nesting
+		{
+			"nested mapping",
+			"a:\n  b:\n    c: 1\n  d: 2",
+			map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": int64(1)}, "d": int64(2)}},
+		},
+		{
+			"sequence of mappings",
+			"items:\n  - name: a\n    tags: [x, y]\n  - name: b\n",
+			map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"name": "a", "tags": []interface{}{"x", "y"}},
+				map[string]interface{}{"name": "b"},
+			}},
+		},
+		{
+			"sequence at key indentation",
+			"a:\n- 1\n- 2\nb: 3",
+			map[string]interface{}{"a": []interface{}{int64(1), int64(2)}, "b": int64(3)},
+		},
+		{
+			"nested sequences",
+			"- - 1\n  - 2\n-\n  - 3",
+			[]interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{int64(3)}},
+		},
+		{
+			"flow collections",
+			`a: {b: [1, "two", {c: d}], e: 'f, g'}`,
+			map[string]interface{}{"a": map[string]interface{}{
+				"b": []interface{}{int64(1), "two", map[string]interface{}{"c": "d"}},
+				"e": "f, g",
+			}},
+		},
+		{
+			"json",
+			`{"a": [1, 2.5, true, null], "b": {"c": "d\u00e9"}}`,
+			map[string]interface{}{"a": []interface{}{int64(1), 2.5, true, nil}, "b": map[string]interface{}{"c": "dé"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("parseYAML(%q): %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseYAML(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"invalid escape", "a: 1\nb: \"\\q\"", `yaml: line 2: invalid escape "\\q"`},
+		{"short unicode escape", `a: "\u00e"`, `yaml: line 1: invalid escape "\\u00e"`},
+		{"invalid code point", `a: "\UFFFFFFFF"`, `yaml: line 1: invalid escape "\\UFFFFFFFF"`},
+		{"unterminated double", `a: "x`, "yaml: line 1: unterminated quoted string"},
+		{"unterminated single", `a: 'it''`, "yaml: line 1: unterminated quoted string"},
+		{"tab indentation", "a:\n\tb: 1", "yaml: line 2: tabs are not allowed in indentation"},
+		{"duplicate key", "a: 1\nb: 2\na: 3", `yaml: line 3: duplicate key "a"`},
+		{"duplicate flow key", "a: {b: 1, b: 2}", `yaml: line 1: duplicate key "b"`},
+		{"unexpected indentation", "a: 1\n  b: 2", "yaml: line 2: unexpected indentation"},
+		{"sequence in mapping", "a:\n  b: 1\n  - 2", "yaml: line 3: sequence item in a mapping"},
+		{"missing bracket", "a:\n  b: [1, 2", `yaml: line 2: missing ']'`},
+		{"trailing text", `a: "x" y`, `yaml: line 1: unexpected "y"`},
+		{"anchor", "a: &x 1", "yaml: line 1: anchors, aliases and tags are not supported"},
+		{"block scalar indentation indicator", "a: |2\n  x", `yaml: line 1: unsupported block scalar header "|2"`},
+		{"multiple documents", "a: 1\n---\nb: 2", "yaml: line 2: multiple documents are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.in))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("parseYAML(%q): got error %v, want %s", tt.in, err, tt.want)
+			}
+		})
+	}
+}